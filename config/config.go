@@ -24,6 +24,10 @@ type Config struct {
 	RateLimitMax      int    `mapstructure:"SSV_RATE_LIMIT_MAX"`
 	RateLimitWindow   int    `mapstructure:"SSV_RATE_LIMIT_WINDOW"`
 
+	// Per-IP limit of POST /v1/auth/login requests, on top of the global limit
+	LoginRateLimitMax    int `mapstructure:"SSV_LOGIN_RATE_LIMIT_MAX"`
+	LoginRateLimitWindow int `mapstructure:"SSV_LOGIN_RATE_LIMIT_WINDOW"` // seconds
+
 	DbHost           string `mapstructure:"SSV_DB_HOST"`
 	DbPort           int16  `mapstructure:"SSV_DB_PORT"`
 	DbSSLMode        string `mapstructure:"SSV_DB_SSL"`
@@ -42,6 +46,10 @@ type Config struct {
 	RedisUser string `mapstructure:"SSV_REDIS_USER"`
 	RedisPass string `mapstructure:"SSV_REDIS_PASS"`
 
+	// Conversation state storage (memory or redis)
+	StateStore             string `mapstructure:"SSV_STATE_STORE"`
+	StateDefaultTTLMinutes int    `mapstructure:"SSV_STATE_DEFAULT_TTL_MINUTES"`
+
 	OtlpEndpoint    string `mapstructure:"SSV_OTLP_ENDPOINT"`
 	OtlpLogsEnabled bool   `mapstructure:"SSV_OTLP_LOGS_ENABLED"`
 	JaegerEndpoint  string `mapstructure:"SSV_JAEGER_ENDPOINT"`
@@ -51,6 +59,21 @@ type Config struct {
 	TelegramDebug    bool   `mapstructure:"SSV_TELEGRAM_DEBUG"`
 	TelegramAdmins   string `mapstructure:"SSV_TELEGRAM_ADMINS"` // Comma-separated list of Telegram IDs
 
+	// Telegram webhook, updates are received through long polling when the URL is empty
+	TelegramWebhookURL            string `mapstructure:"SSV_TELEGRAM_WEBHOOK_URL"`
+	TelegramWebhookSecret         string `mapstructure:"SSV_TELEGRAM_WEBHOOK_SECRET"`
+	TelegramWebhookMaxConnections int    `mapstructure:"SSV_TELEGRAM_WEBHOOK_MAX_CONNECTIONS"`
+
+	// Telegram update processing, updates of one chat are processed in order
+	TelegramUpdateWorkers      int `mapstructure:"SSV_TELEGRAM_UPDATE_WORKERS"`
+	TelegramUpdateQueueSize    int `mapstructure:"SSV_TELEGRAM_UPDATE_QUEUE_SIZE"`
+	TelegramUpdateDrainTimeout int `mapstructure:"SSV_TELEGRAM_UPDATE_DRAIN_TIMEOUT"` // seconds
+
+	// HTTP Sessions Configuration
+	SessionTTLHours        int `mapstructure:"SSV_SESSION_TTL_HOURS"`
+	SessionLoginCodeTTLMin int `mapstructure:"SSV_SESSION_LOGIN_CODE_TTL_MINUTES"`
+	SessionTelegramAuthAge int `mapstructure:"SSV_SESSION_TELEGRAM_AUTH_MAX_AGE"` // seconds
+
 	// OpenAI Configuration
 	OpenAIAPIKey          string  `mapstructure:"SSV_OPENAI_API_KEY"`
 	OpenAIModel           string  `mapstructure:"SSV_OPENAI_MODEL"`
@@ -61,6 +84,25 @@ type Config struct {
 	OpenAIStore           bool    `mapstructure:"SSV_OPENAI_STORE"`
 	OpenAIReasoningEffort string  `mapstructure:"SSV_OPENAI_REASONING_EFFORT"`
 
+	// LLM provider selection: openai, azure, local or rules
+	LLMProvider        string `mapstructure:"SSV_LLM_PROVIDER"`
+	LLMFallbackToRules bool   `mapstructure:"SSV_LLM_FALLBACK_TO_RULES"`
+
+	// Rule-based first pass resolving items against the products table before the LLM is called
+	LLMRulesFirstPass     bool    `mapstructure:"SSV_LLM_RULES_FIRST_PASS"`
+	LLMRulesMinConfidence float64 `mapstructure:"SSV_LLM_RULES_MIN_CONFIDENCE"`
+
+	// Azure OpenAI Configuration
+	AzureOpenAIEndpoint   string `mapstructure:"SSV_AZURE_OPENAI_ENDPOINT"`
+	AzureOpenAIAPIKey     string `mapstructure:"SSV_AZURE_OPENAI_API_KEY"`
+	AzureOpenAIDeployment string `mapstructure:"SSV_AZURE_OPENAI_DEPLOYMENT"`
+	AzureOpenAIAPIVersion string `mapstructure:"SSV_AZURE_OPENAI_API_VERSION"`
+
+	// Local OpenAI-compatible server (Ollama, llama.cpp) Configuration
+	LocalLLMBaseURL string `mapstructure:"SSV_LOCAL_LLM_BASE_URL"`
+	LocalLLMModel   string `mapstructure:"SSV_LOCAL_LLM_MODEL"`
+	LocalLLMAPIKey  string `mapstructure:"SSV_LOCAL_LLM_API_KEY"`
+
 	// Azure Speech Service Configuration
 	AzureSpeechKey    string `mapstructure:"SSV_AZURE_SPEECH_KEY"`
 	AzureSpeechRegion string `mapstructure:"SSV_AZURE_SPEECH_REGION"`
@@ -74,11 +116,42 @@ type Config struct {
 	AzureStorageBaseURL          string `mapstructure:"SSV_AZURE_STORAGE_BASE_URL"`
 	AzureStorageUseHTTPS         bool   `mapstructure:"SSV_AZURE_STORAGE_USE_HTTPS"`
 
+	// AWS S3 / S3-compatible (MinIO) Storage Configuration
+	AWSAccessKeyID      string `mapstructure:"SSV_AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey  string `mapstructure:"SSV_AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken     string `mapstructure:"SSV_AWS_SESSION_TOKEN"`
+	AWSRegion           string `mapstructure:"SSV_AWS_REGION"`
+	AWSS3Bucket         string `mapstructure:"SSV_AWS_S3_BUCKET"`
+	AWSS3Endpoint       string `mapstructure:"SSV_AWS_S3_ENDPOINT"`
+	AWSS3ForcePathStyle bool   `mapstructure:"SSV_AWS_S3_FORCE_PATH_STYLE"`
+	AWSS3BaseURL        string `mapstructure:"SSV_AWS_S3_BASE_URL"`
+
+	// GCP Cloud Storage Configuration
+	GCPProjectID       string `mapstructure:"SSV_GCP_PROJECT_ID"`
+	GCPStorageBucket   string `mapstructure:"SSV_GCP_STORAGE_BUCKET"`
+	GCPCredentialsJSON string `mapstructure:"SSV_GCP_CREDENTIALS_JSON"`
+	GCPCredentialsFile string `mapstructure:"SSV_GCP_CREDENTIALS_FILE"`
+	GCPStorageEndpoint string `mapstructure:"SSV_GCP_STORAGE_ENDPOINT"`
+	GCPStorageBaseURL  string `mapstructure:"SSV_GCP_STORAGE_BASE_URL"`
+
+	// Local Filesystem Storage Configuration (development and tests)
+	LocalStoragePath       string `mapstructure:"SSV_LOCAL_STORAGE_PATH"`
+	LocalStorageBaseURL    string `mapstructure:"SSV_LOCAL_STORAGE_BASE_URL"`
+	LocalStorageSigningKey string `mapstructure:"SSV_LOCAL_STORAGE_SIGNING_KEY"`
+
 	// Azure Document Intelligence Configuration
 	AzureDocumentIntelligenceEndpoint   string `mapstructure:"SSV_AZURE_DOCUMENT_INTELLIGENCE_ENDPOINT"`
 	AzureDocumentIntelligenceAPIKey     string `mapstructure:"SSV_AZURE_DOCUMENT_INTELLIGENCE_API_KEY"`
 	AzureDocumentIntelligenceAPIVersion string `mapstructure:"SSV_AZURE_DOCUMENT_INTELLIGENCE_API_VERSION"`
 	AzureDocumentIntelligenceModel      string `mapstructure:"SSV_AZURE_DOCUMENT_INTELLIGENCE_RECEIPT_MODEL"`
+
+	// Receipt extraction backends, tried in order: azure, vision, tesseract
+	ReceiptExtractors       string  `mapstructure:"SSV_RECEIPT_EXTRACTORS"`
+	ReceiptMinConfidence    float64 `mapstructure:"SSV_RECEIPT_MIN_CONFIDENCE"`
+	ReceiptVisionModel      string  `mapstructure:"SSV_RECEIPT_VISION_MODEL"`
+	ReceiptTesseractPath    string  `mapstructure:"SSV_RECEIPT_TESSERACT_PATH"`
+	ReceiptTesseractLangs   string  `mapstructure:"SSV_RECEIPT_TESSERACT_LANGUAGES"`
+	ReceiptTesseractTimeout int     `mapstructure:"SSV_RECEIPT_TESSERACT_TIMEOUT"`
 }
 
 // DefaultConfig generates a config with sane defaults.
@@ -93,6 +166,9 @@ func DefaultConfig() Config {
 		RateLimitMax:      100,
 		RateLimitWindow:   30,
 
+		LoginRateLimitMax:    5,
+		LoginRateLimitWindow: 60,
+
 		DbHost:           "localhost",
 		DbPort:           5432,
 		DbSSLMode:        "disable",
@@ -111,6 +187,10 @@ func DefaultConfig() Config {
 		RedisUser: "redis",
 		RedisPass: "redis",
 
+		// Conversation state defaults
+		StateStore:             "memory",
+		StateDefaultTTLMinutes: 30,
+
 		OtlpEndpoint:    "localhost:4317",
 		OtlpLogsEnabled: false, // Prefer Promtail for logs, OTEL for metrics/traces only
 		JaegerEndpoint:  "http://localhost:14268/api/traces",
@@ -119,6 +199,19 @@ func DefaultConfig() Config {
 		TelegramDebug:    false,
 		TelegramAdmins:   "",
 
+		TelegramWebhookURL:            "",
+		TelegramWebhookSecret:         "",
+		TelegramWebhookMaxConnections: 40,
+
+		TelegramUpdateWorkers:      16,
+		TelegramUpdateQueueSize:    1000,
+		TelegramUpdateDrainTimeout: 30,
+
+		// HTTP sessions defaults
+		SessionTTLHours:        168, // 7 days, same as the user_sessions table default
+		SessionLoginCodeTTLMin: 5,
+		SessionTelegramAuthAge: 86400,
+
 		// OpenAI defaults
 		OpenAIAPIKey:          "",
 		OpenAIModel:           "gpt-5-nano",
@@ -129,6 +222,26 @@ func DefaultConfig() Config {
 		OpenAIStore:           true,
 		OpenAIReasoningEffort: "medium",
 
+		// LLM provider defaults
+		LLMProvider:        "openai",
+		LLMFallbackToRules: true,
+
+		// Rule-based first pass defaults, only exact product matches skip the LLM. Inflected matches
+		// come from a crude stemmer and are left to the LLM.
+		LLMRulesFirstPass:     true,
+		LLMRulesMinConfidence: 0.95,
+
+		// Azure OpenAI defaults
+		AzureOpenAIEndpoint:   "",
+		AzureOpenAIAPIKey:     "",
+		AzureOpenAIDeployment: "",
+		AzureOpenAIAPIVersion: "",
+
+		// Local LLM defaults
+		LocalLLMBaseURL: "http://localhost:11434/v1",
+		LocalLLMModel:   "llama3.1",
+		LocalLLMAPIKey:  "",
+
 		// Azure Speech service defaults
 		AzureSpeechKey:    "",
 		AzureSpeechRegion: "eastus",
@@ -142,11 +255,42 @@ func DefaultConfig() Config {
 		AzureStorageBaseURL:          "",
 		AzureStorageUseHTTPS:         true,
 
+		// AWS S3 defaults
+		AWSAccessKeyID:      "",
+		AWSSecretAccessKey:  "",
+		AWSSessionToken:     "",
+		AWSRegion:           "us-east-1",
+		AWSS3Bucket:         "files",
+		AWSS3Endpoint:       "",
+		AWSS3ForcePathStyle: false,
+		AWSS3BaseURL:        "",
+
+		// GCP Cloud Storage defaults
+		GCPProjectID:       "",
+		GCPStorageBucket:   "files",
+		GCPCredentialsJSON: "",
+		GCPCredentialsFile: "",
+		GCPStorageEndpoint: "",
+		GCPStorageBaseURL:  "",
+
+		// Local storage defaults
+		LocalStoragePath:       "./data/files",
+		LocalStorageBaseURL:    "http://localhost:3001/files",
+		LocalStorageSigningKey: "",
+
 		// Azure Document Intelligence defaults
 		AzureDocumentIntelligenceEndpoint:   "",
 		AzureDocumentIntelligenceAPIKey:     "",
 		AzureDocumentIntelligenceAPIVersion: "2024-11-30",
 		AzureDocumentIntelligenceModel:      "prebuilt-receipt",
+
+		// Receipt extraction defaults
+		ReceiptExtractors:       "azure",
+		ReceiptMinConfidence:    0.6,
+		ReceiptVisionModel:      "gpt-4o-mini",
+		ReceiptTesseractPath:    "tesseract",
+		ReceiptTesseractLangs:   "eng+rus+ukr+deu",
+		ReceiptTesseractTimeout: 30,
 	}
 }
 
@@ -182,6 +326,8 @@ func ConfigFromEnvironment() (config Config, err error) {
 	viper.SetDefault("SSV_LOG_FORMAT", config.LogFormat)
 	viper.SetDefault("SSV_RATE_LIMIT_MAX", config.RateLimitMax)
 	viper.SetDefault("SSV_RATE_LIMIT_WINDOW", config.RateLimitWindow)
+	viper.SetDefault("SSV_LOGIN_RATE_LIMIT_MAX", config.LoginRateLimitMax)
+	viper.SetDefault("SSV_LOGIN_RATE_LIMIT_WINDOW", config.LoginRateLimitWindow)
 	viper.SetDefault("SSV_DB_HOST", config.DbHost)
 	viper.SetDefault("SSV_DB_PORT", config.DbPort)
 	viper.SetDefault("SSV_DB_SSL", config.DbSSLMode)
@@ -198,9 +344,20 @@ func ConfigFromEnvironment() (config Config, err error) {
 	viper.SetDefault("SSV_REDIS_USER", config.RedisUser)
 	viper.SetDefault("SSV_REDIS_PASS", config.RedisPass)
 	viper.SetDefault("SSV_REDIS_DB", config.RedisDb)
+	viper.SetDefault("SSV_STATE_STORE", config.StateStore)
+	viper.SetDefault("SSV_STATE_DEFAULT_TTL_MINUTES", config.StateDefaultTTLMinutes)
 	viper.SetDefault("SSV_TELEGRAM_BOT_TOKEN", config.TelegramBotToken)
 	viper.SetDefault("SSV_TELEGRAM_DEBUG", config.TelegramDebug)
 	viper.SetDefault("SSV_TELEGRAM_ADMINS", config.TelegramAdmins)
+	viper.SetDefault("SSV_TELEGRAM_WEBHOOK_URL", config.TelegramWebhookURL)
+	viper.SetDefault("SSV_TELEGRAM_WEBHOOK_SECRET", config.TelegramWebhookSecret)
+	viper.SetDefault("SSV_TELEGRAM_WEBHOOK_MAX_CONNECTIONS", config.TelegramWebhookMaxConnections)
+	viper.SetDefault("SSV_TELEGRAM_UPDATE_WORKERS", config.TelegramUpdateWorkers)
+	viper.SetDefault("SSV_TELEGRAM_UPDATE_QUEUE_SIZE", config.TelegramUpdateQueueSize)
+	viper.SetDefault("SSV_TELEGRAM_UPDATE_DRAIN_TIMEOUT", config.TelegramUpdateDrainTimeout)
+	viper.SetDefault("SSV_SESSION_TTL_HOURS", config.SessionTTLHours)
+	viper.SetDefault("SSV_SESSION_LOGIN_CODE_TTL_MINUTES", config.SessionLoginCodeTTLMin)
+	viper.SetDefault("SSV_SESSION_TELEGRAM_AUTH_MAX_AGE", config.SessionTelegramAuthAge)
 	viper.SetDefault("SSV_OPENAI_API_KEY", config.OpenAIAPIKey)
 	viper.SetDefault("SSV_OPENAI_MODEL", config.OpenAIModel)
 	viper.SetDefault("SSV_OPENAI_BASE_URL", config.OpenAIBaseURL)
@@ -209,6 +366,17 @@ func ConfigFromEnvironment() (config Config, err error) {
 	viper.SetDefault("SSV_OPENAI_USE_RESPONSES_API", config.OpenAIUseResponsesAPI)
 	viper.SetDefault("SSV_OPENAI_STORE", config.OpenAIStore)
 	viper.SetDefault("SSV_OPENAI_REASONING_EFFORT", config.OpenAIReasoningEffort)
+	viper.SetDefault("SSV_LLM_PROVIDER", config.LLMProvider)
+	viper.SetDefault("SSV_LLM_FALLBACK_TO_RULES", config.LLMFallbackToRules)
+	viper.SetDefault("SSV_LLM_RULES_FIRST_PASS", config.LLMRulesFirstPass)
+	viper.SetDefault("SSV_LLM_RULES_MIN_CONFIDENCE", config.LLMRulesMinConfidence)
+	viper.SetDefault("SSV_AZURE_OPENAI_ENDPOINT", config.AzureOpenAIEndpoint)
+	viper.SetDefault("SSV_AZURE_OPENAI_API_KEY", config.AzureOpenAIAPIKey)
+	viper.SetDefault("SSV_AZURE_OPENAI_DEPLOYMENT", config.AzureOpenAIDeployment)
+	viper.SetDefault("SSV_AZURE_OPENAI_API_VERSION", config.AzureOpenAIAPIVersion)
+	viper.SetDefault("SSV_LOCAL_LLM_BASE_URL", config.LocalLLMBaseURL)
+	viper.SetDefault("SSV_LOCAL_LLM_MODEL", config.LocalLLMModel)
+	viper.SetDefault("SSV_LOCAL_LLM_API_KEY", config.LocalLLMAPIKey)
 	viper.SetDefault("SSV_AZURE_SPEECH_KEY", config.AzureSpeechKey)
 	viper.SetDefault("SSV_AZURE_SPEECH_REGION", config.AzureSpeechRegion)
 	viper.SetDefault("SSV_CLOUD_PROVIDER", config.CloudProvider)
@@ -218,10 +386,33 @@ func ConfigFromEnvironment() (config Config, err error) {
 	viper.SetDefault("SSV_AZURE_STORAGE_CONTAINER_NAME", config.AzureStorageContainerName)
 	viper.SetDefault("SSV_AZURE_STORAGE_BASE_URL", config.AzureStorageBaseURL)
 	viper.SetDefault("SSV_AZURE_STORAGE_USE_HTTPS", config.AzureStorageUseHTTPS)
+	viper.SetDefault("SSV_AWS_ACCESS_KEY_ID", config.AWSAccessKeyID)
+	viper.SetDefault("SSV_AWS_SECRET_ACCESS_KEY", config.AWSSecretAccessKey)
+	viper.SetDefault("SSV_AWS_SESSION_TOKEN", config.AWSSessionToken)
+	viper.SetDefault("SSV_AWS_REGION", config.AWSRegion)
+	viper.SetDefault("SSV_AWS_S3_BUCKET", config.AWSS3Bucket)
+	viper.SetDefault("SSV_AWS_S3_ENDPOINT", config.AWSS3Endpoint)
+	viper.SetDefault("SSV_AWS_S3_FORCE_PATH_STYLE", config.AWSS3ForcePathStyle)
+	viper.SetDefault("SSV_AWS_S3_BASE_URL", config.AWSS3BaseURL)
+	viper.SetDefault("SSV_GCP_PROJECT_ID", config.GCPProjectID)
+	viper.SetDefault("SSV_GCP_STORAGE_BUCKET", config.GCPStorageBucket)
+	viper.SetDefault("SSV_GCP_CREDENTIALS_JSON", config.GCPCredentialsJSON)
+	viper.SetDefault("SSV_GCP_CREDENTIALS_FILE", config.GCPCredentialsFile)
+	viper.SetDefault("SSV_GCP_STORAGE_ENDPOINT", config.GCPStorageEndpoint)
+	viper.SetDefault("SSV_GCP_STORAGE_BASE_URL", config.GCPStorageBaseURL)
+	viper.SetDefault("SSV_LOCAL_STORAGE_PATH", config.LocalStoragePath)
+	viper.SetDefault("SSV_LOCAL_STORAGE_BASE_URL", config.LocalStorageBaseURL)
+	viper.SetDefault("SSV_LOCAL_STORAGE_SIGNING_KEY", config.LocalStorageSigningKey)
 	viper.SetDefault("SSV_AZURE_DOCUMENT_INTELLIGENCE_ENDPOINT", config.AzureDocumentIntelligenceEndpoint)
 	viper.SetDefault("SSV_AZURE_DOCUMENT_INTELLIGENCE_API_KEY", config.AzureDocumentIntelligenceAPIKey)
 	viper.SetDefault("SSV_AZURE_DOCUMENT_INTELLIGENCE_API_VERSION", config.AzureDocumentIntelligenceAPIVersion)
 	viper.SetDefault("SSV_AZURE_DOCUMENT_INTELLIGENCE_RECEIPT_MODEL", config.AzureDocumentIntelligenceModel)
+	viper.SetDefault("SSV_RECEIPT_EXTRACTORS", config.ReceiptExtractors)
+	viper.SetDefault("SSV_RECEIPT_MIN_CONFIDENCE", config.ReceiptMinConfidence)
+	viper.SetDefault("SSV_RECEIPT_VISION_MODEL", config.ReceiptVisionModel)
+	viper.SetDefault("SSV_RECEIPT_TESSERACT_PATH", config.ReceiptTesseractPath)
+	viper.SetDefault("SSV_RECEIPT_TESSERACT_LANGUAGES", config.ReceiptTesseractLangs)
+	viper.SetDefault("SSV_RECEIPT_TESSERACT_TIMEOUT", config.ReceiptTesseractTimeout)
 
 	// Override config values with environment variables
 	viper.AutomaticEnv()
@@ -334,6 +525,104 @@ type OpenAIConfig struct {
 	ReasoningEffort string // "low", "medium", "high" for GPT-5 reasoning
 }
 
+// GetLLMConfig converts config values to the LLM provider configuration struct.
+func (c Config) GetLLMConfig() LLMConfig {
+	return LLMConfig{
+		Provider:           c.LLMProvider,
+		FallbackToRules:    c.LLMFallbackToRules,
+		RulesFirstPass:     c.LLMRulesFirstPass,
+		RulesMinConfidence: c.LLMRulesMinConfidence,
+		OpenAI:             c.GetOpenAIConfig(),
+		Azure: AzureOpenAIConfig{
+			Endpoint:   c.AzureOpenAIEndpoint,
+			APIKey:     c.AzureOpenAIAPIKey,
+			Deployment: c.AzureOpenAIDeployment,
+			APIVersion: c.AzureOpenAIAPIVersion,
+		},
+		Local: LocalLLMConfig{
+			BaseURL: c.LocalLLMBaseURL,
+			Model:   c.LocalLLMModel,
+			APIKey:  c.LocalLLMAPIKey,
+		},
+	}
+}
+
+// LLMConfig selects the provider behind the AI client
+type LLMConfig struct {
+	Provider           string  // "openai", "azure", "local" or "rules"
+	FallbackToRules    bool    // answer with the rule-based parser when the provider fails
+	RulesFirstPass     bool    // resolve items with the rule-based parser before calling the provider
+	RulesMinConfidence float64 // minimum rule-based confidence to skip the provider
+	OpenAI             OpenAIConfig
+	Azure              AzureOpenAIConfig
+	Local              LocalLLMConfig
+}
+
+// AzureOpenAIConfig holds Azure OpenAI configuration, the request options are shared with OpenAIConfig
+type AzureOpenAIConfig struct {
+	Endpoint   string // e.g. https://my-resource.openai.azure.com
+	APIKey     string
+	Deployment string // deployment name, sent as the model
+	APIVersion string // optional api-version query parameter, e.g. "preview"
+}
+
+// LocalLLMConfig holds configuration of a local OpenAI-compatible server such as Ollama or llama.cpp
+type LocalLLMConfig struct {
+	BaseURL string // e.g. http://localhost:11434/v1
+	Model   string
+	APIKey  string // optional
+}
+
+// GetTelegramWebhookConfig converts config values to Telegram webhook configuration struct.
+func (c Config) GetTelegramWebhookConfig() TelegramWebhookConfig {
+	return TelegramWebhookConfig{
+		URL:            c.TelegramWebhookURL,
+		SecretToken:    c.TelegramWebhookSecret,
+		MaxConnections: c.TelegramWebhookMaxConnections,
+	}
+}
+
+// TelegramWebhookConfig holds Telegram webhook configuration
+type TelegramWebhookConfig struct {
+	URL            string // public HTTPS URL Telegram posts updates to, empty for long polling
+	SecretToken    string // sent by Telegram in the X-Telegram-Bot-Api-Secret-Token header
+	MaxConnections int    // simultaneous connections Telegram opens to the webhook
+}
+
+// GetTelegramDispatcherConfig converts config values to Telegram update processing configuration struct.
+func (c Config) GetTelegramDispatcherConfig() TelegramDispatcherConfig {
+	return TelegramDispatcherConfig{
+		Workers:      c.TelegramUpdateWorkers,
+		QueueSize:    c.TelegramUpdateQueueSize,
+		DrainTimeout: time.Duration(c.TelegramUpdateDrainTimeout) * time.Second,
+	}
+}
+
+// TelegramDispatcherConfig holds Telegram update processing configuration
+type TelegramDispatcherConfig struct {
+	Workers      int           // updates processed in parallel, each chat uses one worker at a time
+	QueueSize    int           // updates queued or running before receiving blocks
+	DrainTimeout time.Duration // time given to queued updates on shutdown
+}
+
+// GetSessionConfig converts config values to HTTP session configuration struct.
+func (c Config) GetSessionConfig() SessionConfig {
+	return SessionConfig{
+		TTL:                time.Duration(c.SessionTTLHours) * time.Hour,
+		LoginCodeTTL:       time.Duration(c.SessionLoginCodeTTLMin) * time.Minute,
+		TelegramAuthMaxAge: time.Duration(c.SessionTelegramAuthAge) * time.Second,
+		BotToken:           c.TelegramBotToken,
+	}
+}
+
+// SessionConfig holds HTTP session configuration
+type SessionConfig struct {
+	TTL                time.Duration // session lifetime, extended on refresh
+	LoginCodeTTL       time.Duration // lifetime of one-time codes issued by the bot
+	TelegramAuthMaxAge time.Duration // max age of Telegram Login Widget auth_date
+	BotToken           string        // used to verify Telegram Login Widget hashes
+}
+
 // GetCloudConfig converts config values to cloud storage configuration struct.
 func (c Config) GetCloudConfig() CloudConfig {
 	return CloudConfig{
@@ -346,6 +635,29 @@ func (c Config) GetCloudConfig() CloudConfig {
 			BaseURL:            c.AzureStorageBaseURL,
 			UseHTTPS:           c.AzureStorageUseHTTPS,
 		},
+		AWS: AWSCloudConfig{
+			AccessKeyID:     c.AWSAccessKeyID,
+			SecretAccessKey: c.AWSSecretAccessKey,
+			SessionToken:    c.AWSSessionToken,
+			Region:          c.AWSRegion,
+			BucketName:      c.AWSS3Bucket,
+			Endpoint:        c.AWSS3Endpoint,
+			ForcePathStyle:  c.AWSS3ForcePathStyle,
+			BaseURL:         c.AWSS3BaseURL,
+		},
+		GCP: GCPCloudConfig{
+			ProjectID:       c.GCPProjectID,
+			BucketName:      c.GCPStorageBucket,
+			CredentialsJSON: c.GCPCredentialsJSON,
+			CredentialsFile: c.GCPCredentialsFile,
+			Endpoint:        c.GCPStorageEndpoint,
+			BaseURL:         c.GCPStorageBaseURL,
+		},
+		Local: LocalCloudConfig{
+			RootPath:   c.LocalStoragePath,
+			BaseURL:    c.LocalStorageBaseURL,
+			SigningKey: c.LocalStorageSigningKey,
+		},
 	}
 }
 
@@ -353,7 +665,9 @@ func (c Config) GetCloudConfig() CloudConfig {
 type CloudConfig struct {
 	Provider string
 	Azure    AzureCloudConfig
-	// AWS and GCP configs can be added later
+	AWS      AWSCloudConfig
+	GCP      GCPCloudConfig
+	Local    LocalCloudConfig
 }
 
 // AzureCloudConfig holds Azure Blob Storage specific configuration
@@ -366,6 +680,35 @@ type AzureCloudConfig struct {
 	UseHTTPS           bool
 }
 
+// AWSCloudConfig holds AWS S3 and S3-compatible storage specific configuration
+type AWSCloudConfig struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	BucketName      string
+	Endpoint        string
+	ForcePathStyle  bool
+	BaseURL         string
+}
+
+// GCPCloudConfig holds Google Cloud Storage specific configuration
+type GCPCloudConfig struct {
+	ProjectID       string
+	BucketName      string
+	CredentialsJSON string
+	CredentialsFile string
+	Endpoint        string
+	BaseURL         string
+}
+
+// LocalCloudConfig holds local filesystem storage specific configuration
+type LocalCloudConfig struct {
+	RootPath   string
+	BaseURL    string
+	SigningKey string
+}
+
 // GetDocumentIntelligenceConfig converts config values to Document Intelligence configuration struct.
 func (c Config) GetDocumentIntelligenceConfig() DocumentIntelligenceConfig {
 	return DocumentIntelligenceConfig{
@@ -383,3 +726,32 @@ type DocumentIntelligenceConfig struct {
 	APIVersion string
 	Model      string
 }
+
+// GetReceiptExtractionConfig converts config values to receipt extraction configuration struct.
+func (c Config) GetReceiptExtractionConfig() ReceiptExtractionConfig {
+	var extractors []string
+	for _, name := range strings.Split(c.ReceiptExtractors, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			extractors = append(extractors, name)
+		}
+	}
+
+	return ReceiptExtractionConfig{
+		Extractors:       extractors,
+		MinConfidence:    c.ReceiptMinConfidence,
+		VisionModel:      c.ReceiptVisionModel,
+		TesseractPath:    c.ReceiptTesseractPath,
+		TesseractLangs:   c.ReceiptTesseractLangs,
+		TesseractTimeout: time.Duration(c.ReceiptTesseractTimeout) * time.Second,
+	}
+}
+
+// ReceiptExtractionConfig selects the receipt extraction backends and when to fall back between them
+type ReceiptExtractionConfig struct {
+	Extractors       []string // "azure", "vision" or "tesseract", tried in order
+	MinConfidence    float64  // results below this confidence are retried with the next backend
+	VisionModel      string   // multimodal model of the configured LLM provider
+	TesseractPath    string
+	TesseractLangs   string // tesseract -l value, e.g. "eng+rus"
+	TesseractTimeout time.Duration
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -34,12 +36,13 @@ type DocumentIntelligenceService struct {
 
 // ReceiptItem represents an item extracted from a receipt
 type ReceiptItem struct {
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	TotalPrice  float64 `json:"totalPrice"`
-	Category    string  `json:"category,omitempty"`
-	Description string  `json:"description,omitempty"`
+	Name         string  `json:"name"`
+	Quantity     float64 `json:"quantity"`               // fractional for weighted items, e.g. 0.734 kg
+	QuantityUnit string  `json:"quantityUnit,omitempty"` // kg, g, l, ml or pcs
+	Price        float64 `json:"price"`                  // price per QuantityUnit
+	TotalPrice   float64 `json:"totalPrice"`
+	Category     string  `json:"category,omitempty"`
+	Description  string  `json:"description,omitempty"`
 }
 
 // ReceiptData represents the parsed receipt data
@@ -620,7 +623,15 @@ func (dis *DocumentIntelligenceService) parseReceiptResponse(response *documentI
 			}
 
 			if qtyField, ok := itemField.ValueObject["Quantity"]; ok && qtyField.ValueNumber != nil {
-				item.Quantity = int(*qtyField.ValueNumber)
+				item.Quantity = *qtyField.ValueNumber
+			}
+
+			if unitField, ok := itemField.ValueObject["QuantityUnit"]; ok {
+				unit := unitField.Content
+				if unitField.ValueString != nil {
+					unit = *unitField.ValueString
+				}
+				item.QuantityUnit = normalizeReceiptUnit(unit)
 			}
 
 			if priceField, ok := itemField.ValueObject["Price"]; ok {
@@ -639,8 +650,13 @@ func (dis *DocumentIntelligenceService) parseReceiptResponse(response *documentI
 				}
 			}
 
+			// Whole quantities without a unit are counted items, fractional ones are left unknown
+			if item.QuantityUnit == "" && item.Quantity > 0 && item.Quantity == math.Trunc(item.Quantity) {
+				item.QuantityUnit = "pcs"
+			}
+
 			if item.Price == 0 && item.TotalPrice > 0 && item.Quantity > 0 {
-				item.Price = item.TotalPrice / float64(item.Quantity)
+				item.Price = math.Round(item.TotalPrice/item.Quantity*100) / 100
 			}
 
 			if catField, ok := itemField.ValueObject["Category"]; ok && catField.ValueString != nil {
@@ -659,6 +675,24 @@ func (dis *DocumentIntelligenceService) parseReceiptResponse(response *documentI
 	return receiptData, nil
 }
 
+// normalizeReceiptUnit maps the unit printed on a receipt to kg, g, l, ml or pcs, unknown units are dropped
+func normalizeReceiptUnit(unit string) string {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(unit)), ".") {
+	case "kg", "kgs", "кг":
+		return "kg"
+	case "g", "gr", "г", "гр":
+		return "g"
+	case "l", "lt", "ltr", "л":
+		return "l"
+	case "ml", "мл":
+		return "ml"
+	case "pc", "pcs", "piece", "pieces", "st", "stk", "szt", "шт", "x":
+		return "pcs"
+	default:
+		return ""
+	}
+}
+
 func (dis *DocumentIntelligenceService) ValidateConfiguration() error {
 	if dis.endpoint == "" {
 		return fmt.Errorf("document intelligence endpoint is required")
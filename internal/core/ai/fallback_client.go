@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+)
+
+// fallbackClient answers with the rule-based parser when the LLM provider fails, so the bot keeps
+// working in degraded mode while the provider is unreachable
+type fallbackClient struct {
+	primary  OpenAIClient
+	fallback OpenAIClient
+	logger   *slog.Logger
+}
+
+func newFallbackClient(primary, fallback OpenAIClient, logger *slog.Logger) OpenAIClient {
+	return &fallbackClient{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (c *fallbackClient) ParseItem(ctx context.Context, rawText, languageCode string) (*ParsedResult, error) {
+	result, err := c.primary.ParseItem(ctx, rawText, languageCode)
+	if err == nil || ctx.Err() != nil {
+		return result, err
+	}
+	c.logFallback("parse_single_item", err)
+	return c.fallback.ParseItem(ctx, rawText, languageCode)
+}
+
+func (c *fallbackClient) ParseItems(ctx context.Context, rawText, languageCode string) ([]*ParsedResult, error) {
+	results, err := c.primary.ParseItems(ctx, rawText, languageCode)
+	if err == nil || ctx.Err() != nil {
+		return results, err
+	}
+	c.logFallback("parse_multiple_items", err)
+	return c.fallback.ParseItems(ctx, rawText, languageCode)
+}
+
+func (c *fallbackClient) DetectLanguage(ctx context.Context, text string) (string, error) {
+	language, err := c.primary.DetectLanguage(ctx, text)
+	if err == nil || ctx.Err() != nil {
+		return language, err
+	}
+	c.logFallback("detect_language", err)
+	return c.fallback.DetectLanguage(ctx, text)
+}
+
+func (c *fallbackClient) DetectProductList(ctx context.Context, text string) (*ProductListDetectionResult, error) {
+	result, err := c.primary.DetectProductList(ctx, text)
+	if err == nil || ctx.Err() != nil {
+		return result, err
+	}
+	c.logFallback("detect_product_list", err)
+	return c.fallback.DetectProductList(ctx, text)
+}
+
+func (c *fallbackClient) Translate(ctx context.Context, originalText, originalLanguage, targetLanguage string) (*TranslationResult, error) {
+	result, err := c.primary.Translate(ctx, originalText, originalLanguage, targetLanguage)
+	if err == nil || ctx.Err() != nil {
+		return result, err
+	}
+	c.logFallback("translate", err)
+	return c.fallback.Translate(ctx, originalText, originalLanguage, targetLanguage)
+}
+
+func (c *fallbackClient) BatchTranslateReceiptItems(ctx context.Context, req *BatchTranslationRequest) (*BatchTranslationResult, error) {
+	result, err := c.primary.BatchTranslateReceiptItems(ctx, req)
+	if err == nil || ctx.Err() != nil {
+		return result, err
+	}
+	c.logFallback("batch_translate", err)
+	return c.fallback.BatchTranslateReceiptItems(ctx, req)
+}
+
+func (c *fallbackClient) logFallback(operation string, err error) {
+	c.logger.Warn("LLM provider failed, answering with rule-based parser",
+		"operation", operation,
+		"error", err.Error(),
+		"component", "ai_service")
+}
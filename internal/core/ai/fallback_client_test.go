@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// stubClient answers every call with its result or error and counts the calls
+type stubClient struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (c *stubClient) ParseItem(ctx context.Context, rawText, languageCode string) (*ParsedResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ParsedResult{StandardizedName: c.name}, nil
+}
+
+func (c *stubClient) ParseItems(ctx context.Context, rawText, languageCode string) ([]*ParsedResult, error) {
+	result, err := c.ParseItem(ctx, rawText, languageCode)
+	if err != nil {
+		return nil, err
+	}
+	return []*ParsedResult{result}, nil
+}
+
+func (c *stubClient) DetectLanguage(ctx context.Context, text string) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.name, nil
+}
+
+func (c *stubClient) DetectProductList(ctx context.Context, text string) (*ProductListDetectionResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ProductListDetectionResult{SampleItems: []string{c.name}}, nil
+}
+
+func (c *stubClient) Translate(ctx context.Context, originalText, originalLanguage, targetLanguage string) (*TranslationResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &TranslationResult{TranslatedText: c.name}, nil
+}
+
+func (c *stubClient) BatchTranslateReceiptItems(ctx context.Context, req *BatchTranslationRequest) (*BatchTranslationResult, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &BatchTranslationResult{DetectedLanguage: c.name}, nil
+}
+
+func TestFallbackClient(t *testing.T) {
+	// Every operation of the client, returning the name of the client that answered
+	operations := map[string]func(ctx context.Context, client OpenAIClient) (string, error){
+		"ParseItem": func(ctx context.Context, client OpenAIClient) (string, error) {
+			result, err := client.ParseItem(ctx, "milk", "en")
+			if err != nil {
+				return "", err
+			}
+			return result.StandardizedName, nil
+		},
+		"ParseItems": func(ctx context.Context, client OpenAIClient) (string, error) {
+			results, err := client.ParseItems(ctx, "milk", "en")
+			if err != nil {
+				return "", err
+			}
+			return results[0].StandardizedName, nil
+		},
+		"DetectLanguage": func(ctx context.Context, client OpenAIClient) (string, error) {
+			return client.DetectLanguage(ctx, "milk")
+		},
+		"DetectProductList": func(ctx context.Context, client OpenAIClient) (string, error) {
+			result, err := client.DetectProductList(ctx, "milk")
+			if err != nil {
+				return "", err
+			}
+			return result.SampleItems[0], nil
+		},
+		"Translate": func(ctx context.Context, client OpenAIClient) (string, error) {
+			result, err := client.Translate(ctx, "milk", "en", "ru")
+			if err != nil {
+				return "", err
+			}
+			return result.TranslatedText, nil
+		},
+		"BatchTranslateReceiptItems": func(ctx context.Context, client OpenAIClient) (string, error) {
+			result, err := client.BatchTranslateReceiptItems(ctx, &BatchTranslationRequest{Items: []string{"milk"}})
+			if err != nil {
+				return "", err
+			}
+			return result.DetectedLanguage, nil
+		},
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		primaryErr    error
+		want          string
+		wantErr       bool
+		fallbackCalls int
+	}{
+		{name: "primary answers", ctx: context.Background(), want: "primary"},
+		{name: "primary fails", ctx: context.Background(), primaryErr: errors.New("timeout"), want: "fallback", fallbackCalls: 1},
+		{name: "canceled request", ctx: canceled, primaryErr: context.Canceled, wantErr: true},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for operation, call := range operations {
+		for _, tt := range tests {
+			t.Run(operation+"/"+tt.name, func(t *testing.T) {
+				primary := &stubClient{name: "primary", err: tt.primaryErr}
+				fallback := &stubClient{name: "fallback"}
+
+				got, err := call(tt.ctx, newFallbackClient(primary, fallback, logger))
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Errorf("answered by %q, want %q", got, tt.want)
+				}
+				if primary.calls != 1 || fallback.calls != tt.fallbackCalls {
+					t.Errorf("primary called %d times, fallback %d times, want 1 and %d", primary.calls, fallback.calls, tt.fallbackCalls)
+				}
+			})
+		}
+	}
+}
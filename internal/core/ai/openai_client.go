@@ -8,6 +8,7 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -29,6 +30,13 @@ type openAIClient struct {
 	promptBuilder  *PromptBuilder
 	productService ProductService
 
+	// Endpoint settings, these differ between OpenAI, Azure OpenAI and local servers
+	provider       string
+	apiVersion     string // sent as the api-version query parameter when set
+	authHeader     string // "Authorization" for a bearer token or "api-key" for Azure
+	detectionModel string // model used for language and product list detection
+	mappingMethod  string // recorded in item_mappings for the items this provider parsed
+
 	// Metrics
 	parseRequestsTotal       metric.Int64Counter
 	parseRequestDuration     metric.Float64Histogram
@@ -44,7 +52,7 @@ type openAIClient struct {
 type ChatCompletionRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
-	MaxTokens   int       `json:"max_tokens"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature"`
 	Store       *bool     `json:"store,omitempty"`
 }
@@ -115,6 +123,10 @@ func NewOpenAIClient(cfg config.OpenAIConfig, logger *slog.Logger, productServic
 }
 
 func NewOpenAIClientWithPrompts(cfg config.OpenAIConfig, logger *slog.Logger, promptsDir string, productService ProductService) OpenAIClient {
+	return newOpenAIClient(cfg, logger, promptsDir, productService)
+}
+
+func newOpenAIClient(cfg config.OpenAIConfig, logger *slog.Logger, promptsDir string, productService ProductService) *openAIClient {
 	if cfg.BaseURL == "" {
 		cfg.BaseURL = "https://api.openai.com/v1"
 	}
@@ -198,6 +210,11 @@ func NewOpenAIClientWithPrompts(cfg config.OpenAIConfig, logger *slog.Logger, pr
 		promptBuilder:  promptBuilder,
 		productService: productService,
 
+		provider:       ProviderOpenAI,
+		authHeader:     "Authorization",
+		detectionModel: "gpt-5-nano",
+		mappingMethod:  MappingMethodOpenAI,
+
 		// Metrics
 		parseRequestsTotal:       parseRequestsTotal,
 		parseRequestDuration:     parseRequestDuration,
@@ -210,12 +227,34 @@ func NewOpenAIClientWithPrompts(cfg config.OpenAIConfig, logger *slog.Logger, pr
 	}
 }
 
+// endpoint returns the URL of an API path such as "/responses"
+func (c *openAIClient) endpoint(path string) string {
+	endpoint := strings.TrimSuffix(c.config.BaseURL, "/") + path
+	if c.apiVersion != "" {
+		endpoint += "?api-version=" + url.QueryEscape(c.apiVersion)
+	}
+	return endpoint
+}
+
+// authorize sets the API key header, local servers usually don't need one
+func (c *openAIClient) authorize(req *http.Request) {
+	if c.config.APIKey == "" {
+		return
+	}
+	if c.authHeader == "Authorization" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+		return
+	}
+	req.Header.Set(c.authHeader, c.config.APIKey)
+}
+
 func (c *openAIClient) ParseItem(ctx context.Context, rawText, languageCode string) (*ParsedResult, error) {
 	startTime := time.Now()
 
 	// Record parse request metrics
 	apiType := map[bool]string{true: "responses_api", false: "chat_completions"}[c.config.UseResponsesAPI]
 	attrs := []attribute.KeyValue{
+		attribute.String("provider", c.provider),
 		attribute.String("api_type", apiType),
 		attribute.String("language_code", languageCode),
 		attribute.String("operation", "parse_single_item"),
@@ -279,6 +318,7 @@ func (c *openAIClient) ParseItems(ctx context.Context, rawText, languageCode str
 	// Record parse request metrics
 	apiType := map[bool]string{true: "responses_api", false: "chat_completions"}[c.config.UseResponsesAPI]
 	attrs := []attribute.KeyValue{
+		attribute.String("provider", c.provider),
 		attribute.String("api_type", apiType),
 		attribute.String("language_code", languageCode),
 		attribute.String("operation", "parse_multiple_items"),
@@ -405,11 +445,11 @@ func (c *openAIClient) parseItemWithResponsesAPI(ctx context.Context, rawText, l
 		return nil, fmt.Errorf("failed to marshal responses request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint("/responses"), bytes.NewBuffer(jsonBody))
 	if err != nil {
 		c.logger.Error("Failed to create HTTP request",
 			"error", err.Error(),
-			"url", c.config.BaseURL+"/responses",
+			"url", c.endpoint("/responses"),
 			"raw_text", rawText,
 			"language_code", languageCode,
 			"component", "ai_parsing",
@@ -418,7 +458,7 @@ func (c *openAIClient) parseItemWithResponsesAPI(ctx context.Context, rawText, l
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.authorize(req)
 
 	requestStart := time.Now()
 
@@ -444,7 +484,7 @@ func (c *openAIClient) parseItemWithResponsesAPI(ctx context.Context, rawText, l
 
 		c.logger.Error("HTTP request to OpenAI failed",
 			"error", err.Error(),
-			"url", c.config.BaseURL+"/responses",
+			"url", c.endpoint("/responses"),
 			"request_duration_ms", requestDuration.Milliseconds(),
 			"raw_text", rawText,
 			"language_code", languageCode,
@@ -480,7 +520,7 @@ func (c *openAIClient) parseItemWithResponsesAPI(ctx context.Context, rawText, l
 			"status_code", resp.StatusCode,
 			"response_body", string(body),
 			"request_duration_ms", requestDuration.Milliseconds(),
-			"url", c.config.BaseURL+"/responses",
+			"url", c.endpoint("/responses"),
 			"raw_text", rawText,
 			"language_code", languageCode,
 			"component", "ai_parsing",
@@ -544,13 +584,13 @@ func (c *openAIClient) parseItemWithChatCompletions(ctx context.Context, rawText
 		return nil, fmt.Errorf("failed to marshal chat completion request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint("/chat/completions"), bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat completion request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.authorize(req)
 
 	requestStart := time.Now()
 
@@ -629,6 +669,93 @@ func (c *openAIClient) parseItemWithChatCompletions(ctx context.Context, rawText
 	return result, nil
 }
 
+// completionRequest is a single prompt sent through whichever API the client is configured for
+type completionRequest struct {
+	operation       string // e.g. "translation", used in error messages
+	model           string
+	prompt          string
+	reasoningEffort string
+	store           bool
+}
+
+// complete sends the prompt through the Responses API or Chat Completions and returns the text output.
+// OpenAI itself is always called through the Responses API, the detection model requires it.
+func (c *openAIClient) complete(ctx context.Context, completion completionRequest) (string, error) {
+	useResponsesAPI := c.provider == ProviderOpenAI || c.config.UseResponsesAPI
+
+	var reqBody any
+	path := "/chat/completions"
+	if useResponsesAPI {
+		path = "/responses"
+		responsesReq := ResponsesRequest{
+			Model:     completion.model,
+			Input:     []ResponsesMessage{{Role: "user", Content: completion.prompt}},
+			Reasoning: &ResponsesReasoning{Effort: completion.reasoningEffort},
+		}
+		if completion.store {
+			responsesReq.Store = &completion.store
+		}
+		reqBody = responsesReq
+	} else {
+		reqBody = ChatCompletionRequest{
+			Model:       completion.model,
+			Messages:    []Message{{Role: "user", Content: completion.prompt}},
+			Temperature: c.config.Temperature,
+		}
+	}
+
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s request: %w", completion.operation, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(path), bytes.NewReader(reqJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s request: %w", completion.operation, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s API request failed: %w", completion.operation, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s response: %w", completion.operation, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s API returned status %d: %s", completion.operation, resp.StatusCode, string(body))
+	}
+
+	if !useResponsesAPI {
+		var chatResp ChatCompletionResponse
+		if err := json.Unmarshal(body, &chatResp); err != nil {
+			return "", fmt.Errorf("failed to unmarshal %s response: %w", completion.operation, err)
+		}
+		if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+			return "", fmt.Errorf("no %s output returned", completion.operation)
+		}
+		return chatResp.Choices[0].Message.Content, nil
+	}
+
+	var apiResp ResponsesResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal %s response: %w", completion.operation, err)
+	}
+
+	content, err := c.extractOutputText(apiResp.Output)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s output: %w", completion.operation, err)
+	}
+
+	return content, nil
+}
+
 func (c *openAIClient) extractOutputText(output []ResponsesOutputItem) (string, error) {
 	for _, item := range output {
 		if item.Type == "message" && item.Role == "assistant" {
@@ -683,6 +810,7 @@ func (c *openAIClient) parseAIResponse(content string) (*ParsedResult, error) {
 	if result.ConfidenceScore < 0.0 || result.ConfidenceScore > 1.0 {
 		result.ConfidenceScore = 0.5 // Default confidence
 	}
+	result.mappingMethod = c.mappingMethod
 
 	return &result, nil
 }
@@ -719,20 +847,20 @@ func (c *openAIClient) parseItemsWithResponsesAPI(ctx context.Context, rawText,
 
 	// Log the full request being sent to OpenAI
 	c.logger.Info("[MULTI] Sending request to OpenAI Responses API",
-		"url", c.config.BaseURL+"/responses",
+		"url", c.endpoint("/responses"),
 		"model", c.config.Model,
 		"api_key_prefix", c.config.APIKey[:min(10, len(c.config.APIKey))]+"...", // Only log first 10 chars for security
 		"raw_text", rawText,
 		"language", languageCode,
 		"request_body", string(jsonBody))
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint("/responses"), bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create responses request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.authorize(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -811,20 +939,20 @@ func (c *openAIClient) parseItemsWithChatCompletions(ctx context.Context, rawTex
 
 	// Log the full request being sent to OpenAI
 	c.logger.Info("[MULTI-CHAT] Sending request to OpenAI Chat Completions API",
-		"url", c.config.BaseURL+"/chat/completions",
+		"url", c.endpoint("/chat/completions"),
 		"model", c.config.Model,
 		"api_key_prefix", c.config.APIKey[:min(10, len(c.config.APIKey))]+"...",
 		"raw_text", rawText,
 		"language", languageCode,
 		"request_body", string(jsonBody))
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint("/chat/completions"), bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chat completion request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.authorize(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -944,6 +1072,7 @@ func (c *openAIClient) parseAIResponseAsArray(content string) ([]*ParsedResult,
 		if result.ConfidenceScore < 0.0 || result.ConfidenceScore > 1.0 {
 			result.ConfidenceScore = 0.5 // Default confidence
 		}
+		result.mappingMethod = c.mappingMethod
 	}
 
 	return results, nil
@@ -965,80 +1094,24 @@ func (c *openAIClient) DetectLanguage(ctx context.Context, text string) (string,
 		return "", fmt.Errorf("prompt builder is required for language detection")
 	}
 
-	reqData := ResponsesRequest{
-		Model: "gpt-5-nano",
-		Input: []ResponsesMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Store:     &[]bool{true}[0],
-		Reasoning: &ResponsesReasoning{Effort: "low"},
-	}
-
-	reqJSON, err := json.Marshal(reqData)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal language detection request: %w", err)
-	}
-
-	c.logger.Info("[LANG] Sending language detection request to OpenAI Responses API",
-		"url", c.config.BaseURL+"/responses",
-		"model", reqData.Model,
-		"text", text,
-		"request_body", string(reqJSON))
-
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewReader(reqJSON))
+	model := c.detectionModel
+	content, err := c.complete(ctx, completionRequest{
+		operation:       "language detection",
+		model:           model,
+		prompt:          prompt,
+		reasoningEffort: "low",
+		store:           true,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create language detection request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("language detection API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read language detection response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("language detection API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp ResponsesResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal language detection response: %w", err)
-	}
-
-	if len(apiResp.Output) == 0 {
-		return "", fmt.Errorf("no language detection output returned")
-	}
-
-	// Find the assistant message in the output
-	var content string
-	for _, output := range apiResp.Output {
-		if output.Role == "assistant" && len(output.Content) > 0 {
-			content = output.Content[0].Text
-			break
-		}
-	}
-
-	if content == "" {
-		return "", fmt.Errorf("no assistant response found in language detection output")
+		return "", err
 	}
 
 	detectedLanguage := strings.TrimSpace(strings.ToLower(content))
 
-	c.logger.Info("Successfully detected language with Responses API",
+	c.logger.Info("Successfully detected language",
 		"text", text,
 		"detected_language", detectedLanguage,
-		"model", reqData.Model)
+		"model", model)
 
 	return detectedLanguage, nil
 }
@@ -1059,72 +1132,16 @@ func (c *openAIClient) DetectProductList(ctx context.Context, text string) (*Pro
 		return nil, fmt.Errorf("prompt builder is required for product list detection")
 	}
 
-	reqData := ResponsesRequest{
-		Model: "gpt-5-nano",
-		Input: []ResponsesMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Store:     &[]bool{true}[0],
-		Reasoning: &ResponsesReasoning{Effort: "low"},
-	}
-
-	reqJSON, err := json.Marshal(reqData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal product list detection request: %w", err)
-	}
-
-	c.logger.Info("[PRODUCT-LIST] Sending product list detection request to OpenAI Responses API",
-		"url", c.config.BaseURL+"/responses",
-		"model", reqData.Model,
-		"text", text,
-		"request_body", string(reqJSON))
-
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewReader(reqJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create product list detection request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-
-	resp, err := c.httpClient.Do(req)
+	model := c.detectionModel
+	content, err := c.complete(ctx, completionRequest{
+		operation:       "product list detection",
+		model:           model,
+		prompt:          prompt,
+		reasoningEffort: "low",
+		store:           true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("product list detection API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read product list detection response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("product list detection API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp ResponsesResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal product list detection response: %w", err)
-	}
-
-	if len(apiResp.Output) == 0 {
-		return nil, fmt.Errorf("no product list detection output returned")
-	}
-
-	// Find the assistant message in the output
-	var content string
-	for _, output := range apiResp.Output {
-		if output.Role == "assistant" && len(output.Content) > 0 {
-			content = output.Content[0].Text
-			break
-		}
-	}
-
-	if content == "" {
-		return nil, fmt.Errorf("no assistant response found in product list detection output")
+		return nil, err
 	}
 
 	// Parse the JSON response
@@ -1150,18 +1167,18 @@ func (c *openAIClient) DetectProductList(ctx context.Context, text string) (*Pro
 		result.Confidence = 0.5 // Default confidence
 	}
 
-	c.logger.Info("Successfully detected product list with Responses API",
+	c.logger.Info("Successfully detected product list",
 		"text", text,
 		"is_product_list", result.IsProductList,
 		"confidence", result.Confidence,
 		"detected_items_count", result.DetectedItemsCount,
 		"sample_items", result.SampleItems,
-		"model", reqData.Model)
+		"model", model)
 
 	return &result, nil
 }
 
-// Translate translates text from one language to another using OpenAI Responses API
+// Translate translates text from one language to another
 func (c *openAIClient) Translate(ctx context.Context, originalText, originalLanguage, targetLanguage string) (*TranslationResult, error) {
 	// Build prompt using PromptBuilder
 	var prompt string
@@ -1177,67 +1194,22 @@ func (c *openAIClient) Translate(ctx context.Context, originalText, originalLang
 		return nil, fmt.Errorf("prompt builder is required for translation")
 	}
 
-	// Use Responses API only
-	reqData := ResponsesRequest{
-		Model: c.config.Model,
-		Input: []ResponsesMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Reasoning: &ResponsesReasoning{Effort: "low"}, // Translation doesn't need high reasoning
-	}
-
-	// Enable storage if configured
-	if c.config.Store {
-		reqData.Store = &c.config.Store
-	}
-
-	reqJSON, err := json.Marshal(reqData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal translation request: %w", err)
-	}
-
-	c.logger.Info("[TRANSLATE] Sending translation request to OpenAI Responses API",
-		"url", c.config.BaseURL+"/responses",
-		"model", reqData.Model,
+	c.logger.Info("[TRANSLATE] Sending translation request",
+		"provider", c.provider,
+		"model", c.config.Model,
 		"original_text", originalText,
 		"from", originalLanguage,
 		"to", targetLanguage)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewReader(reqJSON))
+	translatedText, err := c.complete(ctx, completionRequest{
+		operation:       "translation",
+		model:           c.config.Model,
+		prompt:          prompt,
+		reasoningEffort: "low", // Translation doesn't need high reasoning
+		store:           c.config.Store,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create translation request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("translation API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read translation response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("translation API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp ResponsesResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal translation response: %w", err)
-	}
-
-	// Extract text from responses output
-	translatedText, err := c.extractOutputText(apiResp.Output)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract translated text: %w", err)
+		return nil, err
 	}
 
 	// Clean up the response
@@ -1246,13 +1218,13 @@ func (c *openAIClient) Translate(ctx context.Context, originalText, originalLang
 	// Calculate confidence
 	confidence := c.calculateTranslationConfidence(originalText, translatedText, originalLanguage, targetLanguage)
 
-	c.logger.Info("Successfully translated text with Responses API",
+	c.logger.Info("Successfully translated text",
 		"original_text", originalText,
 		"translated_text", translatedText,
 		"from", originalLanguage,
 		"to", targetLanguage,
 		"confidence", confidence,
-		"model", reqData.Model)
+		"model", c.config.Model)
 
 	return &TranslationResult{
 		TranslatedText: translatedText,
@@ -1315,67 +1287,23 @@ func (c *openAIClient) BatchTranslateReceiptItems(ctx context.Context, req *Batc
 		return nil, fmt.Errorf("failed to build batch translation prompt: %w", err)
 	}
 
-	// Use configured model with low reasoning for translation
-	reqData := ResponsesRequest{
-		Model: c.config.Model, // Use configured model (gpt-5-nano)
-		Input: []ResponsesMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
-		Reasoning: &ResponsesReasoning{Effort: "low"}, // Low effort is sufficient for translation
-	}
-
-	// Enable storage if configured
-	if c.config.Store {
-		reqData.Store = &c.config.Store
-	}
-
-	reqJSON, err := json.Marshal(reqData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal batch translation request: %w", err)
-	}
-
-	c.logger.Info("[BATCH-TRANSLATE] Sending batch translation request to OpenAI Responses API",
-		"url", c.config.BaseURL+"/responses",
-		"model", reqData.Model,
+	c.logger.Info("[BATCH-TRANSLATE] Sending batch translation request",
+		"provider", c.provider,
+		"model", c.config.Model,
 		"items_count", len(req.Items),
 		"target_locale", req.TargetLocale,
 		"items", req.Items)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/responses", bytes.NewReader(reqJSON))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create batch translation request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("batch translation API request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read batch translation response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("batch translation API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp ResponsesResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal batch translation response: %w", err)
-	}
-
-	// Extract text from responses output
-	responseText, err := c.extractOutputText(apiResp.Output)
+	// Use configured model with low reasoning for translation
+	responseText, err := c.complete(ctx, completionRequest{
+		operation:       "batch translation",
+		model:           c.config.Model,
+		prompt:          prompt,
+		reasoningEffort: "low", // Low effort is sufficient for translation
+		store:           c.config.Store,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to extract batch translation text: %w", err)
+		return nil, err
 	}
 
 	// Parse the batch translation response
@@ -1394,7 +1322,7 @@ func (c *openAIClient) BatchTranslateReceiptItems(ctx context.Context, req *Batc
 		"detected_language", result.DetectedLanguage,
 		"translations_count", len(result.Translations),
 		"confidence", result.Confidence,
-		"model", reqData.Model)
+		"model", c.config.Model)
 
 	return result, nil
 }
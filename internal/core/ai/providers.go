@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/PocketPalCo/shopping-service/config"
+)
+
+// Supported LLM providers
+const (
+	ProviderOpenAI = "openai"
+	ProviderAzure  = "azure"
+	ProviderLocal  = "local"
+	ProviderRules  = "rules"
+)
+
+// NewClient creates the AI client for the configured LLM provider. When fallback to rules is
+// enabled, an unconfigured provider degrades to the rule-based parser and provider errors are
+// answered by it instead of being returned.
+func NewClient(cfg config.LLMConfig, logger *slog.Logger, productService ProductService) (OpenAIClient, error) {
+	return NewClientWithPrompts(cfg, logger, "prompts", productService)
+}
+
+func NewClientWithPrompts(cfg config.LLMConfig, logger *slog.Logger, promptsDir string, productService ProductService) (OpenAIClient, error) {
+	provider := strings.ToLower(cfg.Provider)
+	if provider == "" {
+		provider = ProviderOpenAI
+	}
+
+	if provider == ProviderRules {
+		return NewRuleBasedClient(logger, productService), nil
+	}
+
+	if err := ValidateLLMConfig(cfg); err != nil {
+		if !cfg.FallbackToRules {
+			return nil, err
+		}
+		logger.Warn("LLM provider not configured, falling back to rule-based parsing",
+			"provider", provider,
+			"error", err.Error(),
+			"component", "ai_service")
+		return NewRuleBasedClient(logger, productService), nil
+	}
+
+	var client OpenAIClient = newProviderClient(cfg, logger, promptsDir, productService)
+	if cfg.FallbackToRules {
+		client = newFallbackClient(client, NewRuleBasedClient(logger, productService), logger)
+	}
+
+	logger.Info("LLM provider initialized",
+		"provider", provider,
+		"fallback_to_rules", cfg.FallbackToRules,
+		"component", "ai_service")
+
+	return client, nil
+}
+
+// newProviderClient creates the HTTP client of a validated openai, azure or local provider
+func newProviderClient(cfg config.LLMConfig, logger *slog.Logger, promptsDir string, productService ProductService) *openAIClient {
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderAzure:
+		return newAzureOpenAIClient(cfg.Azure, cfg.OpenAI, logger, promptsDir, productService)
+	case ProviderLocal:
+		return newLocalLLMClient(cfg.Local, cfg.OpenAI, logger, promptsDir, productService)
+	default:
+		return newOpenAIClient(cfg.OpenAI, logger, promptsDir, productService)
+	}
+}
+
+// ValidateLLMConfig validates the configuration of the selected LLM provider
+func ValidateLLMConfig(cfg config.LLMConfig) error {
+	provider := strings.ToLower(cfg.Provider)
+
+	switch provider {
+	case "", ProviderOpenAI:
+		if cfg.OpenAI.APIKey == "" {
+			return fmt.Errorf("OpenAI API key not configured (set SSV_OPENAI_API_KEY)")
+		}
+	case ProviderAzure:
+		if cfg.Azure.Endpoint == "" {
+			return fmt.Errorf("Azure OpenAI endpoint not configured (set SSV_AZURE_OPENAI_ENDPOINT)")
+		}
+		if cfg.Azure.APIKey == "" {
+			return fmt.Errorf("Azure OpenAI API key not configured (set SSV_AZURE_OPENAI_API_KEY)")
+		}
+		if cfg.Azure.Deployment == "" {
+			return fmt.Errorf("Azure OpenAI deployment not configured (set SSV_AZURE_OPENAI_DEPLOYMENT)")
+		}
+	case ProviderLocal:
+		if cfg.Local.BaseURL == "" {
+			return fmt.Errorf("local LLM base URL not configured (set SSV_LOCAL_LLM_BASE_URL)")
+		}
+		if cfg.Local.Model == "" {
+			return fmt.Errorf("local LLM model not configured (set SSV_LOCAL_LLM_MODEL)")
+		}
+	case ProviderRules:
+		return nil
+	default:
+		return fmt.Errorf("unsupported LLM provider: %s", provider)
+	}
+
+	return nil
+}
+
+// newAzureOpenAIClient creates a client for an Azure OpenAI deployment. Azure serves the OpenAI
+// v1 API under /openai/v1 of the resource endpoint and authenticates with the api-key header.
+func newAzureOpenAIClient(azure config.AzureOpenAIConfig, options config.OpenAIConfig, logger *slog.Logger, promptsDir string, productService ProductService) *openAIClient {
+	options.APIKey = azure.APIKey
+	options.BaseURL = strings.TrimSuffix(azure.Endpoint, "/") + "/openai/v1"
+	options.Model = azure.Deployment
+
+	client := newOpenAIClient(options, logger, promptsDir, productService)
+	client.provider = ProviderAzure
+	client.apiVersion = azure.APIVersion
+	client.authHeader = "api-key"
+	client.detectionModel = azure.Deployment
+	client.mappingMethod = MappingMethodAzure
+	return client
+}
+
+// newLocalLLMClient creates a client for an OpenAI-compatible local server such as Ollama or
+// llama.cpp. These servers don't implement the Responses API, so Chat Completions is always used.
+func newLocalLLMClient(local config.LocalLLMConfig, options config.OpenAIConfig, logger *slog.Logger, promptsDir string, productService ProductService) *openAIClient {
+	options.APIKey = local.APIKey
+	options.BaseURL = local.BaseURL
+	options.Model = local.Model
+
+	client := newOpenAIClient(options, logger, promptsDir, productService)
+	client.config.UseResponsesAPI = false
+	client.config.Store = false
+	client.provider = ProviderLocal
+	client.detectionModel = local.Model
+	client.mappingMethod = MappingMethodLocal
+	return client
+}
@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/PocketPalCo/shopping-service/config"
+)
+
+func TestValidateLLMConfig(t *testing.T) {
+	azure := config.AzureOpenAIConfig{Endpoint: "https://shop.openai.azure.com", APIKey: "key", Deployment: "gpt-4o-mini"}
+	local := config.LocalLLMConfig{BaseURL: "http://localhost:11434/v1", Model: "llama3.1"}
+
+	tests := []struct {
+		name    string
+		cfg     config.LLMConfig
+		wantErr string
+	}{
+		{"openai by default", config.LLMConfig{OpenAI: config.OpenAIConfig{APIKey: "sk-test"}}, ""},
+		{"openai without key", config.LLMConfig{Provider: "OpenAI"}, "SSV_OPENAI_API_KEY"},
+		{"azure", config.LLMConfig{Provider: ProviderAzure, Azure: azure}, ""},
+		{"azure without endpoint", config.LLMConfig{Provider: ProviderAzure, Azure: config.AzureOpenAIConfig{APIKey: "key", Deployment: "d"}}, "SSV_AZURE_OPENAI_ENDPOINT"},
+		{"azure without key", config.LLMConfig{Provider: ProviderAzure, Azure: config.AzureOpenAIConfig{Endpoint: "https://e", Deployment: "d"}}, "SSV_AZURE_OPENAI_API_KEY"},
+		{"azure without deployment", config.LLMConfig{Provider: ProviderAzure, Azure: config.AzureOpenAIConfig{Endpoint: "https://e", APIKey: "key"}}, "SSV_AZURE_OPENAI_DEPLOYMENT"},
+		{"local", config.LLMConfig{Provider: ProviderLocal, Local: local}, ""},
+		{"local without base URL", config.LLMConfig{Provider: ProviderLocal, Local: config.LocalLLMConfig{Model: "llama3.1"}}, "SSV_LOCAL_LLM_BASE_URL"},
+		{"local without model", config.LLMConfig{Provider: ProviderLocal, Local: config.LocalLLMConfig{BaseURL: "http://localhost:11434/v1"}}, "SSV_LOCAL_LLM_MODEL"},
+		{"rules", config.LLMConfig{Provider: ProviderRules}, ""},
+		{"unknown provider", config.LLMConfig{Provider: "bard"}, "unsupported LLM provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLLMConfig(tt.cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateLLMConfig() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateLLMConfig() = %v, want error mentioning %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewProviderClient(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		cfg            config.LLMConfig
+		provider       string
+		baseURL        string
+		model          string
+		apiKey         string
+		authHeader     string
+		apiVersion     string
+		mappingMethod  string
+		responsesAPI   bool
+		detectionModel string
+	}{
+		{
+			name:           "openai",
+			cfg:            config.LLMConfig{OpenAI: config.OpenAIConfig{APIKey: "sk-test"}},
+			provider:       ProviderOpenAI,
+			baseURL:        "https://api.openai.com/v1",
+			model:          "gpt-5-nano",
+			apiKey:         "sk-test",
+			authHeader:     "Authorization",
+			mappingMethod:  MappingMethodOpenAI,
+			responsesAPI:   true,
+			detectionModel: "gpt-5-nano",
+		},
+		{
+			name: "azure",
+			cfg: config.LLMConfig{
+				Provider: "Azure",
+				OpenAI:   config.OpenAIConfig{APIKey: "sk-unused"},
+				Azure: config.AzureOpenAIConfig{
+					Endpoint:   "https://shop.openai.azure.com/",
+					APIKey:     "azure-key",
+					Deployment: "gpt-4o-mini",
+					APIVersion: "preview",
+				},
+			},
+			provider:       ProviderAzure,
+			baseURL:        "https://shop.openai.azure.com/openai/v1",
+			model:          "gpt-4o-mini",
+			apiKey:         "azure-key",
+			authHeader:     "api-key",
+			apiVersion:     "preview",
+			mappingMethod:  MappingMethodAzure,
+			detectionModel: "gpt-4o-mini",
+		},
+		{
+			name: "local",
+			cfg: config.LLMConfig{
+				Provider: ProviderLocal,
+				OpenAI:   config.OpenAIConfig{APIKey: "sk-unused", UseResponsesAPI: true},
+				Local:    config.LocalLLMConfig{BaseURL: "http://localhost:11434/v1", Model: "llama3.1"},
+			},
+			provider:       ProviderLocal,
+			baseURL:        "http://localhost:11434/v1",
+			model:          "llama3.1",
+			authHeader:     "Authorization",
+			mappingMethod:  MappingMethodLocal,
+			detectionModel: "llama3.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newProviderClient(tt.cfg, logger, t.TempDir(), nil)
+
+			if client.provider != tt.provider || client.config.BaseURL != tt.baseURL || client.config.Model != tt.model ||
+				client.config.APIKey != tt.apiKey || client.authHeader != tt.authHeader || client.apiVersion != tt.apiVersion ||
+				client.config.UseResponsesAPI != tt.responsesAPI || client.detectionModel != tt.detectionModel {
+				t.Errorf("client = provider %q, base URL %q, model %q, key %q, auth %q, api-version %q, responses %v, detection %q",
+					client.provider, client.config.BaseURL, client.config.Model, client.config.APIKey, client.authHeader,
+					client.apiVersion, client.config.UseResponsesAPI, client.detectionModel)
+			}
+			if client.mappingMethod != tt.mappingMethod {
+				t.Errorf("mappingMethod = %q, want %q", client.mappingMethod, tt.mappingMethod)
+			}
+
+			// Items parsed by the provider are recorded with its mapping method
+			result, err := client.parseAIResponse(`{"standardized_name": "milk", "confidence_score": 0.8}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results, err := client.parseAIResponseAsArray(`[{"standardized_name": "milk", "confidence_score": 0.8}]`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.MappingMethod() != tt.mappingMethod || results[0].MappingMethod() != tt.mappingMethod {
+				t.Errorf("MappingMethod() = %q, %q, want %q", result.MappingMethod(), results[0].MappingMethod(), tt.mappingMethod)
+			}
+		})
+	}
+}
+
+func TestNewClientWithPrompts(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	openAI := config.OpenAIConfig{APIKey: "sk-test"}
+
+	tests := []struct {
+		name    string
+		cfg     config.LLMConfig
+		want    string // type of the returned client
+		wantErr bool
+	}{
+		{"rules provider", config.LLMConfig{Provider: ProviderRules}, "*ai.ruleBasedClient", false},
+		{"provider", config.LLMConfig{OpenAI: openAI}, "*ai.openAIClient", false},
+		{"provider with fallback", config.LLMConfig{OpenAI: openAI, FallbackToRules: true}, "*ai.fallbackClient", false},
+		{"unconfigured provider", config.LLMConfig{Provider: ProviderAzure}, "", true},
+		{"unconfigured provider with fallback", config.LLMConfig{Provider: ProviderAzure, FallbackToRules: true}, "*ai.ruleBasedClient", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientWithPrompts(tt.cfg, logger, t.TempDir(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewClientWithPrompts() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := fmt.Sprintf("%T", client); !tt.wantErr && got != tt.want {
+				t.Errorf("NewClientWithPrompts() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
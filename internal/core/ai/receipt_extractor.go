@@ -0,0 +1,194 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Supported receipt extraction backends
+const (
+	ReceiptExtractorAzure     = "azure"
+	ReceiptExtractorVision    = "vision"
+	ReceiptExtractorTesseract = "tesseract"
+)
+
+// ReceiptExtractor extracts structured receipt data from an image or PDF
+type ReceiptExtractor interface {
+	AnalyzeReceipt(ctx context.Context, imageData []byte, contentType string) (*ReceiptData, error)
+}
+
+// namedReceiptExtractor is a backend of the extraction chain
+type namedReceiptExtractor struct {
+	name      string
+	extractor ReceiptExtractor
+}
+
+// receiptExtractorChain tries its backends in order until one succeeds with at least minConfidence.
+// When every backend answers below it, the most confident result is returned.
+type receiptExtractorChain struct {
+	extractors    []namedReceiptExtractor
+	minConfidence float64
+	logger        *slog.Logger
+
+	// Metrics
+	extractionAttemptsTotal metric.Int64Counter
+}
+
+// NewReceiptExtractor creates the receipt extraction chain of the configured backends. Backends that
+// are not configured are skipped, Azure Document Intelligence is used when none is left.
+func NewReceiptExtractor(cfg config.Config, logger *slog.Logger) ReceiptExtractor {
+	extractionConfig := cfg.GetReceiptExtractionConfig()
+	docIntelConfig := cfg.GetDocumentIntelligenceConfig()
+	docIntelService := NewDocumentIntelligenceService(
+		docIntelConfig.Endpoint,
+		docIntelConfig.APIKey,
+		docIntelConfig.APIVersion,
+		docIntelConfig.Model,
+	)
+
+	var extractors []namedReceiptExtractor
+	for _, name := range extractionConfig.Extractors {
+		switch name {
+		case ReceiptExtractorAzure:
+			if err := docIntelService.ValidateConfiguration(); err != nil {
+				logger.Warn("Document Intelligence service not configured properly, skipping receipt extractor",
+					"extractor", name,
+					"error", err)
+				continue
+			}
+			extractors = append(extractors, namedReceiptExtractor{name: name, extractor: docIntelService})
+		case ReceiptExtractorVision:
+			llmConfig := cfg.GetLLMConfig()
+			if err := ValidateLLMConfig(llmConfig); err != nil || strings.EqualFold(llmConfig.Provider, ProviderRules) {
+				logger.Warn("LLM provider not configured, skipping vision receipt extractor",
+					"extractor", name,
+					"provider", llmConfig.Provider)
+				continue
+			}
+			client := newProviderClient(llmConfig, logger, "prompts", nil)
+			extractors = append(extractors, namedReceiptExtractor{
+				name:      name,
+				extractor: newVisionReceiptExtractor(client, extractionConfig.VisionModel, logger),
+			})
+		case ReceiptExtractorTesseract:
+			extractors = append(extractors, namedReceiptExtractor{
+				name:      name,
+				extractor: newTesseractReceiptExtractor(extractionConfig.TesseractPath, extractionConfig.TesseractLangs, extractionConfig.TesseractTimeout, logger),
+			})
+		default:
+			logger.Warn("Unsupported receipt extractor, skipping", "extractor", name)
+		}
+	}
+
+	if len(extractors) == 0 {
+		extractors = append(extractors, namedReceiptExtractor{name: ReceiptExtractorAzure, extractor: docIntelService})
+	}
+
+	if len(extractors) == 1 {
+		return extractors[0].extractor
+	}
+
+	meter := otel.Meter("ai-service")
+	extractionAttemptsTotal, _ := meter.Int64Counter(
+		"receipt_extraction_attempts_total",
+		metric.WithDescription("Total number of receipt extraction attempts per backend and outcome"),
+		metric.WithUnit("1"),
+	)
+
+	return &receiptExtractorChain{
+		extractors:              extractors,
+		minConfidence:           extractionConfig.MinConfidence,
+		logger:                  logger,
+		extractionAttemptsTotal: extractionAttemptsTotal,
+	}
+}
+
+func (c *receiptExtractorChain) AnalyzeReceipt(ctx context.Context, imageData []byte, contentType string) (*ReceiptData, error) {
+	var best *ReceiptData
+	var errs []error
+
+	for _, named := range c.extractors {
+		startTime := time.Now()
+		receiptData, err := named.extractor.AnalyzeReceipt(ctx, imageData, contentType)
+		duration := time.Since(startTime)
+
+		if err != nil {
+			c.recordAttempt(ctx, named.name, "error")
+			c.logger.Warn("Receipt extractor failed, trying next one",
+				"extractor", named.name,
+				"error", err.Error(),
+				"duration_ms", duration.Milliseconds())
+			errs = append(errs, fmt.Errorf("%s: %w", named.name, err))
+
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+
+		if receiptData.Confidence >= c.minConfidence {
+			c.recordAttempt(ctx, named.name, "success")
+			c.logger.Info("Receipt extracted",
+				"extractor", named.name,
+				"confidence", receiptData.Confidence,
+				"items_extracted", len(receiptData.Items),
+				"duration_ms", duration.Milliseconds())
+			return receiptData, nil
+		}
+
+		c.recordAttempt(ctx, named.name, "low_confidence")
+		c.logger.Warn("Receipt extractor returned low confidence, trying next one",
+			"extractor", named.name,
+			"confidence", receiptData.Confidence,
+			"min_confidence", c.minConfidence,
+			"duration_ms", duration.Milliseconds())
+
+		if best == nil || receiptData.Confidence > best.Confidence {
+			best = receiptData
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+
+	return nil, fmt.Errorf("all receipt extractors failed: %w", errors.Join(errs...))
+}
+
+func (c *receiptExtractorChain) recordAttempt(ctx context.Context, extractor, outcome string) {
+	c.extractionAttemptsTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("extractor", extractor),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// receiptTotalsConsistency returns how well the item totals add up to the receipt total, from 0 when
+// they don't to 1 when they match within a cent per item. Used to score backends without their own
+// confidence.
+func receiptTotalsConsistency(receiptData *ReceiptData) float64 {
+	if receiptData.Total <= 0 || len(receiptData.Items) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, item := range receiptData.Items {
+		sum += item.TotalPrice
+	}
+
+	difference := math.Abs(sum - receiptData.Total)
+	if difference <= 0.01*float64(len(receiptData.Items)) {
+		return 1
+	}
+
+	return math.Max(0, 1-difference/receiptData.Total)
+}
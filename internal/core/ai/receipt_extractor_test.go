@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// stubReceiptExtractor answers with a receipt of its confidence or its error and counts the calls
+type stubReceiptExtractor struct {
+	confidence float64
+	err        error
+	calls      int
+}
+
+func (e *stubReceiptExtractor) AnalyzeReceipt(ctx context.Context, imageData []byte, contentType string) (*ReceiptData, error) {
+	e.calls++
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &ReceiptData{Confidence: e.confidence}, nil
+}
+
+func TestReceiptExtractorChainAnalyzeReceipt(t *testing.T) {
+	failing := func() *stubReceiptExtractor { return &stubReceiptExtractor{err: errors.New("unavailable")} }
+	answering := func(confidence float64) *stubReceiptExtractor { return &stubReceiptExtractor{confidence: confidence} }
+
+	tests := []struct {
+		name       string
+		extractors []*stubReceiptExtractor
+		want       float64 // confidence of the returned receipt
+		wantErr    string
+		wantCalls  []int
+	}{
+		{
+			name:       "first extractor confident",
+			extractors: []*stubReceiptExtractor{answering(0.9), answering(0.95)},
+			want:       0.9,
+			wantCalls:  []int{1, 0},
+		},
+		{
+			name:       "first extractor fails",
+			extractors: []*stubReceiptExtractor{failing(), answering(0.8)},
+			want:       0.8,
+			wantCalls:  []int{1, 1},
+		},
+		{
+			name:       "low confidence tries the next one",
+			extractors: []*stubReceiptExtractor{answering(0.4), answering(0.7), answering(0.9)},
+			want:       0.7,
+			wantCalls:  []int{1, 1, 0},
+		},
+		{
+			name:       "exactly the minimum confidence",
+			extractors: []*stubReceiptExtractor{answering(0.6), answering(0.9)},
+			want:       0.6,
+			wantCalls:  []int{1, 0},
+		},
+		{
+			name:       "all low confidence returns the most confident",
+			extractors: []*stubReceiptExtractor{answering(0.3), answering(0.5), failing(), answering(0.2)},
+			want:       0.5,
+			wantCalls:  []int{1, 1, 1, 1},
+		},
+		{
+			name:       "all fail",
+			extractors: []*stubReceiptExtractor{failing(), failing()},
+			wantErr:    "all receipt extractors failed: azure: unavailable\ntesseract: unavailable",
+			wantCalls:  []int{1, 1},
+		},
+	}
+
+	counter, err := otel.Meter("ai-service-test").Int64Counter("receipt_extraction_attempts_total", metric.WithUnit("1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{ReceiptExtractorAzure, ReceiptExtractorTesseract, ReceiptExtractorVision, "fourth"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &receiptExtractorChain{
+				minConfidence:           0.6,
+				logger:                  slog.New(slog.NewTextHandler(io.Discard, nil)),
+				extractionAttemptsTotal: counter,
+			}
+			for i, extractor := range tt.extractors {
+				chain.extractors = append(chain.extractors, namedReceiptExtractor{name: names[i], extractor: extractor})
+			}
+
+			got, err := chain.AnalyzeReceipt(context.Background(), []byte("receipt"), "image/jpeg")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("AnalyzeReceipt() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("AnalyzeReceipt() error = %v", err)
+			} else if got.Confidence != tt.want {
+				t.Errorf("AnalyzeReceipt() returned confidence %v, want %v", got.Confidence, tt.want)
+			}
+
+			for i, extractor := range tt.extractors {
+				if extractor.calls != tt.wantCalls[i] {
+					t.Errorf("extractor %d called %d times, want %d", i, extractor.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReceiptExtractorChainStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	first := &stubReceiptExtractor{err: context.Canceled}
+	second := &stubReceiptExtractor{confidence: 0.9}
+
+	counter, _ := otel.Meter("ai-service-test").Int64Counter("receipt_extraction_attempts_total")
+	chain := &receiptExtractorChain{
+		extractors: []namedReceiptExtractor{
+			{name: ReceiptExtractorAzure, extractor: first},
+			{name: ReceiptExtractorTesseract, extractor: second},
+		},
+		minConfidence:           0.6,
+		logger:                  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		extractionAttemptsTotal: counter,
+	}
+
+	cancel()
+	if _, err := chain.AnalyzeReceipt(ctx, nil, "image/jpeg"); !errors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzeReceipt() error = %v, want context.Canceled", err)
+	}
+	if second.calls != 0 {
+		t.Errorf("next extractor called after the request was canceled")
+	}
+}
+
+func TestReceiptTotalsConsistency(t *testing.T) {
+	tests := []struct {
+		name  string
+		total float64
+		items []float64
+		want  float64
+	}{
+		{"no total", 0, []float64{1, 2}, 0},
+		{"no items", 10, nil, 0},
+		{"items add up", 3.5, []float64{1.25, 2.25}, 1},
+		{"rounding within a cent per item", 3.5, []float64{1.255, 2.255}, 1},
+		{"items half of the total", 10, []float64{5}, 0.5},
+		{"items far above the total", 10, []float64{30}, 0},
+	}
+
+	for _, tt := range tests {
+		receiptData := &ReceiptData{Total: tt.total}
+		for _, price := range tt.items {
+			receiptData.Items = append(receiptData.Items, ReceiptItem{TotalPrice: price})
+		}
+		if got := receiptTotalsConsistency(receiptData); !almostEqual(got, tt.want) {
+			t.Errorf("%s: receiptTotalsConsistency() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
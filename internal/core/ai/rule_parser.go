@@ -0,0 +1,337 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/products"
+)
+
+const (
+	// How long the products table is cached by the rule-based parser
+	rulesProductsCacheTTL = 5 * time.Minute
+
+	rulesExactMatchConfidence   = 0.95
+	rulesStemMatchConfidence    = 0.9
+	rulesPartialMatchConfidence = 0.7
+	rulesNoMatchConfidence      = 0.3
+
+	// Mapping method recorded in item_mappings for items resolved without the LLM
+	MappingMethodRules = "rules"
+)
+
+var (
+	// A quantity token such as "2", "1,5", "2kg", "500г", "x3" or "3x"
+	rulesQuantityToken = regexp.MustCompile(`^[xх×*]?(\d+(?:[.,]\d+)?)[xх×*]?(\p{L}*)\.?$`)
+
+	// Text in parentheses, either a quantity "Milk (2L)" or notes "milk (lactose free)"
+	rulesParentheses = regexp.MustCompile(`\(([^)]*)\)`)
+
+	// Maps unit spellings in English, Russian and Ukrainian to the units used by the prompts
+	rulesUnits = map[string]string{
+		"kg": "kg", "kgs": "kg", "кг": "kg",
+		"g": "g", "gr": "g", "gram": "g", "grams": "g", "г": "g", "гр": "g",
+		"lb": "lb", "lbs": "lb",
+		"oz": "oz",
+		"l":  "L", "liter": "L", "liters": "L", "litre": "L", "litres": "L", "л": "L",
+		"ml": "ml", "мл": "ml",
+		"pc": "pieces", "pcs": "pieces", "piece": "pieces", "pieces": "pieces", "шт": "pieces",
+		"pack": "pack", "packs": "pack", "уп": "pack", "пачка": "pack", "пачки": "pack", "пачок": "pack",
+		"box": "box", "boxes": "box", "коробка": "box", "коробки": "box",
+		"bottle": "bottle", "bottles": "bottle", "бут": "bottle", "бутылка": "bottle", "бутылки": "bottle", "пляшка": "bottle", "пляшки": "bottle",
+		"can": "can", "cans": "can", "банка": "can", "банки": "can",
+		"bag": "bag", "bags": "bag", "пакет": "bag", "пакета": "bag", "пакети": "bag",
+	}
+)
+
+// ruleParser resolves items deterministically: a quantity and unit grammar plus a match against the
+// names and aliases of the products table. Whatever is left of the text becomes notes.
+type ruleParser struct {
+	productService ProductService
+	logger         *slog.Logger
+
+	mu               sync.RWMutex
+	index            []indexedProduct
+	productsLoadedAt time.Time
+}
+
+// indexedProduct holds the normalized names and aliases of a product and their stems
+type indexedProduct struct {
+	product *products.Product
+	terms   []string
+	stems   []string
+}
+
+// rulesMatch is a product found for a piece of text
+type rulesMatch struct {
+	product    *products.Product
+	term       string // normalized name or alias that matched
+	confidence float64
+}
+
+func newRuleParser(productService ProductService, logger *slog.Logger) *ruleParser {
+	return &ruleParser{
+		productService: productService,
+		logger:         logger,
+	}
+}
+
+// splitItems splits free text into the separate items it lists
+func (p *ruleParser) splitItems(rawText string) []string {
+	var items []string
+	for _, part := range splitRulesItems(rawText) {
+		part = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(part), "-•*·"))
+		if part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// splitRulesItems splits text on new lines, semicolons and commas. A comma between two digits is
+// the decimal comma of "1,5 кг" or "2,5%" and stays in the item.
+func splitRulesItems(text string) []string {
+	runes := []rune(text)
+	var parts []string
+	start := 0
+	for i, r := range runes {
+		switch r {
+		case '\n', '\r', ';':
+		case ',':
+			if i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]) {
+				continue
+			}
+		default:
+			continue
+		}
+		parts = append(parts, string(runes[start:i]))
+		start = i + 1
+	}
+	return append(parts, string(runes[start:]))
+}
+
+// parse resolves a single item, an unknown product is returned as is with low confidence
+func (p *ruleParser) parse(ctx context.Context, text, languageCode string) *ParsedResult {
+	text, notes := extractRulesParentheses(text)
+	name, quantityValue, quantityUnit := extractRulesQuantity(text)
+
+	result := &ParsedResult{
+		StandardizedName: strings.ToLower(name),
+		Category:         "other",
+		QuantityValue:    quantityValue,
+		QuantityUnit:     quantityUnit,
+		ConfidenceScore:  rulesNoMatchConfidence,
+		mappingMethod:    MappingMethodRules,
+	}
+
+	if match := p.matchProduct(ctx, name); match != nil {
+		result.StandardizedName = productName(match.product, languageCode)
+		result.Category = match.product.Category
+		result.Subcategory = match.product.Subcategory
+		result.ConfidenceScore = match.confidence
+
+		// Words around a partially matched product are kept as notes, e.g. "cherry" of "cherry tomato"
+		if match.confidence == rulesPartialMatchConfidence {
+			rest := strings.TrimSpace(strings.Replace(normalizeRulesText(name), match.term, "", 1))
+			notes = append([]string{rest}, notes...)
+		}
+	}
+
+	var nonEmpty []string
+	for _, note := range notes {
+		if note = strings.TrimSpace(note); note != "" {
+			nonEmpty = append(nonEmpty, note)
+		}
+	}
+	if len(nonEmpty) > 0 {
+		joined := strings.Join(nonEmpty, ", ")
+		result.Notes = &joined
+	}
+
+	return result
+}
+
+// matchProduct finds the product whose name or alias equals the text, then one whose stems equal
+// the stems of the text ("картошки" for "картошка"), or else the longest one contained in it as
+// whole words
+func (p *ruleParser) matchProduct(ctx context.Context, text string) *rulesMatch {
+	text = normalizeRulesText(text)
+	if text == "" {
+		return nil
+	}
+	stem := stemRulesText(text)
+
+	var stemMatch, partial *rulesMatch
+	for _, indexed := range p.getIndex(ctx) {
+		for i, term := range indexed.terms {
+			if term == text {
+				return &rulesMatch{product: indexed.product, term: term, confidence: rulesExactMatchConfidence}
+			}
+			if stemMatch == nil && indexed.stems[i] == stem {
+				stemMatch = &rulesMatch{product: indexed.product, term: term, confidence: rulesStemMatchConfidence}
+			}
+			if (partial == nil || len(term) > len(partial.term)) && strings.Contains(" "+text+" ", " "+term+" ") {
+				partial = &rulesMatch{product: indexed.product, term: term, confidence: rulesPartialMatchConfidence}
+			}
+		}
+	}
+
+	if stemMatch != nil {
+		return stemMatch
+	}
+	return partial
+}
+
+// getIndex returns the cached products index, reloading it once the cache expires
+func (p *ruleParser) getIndex(ctx context.Context) []indexedProduct {
+	p.mu.RLock()
+	if p.index != nil && time.Since(p.productsLoadedAt) < rulesProductsCacheTTL {
+		defer p.mu.RUnlock()
+		return p.index
+	}
+	p.mu.RUnlock()
+
+	if p.productService == nil {
+		return nil
+	}
+
+	loaded, err := p.productService.GetAllProducts(ctx)
+	if err != nil {
+		p.logger.Error("Failed to load products for rule-based parsing",
+			"error", err.Error(),
+			"component", "ai_parsing")
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.index // Keep using the stale index if there is one
+	}
+
+	index := make([]indexedProduct, 0, len(loaded))
+	for _, product := range loaded {
+		terms := productTerms(product)
+		stems := make([]string, len(terms))
+		for i, term := range terms {
+			stems[i] = stemRulesText(term)
+		}
+		index = append(index, indexedProduct{product: product, terms: terms, stems: stems})
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.index = index
+	p.productsLoadedAt = time.Now()
+	return p.index
+}
+
+// extractRulesParentheses moves text in parentheses out of the item, a quantity such as "Milk (2L)"
+// is put back for the quantity grammar and anything else is returned as notes
+func extractRulesParentheses(text string) (string, []string) {
+	var notes []string
+	text = rulesParentheses.ReplaceAllStringFunc(text, func(group string) string {
+		inner := strings.TrimSpace(group[1 : len(group)-1])
+		if _, value, _ := extractRulesQuantity(inner + " _"); value != nil {
+			return " " + inner + " "
+		}
+		notes = append(notes, inner)
+		return " "
+	})
+	return strings.Join(strings.Fields(text), " "), notes
+}
+
+// extractRulesQuantity splits "milk 2L", "2 kg apples" or "x3 bread" into name, value and unit
+func extractRulesQuantity(text string) (string, *float64, string) {
+	tokens := strings.Fields(text)
+	for i, token := range tokens {
+		m := rulesQuantityToken.FindStringSubmatch(strings.ToLower(token))
+		if m == nil {
+			continue
+		}
+
+		unit := "pieces"
+		consumed := 1
+		if m[2] != "" {
+			normalized, ok := rulesUnits[m[2]]
+			if !ok {
+				continue // e.g. "7up" is a name, not a quantity
+			}
+			unit = normalized
+		} else if i+1 < len(tokens) {
+			if normalized, ok := rulesUnits[strings.TrimSuffix(strings.ToLower(tokens[i+1]), ".")]; ok {
+				unit = normalized
+				consumed = 2
+			}
+		}
+
+		value, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64)
+		if err != nil {
+			continue
+		}
+
+		name := strings.Join(append(append([]string{}, tokens[:i]...), tokens[i+consumed:]...), " ")
+		name = strings.Trim(name, " ()-")
+		if name == "" {
+			return text, nil, "pieces"
+		}
+		return name, &value, unit
+	}
+
+	return text, nil, "pieces"
+}
+
+// productName returns the name of the product in the given language, English by default
+func productName(product *products.Product, languageCode string) string {
+	switch languageCode {
+	case "ru":
+		if product.NameRu != "" {
+			return product.NameRu
+		}
+	case "uk":
+		if product.NameUk != "" {
+			return product.NameUk
+		}
+	}
+	return product.NameEn
+}
+
+// productTerms returns the normalized names and aliases of the product
+func productTerms(product *products.Product) []string {
+	terms := make([]string, 0, 3+len(product.Aliases))
+	for _, term := range append([]string{product.NameEn, product.NameRu, product.NameUk}, product.Aliases...) {
+		if term = normalizeRulesText(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func normalizeRulesText(text string) string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// stemRulesText stems every word of the text. It is deliberately crude, stems only count when the
+// whole text matches.
+func stemRulesText(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = StemWord(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// StemWord drops the inflection endings of a lower case word so that "картошки" matches
+// "картошка" and "apples" matches "apple". Words keep at least three letters.
+func StemWord(word string) string {
+	runes := []rune(word)
+	for len(runes) > 3 && strings.ContainsRune("аяоеиыуюіїєьйse", runes[len(runes)-1]) {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
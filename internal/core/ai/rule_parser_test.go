@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitItems(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single item", "milk", []string{"milk"}},
+		{"commas", "milk, bread,eggs", []string{"milk", "bread", "eggs"}},
+		{"new lines and semicolons", "milk\nbread;eggs\r\nbutter", []string{"milk", "bread", "eggs", "butter"}},
+		{"bullets", "- milk\n• bread\n* eggs", []string{"milk", "bread", "eggs"}},
+		{"empty parts", "milk,, ;\n\nbread", []string{"milk", "bread"}},
+		{"decimal comma quantity", "1,5 кг картошки", []string{"1,5 кг картошки"}},
+		{"decimal comma percent", "молоко 2,5%", []string{"молоко 2,5%"}},
+		{"decimal comma among items", "молоко 2,5%, хлеб", []string{"молоко 2,5%", "хлеб"}},
+		{"comma after number", "яйца 10, хлеб", []string{"яйца 10", "хлеб"}},
+		{"comma before number", "хлеб,2 яблока", []string{"хлеб", "2 яблока"}},
+		{"trailing comma after digit", "eggs 10,", []string{"eggs 10"}},
+		{"blank", "  \n ", nil},
+	}
+
+	parser := &ruleParser{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parser.splitItems(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitItems(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractRulesQuantity(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantName  string
+		wantValue *float64
+		wantUnit  string
+	}{
+		{"milk", "milk", nil, "pieces"},
+		{"milk 2L", "milk", ptr(2), "L"},
+		{"2 kg apples", "apples", ptr(2), "kg"},
+		{"1,5 кг картошки", "картошки", ptr(1.5), "kg"},
+		{"картошка 1.5кг", "картошка", ptr(1.5), "kg"},
+		{"x3 bread", "bread", ptr(3), "pieces"},
+		{"bread 3x", "bread", ptr(3), "pieces"},
+		{"яйца 10 шт.", "яйца", ptr(10), "pieces"},
+		{"сок 2 пачки", "сок", ptr(2), "pack"},
+		{"7up", "7up", nil, "pieces"},
+		{"молоко 2,5%", "молоко 2,5%", nil, "pieces"},
+		{"500", "500", nil, "pieces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			name, value, unit := extractRulesQuantity(tt.text)
+			if name != tt.wantName || unit != tt.wantUnit || !reflect.DeepEqual(value, tt.wantValue) {
+				t.Errorf("extractRulesQuantity(%q) = %q, %v, %q, want %q, %v, %q",
+					tt.text, name, deref(value), unit, tt.wantName, deref(tt.wantValue), tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestExtractRulesParentheses(t *testing.T) {
+	tests := []struct {
+		text      string
+		wantText  string
+		wantNotes []string
+	}{
+		{"Milk (2L)", "Milk 2L", nil},
+		{"milk (lactose free)", "milk", []string{"lactose free"}},
+		{"хлеб (белый) (2 шт)", "хлеб 2 шт", []string{"белый"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			text, notes := extractRulesParentheses(tt.text)
+			if text != tt.wantText || !reflect.DeepEqual(notes, tt.wantNotes) {
+				t.Errorf("extractRulesParentheses(%q) = %q, %q, want %q, %q", tt.text, text, notes, tt.wantText, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestStemRulesText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"картошки", "картошк"},
+		{"картошка", "картошк"},
+		{"apples", "appl"},
+		{"apple", "appl"},
+		{"чай", "чай"},
+	}
+
+	for _, tt := range tests {
+		if got := stemRulesText(tt.text); got != tt.want {
+			t.Errorf("stemRulesText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestStemWord(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"яблоки", "яблок"},
+		{"яблоко", "яблок"},
+		{"молоко", "молок"},
+		{"cheese", "che"},
+		{"сыр", "сыр"},
+		{"чай", "чай"},
+		{"apples", "appl"},
+		{"eggs", "egg"},
+	}
+
+	for _, tt := range tests {
+		if got := StemWord(tt.word); got != tt.want {
+			t.Errorf("StemWord(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
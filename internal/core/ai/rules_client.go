@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// ruleBasedClient is a deterministic OpenAIClient that parses items against the products table and
+// its aliases. It keeps the bot working in degraded mode when no LLM is reachable.
+type ruleBasedClient struct {
+	parser *ruleParser
+	logger *slog.Logger
+}
+
+func NewRuleBasedClient(logger *slog.Logger, productService ProductService) OpenAIClient {
+	return &ruleBasedClient{
+		parser: newRuleParser(productService, logger),
+		logger: logger,
+	}
+}
+
+func (c *ruleBasedClient) ParseItem(ctx context.Context, rawText, languageCode string) (*ParsedResult, error) {
+	text := strings.TrimSpace(rawText)
+	if text == "" {
+		return nil, fmt.Errorf("no item text to parse")
+	}
+
+	return c.parser.parse(ctx, text, languageCode), nil
+}
+
+func (c *ruleBasedClient) ParseItems(ctx context.Context, rawText, languageCode string) ([]*ParsedResult, error) {
+	var results []*ParsedResult
+	for _, item := range c.parser.splitItems(rawText) {
+		results = append(results, c.parser.parse(ctx, item, languageCode))
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no items found in text")
+	}
+
+	c.logger.Info("Parsed items with rule-based parser",
+		"raw_text", rawText,
+		"language_code", languageCode,
+		"items_parsed", len(results),
+		"component", "ai_parsing")
+
+	return results, nil
+}
+
+// DetectLanguage detects ru, uk or en from the alphabet used in the text
+func (c *ruleBasedClient) DetectLanguage(ctx context.Context, text string) (string, error) {
+	cyrillic, latin := 0, 0
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case strings.ContainsRune("іїєґ", r):
+			return "uk", nil
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	if cyrillic > latin {
+		return "ru", nil
+	}
+	return "en", nil
+}
+
+// DetectProductList treats text as a product list when most of its lines match known products
+func (c *ruleBasedClient) DetectProductList(ctx context.Context, text string) (*ProductListDetectionResult, error) {
+	result := &ProductListDetectionResult{SampleItems: []string{}}
+
+	items := c.parser.splitItems(text)
+	for _, item := range items {
+		name, _, _ := extractRulesQuantity(item)
+		if c.parser.matchProduct(ctx, name) != nil {
+			result.DetectedItemsCount++
+			if len(result.SampleItems) < 3 {
+				result.SampleItems = append(result.SampleItems, name)
+			}
+		}
+	}
+
+	if len(items) > 0 {
+		result.Confidence = float64(result.DetectedItemsCount) / float64(len(items))
+	}
+	result.IsProductList = result.DetectedItemsCount >= 2 && result.Confidence >= 0.5
+
+	return result, nil
+}
+
+// Translate only knows the names of products, anything else is returned as is with low confidence
+func (c *ruleBasedClient) Translate(ctx context.Context, originalText, originalLanguage, targetLanguage string) (*TranslationResult, error) {
+	translatedText, confidence := c.translateName(ctx, originalText, targetLanguage)
+	return &TranslationResult{
+		TranslatedText: translatedText,
+		Confidence:     confidence,
+	}, nil
+}
+
+func (c *ruleBasedClient) BatchTranslateReceiptItems(ctx context.Context, req *BatchTranslationRequest) (*BatchTranslationResult, error) {
+	detectedLanguage, _ := c.DetectLanguage(ctx, strings.Join(req.Items, " "))
+
+	result := &BatchTranslationResult{
+		DetectedLanguage: detectedLanguage,
+		TargetLanguage:   req.TargetLocale,
+		Translations:     make([]ReceiptItemTranslation, 0, len(req.Items)),
+		Degraded:         true,
+	}
+
+	totalConfidence := 0.0
+	for _, item := range req.Items {
+		translatedText, confidence := c.translateName(ctx, item, req.TargetLocale)
+		totalConfidence += confidence
+		result.Translations = append(result.Translations, ReceiptItemTranslation{
+			OriginalText:     item,
+			TranslatedText:   translatedText,
+			DetectedLanguage: detectedLanguage,
+			TargetLanguage:   req.TargetLocale,
+			Confidence:       confidence,
+		})
+	}
+
+	if len(req.Items) > 0 {
+		result.Confidence = totalConfidence / float64(len(req.Items))
+	}
+
+	return result, nil
+}
+
+func (c *ruleBasedClient) translateName(ctx context.Context, text, targetLanguage string) (string, float64) {
+	match := c.parser.matchProduct(ctx, text)
+	if match == nil || match.confidence < rulesStemMatchConfidence {
+		return text, rulesNoMatchConfidence
+	}
+	return productName(match.product, targetLanguage), match.confidence
+}
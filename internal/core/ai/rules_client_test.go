@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/products"
+)
+
+// stubProductService serves a fixed products table
+type stubProductService struct {
+	products []*products.Product
+	err      error
+}
+
+func (s stubProductService) GetAllProducts(ctx context.Context) ([]*products.Product, error) {
+	return s.products, s.err
+}
+
+func newTestRuleBasedClient() OpenAIClient {
+	return NewRuleBasedClient(slog.New(slog.NewTextHandler(io.Discard, nil)), stubProductService{
+		products: []*products.Product{
+			{NameEn: "milk", NameRu: "молоко", NameUk: "молоко", Category: "dairy", Subcategory: "milk"},
+			{NameEn: "potato", NameRu: "картошка", NameUk: "картопля", Category: "vegetables"},
+			{NameEn: "tomato", NameRu: "помидор", NameUk: "помідор", Category: "vegetables", Aliases: []string{"tomatoes"}},
+		},
+	})
+}
+
+func TestRuleBasedClientParseItems(t *testing.T) {
+	client := newTestRuleBasedClient()
+
+	results, err := client.ParseItems(context.Background(), "молоко 2л, 1,5 кг картошки\ncherry tomato\nwidget", "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name       string
+		category   string
+		quantity   any
+		unit       string
+		confidence float64
+	}{
+		{"молоко", "dairy", 2.0, "L", rulesExactMatchConfidence},
+		{"картошка", "vegetables", 1.5, "kg", rulesStemMatchConfidence},
+		{"помидор", "vegetables", nil, "pieces", rulesPartialMatchConfidence},
+		{"widget", "other", nil, "pieces", rulesNoMatchConfidence},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("ParseItems() returned %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.StandardizedName != w.name || got.Category != w.category || deref(got.QuantityValue) != w.quantity ||
+			got.QuantityUnit != w.unit || got.ConfidenceScore != w.confidence {
+			t.Errorf("result %d = %+v (quantity %v), want %+v", i, *got, deref(got.QuantityValue), w)
+		}
+		if got.MappingMethod() != MappingMethodRules {
+			t.Errorf("result %d MappingMethod() = %q, want %q", i, got.MappingMethod(), MappingMethodRules)
+		}
+	}
+	if results[2].Notes == nil || *results[2].Notes != "cherry" {
+		t.Errorf("partial match notes = %v, want cherry", results[2].Notes)
+	}
+
+	if _, err := client.ParseItems(context.Background(), " ,\n ", "en"); err == nil {
+		t.Error("ParseItems() accepted text without items")
+	}
+	if _, err := client.ParseItem(context.Background(), "  ", "en"); err == nil {
+		t.Error("ParseItem() accepted blank text")
+	}
+}
+
+func TestRuleBasedClientDetectLanguage(t *testing.T) {
+	client := newTestRuleBasedClient()
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"milk and bread", "en"},
+		{"молоко и хлеб", "ru"},
+		{"молоко і хліб", "uk"},
+		{"Їжа", "uk"},
+		{"milk, молоко", "ru"},
+		{"123", "en"},
+	}
+
+	for _, tt := range tests {
+		got, err := client.DetectLanguage(context.Background(), tt.text)
+		if err != nil || got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, %v, want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
+
+func TestRuleBasedClientDetectProductList(t *testing.T) {
+	client := newTestRuleBasedClient()
+
+	tests := []struct {
+		name          string
+		text          string
+		isProductList bool
+		detected      int
+	}{
+		{"known products", "milk\n2 kg potato\ntomatoes", true, 3},
+		{"half known", "milk, potato, hello, world", true, 2},
+		{"single product", "milk", false, 1},
+		{"chat message", "see you tomorrow, bring the car", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.DetectProductList(context.Background(), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.IsProductList != tt.isProductList || got.DetectedItemsCount != tt.detected {
+				t.Errorf("DetectProductList(%q) = %+v, want product list %v with %d items", tt.text, *got, tt.isProductList, tt.detected)
+			}
+			if len(got.SampleItems) > 3 {
+				t.Errorf("DetectProductList(%q) returned %d sample items", tt.text, len(got.SampleItems))
+			}
+		})
+	}
+}
+
+func TestRuleBasedClientTranslate(t *testing.T) {
+	client := newTestRuleBasedClient()
+
+	got, err := client.Translate(context.Background(), "картошки", "ru", "uk")
+	if err != nil || got.TranslatedText != "картопля" || got.Confidence != rulesStemMatchConfidence {
+		t.Errorf("Translate(картошки) = %+v, %v, want картопля", got, err)
+	}
+
+	// Partial matches are not trusted for translations
+	got, err = client.Translate(context.Background(), "cherry tomato", "en", "ru")
+	if err != nil || got.TranslatedText != "cherry tomato" || got.Confidence != rulesNoMatchConfidence {
+		t.Errorf("Translate(cherry tomato) = %+v, %v, want it untranslated", got, err)
+	}
+
+	batch, err := client.BatchTranslateReceiptItems(context.Background(), &BatchTranslationRequest{
+		Items:        []string{"Milk", "Widget"},
+		TargetLocale: "ru",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch.Degraded || batch.DetectedLanguage != "en" || len(batch.Translations) != 2 {
+		t.Fatalf("BatchTranslateReceiptItems() = %+v", *batch)
+	}
+	if batch.Translations[0].TranslatedText != "молоко" || batch.Translations[1].TranslatedText != "Widget" {
+		t.Errorf("translations = %+v", batch.Translations)
+	}
+	if !almostEqual(batch.Confidence, (rulesExactMatchConfidence+rulesNoMatchConfidence)/2) {
+		t.Errorf("Confidence = %v", batch.Confidence)
+	}
+}
+
+func TestRuleBasedClientStaleProducts(t *testing.T) {
+	client := NewRuleBasedClient(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		stubProductService{err: errors.New("database is down")})
+
+	got, err := client.ParseItem(context.Background(), "milk", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StandardizedName != "milk" || got.ConfidenceScore != rulesNoMatchConfidence {
+		t.Errorf("ParseItem() without products = %+v", *got)
+	}
+}
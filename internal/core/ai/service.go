@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/PocketPalCo/shopping-service/config"
+	"github.com/PocketPalCo/shopping-service/internal/core/products"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
@@ -64,6 +65,8 @@ type ParsedResult struct {
 	ConfidenceScore  float64    `json:"confidence_score"`
 	OriginalItemID   *uuid.UUID `json:"original_item_id,omitempty"`
 	ParsedItemID     *uuid.UUID `json:"parsed_item_id,omitempty"`
+
+	mappingMethod string // recorded in item_mappings, set by the client that parsed the item
 }
 
 type ProductListDetectionResult struct {
@@ -96,6 +99,7 @@ type BatchTranslationResult struct {
 	TargetLanguage   string                   `json:"target_language"`
 	Translations     []ReceiptItemTranslation `json:"translations"`
 	Confidence       float64                  `json:"confidence"`
+	Degraded         bool                     `json:"-"` // answered by the rule-based parser, not cached
 }
 
 type TranslationCache struct {
@@ -119,35 +123,38 @@ type OpenAIClient interface {
 	BatchTranslateReceiptItems(ctx context.Context, req *BatchTranslationRequest) (*BatchTranslationResult, error)
 }
 
+// Mapping methods recorded in item_mappings for items parsed by an LLM provider. Local servers
+// are recorded as ai_llama, the value migration 010 reserved for self-hosted models.
+const (
+	MappingMethodOpenAI = "ai_openai"
+	MappingMethodAzure  = "ai_azure"
+	MappingMethodLocal  = "ai_llama"
+)
+
 type Service struct {
-	db                   *pgxpool.Pool
-	openaiClient         OpenAIClient
-	DocumentIntelligence *DocumentIntelligenceService
-	logger               *slog.Logger
+	db               *pgxpool.Pool
+	openaiClient     OpenAIClient
+	ReceiptExtractor ReceiptExtractor
+	logger           *slog.Logger
+
+	// Rule-based first pass, items it resolves with at least rulesMinConfidence skip the LLM
+	ruleParser         *ruleParser
+	rulesFirstPass     bool
+	rulesMinConfidence float64
 }
 
 func NewService(db *pgxpool.Pool, openaiClient OpenAIClient, cfg config.Config, logger *slog.Logger) (*Service, error) {
-	// Initialize Document Intelligence service
-	docIntelConfig := cfg.GetDocumentIntelligenceConfig()
-	docIntelService := NewDocumentIntelligenceService(
-		docIntelConfig.Endpoint,
-		docIntelConfig.APIKey,
-		docIntelConfig.APIVersion,
-		docIntelConfig.Model,
-	)
-
-	// Validate configuration if endpoints are provided
-	if docIntelConfig.Endpoint != "" || docIntelConfig.APIKey != "" {
-		if err := docIntelService.ValidateConfiguration(); err != nil {
-			logger.Warn("Document Intelligence service not configured properly", "error", err)
-		}
-	}
+	llmConfig := cfg.GetLLMConfig()
 
 	return &Service{
-		db:                   db,
-		openaiClient:         openaiClient,
-		DocumentIntelligence: docIntelService,
-		logger:               logger,
+		db:               db,
+		openaiClient:     openaiClient,
+		ReceiptExtractor: NewReceiptExtractor(cfg, logger),
+		logger:           logger,
+
+		ruleParser:         newRuleParser(products.NewService(db, logger), logger),
+		rulesFirstPass:     llmConfig.RulesFirstPass,
+		rulesMinConfidence: llmConfig.RulesMinConfidence,
 	}, nil
 }
 
@@ -165,12 +172,15 @@ func (s *Service) ParseAndStoreItem(ctx context.Context, rawText, languageCode s
 		return nil, fmt.Errorf("failed to store original item: %w", err)
 	}
 
-	// Parse item using AI
-	parsedResult, err := s.openaiClient.ParseItem(ctx, itemText, languageCode)
-	if err != nil {
-		span.RecordError(err)
-		s.logger.Error("Failed to parse item with AI", "error", err, "raw_text", rawText)
-		return nil, fmt.Errorf("failed to parse item: %w", err)
+	// Resolve the item with the rule-based parser, the AI is only asked when it isn't confident
+	parsedResult := s.parseItemWithRules(ctx, rawText, languageCode)
+	if parsedResult == nil {
+		parsedResult, err = s.openaiClient.ParseItem(ctx, itemText, languageCode)
+		if err != nil {
+			span.RecordError(err)
+			s.logger.Error("Failed to parse item with AI", "error", err, "raw_text", rawText)
+			return nil, fmt.Errorf("failed to parse item: %w", err)
+		}
 	}
 
 	// Store parsed item
@@ -181,7 +191,7 @@ func (s *Service) ParseAndStoreItem(ctx context.Context, rawText, languageCode s
 	}
 
 	// Create mapping
-	err = s.createItemMapping(ctx, originalItem.ID, parsedItem.ID, "ai_openai")
+	err = s.createItemMapping(ctx, originalItem.ID, parsedItem.ID, parsedResult.MappingMethod())
 	if err != nil {
 		span.RecordError(err)
 		s.logger.Error("Failed to create item mapping", "error", err)
@@ -395,14 +405,20 @@ func (s *Service) ParseAndStoreItems(ctx context.Context, rawText, languageCode
 		"language", languageCode,
 		"user_id", userID)
 
-	parsedResults, err := s.openaiClient.ParseItems(ctx, rawText, languageCode)
-	if err != nil {
-		s.logger.Error("Failed to parse items with AI",
-			"error", err,
-			"raw_text", rawText,
-			"language", languageCode,
-			"user_id", userID)
-		return nil, fmt.Errorf("failed to parse items: %w", err)
+	// Items the rule-based parser resolves confidently skip the AI, the rest are parsed together
+	// and put back in the place of the items they were parsed from
+	parsedResults, unresolvedText := s.parseItemsWithRules(ctx, rawText, languageCode)
+	if unresolvedText != "" {
+		aiResults, err := s.openaiClient.ParseItems(ctx, unresolvedText, languageCode)
+		if err != nil {
+			s.logger.Error("Failed to parse items with AI",
+				"error", err,
+				"raw_text", rawText,
+				"language", languageCode,
+				"user_id", userID)
+			return nil, fmt.Errorf("failed to parse items: %w", err)
+		}
+		parsedResults = mergeParsedResults(parsedResults, aiResults)
 	}
 
 	// Log what the AI returned for debugging - each item individually
@@ -497,7 +513,7 @@ func (s *Service) ParseAndStoreItems(ctx context.Context, rawText, languageCode
 		}
 
 		// Store the mapping between this specific original and parsed item
-		err = s.storeItemMapping(ctx, originalItem.ID, parsedItem.ID, "ai_openai", parsedResult.ConfidenceScore, userID)
+		err = s.storeItemMapping(ctx, originalItem.ID, parsedItem.ID, parsedResult.MappingMethod(), parsedResult.ConfidenceScore, userID)
 		if err != nil {
 			s.logger.Error("Failed to store item mapping", "error", err, "original_id", originalItem.ID, "parsed_id", parsedItem.ID)
 			// Don't skip - the items are stored, just the mapping failed
@@ -528,6 +544,87 @@ func (s *Service) ParseAndStoreItems(ctx context.Context, rawText, languageCode
 	return results, nil
 }
 
+// parseItemWithRules returns the rule-based result for a single item when it is confident enough
+func (s *Service) parseItemWithRules(ctx context.Context, rawText, languageCode string) *ParsedResult {
+	if !s.rulesFirstPass {
+		return nil
+	}
+
+	result := s.ruleParser.parse(ctx, strings.TrimSpace(rawText), languageCode)
+	if result.ConfidenceScore < s.rulesMinConfidence {
+		return nil
+	}
+
+	s.logger.Info("Item resolved by rule-based parser, skipping AI",
+		"raw_text", rawText,
+		"standardized_name", result.StandardizedName,
+		"confidence_score", result.ConfidenceScore)
+
+	return result
+}
+
+// parseItemsWithRules resolves the items of the text that the rule-based parser is confident about.
+// It returns a result per item in input order, nil for the items left to the AI, together with
+// those items joined by new lines. The first pass off, the whole text is left to the AI.
+func (s *Service) parseItemsWithRules(ctx context.Context, rawText, languageCode string) ([]*ParsedResult, string) {
+	if !s.rulesFirstPass {
+		return []*ParsedResult{nil}, rawText
+	}
+
+	items := s.ruleParser.splitItems(rawText)
+	results := make([]*ParsedResult, len(items))
+	var unresolved []string
+	for i, item := range items {
+		result := s.ruleParser.parse(ctx, item, languageCode)
+		if result.ConfidenceScore < s.rulesMinConfidence {
+			unresolved = append(unresolved, item)
+			continue
+		}
+		results[i] = result
+	}
+
+	s.logger.Info("Rule-based first pass completed",
+		"raw_text", rawText,
+		"language", languageCode,
+		"resolved_count", len(items)-len(unresolved),
+		"unresolved_count", len(unresolved))
+
+	return results, strings.Join(unresolved, "\n")
+}
+
+// mergeParsedResults fills the unresolved (nil) places of the rule-based results with the AI results
+// in order. The AI may split or join items differently, results left over once every place is
+// taken are put after the last unresolved item and places left without a result are dropped.
+func mergeParsedResults(ruleResults, aiResults []*ParsedResult) []*ParsedResult {
+	lastUnresolved := -1
+	for i, result := range ruleResults {
+		if result == nil {
+			lastUnresolved = i
+		}
+	}
+
+	merged := make([]*ParsedResult, 0, len(ruleResults)+len(aiResults))
+	for i, result := range ruleResults {
+		switch {
+		case result != nil:
+			merged = append(merged, result)
+		case i == lastUnresolved:
+			merged = append(merged, aiResults...)
+			aiResults = nil
+		case len(aiResults) > 0:
+			merged = append(merged, aiResults[0])
+			aiResults = aiResults[1:]
+		}
+	}
+
+	return merged
+}
+
+// MappingMethod returns how the item was parsed, as recorded in item_mappings
+func (r *ParsedResult) MappingMethod() string {
+	return r.mappingMethod
+}
+
 func (s *Service) storeItemMapping(ctx context.Context, originalItemID, parsedItemID uuid.UUID, mappingMethod string, confidence float64, userID uuid.UUID) error {
 	query := `
 		INSERT INTO item_mappings (original_item_id, parsed_item_id, mapping_method, is_validated, created_at, updated_at)
@@ -622,10 +719,12 @@ func (s *Service) BatchTranslateReceiptItems(ctx context.Context, req *BatchTran
 			return nil, fmt.Errorf("failed to batch translate receipt items: %w", err)
 		}
 
-		// Step 3: Store AI results in cache
-		for _, item := range uncachedItems {
-			if err := s.storeCachedTranslation(ctx, item, req.TargetLocale, aiResult); err != nil {
-				s.logger.Warn("Failed to store translation in cache", "item", item, "error", err)
+		// Step 3: Store AI results in cache, rule-based answers are retried once the LLM is back
+		if !aiResult.Degraded {
+			for _, item := range uncachedItems {
+				if err := s.storeCachedTranslation(ctx, item, req.TargetLocale, aiResult); err != nil {
+					s.logger.Warn("Failed to store translation in cache", "item", item, "error", err)
+				}
 			}
 		}
 	}
@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/products"
+)
+
+func TestParseItemsWithRules(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	service := &Service{
+		logger: logger,
+		ruleParser: newRuleParser(stubProductService{products: []*products.Product{
+			{NameEn: "milk", Category: "dairy"},
+			{NameEn: "bread", Category: "bakery"},
+		}}, logger),
+		rulesFirstPass:     true,
+		rulesMinConfidence: 0.95,
+	}
+
+	results, unresolved := service.parseItemsWithRules(context.Background(), "milk\nbreads\nwidget, bread", "en")
+
+	var names []string
+	for _, result := range results {
+		if result == nil {
+			names = append(names, "")
+			continue
+		}
+		names = append(names, result.StandardizedName)
+	}
+	// An inflected match is below the default threshold and left to the AI with the unknown item
+	if want := []string{"milk", "", "", "bread"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("parseItemsWithRules() resolved %q, want %q", names, want)
+	}
+	if unresolved != "breads\nwidget" {
+		t.Errorf("parseItemsWithRules() left %q to the AI", unresolved)
+	}
+
+	service.rulesFirstPass = false
+	results, unresolved = service.parseItemsWithRules(context.Background(), "milk, bread", "en")
+	if len(results) != 1 || results[0] != nil || unresolved != "milk, bread" {
+		t.Errorf("parseItemsWithRules() without first pass = %v, %q", results, unresolved)
+	}
+}
+
+func TestMergeParsedResults(t *testing.T) {
+	item := func(name string) *ParsedResult { return &ParsedResult{StandardizedName: name} }
+
+	tests := []struct {
+		name        string
+		ruleResults []*ParsedResult
+		aiResults   []*ParsedResult
+		want        []string
+	}{
+		{
+			name:        "all resolved by rules",
+			ruleResults: []*ParsedResult{item("milk"), item("bread")},
+			want:        []string{"milk", "bread"},
+		},
+		{
+			name:        "all left to the AI",
+			ruleResults: []*ParsedResult{nil},
+			aiResults:   []*ParsedResult{item("milk"), item("bread")},
+			want:        []string{"milk", "bread"},
+		},
+		{
+			name:        "AI results keep the place of their items",
+			ruleResults: []*ParsedResult{nil, item("milk"), nil, item("bread")},
+			aiResults:   []*ParsedResult{item("apples"), item("cheese")},
+			want:        []string{"apples", "milk", "cheese", "bread"},
+		},
+		{
+			name:        "AI split an item",
+			ruleResults: []*ParsedResult{nil, item("milk"), nil, item("bread")},
+			aiResults:   []*ParsedResult{item("apples"), item("salt"), item("pepper")},
+			want:        []string{"apples", "milk", "salt", "pepper", "bread"},
+		},
+		{
+			name:        "AI joined items",
+			ruleResults: []*ParsedResult{nil, nil, item("milk")},
+			aiResults:   []*ParsedResult{item("salt and pepper")},
+			want:        []string{"salt and pepper", "milk"},
+		},
+		{
+			name:        "AI found nothing",
+			ruleResults: []*ParsedResult{item("milk"), nil},
+			want:        []string{"milk"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, result := range mergeParsedResults(tt.ruleResults, tt.aiResults) {
+				got = append(got, result.StandardizedName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeParsedResults() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	// An amount at the end of a line, optionally followed by a tax class letter: "Milk 1,29 A"
+	tesseractLineAmount = regexp.MustCompile(`^(.*?)\s+(-?\d{1,6}[.,]\d{2})\s*(?:[A-Za-z]|€|EUR|грн|₴)?$`)
+
+	// A weighed or multiplied quantity: "0,734 kg x 12,99", "2 x 1,29" or "3 шт * 0.50"
+	tesseractQuantityLine = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(kg|кг|g|г|l|л|pcs|шт|st|stk)?\s*[x×*]\s*(\d+[.,]\d{2})`)
+
+	// Total, tax and date lines in the languages of our receipts. \b only knows ASCII letters, the
+	// keywords end at anything but a letter or digit instead so that Cyrillic ones match too.
+	tesseractTotalLine = regexp.MustCompile(`(?i)^(total|summe|gesamt|zu zahlen|итого|всего|к оплате|разом|до сплати|сума|suma|razem)([^\pL\pN]|$)`)
+	tesseractTaxLine   = regexp.MustCompile(`(?i)^(vat|tax|mwst|ust|ндс|пдв|ptu)([^\pL\pN]|$)`)
+	tesseractDate      = regexp.MustCompile(`\b(\d{1,2})[./-](\d{1,2})[./-](\d{2,4})\b|\b(\d{4})-(\d{2})-(\d{2})\b`)
+)
+
+// tesseractReceiptExtractor runs the tesseract OCR binary locally and reads items, totals and the date
+// from the recognized text line by line. It needs no network access, but only understands the
+// common "name ... amount" receipt layout, so its confidence is how well the items add up.
+type tesseractReceiptExtractor struct {
+	path      string
+	languages string
+	timeout   time.Duration
+	logger    *slog.Logger
+}
+
+func newTesseractReceiptExtractor(path, languages string, timeout time.Duration, logger *slog.Logger) *tesseractReceiptExtractor {
+	if path == "" {
+		path = "tesseract"
+	}
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	return &tesseractReceiptExtractor{
+		path:      path,
+		languages: languages,
+		timeout:   timeout,
+		logger:    logger,
+	}
+}
+
+func (e *tesseractReceiptExtractor) AnalyzeReceipt(ctx context.Context, imageData []byte, contentType string) (*ReceiptData, error) {
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("tesseract extraction supports images only, got %s", contentType)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	// psm 4 treats the image as a single column of text of variable sizes, which fits receipts
+	args := []string{"stdin", "stdout", "--psm", "4"}
+	if e.languages != "" {
+		args = append(args, "-l", e.languages)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.path, args...)
+	cmd.Stdin = bytes.NewReader(imageData)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	startTime := time.Now()
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tesseract failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	receiptData := parseReceiptText(stdout.String())
+
+	e.logger.Info("Receipt extracted with tesseract",
+		"languages", e.languages,
+		"items_extracted", len(receiptData.Items),
+		"total", receiptData.Total,
+		"confidence", receiptData.Confidence,
+		"duration_ms", time.Since(startTime).Milliseconds())
+
+	return receiptData, nil
+}
+
+// parseReceiptText reads a receipt from OCR text: the merchant from the first line, items from
+// "name ... amount" lines up to the total and quantities from "amount x price" lines around them
+func parseReceiptText(text string) *ReceiptData {
+	receiptData := &ReceiptData{
+		Items:   make([]ReceiptItem, 0),
+		ModelID: ReceiptExtractorTesseract,
+	}
+
+	var pendingQuantity *ReceiptItem
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if receiptData.MerchantName == "" && strings.IndexFunc(line, unicode.IsLetter) >= 0 {
+			receiptData.MerchantName = line
+			continue
+		}
+
+		if receiptData.TransactionDate.IsZero() {
+			if date, ok := parseReceiptTextDate(line); ok {
+				receiptData.TransactionDate = date
+			}
+		}
+
+		if receiptData.Currency == "" {
+			receiptData.Currency = detectReceiptTextCurrency(line)
+		}
+
+		if tesseractTotalLine.MatchString(line) {
+			if m := tesseractLineAmount.FindStringSubmatch(line); m != nil {
+				receiptData.Total = parseReceiptTextAmount(m[2])
+				break // Payment details follow the total
+			}
+			continue
+		}
+
+		if tesseractTaxLine.MatchString(line) {
+			if m := tesseractLineAmount.FindStringSubmatch(line); m != nil {
+				receiptData.Tax = parseReceiptTextAmount(m[2])
+			}
+			continue
+		}
+
+		if q := tesseractQuantityLine.FindStringSubmatch(line); q != nil {
+			quantity := ReceiptItem{
+				Quantity:     parseReceiptTextAmount(q[1]),
+				QuantityUnit: normalizeReceiptUnit(q[2]),
+				Price:        parseReceiptTextAmount(q[3]),
+			}
+			if quantity.QuantityUnit == "" {
+				quantity.QuantityUnit = "pcs"
+			}
+
+			// "0,734 kg x 12,99" under its item line belongs to the item when the amounts agree
+			if n := len(receiptData.Items); n > 0 && receiptData.Items[n-1].Quantity == 1 &&
+				math.Abs(receiptData.Items[n-1].TotalPrice-quantity.Quantity*quantity.Price) < 0.05 {
+				receiptData.Items[n-1].Quantity = quantity.Quantity
+				receiptData.Items[n-1].QuantityUnit = quantity.QuantityUnit
+				receiptData.Items[n-1].Price = quantity.Price
+				continue
+			}
+
+			// Otherwise it is printed above its item, or on the same line as the name and amount
+			name := strings.TrimSpace(line[:strings.Index(line, q[0])])
+			if m := tesseractLineAmount.FindStringSubmatch(line); m != nil && strings.IndexFunc(name, unicode.IsLetter) >= 0 {
+				quantity.Name = name
+				quantity.TotalPrice = parseReceiptTextAmount(m[2])
+				receiptData.Items = append(receiptData.Items, quantity)
+				continue
+			}
+			pendingQuantity = &quantity
+			continue
+		}
+
+		m := tesseractLineAmount.FindStringSubmatch(line)
+		if m == nil || strings.IndexFunc(m[1], unicode.IsLetter) < 0 {
+			continue
+		}
+
+		item := ReceiptItem{
+			Name:         strings.TrimSpace(m[1]),
+			Quantity:     1,
+			QuantityUnit: "pcs",
+			TotalPrice:   parseReceiptTextAmount(m[2]),
+		}
+		item.Price = item.TotalPrice
+		if pendingQuantity != nil {
+			item.Quantity = pendingQuantity.Quantity
+			item.QuantityUnit = pendingQuantity.QuantityUnit
+			item.Price = pendingQuantity.Price
+			pendingQuantity = nil
+		}
+		receiptData.Items = append(receiptData.Items, item)
+	}
+
+	if receiptData.Total > 0 {
+		receiptData.Subtotal = receiptData.Total - receiptData.Tax
+	}
+
+	// Item totals that add up to the receipt total are the only sign that the text was read correctly
+	receiptData.Confidence = 0.1
+	if len(receiptData.Items) > 0 {
+		receiptData.Confidence = 0.3 + 0.6*receiptTotalsConsistency(receiptData)
+	}
+
+	return receiptData
+}
+
+func parseReceiptTextAmount(amount string) float64 {
+	value, _ := strconv.ParseFloat(strings.ReplaceAll(amount, ",", "."), 64)
+	return value
+}
+
+// parseReceiptTextDate finds a DD.MM.YYYY, DD/MM/YY or YYYY-MM-DD date in the line
+func parseReceiptTextDate(line string) (time.Time, bool) {
+	m := tesseractDate.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}, false
+	}
+
+	var year, month, day int
+	if m[4] != "" {
+		year, _ = strconv.Atoi(m[4])
+		month, _ = strconv.Atoi(m[5])
+		day, _ = strconv.Atoi(m[6])
+	} else {
+		day, _ = strconv.Atoi(m[1])
+		month, _ = strconv.Atoi(m[2])
+		year, _ = strconv.Atoi(m[3])
+		if year < 100 {
+			year += 2000
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), true
+}
+
+// detectReceiptTextCurrency returns the ISO code of a currency symbol or code in the line
+func detectReceiptTextCurrency(line string) string {
+	upper := strings.ToUpper(line)
+	switch {
+	case strings.Contains(line, "€") || strings.Contains(upper, "EUR"):
+		return "EUR"
+	case strings.Contains(line, "₴") || strings.Contains(upper, "ГРН") || strings.Contains(upper, "UAH"):
+		return "UAH"
+	case strings.Contains(upper, "ZŁ") || strings.Contains(upper, "PLN"):
+		return "PLN"
+	case strings.Contains(line, "₽") || strings.Contains(upper, "РУБ") || strings.Contains(upper, "RUB"):
+		return "RUB"
+	case strings.Contains(line, "$") || strings.Contains(upper, "USD"):
+		return "USD"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,196 @@
+package ai
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseReceiptText(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		merchant   string
+		date       time.Time
+		currency   string
+		total      float64
+		tax        float64
+		items      []ReceiptItem
+		confidence float64
+	}{
+		{
+			name: "german supermarket",
+			text: `
+				REWE Markt GmbH
+				Musterstr. 1
+				05.01.2025 17:30
+				Vollmilch 3,5% 1,29 A
+				Bananen 1,31 A
+				0,734 kg x 1,79
+				2 x 0,99
+				Joghurt 1,98 B
+				Brot 2,49 A
+				MwSt 0,46
+				SUMME EUR 7,07
+				Bar 10,00
+				Rückgeld 2,93`,
+			merchant: "REWE Markt GmbH",
+			date:     time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC),
+			currency: "EUR",
+			total:    7.07,
+			tax:      0.46,
+			items: []ReceiptItem{
+				{Name: "Vollmilch 3,5%", Quantity: 1, QuantityUnit: "pcs", Price: 1.29, TotalPrice: 1.29},
+				{Name: "Bananen", Quantity: 0.734, QuantityUnit: "kg", Price: 1.79, TotalPrice: 1.31},
+				{Name: "Joghurt", Quantity: 2, QuantityUnit: "pcs", Price: 0.99, TotalPrice: 1.98},
+				{Name: "Brot", Quantity: 1, QuantityUnit: "pcs", Price: 2.49, TotalPrice: 2.49},
+			},
+			confidence: 0.9,
+		},
+		{
+			name: "ukrainian supermarket",
+			text: `АТБ-Маркет
+				2025-02-14 10:05
+				Хліб 24,50
+				Молоко 2,5% 1л 42,90
+				3 шт * 15.00
+				Вода 45.00
+				Знижка -5,00
+				ПДВ 20% 17,90
+				СУМА 107,40 грн`,
+			merchant: "АТБ-Маркет",
+			date:     time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC),
+			currency: "UAH",
+			total:    107.40,
+			tax:      17.90,
+			items: []ReceiptItem{
+				{Name: "Хліб", Quantity: 1, QuantityUnit: "pcs", Price: 24.50, TotalPrice: 24.50},
+				{Name: "Молоко 2,5% 1л", Quantity: 1, QuantityUnit: "pcs", Price: 42.90, TotalPrice: 42.90},
+				{Name: "Вода", Quantity: 3, QuantityUnit: "pcs", Price: 15, TotalPrice: 45},
+				{Name: "Знижка", Quantity: 1, QuantityUnit: "pcs", Price: -5, TotalPrice: -5},
+			},
+			confidence: 0.9,
+		},
+		{
+			name: "quantity on the item line",
+			text: `Kiosk
+				12/03/24
+				Cola 2 x 1,50 3,00
+				Total 3,00 €`,
+			merchant: "Kiosk",
+			date:     time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC),
+			currency: "EUR",
+			total:    3,
+			items: []ReceiptItem{
+				{Name: "Cola", Quantity: 2, QuantityUnit: "pcs", Price: 1.50, TotalPrice: 3},
+			},
+			confidence: 0.9,
+		},
+		{
+			name: "items not adding up to the total",
+			text: `Shop
+				Apples 2,00
+				TOTAL 4,00`,
+			merchant: "Shop",
+			total:    4,
+			items: []ReceiptItem{
+				{Name: "Apples", Quantity: 1, QuantityUnit: "pcs", Price: 2, TotalPrice: 2},
+			},
+			confidence: 0.6,
+		},
+		{
+			name:       "no items",
+			text:       "Unreadable\n\n 12 34 \n",
+			merchant:   "Unreadable",
+			items:      []ReceiptItem{},
+			confidence: 0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseReceiptText(tt.text)
+
+			if got.MerchantName != tt.merchant {
+				t.Errorf("MerchantName = %q, want %q", got.MerchantName, tt.merchant)
+			}
+			if !got.TransactionDate.Equal(tt.date) {
+				t.Errorf("TransactionDate = %s, want %s", got.TransactionDate, tt.date)
+			}
+			if got.Currency != tt.currency {
+				t.Errorf("Currency = %q, want %q", got.Currency, tt.currency)
+			}
+			if !almostEqual(got.Total, tt.total) || !almostEqual(got.Tax, tt.tax) {
+				t.Errorf("Total, Tax = %v, %v, want %v, %v", got.Total, got.Tax, tt.total, tt.tax)
+			}
+			if tt.total > 0 && !almostEqual(got.Subtotal, tt.total-tt.tax) {
+				t.Errorf("Subtotal = %v, want %v", got.Subtotal, tt.total-tt.tax)
+			}
+			if !almostEqual(got.Confidence, tt.confidence) {
+				t.Errorf("Confidence = %v, want %v", got.Confidence, tt.confidence)
+			}
+			if got.ModelID != ReceiptExtractorTesseract {
+				t.Errorf("ModelID = %q", got.ModelID)
+			}
+
+			if len(got.Items) != len(tt.items) {
+				t.Fatalf("got %d items, want %d: %+v", len(got.Items), len(tt.items), got.Items)
+			}
+			for i, want := range tt.items {
+				item := got.Items[i]
+				if item.Name != want.Name || item.QuantityUnit != want.QuantityUnit ||
+					!almostEqual(item.Quantity, want.Quantity) || !almostEqual(item.Price, want.Price) ||
+					!almostEqual(item.TotalPrice, want.TotalPrice) {
+					t.Errorf("item %d = %+v, want %+v", i, item, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseReceiptTextDate(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   time.Time
+		wantOK bool
+	}{
+		{"05.01.2025 17:30", time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), true},
+		{"Datum: 5/1/25", time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), true},
+		{"Дата 2025-02-14 10:05", time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC), true},
+		{"31-12-2024", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), true},
+		{"13.13.2024", time.Time{}, false},
+		{"Milk 1,29", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseReceiptTextDate(tt.line)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parseReceiptTextDate(%q) = %s, %v, want %s, %v", tt.line, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDetectReceiptTextCurrency(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"Summe 7,07 €", "EUR"},
+		{"SUMME EUR 7,07", "EUR"},
+		{"СУМА 107,40 грн", "UAH"},
+		{"Razem 12,00 zł", "PLN"},
+		{"ИТОГО 500 руб", "RUB"},
+		{"Total $3.00", "USD"},
+		{"Milk 1,29", ""},
+	}
+
+	for _, tt := range tests {
+		if got := detectReceiptTextCurrency(tt.line); got != tt.want {
+			t.Errorf("detectReceiptTextCurrency(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,270 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// visionReceiptPrompt asks the model for the fields of ReceiptData, the shape is enforced by visionReceiptSchema
+const visionReceiptPrompt = `Extract the purchase receipt in the image.
+- Copy item names exactly as printed, in the receipt's language.
+- quantity is the purchased amount, fractional for weighed goods (e.g. 0.734), 1 when not printed.
+- quantityUnit is one of kg, g, l, ml, pcs.
+- price is the price per quantityUnit, totalPrice the amount paid for the line.
+- Dates as YYYY-MM-DD, times as HH:MM, currency as an ISO 4217 code, countryRegion as an ISO 3166 alpha-3 code.
+- detectedLanguage is the ISO 639-1 code of the receipt's language.
+- confidence (0-1) is how sure you are the receipt was read correctly; use empty strings and 0 for fields that are not printed.`
+
+// visionReceiptExtractor sends the receipt image to a multimodal model of the configured LLM provider
+// and asks for a structured answer through a JSON schema
+type visionReceiptExtractor struct {
+	client *openAIClient
+	model  string
+	logger *slog.Logger
+}
+
+type visionChatRequest struct {
+	Model          string               `json:"model"`
+	Messages       []visionMessage      `json:"messages"`
+	ResponseFormat visionResponseFormat `json:"response_format"`
+}
+
+type visionMessage struct {
+	Role    string          `json:"role"`
+	Content []visionContent `json:"content"`
+}
+
+type visionContent struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *visionImageURL `json:"image_url,omitempty"`
+}
+
+type visionImageURL struct {
+	URL string `json:"url"`
+}
+
+type visionResponseFormat struct {
+	Type       string           `json:"type"`
+	JSONSchema visionJSONSchema `json:"json_schema"`
+}
+
+type visionJSONSchema struct {
+	Name   string          `json:"name"`
+	Strict bool            `json:"strict"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// visionReceipt is the structured answer of the model
+type visionReceipt struct {
+	MerchantName     string  `json:"merchantName"`
+	MerchantAddress  string  `json:"merchantAddress"`
+	MerchantPhone    string  `json:"merchantPhone"`
+	TransactionDate  string  `json:"transactionDate"`
+	TransactionTime  string  `json:"transactionTime"`
+	Currency         string  `json:"currency"`
+	CountryRegion    string  `json:"countryRegion"`
+	DetectedLanguage string  `json:"detectedLanguage"`
+	Subtotal         float64 `json:"subtotal"`
+	Tax              float64 `json:"tax"`
+	Total            float64 `json:"total"`
+	Confidence       float64 `json:"confidence"`
+	Items            []struct {
+		Name         string  `json:"name"`
+		Quantity     float64 `json:"quantity"`
+		QuantityUnit string  `json:"quantityUnit"`
+		Price        float64 `json:"price"`
+		TotalPrice   float64 `json:"totalPrice"`
+	} `json:"items"`
+}
+
+var visionReceiptSchema = json.RawMessage(`{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["merchantName", "merchantAddress", "merchantPhone", "transactionDate", "transactionTime", "currency", "countryRegion", "detectedLanguage", "subtotal", "tax", "total", "confidence", "items"],
+	"properties": {
+		"merchantName": {"type": "string"},
+		"merchantAddress": {"type": "string"},
+		"merchantPhone": {"type": "string"},
+		"transactionDate": {"type": "string"},
+		"transactionTime": {"type": "string"},
+		"currency": {"type": "string"},
+		"countryRegion": {"type": "string"},
+		"detectedLanguage": {"type": "string"},
+		"subtotal": {"type": "number"},
+		"tax": {"type": "number"},
+		"total": {"type": "number"},
+		"confidence": {"type": "number"},
+		"items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"additionalProperties": false,
+				"required": ["name", "quantity", "quantityUnit", "price", "totalPrice"],
+				"properties": {
+					"name": {"type": "string"},
+					"quantity": {"type": "number"},
+					"quantityUnit": {"type": "string", "enum": ["kg", "g", "l", "ml", "pcs"]},
+					"price": {"type": "number"},
+					"totalPrice": {"type": "number"}
+				}
+			}
+		}
+	}
+}`)
+
+func newVisionReceiptExtractor(client *openAIClient, model string, logger *slog.Logger) *visionReceiptExtractor {
+	if model == "" {
+		model = client.config.Model
+	}
+
+	return &visionReceiptExtractor{
+		client: client,
+		model:  model,
+		logger: logger,
+	}
+}
+
+func (e *visionReceiptExtractor) AnalyzeReceipt(ctx context.Context, imageData []byte, contentType string) (*ReceiptData, error) {
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("vision extraction supports images only, got %s", contentType)
+	}
+
+	reqBody := visionChatRequest{
+		Model: e.model,
+		Messages: []visionMessage{
+			{
+				Role: "user",
+				Content: []visionContent{
+					{Type: "text", Text: visionReceiptPrompt},
+					{Type: "image_url", ImageURL: &visionImageURL{
+						URL: "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(imageData),
+					}},
+				},
+			},
+		},
+		ResponseFormat: visionResponseFormat{
+			Type: "json_schema",
+			JSONSchema: visionJSONSchema{
+				Name:   "receipt",
+				Strict: true,
+				Schema: visionReceiptSchema,
+			},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vision receipt request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.client.endpoint("/chat/completions"), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vision receipt request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	e.client.authorize(req)
+
+	startTime := time.Now()
+	resp, err := e.client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vision receipt request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vision receipt response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vision receipt API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp ChatCompletionResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vision receipt response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 || chatResp.Choices[0].Message.Content == "" {
+		return nil, fmt.Errorf("no vision receipt output returned")
+	}
+
+	var extracted visionReceipt
+	if err := json.Unmarshal([]byte(chatResp.Choices[0].Message.Content), &extracted); err != nil {
+		return nil, fmt.Errorf("failed to parse vision receipt output: %w", err)
+	}
+
+	receiptData := e.toReceiptData(&extracted)
+
+	e.logger.Info("Receipt extracted with vision model",
+		"provider", e.client.provider,
+		"model", e.model,
+		"items_extracted", len(receiptData.Items),
+		"total", receiptData.Total,
+		"confidence", receiptData.Confidence,
+		"duration_ms", time.Since(startTime).Milliseconds())
+
+	return receiptData, nil
+}
+
+// toReceiptData converts the model answer, its self-reported confidence is capped by how well the
+// item totals add up to the receipt total
+func (e *visionReceiptExtractor) toReceiptData(extracted *visionReceipt) *ReceiptData {
+	receiptData := &ReceiptData{
+		MerchantName:     extracted.MerchantName,
+		MerchantAddress:  extracted.MerchantAddress,
+		MerchantPhone:    extracted.MerchantPhone,
+		Items:            make([]ReceiptItem, 0, len(extracted.Items)),
+		Subtotal:         extracted.Subtotal,
+		Tax:              extracted.Tax,
+		Total:            extracted.Total,
+		Currency:         strings.ToUpper(extracted.Currency),
+		CountryRegion:    strings.ToUpper(extracted.CountryRegion),
+		DetectedLanguage: strings.ToLower(extracted.DetectedLanguage),
+		APIVersion:       e.client.provider,
+		ModelID:          e.model,
+	}
+
+	if date, err := time.Parse("2006-01-02", extracted.TransactionDate); err == nil {
+		receiptData.TransactionDate = date
+	}
+	if transactionTime, err := time.Parse("15:04", extracted.TransactionTime); err == nil {
+		receiptData.TransactionTime = transactionTime
+	}
+
+	for _, extractedItem := range extracted.Items {
+		if strings.TrimSpace(extractedItem.Name) == "" {
+			continue
+		}
+		item := ReceiptItem{
+			Name:         strings.TrimSpace(extractedItem.Name),
+			Quantity:     extractedItem.Quantity,
+			QuantityUnit: normalizeReceiptUnit(extractedItem.QuantityUnit),
+			Price:        extractedItem.Price,
+			TotalPrice:   extractedItem.TotalPrice,
+		}
+		if item.Price == 0 && item.TotalPrice > 0 && item.Quantity > 0 {
+			item.Price = math.Round(item.TotalPrice/item.Quantity*100) / 100
+		}
+		receiptData.Items = append(receiptData.Items, item)
+	}
+
+	if receiptData.Subtotal == 0 && receiptData.Total > 0 {
+		receiptData.Subtotal = receiptData.Total - receiptData.Tax
+	}
+
+	confidence := math.Min(math.Max(extracted.Confidence, 0), 1)
+	receiptData.Confidence = math.Min(confidence, 0.5+receiptTotalsConsistency(receiptData)/2)
+
+	return receiptData
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestVisionReceiptExtractorToReceiptData(t *testing.T) {
+	extractor := &visionReceiptExtractor{client: &openAIClient{provider: ProviderAzure}, model: "gpt-4o"}
+
+	tests := []struct {
+		name       string
+		answer     string
+		confidence float64
+	}{
+		{
+			name:       "items add up",
+			answer:     `{"total": 3.5, "confidence": 0.95, "items": [{"name": "Milk", "totalPrice": 1.25}, {"name": "Bread", "totalPrice": 2.25}]}`,
+			confidence: 0.95,
+		},
+		{
+			name:       "half of the total is capped at 0.75",
+			answer:     `{"total": 10, "confidence": 0.9, "items": [{"name": "Milk", "totalPrice": 5}]}`,
+			confidence: 0.75,
+		},
+		{
+			name:       "no total is capped at 0.5",
+			answer:     `{"confidence": 0.99, "items": [{"name": "Milk", "totalPrice": 5}]}`,
+			confidence: 0.5,
+		},
+		{
+			name:       "low confidence is kept",
+			answer:     `{"total": 1, "confidence": 0.3, "items": [{"name": "Milk", "totalPrice": 1}]}`,
+			confidence: 0.3,
+		},
+		{
+			name:       "out of range confidence is clamped",
+			answer:     `{"total": 1, "confidence": 7, "items": [{"name": "Milk", "totalPrice": 1}]}`,
+			confidence: 1,
+		},
+		{
+			name:       "negative confidence is clamped",
+			answer:     `{"total": 1, "confidence": -1, "items": [{"name": "Milk", "totalPrice": 1}]}`,
+			confidence: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var extracted visionReceipt
+			if err := json.Unmarshal([]byte(tt.answer), &extracted); err != nil {
+				t.Fatal(err)
+			}
+			if got := extractor.toReceiptData(&extracted); !almostEqual(got.Confidence, tt.confidence) {
+				t.Errorf("Confidence = %v, want %v", got.Confidence, tt.confidence)
+			}
+		})
+	}
+}
+
+func TestVisionReceiptExtractorToReceiptDataFields(t *testing.T) {
+	extractor := &visionReceiptExtractor{client: &openAIClient{provider: ProviderAzure}, model: "gpt-4o"}
+
+	var extracted visionReceipt
+	if err := json.Unmarshal([]byte(`{
+		"merchantName": "REWE", "transactionDate": "2025-01-05", "transactionTime": "17:30",
+		"currency": "eur", "countryRegion": "de", "detectedLanguage": "DE",
+		"tax": 0.5, "total": 4.5, "confidence": 0.9,
+		"items": [
+			{"name": " Bananen ", "quantity": 0.5, "quantityUnit": "KG", "totalPrice": 1},
+			{"name": "  ", "totalPrice": 2},
+			{"name": "Brot", "quantity": 1, "price": 3.5, "totalPrice": 3.5}
+		]
+	}`), &extracted); err != nil {
+		t.Fatal(err)
+	}
+
+	got := extractor.toReceiptData(&extracted)
+	if got.MerchantName != "REWE" || got.Currency != "EUR" || got.CountryRegion != "DE" || got.DetectedLanguage != "de" ||
+		got.APIVersion != ProviderAzure || got.ModelID != "gpt-4o" {
+		t.Errorf("receipt = %+v", *got)
+	}
+	if !got.TransactionDate.Equal(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)) || got.TransactionTime.Format("15:04") != "17:30" {
+		t.Errorf("transaction = %s %s", got.TransactionDate, got.TransactionTime)
+	}
+	if !almostEqual(got.Subtotal, 4) {
+		t.Errorf("Subtotal = %v, want the total without tax", got.Subtotal)
+	}
+	if len(got.Items) != 2 {
+		t.Fatalf("got %d items, want the 2 named ones: %+v", len(got.Items), got.Items)
+	}
+	if item := got.Items[0]; item.Name != "Bananen" || !almostEqual(item.Price, 2) {
+		t.Errorf("item 0 = %+v, want Bananen at 2 per unit", item)
+	}
+}
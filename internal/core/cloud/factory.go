@@ -13,9 +13,11 @@ func NewProvider(config Config) (Provider, error) {
 	case "azure":
 		return NewAzureProvider(config.Azure)
 	case "aws":
-		return nil, fmt.Errorf("AWS S3 provider not yet implemented")
+		return NewS3Provider(config.AWS)
 	case "gcp":
-		return nil, fmt.Errorf("GCP Cloud Storage provider not yet implemented")
+		return NewGCSProvider(config.GCP)
+	case "local":
+		return NewLocalProvider(config.Local)
 	default:
 		return nil, &CloudError{
 			Code:    "INVALID_PROVIDER",
@@ -42,6 +44,8 @@ func ValidateConfig(config Config) error {
 		return ValidateAWSConfig(config.AWS)
 	case "gcp":
 		return ValidateGCPConfig(config.GCP)
+	case "local":
+		return ValidateLocalConfig(config.Local)
 	default:
 		return &CloudError{
 			Code:    "INVALID_PROVIDER",
@@ -77,19 +81,20 @@ func ValidateAzureConfig(config AzureConfig) error {
 	return nil
 }
 
-// ValidateAWSConfig validates AWS S3 configuration (for future use)
+// ValidateAWSConfig validates AWS S3 configuration
 func ValidateAWSConfig(config AWSConfig) error {
-	if config.AccessKeyID == "" {
+	// Without static keys the default AWS credential chain is used (environment, shared config, IAM role)
+	if config.AccessKeyID == "" && config.SecretAccessKey != "" {
 		return &CloudError{
 			Code:    "MISSING_AWS_ACCESS_KEY",
-			Message: "AWS access key ID is required",
+			Message: "AWS access key ID is required with a secret access key",
 		}
 	}
 
-	if config.SecretAccessKey == "" {
+	if config.AccessKeyID != "" && config.SecretAccessKey == "" {
 		return &CloudError{
 			Code:    "MISSING_AWS_SECRET_KEY",
-			Message: "AWS secret access key is required",
+			Message: "AWS secret access key is required with an access key ID",
 		}
 	}
 
@@ -110,7 +115,7 @@ func ValidateAWSConfig(config AWSConfig) error {
 	return nil
 }
 
-// ValidateGCPConfig validates GCP Cloud Storage configuration (for future use)
+// ValidateGCPConfig validates GCP Cloud Storage configuration
 func ValidateGCPConfig(config GCPConfig) error {
 	if config.ProjectID == "" {
 		return &CloudError{
@@ -126,10 +131,31 @@ func ValidateGCPConfig(config GCPConfig) error {
 		}
 	}
 
-	if config.CredentialsJSON == "" && config.CredentialsFile == "" {
+	// Without credentials Application Default Credentials are used
+
+	return nil
+}
+
+// ValidateLocalConfig validates local filesystem storage configuration
+func ValidateLocalConfig(config LocalConfig) error {
+	if config.RootPath == "" {
+		return &CloudError{
+			Code:    "MISSING_LOCAL_ROOT",
+			Message: "local storage root path is required",
+		}
+	}
+
+	if config.BaseURL == "" {
+		return &CloudError{
+			Code:    "MISSING_LOCAL_BASE_URL",
+			Message: "local storage base URL is required",
+		}
+	}
+
+	if config.SigningKey == "" {
 		return &CloudError{
-			Code:    "MISSING_GCP_CREDENTIALS",
-			Message: "GCP credentials JSON or credentials file is required",
+			Code:    "MISSING_LOCAL_SIGNING_KEY",
+			Message: "local storage signing key is required",
 		}
 	}
 
@@ -0,0 +1,605 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const (
+	gcsDefaultEndpoint = "https://storage.googleapis.com"
+	gcsRequestTimeout  = 60 * time.Second
+
+	// gcsListPageSize is used when listing without a page size limit
+	gcsListPageSize = 1000
+
+	// gcsMaxSignedURLExpiration is the longest validity Cloud Storage accepts for signed URLs
+	gcsMaxSignedURLExpiration = 7 * 24 * time.Hour
+
+	// gcsTagPrefix marks tags in object metadata, Cloud Storage has no separate object tags
+	gcsTagPrefix = "tag_"
+)
+
+// GCSProvider implements the Provider interface for Google Cloud Storage
+type GCSProvider struct {
+	client     *storage.Client
+	bucket     *storage.BucketHandle
+	endpoint   *url.URL
+	bucketName string
+	config     GCPConfig
+}
+
+// NewGCSProvider creates a new Google Cloud Storage provider and creates the bucket when it doesn't exist
+func NewGCSProvider(config GCPConfig) (*GCSProvider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gcsRequestTimeout)
+	defer cancel()
+
+	provider, err := newGCSProvider(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure bucket exists
+	if err := provider.ensureBucket(ctx); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// newGCSProvider creates the Cloud Storage client without touching the bucket. The configured
+// service account key is used when set, otherwise Application Default Credentials
+// (GOOGLE_APPLICATION_CREDENTIALS, gcloud or the metadata server).
+func newGCSProvider(ctx context.Context, config GCPConfig) (*GCSProvider, error) {
+	if err := ValidateGCPConfig(config); err != nil {
+		return nil, err
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = gcsDefaultEndpoint
+	}
+
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || endpointURL.Host == "" {
+		return nil, &CloudError{
+			Code:    "INVALID_GCP_ENDPOINT",
+			Message: fmt.Sprintf("invalid Cloud Storage endpoint: %s", config.Endpoint),
+			Cause:   err,
+		}
+	}
+
+	var options []option.ClientOption
+	switch {
+	case config.CredentialsJSON != "":
+		options = append(options, option.WithAuthCredentialsJSON(option.ServiceAccount, []byte(config.CredentialsJSON)))
+	case config.CredentialsFile != "":
+		options = append(options, option.WithAuthCredentialsFile(option.ServiceAccount, config.CredentialsFile))
+	}
+	if config.Endpoint != "" {
+		options = append(options, option.WithEndpoint(endpointURL.String()+"/storage/v1/"))
+	}
+
+	client, err := storage.NewClient(ctx, options...)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "GCP_CLIENT_ERROR",
+			Message: "failed to create Cloud Storage client",
+			Cause:   err,
+		}
+	}
+
+	return &GCSProvider{
+		client:     client,
+		bucket:     client.Bucket(config.BucketName),
+		endpoint:   endpointURL,
+		bucketName: config.BucketName,
+		config:     config,
+	}, nil
+}
+
+// UploadFile uploads a file to Cloud Storage
+func (p *GCSProvider) UploadFile(ctx context.Context, req *UploadRequest) (*UploadResponse, error) {
+	if req == nil {
+		return nil, &CloudError{
+			Code:    "INVALID_REQUEST",
+			Message: "upload request cannot be nil",
+		}
+	}
+
+	objectName := generateObjectKey(req)
+
+	metadata := make(map[string]string, len(req.Metadata)+len(req.Tags)+2)
+	if req.FileName != "" {
+		metadata["filename"] = req.FileName
+	}
+	if req.FolderPath != "" {
+		metadata["folder_path"] = req.FolderPath
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range req.Tags {
+		metadata[gcsTagPrefix+k] = v
+	}
+
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := p.bucket.Object(objectName).NewWriter(ctx)
+	writer.ContentType = contentType
+	writer.Metadata = metadata
+
+	if _, err := io.Copy(writer, req.Content); err != nil {
+		// Cancelling aborts the upload instead of committing a partial object
+		cancel()
+		_ = writer.Close()
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to upload file to Cloud Storage",
+			Cause:   err,
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to upload file to Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	attrs := writer.Attrs()
+
+	return &UploadResponse{
+		FileID:      objectName,
+		PublicURL:   p.generatePublicURL(objectName),
+		Size:        attrs.Size,
+		ContentType: req.ContentType,
+		ETag:        attrs.Etag,
+		UploadedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// GetFileURL generates a public URL for accessing a file
+func (p *GCSProvider) GetFileURL(ctx context.Context, fileID string) (string, error) {
+	if fileID == "" {
+		return "", ErrInvalidFileID
+	}
+
+	return p.generatePublicURL(fileID), nil
+}
+
+// GetPresignedURL generates a temporary signed URL for file access
+func (p *GCSProvider) GetPresignedURL(ctx context.Context, fileID string, expiration time.Duration) (string, error) {
+	if fileID == "" {
+		return "", ErrInvalidFileID
+	}
+
+	// Check if file exists
+	if _, err := p.bucket.Object(fileID).Attrs(ctx); err != nil {
+		return "", &CloudError{
+			Code:    "FILE_NOT_FOUND",
+			Message: "file not found in Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	return p.signedGetURL(fileID, expiration)
+}
+
+// signedGetURL signs a V4 GET URL of the object, capped at the longest validity Cloud Storage
+// accepts. The SDK signs with the key of the service account credentials, or through the IAM
+// signBlob API for credentials without a key such as the metadata server.
+func (p *GCSProvider) signedGetURL(fileID string, expiration time.Duration) (string, error) {
+	if expiration <= 0 || expiration > gcsMaxSignedURLExpiration {
+		expiration = gcsMaxSignedURLExpiration
+	}
+
+	signedURL, err := p.bucket.SignedURL(fileID, &storage.SignedURLOptions{
+		Scheme:   storage.SigningSchemeV4,
+		Method:   http.MethodGet,
+		Expires:  time.Now().Add(expiration),
+		Insecure: p.endpoint.Scheme == "http",
+	})
+	if err != nil {
+		return "", &CloudError{
+			Code:    "SIGNED_URL_FAILED",
+			Message: "failed to generate signed URL",
+			Cause:   err,
+		}
+	}
+
+	return signedURL, nil
+}
+
+// DeleteFile removes a file from Cloud Storage
+func (p *GCSProvider) DeleteFile(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return ErrInvalidFileID
+	}
+
+	if err := p.bucket.Object(fileID).Delete(ctx); err != nil {
+		return &CloudError{
+			Code:    "DELETE_FAILED",
+			Message: "failed to delete file from Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFiles lists files with optional prefix filtering
+func (p *GCSProvider) ListFiles(ctx context.Context, req *ListFilesRequest) (*ListFilesResponse, error) {
+	if req == nil {
+		req = &ListFilesRequest{}
+	}
+
+	// Set prefix based on folder path or explicit prefix
+	var prefix string
+	if req.FolderPath != "" {
+		prefix = strings.Trim(req.FolderPath, "/") + "/"
+	}
+	prefix += req.Prefix
+
+	delimiter := ""
+	if req.FolderPath != "" && !req.Recursive {
+		delimiter = "/"
+	}
+
+	objects, nextPageToken, err := p.listObjects(ctx, prefix, delimiter, req.ContinuationToken, req.MaxResults)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "LIST_FAILED",
+			Message: "failed to list files from Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	response := &ListFilesResponse{
+		Files:                 []*FileInfo{},
+		NextContinuationToken: nextPageToken,
+		IsTruncated:           nextPageToken != "",
+	}
+
+	for _, attrs := range objects {
+		response.Files = append(response.Files, p.fileInfo(attrs))
+	}
+
+	return response, nil
+}
+
+// GetFileInfo retrieves metadata about a file
+func (p *GCSProvider) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error) {
+	if fileID == "" {
+		return nil, ErrInvalidFileID
+	}
+
+	attrs, err := p.bucket.Object(fileID).Attrs(ctx)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "FILE_NOT_FOUND",
+			Message: "file not found in Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	return p.fileInfo(attrs), nil
+}
+
+// DownloadFile downloads file content directly from Cloud Storage
+func (p *GCSProvider) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	if fileID == "" {
+		return nil, ErrInvalidFileID
+	}
+
+	reader, err := p.bucket.Object(fileID).NewReader(ctx)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "DOWNLOAD_FAILED",
+			Message: "failed to download file from Cloud Storage",
+			Cause:   err,
+		}
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "READ_FAILED",
+			Message: "failed to read file content",
+			Cause:   err,
+		}
+	}
+
+	return data, nil
+}
+
+// CreateFolder creates a virtual folder by creating a marker object
+func (p *GCSProvider) CreateFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	// Normalize folder path
+	markerName := strings.Trim(folderPath, "/") + "/.folder_marker"
+
+	writer := p.bucket.Object(markerName).NewWriter(ctx)
+	writer.ContentType = "application/octet-stream"
+	writer.Metadata = map[string]string{
+		"folder_marker": "true",
+		"created_at":    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if err := writer.Close(); err != nil {
+		return &CloudError{
+			Code:    "FOLDER_CREATE_FAILED",
+			Message: "failed to create folder in Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFolders lists folders within a path
+func (p *GCSProvider) ListFolders(ctx context.Context, parentPath string) ([]*FolderInfo, error) {
+	// Normalize parent path
+	if parentPath != "" {
+		parentPath = strings.Trim(parentPath, "/") + "/"
+	}
+
+	folderMap := make(map[string]*FolderInfo)
+
+	pageToken := ""
+	for {
+		objects, nextPageToken, err := p.listObjects(ctx, parentPath, "", pageToken, 0)
+		if err != nil {
+			return nil, &CloudError{
+				Code:    "LIST_FOLDERS_FAILED",
+				Message: "failed to list folders from Cloud Storage",
+				Cause:   err,
+			}
+		}
+
+		for _, attrs := range objects {
+			relativePath := strings.TrimPrefix(attrs.Name, parentPath)
+
+			// Find the immediate folder
+			parts := strings.Split(relativePath, "/")
+			if len(parts) < 2 {
+				continue
+			}
+
+			folderName := parts[0]
+			fullFolderPath := parentPath + folderName
+
+			folderInfo, exists := folderMap[fullFolderPath]
+			if !exists {
+				folderInfo = &FolderInfo{
+					FolderPath:   fullFolderPath,
+					FolderName:   folderName,
+					ParentPath:   strings.TrimSuffix(parentPath, "/"),
+					CreatedAt:    attrs.Created,
+					LastModified: attrs.Updated,
+					Metadata:     make(map[string]string),
+				}
+				folderMap[fullFolderPath] = folderInfo
+			}
+
+			folderInfo.FileCount++
+			folderInfo.TotalSize += attrs.Size
+			if attrs.Updated.After(folderInfo.LastModified) {
+				folderInfo.LastModified = attrs.Updated
+			}
+			if attrs.Created.Before(folderInfo.CreatedAt) {
+				folderInfo.CreatedAt = attrs.Created
+			}
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+
+	// Convert map to slice and sort
+	folders := make([]*FolderInfo, 0, len(folderMap))
+	for _, folder := range folderMap {
+		folders = append(folders, folder)
+	}
+
+	// Sort folders by name
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].FolderName < folders[j].FolderName
+	})
+
+	return folders, nil
+}
+
+// DeleteFolder deletes a folder and all its contents
+func (p *GCSProvider) DeleteFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	// Normalize folder path
+	folderPath = strings.Trim(folderPath, "/") + "/"
+
+	var deletionErrors []string
+
+	pageToken := ""
+	for {
+		objects, nextPageToken, err := p.listObjects(ctx, folderPath, "", pageToken, 0)
+		if err != nil {
+			return &CloudError{
+				Code:    "DELETE_FOLDER_FAILED",
+				Message: "failed to list folder contents for deletion",
+				Cause:   err,
+			}
+		}
+
+		// Delete each object in the folder
+		for _, attrs := range objects {
+			if err := p.DeleteFile(ctx, attrs.Name); err != nil {
+				deletionErrors = append(deletionErrors, fmt.Sprintf("failed to delete %s: %v", attrs.Name, err))
+			}
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+
+	if len(deletionErrors) > 0 {
+		return &CloudError{
+			Code:    "DELETE_FOLDER_PARTIAL_FAILURE",
+			Message: "some files in folder could not be deleted: " + strings.Join(deletionErrors, "; "),
+		}
+	}
+
+	return nil
+}
+
+// FileIDFromURL returns the object name of a public URL generated by this provider
+func (p *GCSProvider) FileIDFromURL(fileURL string) (string, bool) {
+	prefix := p.generatePublicURL("")
+	if !strings.HasPrefix(fileURL, prefix) {
+		return "", false
+	}
+
+	objectName, err := url.PathUnescape(strings.TrimPrefix(fileURL, prefix))
+	if err != nil || objectName == "" {
+		return "", false
+	}
+
+	return objectName, true
+}
+
+// fileInfo converts object attributes, splitting the tags off the metadata
+func (p *GCSProvider) fileInfo(attrs *storage.ObjectAttrs) *FileInfo {
+	folderPath, fileName := parseObjectPath(attrs.Name)
+
+	fileInfo := &FileInfo{
+		FileID:       attrs.Name,
+		FolderPath:   folderPath,
+		FileName:     fileName,
+		RelativePath: attrs.Name,
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		ETag:         attrs.Etag,
+		LastModified: attrs.Updated,
+		PublicURL:    p.generatePublicURL(attrs.Name),
+		Metadata:     make(map[string]string),
+		Tags:         make(map[string]string),
+	}
+
+	for k, v := range attrs.Metadata {
+		if tag, ok := strings.CutPrefix(k, gcsTagPrefix); ok {
+			fileInfo.Tags[tag] = v
+			continue
+		}
+		fileInfo.Metadata[k] = v
+	}
+
+	return fileInfo
+}
+
+// listObjects lists a single page of objects, skipping the prefixes returned with a delimiter
+func (p *GCSProvider) listObjects(ctx context.Context, prefix, delimiter, pageToken string, maxResults int) ([]*storage.ObjectAttrs, string, error) {
+	if maxResults <= 0 {
+		maxResults = gcsListPageSize
+	}
+
+	it := p.bucket.Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: delimiter})
+
+	var page []*storage.ObjectAttrs
+	nextPageToken, err := iterator.NewPager(it, maxResults, pageToken).NextPage(&page)
+	if err != nil {
+		return nil, "", err
+	}
+
+	objects := make([]*storage.ObjectAttrs, 0, len(page))
+	for _, attrs := range page {
+		if attrs.Name != "" {
+			objects = append(objects, attrs)
+		}
+	}
+
+	return objects, nextPageToken, nil
+}
+
+// ensureBucket creates the bucket when it doesn't exist yet
+func (p *GCSProvider) ensureBucket(ctx context.Context) error {
+	_, err := p.bucket.Attrs(ctx)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, storage.ErrBucketNotExist) {
+		return &CloudError{
+			Code:    "BUCKET_CHECK_FAILED",
+			Message: "failed to check Cloud Storage bucket",
+			Cause:   err,
+		}
+	}
+
+	if err := p.bucket.Create(ctx, p.config.ProjectID, nil); err != nil {
+		// Bucket might have been created concurrently, which is fine
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+			return nil
+		}
+		return &CloudError{
+			Code:    "BUCKET_CREATE_FAILED",
+			Message: "failed to create bucket in Cloud Storage",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// objectURL builds the URL of an object (endpoint/bucket/name), used for public links
+func (p *GCSProvider) objectURL(objectName string) string {
+	u := *p.endpoint
+
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + p.bucketName + "/" + objectName
+	u.RawPath = strings.TrimSuffix(p.endpoint.EscapedPath(), "/") + "/" + url.PathEscape(p.bucketName) + "/" + escapeObjectKey(objectName)
+	u.RawQuery = ""
+
+	return u.String()
+}
+
+// generatePublicURL creates a public URL for the object
+func (p *GCSProvider) generatePublicURL(objectName string) string {
+	if p.config.BaseURL != "" {
+		// FileIDFromURL unescapes the name, so it has to be escaped here
+		return strings.TrimSuffix(p.config.BaseURL, "/") + "/" + escapeObjectKey(objectName)
+	}
+
+	return p.objectURL(objectName)
+}
@@ -0,0 +1,702 @@
+package cloud
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// localMetaDir keeps the JSON sidecar with metadata and tags of every file
+	localMetaDir = ".meta"
+	// localTempDir keeps partially written uploads until they are moved in place
+	localTempDir = ".tmp"
+
+	localMetaSuffix             = ".json"
+	localDefaultPresignDuration = time.Hour
+	localMaxPresignExpiration   = 7 * 24 * time.Hour
+)
+
+// LocalProvider implements the Provider interface on the local filesystem. It is meant for
+// development and tests: files are kept under a root directory and served by the HTTP server
+// through links signed with the configured key.
+type LocalProvider struct {
+	root    *os.Root
+	baseURL *url.URL
+	config  LocalConfig
+}
+
+// localFileMeta is the sidecar stored next to every file
+type localFileMeta struct {
+	FileName    string            `json:"file_name"`
+	FolderPath  string            `json:"folder_path"`
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	Metadata    map[string]string `json:"metadata"`
+	Tags        map[string]string `json:"tags"`
+	UploadedAt  time.Time         `json:"uploaded_at"`
+}
+
+// NewLocalProvider creates a new filesystem provider and creates the root directory when it doesn't exist
+func NewLocalProvider(config LocalConfig) (*LocalProvider, error) {
+	if err := ValidateLocalConfig(config); err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(config.BaseURL, "/"))
+	if err != nil || baseURL.Host == "" {
+		return nil, &CloudError{
+			Code:    "INVALID_LOCAL_BASE_URL",
+			Message: fmt.Sprintf("invalid local storage base URL: %s", config.BaseURL),
+			Cause:   err,
+		}
+	}
+
+	if err := os.MkdirAll(config.RootPath, 0o755); err != nil {
+		return nil, &CloudError{
+			Code:    "ROOT_CREATE_FAILED",
+			Message: "failed to create local storage directory",
+			Cause:   err,
+		}
+	}
+
+	root, err := os.OpenRoot(config.RootPath)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "ROOT_OPEN_FAILED",
+			Message: "failed to open local storage directory",
+			Cause:   err,
+		}
+	}
+
+	return &LocalProvider{
+		root:    root,
+		baseURL: baseURL,
+		config:  config,
+	}, nil
+}
+
+// UploadFile writes a file below the root directory
+func (p *LocalProvider) UploadFile(ctx context.Context, req *UploadRequest) (*UploadResponse, error) {
+	if req == nil {
+		return nil, &CloudError{
+			Code:    "INVALID_REQUEST",
+			Message: "upload request cannot be nil",
+		}
+	}
+
+	objectKey, err := localObjectKey(generateObjectKey(req))
+	if err != nil {
+		return nil, err
+	}
+
+	// Write to a temporary file first so readers never see partial uploads
+	if err := p.root.MkdirAll(localTempDir, 0o755); err != nil {
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to create temporary directory",
+			Cause:   err,
+		}
+	}
+
+	tempName := path.Join(localTempDir, uuid.New().String())
+	tempFile, err := p.root.Create(tempName)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to create temporary file",
+			Cause:   err,
+		}
+	}
+
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(tempFile, hash), req.Content)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = p.root.Remove(tempName)
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to write file to local storage",
+			Cause:   err,
+		}
+	}
+
+	if err := p.root.MkdirAll(path.Dir(objectKey), 0o755); err != nil {
+		_ = p.root.Remove(tempName)
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to create folder in local storage",
+			Cause:   err,
+		}
+	}
+
+	if err := p.root.Rename(tempName, objectKey); err != nil {
+		_ = p.root.Remove(tempName)
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to move file into local storage",
+			Cause:   err,
+		}
+	}
+
+	uploadedAt := time.Now().UTC()
+	meta := &localFileMeta{
+		FileName:    req.FileName,
+		FolderPath:  strings.Trim(req.FolderPath, "/"),
+		ContentType: req.ContentType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+		Metadata:    req.Metadata,
+		Tags:        req.Tags,
+		UploadedAt:  uploadedAt,
+	}
+	if err := p.writeMeta(objectKey, meta); err != nil {
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to write file metadata",
+			Cause:   err,
+		}
+	}
+
+	return &UploadResponse{
+		FileID:      objectKey,
+		PublicURL:   p.generatePublicURL(objectKey),
+		Size:        size,
+		ContentType: req.ContentType,
+		ETag:        meta.ETag,
+		UploadedAt:  uploadedAt,
+	}, nil
+}
+
+// GetFileURL generates the URL of a file. Local files are only served through presigned URLs.
+func (p *LocalProvider) GetFileURL(ctx context.Context, fileID string) (string, error) {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return "", err
+	}
+
+	return p.generatePublicURL(objectKey), nil
+}
+
+// GetPresignedURL generates a temporary URL served by the HTTP server
+func (p *LocalProvider) GetPresignedURL(ctx context.Context, fileID string, expiration time.Duration) (string, error) {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return "", err
+	}
+
+	// Check if file exists
+	if _, err := p.statFile(objectKey); err != nil {
+		return "", err
+	}
+
+	if expiration <= 0 {
+		expiration = localDefaultPresignDuration
+	}
+	if expiration > localMaxPresignExpiration {
+		expiration = localMaxPresignExpiration
+	}
+
+	expires := time.Now().Add(expiration).Unix()
+
+	u := p.objectURL(objectKey)
+	u.RawQuery = url.Values{
+		"expires":   {strconv.FormatInt(expires, 10)},
+		"signature": {p.sign(objectKey, expires)},
+	}.Encode()
+
+	return u.String(), nil
+}
+
+// DeleteFile removes a file and its metadata
+func (p *LocalProvider) DeleteFile(ctx context.Context, fileID string) error {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.root.Remove(objectKey); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &CloudError{
+			Code:    "DELETE_FAILED",
+			Message: "failed to delete file from local storage",
+			Cause:   err,
+		}
+	}
+
+	if err := p.root.Remove(localMetaPath(objectKey)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return &CloudError{
+			Code:    "DELETE_FAILED",
+			Message: "failed to delete file metadata",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFiles lists files with optional prefix filtering. The continuation token is the last returned file ID.
+func (p *LocalProvider) ListFiles(ctx context.Context, req *ListFilesRequest) (*ListFilesResponse, error) {
+	if req == nil {
+		req = &ListFilesRequest{}
+	}
+
+	// Set prefix based on folder path or explicit prefix
+	var folderPrefix string
+	if req.FolderPath != "" {
+		folderPrefix = strings.Trim(req.FolderPath, "/") + "/"
+	}
+	prefix := folderPrefix + req.Prefix
+
+	keys, err := p.listKeys(".")
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "LIST_FAILED",
+			Message: "failed to list files from local storage",
+			Cause:   err,
+		}
+	}
+
+	response := &ListFilesResponse{
+		Files: []*FileInfo{},
+	}
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= req.ContinuationToken {
+			continue
+		}
+		if req.FolderPath != "" && !req.Recursive && strings.Contains(strings.TrimPrefix(key, folderPrefix), "/") {
+			continue
+		}
+
+		if req.MaxResults > 0 && len(response.Files) == req.MaxResults {
+			response.IsTruncated = true
+			response.NextContinuationToken = response.Files[len(response.Files)-1].FileID
+			break
+		}
+
+		fileInfo, err := p.fileInfo(key)
+		if err != nil {
+			// The file was removed while listing
+			continue
+		}
+		response.Files = append(response.Files, fileInfo)
+	}
+
+	return response, nil
+}
+
+// GetFileInfo retrieves metadata about a file
+func (p *LocalProvider) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error) {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.fileInfo(objectKey)
+}
+
+// DownloadFile reads file content from the root directory
+func (p *LocalProvider) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := p.root.ReadFile(objectKey)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrFileNotFound
+		}
+		return nil, &CloudError{
+			Code:    "DOWNLOAD_FAILED",
+			Message: "failed to read file from local storage",
+			Cause:   err,
+		}
+	}
+
+	return data, nil
+}
+
+// OpenFile opens a file for streaming, the caller closes it
+func (p *LocalProvider) OpenFile(fileID string) (*os.File, *FileInfo, error) {
+	objectKey, err := localObjectKey(fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileInfo, err := p.fileInfo(objectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := p.root.Open(objectKey)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, &CloudError{
+			Code:    "DOWNLOAD_FAILED",
+			Message: "failed to open file from local storage",
+			Cause:   err,
+		}
+	}
+
+	return file, fileInfo, nil
+}
+
+// VerifySignature reports whether a presigned URL signature is valid and not expired
+func (p *LocalProvider) VerifySignature(fileID string, expires int64, signature string, now time.Time) bool {
+	if expires <= 0 || now.Unix() > expires {
+		return false
+	}
+
+	expected, err := hex.DecodeString(p.sign(fileID, expires))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(expected, actual)
+}
+
+// FileIDFromURL returns the file ID of a URL generated by this provider
+func (p *LocalProvider) FileIDFromURL(fileURL string) (string, bool) {
+	prefix := p.baseURL.String() + "/"
+	if !strings.HasPrefix(fileURL, prefix) {
+		return "", false
+	}
+
+	rest, _, _ := strings.Cut(strings.TrimPrefix(fileURL, prefix), "?")
+	objectKey, err := url.PathUnescape(rest)
+	if err != nil || objectKey == "" {
+		return "", false
+	}
+
+	return objectKey, true
+}
+
+// BasePath is the URL path below which the HTTP server has to serve files
+func (p *LocalProvider) BasePath() string {
+	if p.baseURL.Path == "" {
+		return "/"
+	}
+	return p.baseURL.Path
+}
+
+// CreateFolder creates a directory below the root directory
+func (p *LocalProvider) CreateFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	folderKey, err := localObjectKey(folderPath)
+	if err != nil {
+		return err
+	}
+
+	if err := p.root.MkdirAll(folderKey, 0o755); err != nil {
+		return &CloudError{
+			Code:    "FOLDER_CREATE_FAILED",
+			Message: "failed to create folder in local storage",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFolders lists folders within a path
+func (p *LocalProvider) ListFolders(ctx context.Context, parentPath string) ([]*FolderInfo, error) {
+	parentKey := "."
+	var parentFolder string
+	if strings.Trim(parentPath, "/") != "" {
+		key, err := localObjectKey(parentPath)
+		if err != nil {
+			return nil, err
+		}
+		parentKey, parentFolder = key, key
+	}
+
+	entries, err := fs.ReadDir(p.root.FS(), parentKey)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []*FolderInfo{}, nil
+		}
+		return nil, &CloudError{
+			Code:    "LIST_FOLDERS_FAILED",
+			Message: "failed to list folders from local storage",
+			Cause:   err,
+		}
+	}
+
+	folders := []*FolderInfo{}
+	for _, entry := range entries {
+		if !entry.IsDir() || (parentKey == "." && localReservedDir(entry.Name())) {
+			continue
+		}
+
+		folderKey := path.Join(parentKey, entry.Name())
+		folderInfo := &FolderInfo{
+			FolderPath: folderKey,
+			FolderName: entry.Name(),
+			ParentPath: parentFolder,
+			Metadata:   make(map[string]string),
+		}
+
+		if info, err := entry.Info(); err == nil {
+			folderInfo.CreatedAt = info.ModTime()
+			folderInfo.LastModified = info.ModTime()
+		}
+
+		err := fs.WalkDir(p.root.FS(), folderKey, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if name == folderKey {
+				return nil
+			}
+			if d.IsDir() {
+				folderInfo.SubfolderCount++
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			folderInfo.FileCount++
+			folderInfo.TotalSize += info.Size()
+			if info.ModTime().After(folderInfo.LastModified) {
+				folderInfo.LastModified = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, &CloudError{
+				Code:    "LIST_FOLDERS_FAILED",
+				Message: "failed to read folder contents",
+				Cause:   err,
+			}
+		}
+
+		folders = append(folders, folderInfo)
+	}
+
+	// Sort folders by name
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].FolderName < folders[j].FolderName
+	})
+
+	return folders, nil
+}
+
+// DeleteFolder deletes a folder and all its contents
+func (p *LocalProvider) DeleteFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	folderKey, err := localObjectKey(folderPath)
+	if err != nil {
+		return err
+	}
+
+	if err := p.root.RemoveAll(folderKey); err != nil {
+		return &CloudError{
+			Code:    "DELETE_FOLDER_FAILED",
+			Message: "failed to delete folder from local storage",
+			Cause:   err,
+		}
+	}
+
+	if err := p.root.RemoveAll(path.Join(localMetaDir, folderKey)); err != nil {
+		return &CloudError{
+			Code:    "DELETE_FOLDER_FAILED",
+			Message: "failed to delete folder metadata",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// fileInfo builds the file information from the file and its metadata sidecar
+func (p *LocalProvider) fileInfo(objectKey string) (*FileInfo, error) {
+	info, err := p.statFile(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	folderPath, fileName := parseObjectPath(objectKey)
+	fileInfo := &FileInfo{
+		FileID:       objectKey,
+		FolderPath:   folderPath,
+		FileName:     fileName,
+		RelativePath: objectKey,
+		Size:         info.Size(),
+		LastModified: info.ModTime().UTC(),
+		PublicURL:    p.generatePublicURL(objectKey),
+		Metadata:     make(map[string]string),
+		Tags:         make(map[string]string),
+	}
+
+	// Files copied into the directory by hand have no sidecar
+	meta, err := p.readMeta(objectKey)
+	if err != nil {
+		return fileInfo, nil
+	}
+
+	fileInfo.ContentType = meta.ContentType
+	fileInfo.ETag = meta.ETag
+	if meta.FileName != "" {
+		fileInfo.Metadata["filename"] = meta.FileName
+	}
+	for k, v := range meta.Metadata {
+		fileInfo.Metadata[k] = v
+	}
+	for k, v := range meta.Tags {
+		fileInfo.Tags[k] = v
+	}
+
+	return fileInfo, nil
+}
+
+func (p *LocalProvider) statFile(objectKey string) (fs.FileInfo, error) {
+	info, err := p.root.Stat(objectKey)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrFileNotFound
+		}
+		return nil, &CloudError{
+			Code:    "FILE_NOT_FOUND",
+			Message: "failed to read file from local storage",
+			Cause:   err,
+		}
+	}
+	if info.IsDir() {
+		return nil, ErrFileNotFound
+	}
+
+	return info, nil
+}
+
+// listKeys returns the sorted keys of all files below dir, skipping the reserved directories
+func (p *LocalProvider) listKeys(dir string) ([]string, error) {
+	var keys []string
+
+	err := fs.WalkDir(p.root.FS(), dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name != "." && localReservedDir(name) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		keys = append(keys, name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (p *LocalProvider) readMeta(objectKey string) (*localFileMeta, error) {
+	data, err := p.root.ReadFile(localMetaPath(objectKey))
+	if err != nil {
+		return nil, err
+	}
+
+	var meta localFileMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+
+	return &meta, nil
+}
+
+func (p *LocalProvider) writeMeta(objectKey string, meta *localFileMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	metaPath := localMetaPath(objectKey)
+	if err := p.root.MkdirAll(path.Dir(metaPath), 0o755); err != nil {
+		return err
+	}
+
+	return p.root.WriteFile(metaPath, data, 0o644)
+}
+
+// sign computes the presigned URL signature of a file ID and expiry
+func (p *LocalProvider) sign(objectKey string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(p.config.SigningKey))
+	mac.Write([]byte(objectKey + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *LocalProvider) objectURL(objectKey string) *url.URL {
+	u := *p.baseURL
+	u.Path = p.baseURL.Path + "/" + objectKey
+	u.RawPath = ""
+	return &u
+}
+
+// generatePublicURL creates the unsigned URL of a file
+func (p *LocalProvider) generatePublicURL(objectKey string) string {
+	return p.objectURL(objectKey).String()
+}
+
+// localObjectKey normalizes a file ID and rejects IDs escaping the root or pointing into reserved directories
+func localObjectKey(fileID string) (string, error) {
+	objectKey := strings.Trim(fileID, "/")
+	if objectKey == "" || !fs.ValidPath(objectKey) {
+		return "", ErrInvalidFileID
+	}
+
+	firstSegment, _, _ := strings.Cut(objectKey, "/")
+	if localReservedDir(firstSegment) {
+		return "", ErrInvalidFileID
+	}
+
+	return objectKey, nil
+}
+
+func localMetaPath(objectKey string) string {
+	return path.Join(localMetaDir, objectKey+localMetaSuffix)
+}
+
+func localReservedDir(name string) bool {
+	return name == localMetaDir || name == localTempDir
+}
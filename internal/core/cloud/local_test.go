@@ -0,0 +1,200 @@
+package cloud
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const localTestSigningKey = "test-signing-key"
+
+func newTestLocalProvider(t *testing.T, baseURL string) *LocalProvider {
+	t.Helper()
+
+	provider, err := NewLocalProvider(LocalConfig{
+		RootPath:   t.TempDir(),
+		BaseURL:    baseURL,
+		SigningKey: localTestSigningKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { provider.root.Close() })
+
+	return provider
+}
+
+func TestLocalSign(t *testing.T) {
+	provider := newTestLocalProvider(t, "https://files.example.com/files")
+
+	// printf 'receipts/чек 1.jpg\n1700000000' | openssl dgst -sha256 -hmac test-signing-key
+	want := "01b145e3322853e453f3ad6375bfbfbcfd8e0d95c4e61b7c87f578c3c84ef7d0"
+	if got := provider.sign("receipts/чек 1.jpg", 1700000000); got != want {
+		t.Errorf("sign() = %s, want %s", got, want)
+	}
+}
+
+func TestLocalVerifySignature(t *testing.T) {
+	provider := newTestLocalProvider(t, "https://files.example.com/files")
+
+	const (
+		fileID  = "receipts/чек 1.jpg"
+		expires = int64(1700000000)
+	)
+	signature := provider.sign(fileID, expires)
+	beforeExpiry := time.Unix(expires-60, 0)
+
+	tests := []struct {
+		name      string
+		fileID    string
+		expires   int64
+		signature string
+		now       time.Time
+		want      bool
+	}{
+		{"valid", fileID, expires, signature, beforeExpiry, true},
+		{"valid at expiry", fileID, expires, signature, time.Unix(expires, 0), true},
+		{"upper case hex", fileID, expires, strings.ToUpper(signature), beforeExpiry, true},
+		{"expired", fileID, expires, signature, time.Unix(expires+1, 0), false},
+		{"other file", "receipts/чек 2.jpg", expires, signature, beforeExpiry, false},
+		{"extended expiry", fileID, expires + 3600, signature, beforeExpiry, false},
+		{"tampered signature", fileID, expires, "00" + signature[2:], beforeExpiry, false},
+		{"truncated signature", fileID, expires, signature[:32], beforeExpiry, false},
+		{"invalid hex", fileID, expires, "zz" + signature[2:], beforeExpiry, false},
+		{"empty signature", fileID, expires, "", beforeExpiry, false},
+		{"no expiry", fileID, 0, provider.sign(fileID, 0), time.Unix(0, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := provider.VerifySignature(tt.fileID, tt.expires, tt.signature, tt.now); got != tt.want {
+				t.Errorf("VerifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	otherKey := newTestLocalProvider(t, "https://files.example.com/files")
+	otherKey.config.SigningKey = "other-key"
+	if otherKey.VerifySignature(fileID, expires, signature, beforeExpiry) {
+		t.Error("VerifySignature() accepted a signature of another key")
+	}
+}
+
+func TestLocalPresignedURL(t *testing.T) {
+	provider := newTestLocalProvider(t, "https://files.example.com/files/")
+
+	upload, err := provider.UploadFile(context.Background(), &UploadRequest{
+		FolderPath:    "receipts",
+		FileName:      "чек 1.jpg",
+		ContentType:   "image/jpeg",
+		Content:       strings.NewReader("receipt"),
+		ContentLength: int64(len("receipt")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	presignedURL, err := provider.GetPresignedURL(context.Background(), upload.FileID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(presignedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileID, ok := provider.FileIDFromURL(presignedURL)
+	if !ok || fileID != upload.FileID {
+		t.Fatalf("FileIDFromURL(%q) = %q, %v, want %q", presignedURL, fileID, ok, upload.FileID)
+	}
+	if !strings.HasPrefix(u.Path, provider.BasePath()+"/") {
+		t.Errorf("presigned URL path %q is not below %q", u.Path, provider.BasePath())
+	}
+
+	expires, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := u.Query().Get("signature")
+	if !provider.VerifySignature(fileID, expires, signature, time.Now()) {
+		t.Errorf("VerifySignature() rejected presigned URL %s", presignedURL)
+	}
+	if provider.VerifySignature(fileID, expires, signature, time.Unix(expires+1, 0)) {
+		t.Errorf("VerifySignature() accepted expired URL %s", presignedURL)
+	}
+}
+
+func TestLocalFileIDFromURL(t *testing.T) {
+	provider := newTestLocalProvider(t, "https://files.example.com/files")
+
+	tests := []struct {
+		name    string
+		fileURL string
+		want    string
+		wantOK  bool
+	}{
+		{"plain", "https://files.example.com/files/receipts/a.jpg", "receipts/a.jpg", true},
+		{"escaped", "https://files.example.com/files/receipts/%D1%87%D0%B5%D0%BA%201.jpg", "receipts/чек 1.jpg", true},
+		{"signed", "https://files.example.com/files/receipts/a.jpg?expires=1&signature=00", "receipts/a.jpg", true},
+		{"other host", "https://example.com/files/receipts/a.jpg", "", false},
+		{"other path", "https://files.example.com/filesystem/a.jpg", "", false},
+		{"base URL", "https://files.example.com/files/", "", false},
+		{"invalid escape", "https://files.example.com/files/a%zz.jpg", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := provider.FileIDFromURL(tt.fileURL)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("FileIDFromURL(%q) = %q, %v, want %q, %v", tt.fileURL, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLocalBasePath(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"https://files.example.com", "/"},
+		{"https://files.example.com/", "/"},
+		{"https://files.example.com/files", "/files"},
+		{"https://files.example.com/files/", "/files"},
+	}
+
+	for _, tt := range tests {
+		if got := newTestLocalProvider(t, tt.baseURL).BasePath(); got != tt.want {
+			t.Errorf("BasePath() of %q = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestLocalObjectKey(t *testing.T) {
+	tests := []struct {
+		fileID  string
+		want    string
+		wantErr bool
+	}{
+		{"receipts/a.jpg", "receipts/a.jpg", false},
+		{"/receipts/a.jpg/", "receipts/a.jpg", false},
+		{"receipts/чек 1.jpg", "receipts/чек 1.jpg", false},
+		{"", "", true},
+		{"/", "", true},
+		{"../secret", "", true},
+		{"receipts/../../secret", "", true},
+		{"receipts//a.jpg", "", true},
+		{".meta/receipts/a.jpg.json", "", true},
+		{".tmp/upload", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := localObjectKey(tt.fileID)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("localObjectKey(%q) = %q, %v, want %q, error %v", tt.fileID, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,744 @@
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+)
+
+const (
+	s3DefaultRegion  = "us-east-1"
+	s3RequestTimeout = 60 * time.Second
+
+	// s3MaxPresignExpiration is the longest validity S3 accepts for presigned URLs
+	s3MaxPresignExpiration = 7 * 24 * time.Hour
+)
+
+// S3Provider implements the Provider interface for AWS S3 and S3-compatible storages such as MinIO
+type S3Provider struct {
+	client        *s3.Client
+	presignClient *s3.PresignClient
+	endpoint      *url.URL
+	bucketName    string
+	config        AWSConfig
+}
+
+// NewS3Provider creates a new S3 provider and creates the bucket when it doesn't exist
+func NewS3Provider(config AWSConfig) (*S3Provider, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	provider, err := newS3Provider(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure bucket exists
+	if err := provider.ensureBucket(ctx); err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+// newS3Provider creates the S3 client without touching the bucket. Static keys are used when
+// configured, otherwise the default AWS credential chain (environment, shared config, IAM role).
+func newS3Provider(ctx context.Context, config AWSConfig) (*S3Provider, error) {
+	if err := ValidateAWSConfig(config); err != nil {
+		return nil, err
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	endpointURL, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || endpointURL.Host == "" {
+		return nil, &CloudError{
+			Code:    "INVALID_AWS_ENDPOINT",
+			Message: fmt.Sprintf("invalid S3 endpoint: %s", config.Endpoint),
+			Cause:   err,
+		}
+	}
+
+	loadOptions := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(config.Region),
+		awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(s3RequestTimeout)),
+	}
+	if config.AccessKeyID != "" {
+		loadOptions = append(loadOptions, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(config.AccessKeyID, config.SecretAccessKey, config.SessionToken)))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "AWS_CREDENTIAL_ERROR",
+			Message: "failed to load AWS configuration",
+			Cause:   err,
+		}
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(endpointURL.String())
+			// S3-compatible storages don't all support the default flexible checksums
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+		o.UsePathStyle = config.ForcePathStyle
+	})
+
+	return &S3Provider{
+		client:        client,
+		presignClient: s3.NewPresignClient(client),
+		endpoint:      endpointURL,
+		bucketName:    config.BucketName,
+		config:        config,
+	}, nil
+}
+
+// UploadFile uploads a file to S3
+func (p *S3Provider) UploadFile(ctx context.Context, req *UploadRequest) (*UploadResponse, error) {
+	if req == nil {
+		return nil, &CloudError{
+			Code:    "INVALID_REQUEST",
+			Message: "upload request cannot be nil",
+		}
+	}
+
+	objectKey := generateObjectKey(req)
+
+	// The SDK needs a seekable body to sign the payload, uploads are small enough to be buffered
+	content, err := io.ReadAll(req.Content)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "READ_FAILED",
+			Message: "failed to read upload content",
+			Cause:   err,
+		}
+	}
+
+	metadata := make(map[string]string, len(req.Metadata)+2)
+	if req.FileName != "" {
+		metadata["filename"] = s3MetadataValue(req.FileName)
+	}
+	if req.FolderPath != "" {
+		metadata["folder_path"] = s3MetadataValue(req.FolderPath)
+	}
+	for k, v := range req.Metadata {
+		metadata[strings.ToLower(k)] = s3MetadataValue(v)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:   aws.String(p.bucketName),
+		Key:      aws.String(objectKey),
+		Body:     bytes.NewReader(content),
+		Metadata: metadata,
+	}
+	if req.ContentType != "" {
+		input.ContentType = aws.String(req.ContentType)
+	}
+	if len(req.Tags) > 0 {
+		tags := url.Values{}
+		for k, v := range req.Tags {
+			tags.Set(k, v)
+		}
+		input.Tagging = aws.String(tags.Encode())
+	}
+
+	output, err := p.client.PutObject(ctx, input)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "UPLOAD_FAILED",
+			Message: "failed to upload file to S3",
+			Cause:   err,
+		}
+	}
+
+	return &UploadResponse{
+		FileID:      objectKey,
+		PublicURL:   p.generatePublicURL(objectKey),
+		Size:        int64(len(content)),
+		ContentType: req.ContentType,
+		ETag:        strings.Trim(aws.ToString(output.ETag), `"`),
+		UploadedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// GetFileURL generates a public URL for accessing a file
+func (p *S3Provider) GetFileURL(ctx context.Context, fileID string) (string, error) {
+	if fileID == "" {
+		return "", ErrInvalidFileID
+	}
+
+	return p.generatePublicURL(fileID), nil
+}
+
+// GetPresignedURL generates a temporary presigned URL for file access
+func (p *S3Provider) GetPresignedURL(ctx context.Context, fileID string, expiration time.Duration) (string, error) {
+	if fileID == "" {
+		return "", ErrInvalidFileID
+	}
+
+	// Check if file exists
+	if _, err := p.headObject(ctx, fileID); err != nil {
+		return "", &CloudError{
+			Code:    "FILE_NOT_FOUND",
+			Message: "file not found in S3",
+			Cause:   err,
+		}
+	}
+
+	return p.presignGetURL(ctx, fileID, expiration)
+}
+
+// presignGetURL signs a GET URL of the object, capped at the longest validity S3 accepts
+func (p *S3Provider) presignGetURL(ctx context.Context, fileID string, expiration time.Duration) (string, error) {
+	if expiration <= 0 || expiration > s3MaxPresignExpiration {
+		expiration = s3MaxPresignExpiration
+	}
+
+	request, err := p.presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(fileID),
+	}, s3.WithPresignExpires(expiration))
+	if err != nil {
+		return "", &CloudError{
+			Code:    "PRESIGN_FAILED",
+			Message: "failed to presign S3 URL",
+			Cause:   err,
+		}
+	}
+
+	return request.URL, nil
+}
+
+// DeleteFile removes a file from S3
+func (p *S3Provider) DeleteFile(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return ErrInvalidFileID
+	}
+
+	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(fileID),
+	})
+	if err != nil {
+		return &CloudError{
+			Code:    "DELETE_FAILED",
+			Message: "failed to delete file from S3",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFiles lists files with optional prefix filtering
+func (p *S3Provider) ListFiles(ctx context.Context, req *ListFilesRequest) (*ListFilesResponse, error) {
+	if req == nil {
+		req = &ListFilesRequest{}
+	}
+
+	// Set prefix based on folder path or explicit prefix
+	var prefix string
+	if req.FolderPath != "" {
+		prefix = strings.Trim(req.FolderPath, "/") + "/"
+	}
+	prefix += req.Prefix
+
+	delimiter := ""
+	if req.FolderPath != "" && !req.Recursive {
+		delimiter = "/"
+	}
+
+	result, err := p.listObjects(ctx, prefix, delimiter, req.ContinuationToken, req.MaxResults)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "LIST_FAILED",
+			Message: "failed to list files from S3",
+			Cause:   err,
+		}
+	}
+
+	response := &ListFilesResponse{
+		Files:                 []*FileInfo{},
+		NextContinuationToken: aws.ToString(result.NextContinuationToken),
+		IsTruncated:           aws.ToBool(result.IsTruncated),
+	}
+
+	for _, object := range result.Contents {
+		objectKey := aws.ToString(object.Key)
+		folderPath, fileName := parseObjectPath(objectKey)
+		response.Files = append(response.Files, &FileInfo{
+			FileID:       objectKey,
+			FolderPath:   folderPath,
+			FileName:     fileName,
+			RelativePath: objectKey,
+			Size:         aws.ToInt64(object.Size),
+			ETag:         strings.Trim(aws.ToString(object.ETag), `"`),
+			LastModified: aws.ToTime(object.LastModified),
+			PublicURL:    p.generatePublicURL(objectKey),
+			Metadata:     make(map[string]string),
+			Tags:         make(map[string]string),
+		})
+	}
+
+	return response, nil
+}
+
+// GetFileInfo retrieves metadata about a file
+func (p *S3Provider) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error) {
+	if fileID == "" {
+		return nil, ErrInvalidFileID
+	}
+
+	head, err := p.headObject(ctx, fileID)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "FILE_NOT_FOUND",
+			Message: "file not found in S3",
+			Cause:   err,
+		}
+	}
+
+	folderPath, fileName := parseObjectPath(fileID)
+
+	fileInfo := &FileInfo{
+		FileID:       fileID,
+		FolderPath:   folderPath,
+		FileName:     fileName,
+		RelativePath: fileID,
+		Size:         aws.ToInt64(head.ContentLength),
+		ContentType:  aws.ToString(head.ContentType),
+		ETag:         strings.Trim(aws.ToString(head.ETag), `"`),
+		LastModified: aws.ToTime(head.LastModified),
+		PublicURL:    p.generatePublicURL(fileID),
+		Metadata:     make(map[string]string),
+		Tags:         make(map[string]string),
+	}
+
+	// Convert metadata
+	for name, value := range head.Metadata {
+		key := strings.ToLower(name)
+		value = s3DecodeMetadataValue(value)
+		if key == "filename" && fileInfo.FileName == "" {
+			fileInfo.FileName = value
+		}
+		fileInfo.Metadata[key] = value
+	}
+
+	// Get object tags
+	if tags, err := p.getObjectTags(ctx, fileID); err == nil {
+		fileInfo.Tags = tags
+	}
+
+	return fileInfo, nil
+}
+
+// DownloadFile downloads file content directly from S3
+func (p *S3Provider) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	if fileID == "" {
+		return nil, ErrInvalidFileID
+	}
+
+	output, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(fileID),
+	})
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "DOWNLOAD_FAILED",
+			Message: "failed to download file from S3",
+			Cause:   err,
+		}
+	}
+	defer output.Body.Close()
+
+	data, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, &CloudError{
+			Code:    "READ_FAILED",
+			Message: "failed to read file content",
+			Cause:   err,
+		}
+	}
+
+	return data, nil
+}
+
+// CreateFolder creates a virtual folder by creating a marker object
+func (p *S3Provider) CreateFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	// Normalize folder path
+	markerKey := strings.Trim(folderPath, "/") + "/.folder_marker"
+
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(markerKey),
+		Body:   bytes.NewReader(nil),
+		Metadata: map[string]string{
+			"folder_marker": "true",
+			"created_at":    time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return &CloudError{
+			Code:    "FOLDER_CREATE_FAILED",
+			Message: "failed to create folder in S3",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// ListFolders lists folders within a path
+func (p *S3Provider) ListFolders(ctx context.Context, parentPath string) ([]*FolderInfo, error) {
+	// Normalize parent path
+	if parentPath != "" {
+		parentPath = strings.Trim(parentPath, "/") + "/"
+	}
+
+	folderMap := make(map[string]*FolderInfo)
+
+	continuationToken := ""
+	for {
+		result, err := p.listObjects(ctx, parentPath, "", continuationToken, 0)
+		if err != nil {
+			return nil, &CloudError{
+				Code:    "LIST_FOLDERS_FAILED",
+				Message: "failed to list folders from S3",
+				Cause:   err,
+			}
+		}
+
+		for _, object := range result.Contents {
+			lastModified := aws.ToTime(object.LastModified)
+			relativePath := strings.TrimPrefix(aws.ToString(object.Key), parentPath)
+
+			// Find the immediate folder
+			parts := strings.Split(relativePath, "/")
+			if len(parts) < 2 {
+				continue
+			}
+
+			folderName := parts[0]
+			fullFolderPath := parentPath + folderName
+
+			folderInfo, exists := folderMap[fullFolderPath]
+			if !exists {
+				folderInfo = &FolderInfo{
+					FolderPath:   fullFolderPath,
+					FolderName:   folderName,
+					ParentPath:   strings.TrimSuffix(parentPath, "/"),
+					CreatedAt:    lastModified,
+					LastModified: lastModified,
+					Metadata:     make(map[string]string),
+				}
+				folderMap[fullFolderPath] = folderInfo
+			}
+
+			folderInfo.FileCount++
+			folderInfo.TotalSize += aws.ToInt64(object.Size)
+			if lastModified.After(folderInfo.LastModified) {
+				folderInfo.LastModified = lastModified
+			}
+			if lastModified.Before(folderInfo.CreatedAt) {
+				folderInfo.CreatedAt = lastModified
+			}
+		}
+
+		if !aws.ToBool(result.IsTruncated) || aws.ToString(result.NextContinuationToken) == "" {
+			break
+		}
+		continuationToken = aws.ToString(result.NextContinuationToken)
+	}
+
+	// Convert map to slice and sort
+	folders := make([]*FolderInfo, 0, len(folderMap))
+	for _, folder := range folderMap {
+		folders = append(folders, folder)
+	}
+
+	// Sort folders by name
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].FolderName < folders[j].FolderName
+	})
+
+	return folders, nil
+}
+
+// DeleteFolder deletes a folder and all its contents
+func (p *S3Provider) DeleteFolder(ctx context.Context, folderPath string) error {
+	if folderPath == "" {
+		return &CloudError{
+			Code:    "INVALID_FOLDER_PATH",
+			Message: "folder path cannot be empty",
+		}
+	}
+
+	// Normalize folder path
+	folderPath = strings.Trim(folderPath, "/") + "/"
+
+	var deletionErrors []string
+
+	continuationToken := ""
+	for {
+		result, err := p.listObjects(ctx, folderPath, "", continuationToken, 0)
+		if err != nil {
+			return &CloudError{
+				Code:    "DELETE_FOLDER_FAILED",
+				Message: "failed to list folder contents for deletion",
+				Cause:   err,
+			}
+		}
+
+		// Delete each object in the folder
+		for _, object := range result.Contents {
+			objectKey := aws.ToString(object.Key)
+			if err := p.DeleteFile(ctx, objectKey); err != nil {
+				deletionErrors = append(deletionErrors, fmt.Sprintf("failed to delete %s: %v", objectKey, err))
+			}
+		}
+
+		if !aws.ToBool(result.IsTruncated) || aws.ToString(result.NextContinuationToken) == "" {
+			break
+		}
+		continuationToken = aws.ToString(result.NextContinuationToken)
+	}
+
+	if len(deletionErrors) > 0 {
+		return &CloudError{
+			Code:    "DELETE_FOLDER_PARTIAL_FAILURE",
+			Message: "some files in folder could not be deleted: " + strings.Join(deletionErrors, "; "),
+		}
+	}
+
+	return nil
+}
+
+// listObjects runs a single ListObjectsV2 request
+func (p *S3Provider) listObjects(ctx context.Context, prefix, delimiter, continuationToken string, maxKeys int) (*s3.ListObjectsV2Output, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(p.bucketName),
+	}
+	if prefix != "" {
+		input.Prefix = aws.String(prefix)
+	}
+	if delimiter != "" {
+		input.Delimiter = aws.String(delimiter)
+	}
+	if continuationToken != "" {
+		input.ContinuationToken = aws.String(continuationToken)
+	}
+	if maxKeys > 0 {
+		input.MaxKeys = aws.Int32(int32(maxKeys))
+	}
+
+	return p.client.ListObjectsV2(ctx, input)
+}
+
+func (p *S3Provider) headObject(ctx context.Context, objectKey string) (*s3.HeadObjectOutput, error) {
+	return p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(objectKey),
+	})
+}
+
+func (p *S3Provider) getObjectTags(ctx context.Context, objectKey string) (map[string]string, error) {
+	output, err := p.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// ensureBucket creates the bucket when it doesn't exist yet
+func (p *S3Provider) ensureBucket(ctx context.Context) error {
+	_, err := p.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(p.bucketName)})
+	if err == nil {
+		return nil
+	}
+
+	var notFound *types.NotFound
+	if !errors.As(err, &notFound) {
+		return &CloudError{
+			Code:    "BUCKET_CHECK_FAILED",
+			Message: "failed to check S3 bucket",
+			Cause:   err,
+		}
+	}
+
+	input := &s3.CreateBucketInput{Bucket: aws.String(p.bucketName)}
+	// us-east-1 is the default location and must not be sent as a constraint
+	if p.config.Region != s3DefaultRegion {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(p.config.Region),
+		}
+	}
+
+	if _, err := p.client.CreateBucket(ctx, input); err != nil {
+		// Bucket might have been created concurrently, which is fine
+		var alreadyOwned *types.BucketAlreadyOwnedByYou
+		if errors.As(err, &alreadyOwned) {
+			return nil
+		}
+		return &CloudError{
+			Code:    "BUCKET_CREATE_FAILED",
+			Message: "failed to create bucket in S3",
+			Cause:   err,
+		}
+	}
+
+	return nil
+}
+
+// objectURL builds the URL of an object, using path-style addressing (endpoint/bucket/key)
+// when configured, as required by MinIO
+func (p *S3Provider) objectURL(objectKey string) string {
+	u := *p.endpoint
+
+	basePath := strings.TrimSuffix(u.Path, "/")
+	rawBasePath := strings.TrimSuffix(u.EscapedPath(), "/")
+	if p.config.ForcePathStyle {
+		basePath += "/" + p.bucketName
+		rawBasePath += "/" + p.bucketName
+	} else {
+		u.Host = p.bucketName + "." + u.Host
+	}
+
+	u.Path = basePath + "/" + objectKey
+	u.RawPath = rawBasePath + "/" + escapeObjectKey(objectKey)
+
+	return u.String()
+}
+
+// generatePublicURL creates a public URL for the object
+func (p *S3Provider) generatePublicURL(objectKey string) string {
+	if p.config.BaseURL != "" {
+		// FileIDFromURL unescapes the key, so it has to be escaped here
+		return strings.TrimSuffix(p.config.BaseURL, "/") + "/" + escapeObjectKey(objectKey)
+	}
+
+	return p.objectURL(objectKey)
+}
+
+// FileIDFromURL returns the object key of a public URL: one under the configured base URL, or a
+// path-style (endpoint/bucket/key) or virtual-hosted (bucket.endpoint/key) URL of the bucket
+func (p *S3Provider) FileIDFromURL(fileURL string) (string, bool) {
+	if p.config.BaseURL != "" {
+		prefix := strings.TrimSuffix(p.config.BaseURL, "/") + "/"
+		if rest, ok := strings.CutPrefix(fileURL, prefix); ok {
+			objectKey, err := url.PathUnescape(rest)
+			if err != nil || objectKey == "" {
+				return "", false
+			}
+			return objectKey, true
+		}
+	}
+
+	u, err := url.Parse(fileURL)
+	if err != nil {
+		return "", false
+	}
+
+	basePath := strings.TrimSuffix(p.endpoint.Path, "/") + "/"
+	var objectKey string
+	var found bool
+	switch {
+	case strings.EqualFold(u.Host, p.bucketName+"."+p.endpoint.Host):
+		objectKey, found = strings.CutPrefix(u.Path, basePath)
+	case strings.EqualFold(u.Host, p.endpoint.Host):
+		objectKey, found = strings.CutPrefix(u.Path, basePath+p.bucketName+"/")
+	}
+
+	if !found || objectKey == "" {
+		return "", false
+	}
+	return objectKey, true
+}
+
+// generateObjectKey builds the object key of an upload, using a random name with the
+// original extension when no FileID is given
+func generateObjectKey(req *UploadRequest) string {
+	fileID := req.FileID
+	if fileID == "" {
+		fileID = uuid.New().String()
+		if parts := strings.Split(req.FileName, "."); len(parts) > 1 {
+			fileID = fileID + "." + parts[len(parts)-1]
+		}
+	}
+
+	if req.FolderPath == "" {
+		return fileID
+	}
+
+	return strings.Trim(req.FolderPath, "/") + "/" + fileID
+}
+
+// escapeObjectKey percent-encodes each segment of an object key, keeping the slashes
+func escapeObjectKey(objectKey string) string {
+	segments := strings.Split(objectKey, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// parseObjectPath splits an object key into folder path and filename
+func parseObjectPath(objectKey string) (folderPath, fileName string) {
+	lastSlash := strings.LastIndex(objectKey, "/")
+	if lastSlash < 0 {
+		return "", objectKey
+	}
+
+	return objectKey[:lastSlash], objectKey[lastSlash+1:]
+}
+
+// s3MetadataValue RFC 2047 encodes non-ASCII metadata values, S3 only accepts US-ASCII in headers
+func s3MetadataValue(value string) string {
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+func s3DecodeMetadataValue(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
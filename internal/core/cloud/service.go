@@ -32,6 +32,29 @@ func NewService(cfg config.CloudConfig, logger *slog.Logger) (*Service, error) {
 			BaseURL:            cfg.Azure.BaseURL,
 			UseHTTPS:           cfg.Azure.UseHTTPS,
 		},
+		AWS: AWSConfig{
+			AccessKeyID:     cfg.AWS.AccessKeyID,
+			SecretAccessKey: cfg.AWS.SecretAccessKey,
+			SessionToken:    cfg.AWS.SessionToken,
+			Region:          cfg.AWS.Region,
+			BucketName:      cfg.AWS.BucketName,
+			Endpoint:        cfg.AWS.Endpoint,
+			ForcePathStyle:  cfg.AWS.ForcePathStyle,
+			BaseURL:         cfg.AWS.BaseURL,
+		},
+		GCP: GCPConfig{
+			ProjectID:       cfg.GCP.ProjectID,
+			BucketName:      cfg.GCP.BucketName,
+			CredentialsJSON: cfg.GCP.CredentialsJSON,
+			CredentialsFile: cfg.GCP.CredentialsFile,
+			Endpoint:        cfg.GCP.Endpoint,
+			BaseURL:         cfg.GCP.BaseURL,
+		},
+		Local: LocalConfig{
+			RootPath:   cfg.Local.RootPath,
+			BaseURL:    cfg.Local.BaseURL,
+			SigningKey: cfg.Local.SigningKey,
+		},
 	}
 
 	// Validate configuration
@@ -520,6 +543,16 @@ func (s *Service) DownloadFile(ctx context.Context, fileID string) ([]byte, erro
 	return data, nil
 }
 
+// FileIDFromURL resolves the file ID of a public URL when the provider supports it
+func (s *Service) FileIDFromURL(fileURL string) (string, bool) {
+	resolver, ok := s.provider.(FileURLResolver)
+	if !ok {
+		return "", false
+	}
+
+	return resolver.FileIDFromURL(fileURL)
+}
+
 // FileUploadResult contains the result of a file upload operation
 type FileUploadResult struct {
 	FileID      string
@@ -40,6 +40,13 @@ type Provider interface {
 	DeleteFolder(ctx context.Context, folderPath string) error
 }
 
+// FileURLResolver is implemented by providers whose public URLs can't be mapped back to
+// file IDs by dropping the container segment of the path
+type FileURLResolver interface {
+	// FileIDFromURL returns the file ID of a public URL generated by the provider
+	FileIDFromURL(fileURL string) (string, bool)
+}
+
 // UploadRequest contains parameters for file upload
 type UploadRequest struct {
 	// FileID is the unique identifier for the file (can be auto-generated if empty)
@@ -186,17 +193,20 @@ type FolderInfo struct {
 
 // Config contains cloud provider configuration
 type Config struct {
-	// Provider specifies which cloud provider to use (azure, aws, gcp)
+	// Provider specifies which cloud provider to use (azure, aws, gcp, local)
 	Provider string
 
 	// Azure Blob Storage configuration
 	Azure AzureConfig
 
-	// AWS S3 configuration (for future use)
+	// AWS S3 configuration
 	AWS AWSConfig
 
-	// GCP Cloud Storage configuration (for future use)
+	// GCP Cloud Storage configuration
 	GCP GCPConfig
+
+	// Local filesystem configuration
+	Local LocalConfig
 }
 
 // AzureConfig contains Azure Blob Storage specific configuration
@@ -220,7 +230,7 @@ type AzureConfig struct {
 	UseHTTPS bool
 }
 
-// AWSConfig contains AWS S3 specific configuration (for future use)
+// AWSConfig contains AWS S3 and S3-compatible storage configuration
 type AWSConfig struct {
 	// AccessKeyID is the AWS access key ID
 	AccessKeyID string
@@ -239,9 +249,15 @@ type AWSConfig struct {
 
 	// Endpoint is the S3 endpoint URL (for S3-compatible services)
 	Endpoint string
+
+	// ForcePathStyle addresses objects as endpoint/bucket/key instead of bucket.endpoint/key (required by MinIO)
+	ForcePathStyle bool
+
+	// BaseURL is the base URL for public object access (optional, the bucket URL if empty)
+	BaseURL string
 }
 
-// GCPConfig contains Google Cloud Storage specific configuration (for future use)
+// GCPConfig contains Google Cloud Storage specific configuration
 type GCPConfig struct {
 	// ProjectID is the GCP project ID
 	ProjectID string
@@ -252,8 +268,27 @@ type GCPConfig struct {
 	// CredentialsJSON is the service account credentials JSON
 	CredentialsJSON string
 
-	// CredentialsFile is the path to the service account credentials file
+	// CredentialsFile is the path to the service account credentials file (optional, with neither
+	// Application Default Credentials are used)
 	CredentialsFile string
+
+	// Endpoint is the Cloud Storage endpoint URL (optional, for emulators)
+	Endpoint string
+
+	// BaseURL is the base URL for public object access (optional, the bucket URL if empty)
+	BaseURL string
+}
+
+// LocalConfig contains local filesystem storage configuration
+type LocalConfig struct {
+	// RootPath is the directory files are stored in
+	RootPath string
+
+	// BaseURL is the URL the HTTP server serves files under (e.g., "http://localhost:3001/files")
+	BaseURL string
+
+	// SigningKey is the secret used to sign presigned URLs
+	SigningKey string
 }
 
 // Error types for cloud operations
@@ -0,0 +1,516 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("notifications-service")
+
+// Delivery modes of family activity notifications
+const (
+	DeliveryInstant = "instant" // shortly after the activity
+	DeliveryBatched = "batched" // a digest every BatchIntervalMinutes
+	DeliveryOff     = "off"
+)
+
+// Family activity sent in digests
+const (
+	ActivityItemsAdded    = "items_added"
+	ActivityListCompleted = "list_completed"
+)
+
+const (
+	DefaultBatchIntervalMinutes = 30
+	MinBatchIntervalMinutes     = 5
+	MaxBatchIntervalMinutes     = 1440
+
+	// instantDelay collects the activity of a moment, e.g. several items added from one message,
+	// into one notification
+	instantDelay = time.Minute
+
+	// maxDigestItemNames is how many item names a digest entry lists
+	maxDigestItemNames = 5
+)
+
+var (
+	ErrInvalidDelivery      = errors.New("invalid notification delivery")
+	ErrInvalidQuietHours    = errors.New("invalid quiet hours")
+	ErrInvalidTimezone      = errors.New("unknown timezone")
+	ErrInvalidBatchInterval = fmt.Errorf("batch interval must be between %d and %d minutes", MinBatchIntervalMinutes, MaxBatchIntervalMinutes)
+)
+
+// Preferences are how a user hears about the activity in the lists of their families
+type Preferences struct {
+	UserID               uuid.UUID   `json:"user_id"`
+	Delivery             string      `json:"delivery"`
+	BatchIntervalMinutes int         `json:"batch_interval_minutes"`
+	QuietStartMinute     *int        `json:"quiet_start_minute"` // minute of the day, nil without quiet hours
+	QuietEndMinute       *int        `json:"quiet_end_minute"`
+	Timezone             string      `json:"timezone"`
+	MutedLists           []MutedList `json:"muted_lists"`
+}
+
+// MutedList is a list the user gets no notifications for
+type MutedList struct {
+	ListID   uuid.UUID `json:"list_id"`
+	ListName string    `json:"list_name"`
+}
+
+// HasQuietHours reports whether the user set quiet hours
+func (p *Preferences) HasQuietHours() bool {
+	return p.QuietStartMinute != nil && p.QuietEndMinute != nil
+}
+
+// QuietStart is the start of the quiet hours as HH:MM
+func (p *Preferences) QuietStart() string {
+	return formatMinute(p.QuietStartMinute)
+}
+
+// QuietEnd is the end of the quiet hours as HH:MM
+func (p *Preferences) QuietEnd() string {
+	return formatMinute(p.QuietEndMinute)
+}
+
+// InQuietHours reports whether no notifications are sent to the user at the time. Quiet hours
+// may span midnight, e.g. 22:00-07:00.
+func (p *Preferences) InQuietHours(now time.Time) bool {
+	if !p.HasQuietHours() {
+		return false
+	}
+
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+
+	start, end := *p.QuietStartMinute, *p.QuietEndMinute
+	if start <= end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// DigestDue reports whether activity queued since oldest is sent to the user at now: shortly
+// after it for instant delivery, once it is BatchIntervalMinutes old for batched digests and
+// never during quiet hours or with notifications turned off
+func (p *Preferences) DigestDue(oldest, now time.Time) bool {
+	delay := instantDelay
+	switch p.Delivery {
+	case DeliveryOff:
+		return false
+	case DeliveryBatched:
+		delay = time.Duration(p.BatchIntervalMinutes) * time.Minute
+	}
+
+	return now.Sub(oldest) >= delay && !p.InQuietHours(now)
+}
+
+// Activity is a change of a family list queued for the family members
+type Activity struct {
+	EventID  uuid.UUID
+	ListID   uuid.UUID
+	ActorID  uuid.UUID
+	Activity string
+	ItemName *string // the added item
+}
+
+// DigestEntry summarizes what one member did in one list since the last digest
+type DigestEntry struct {
+	ListID     uuid.UUID
+	ListName   string
+	FamilyName string
+	ActorName  string
+	Activity   string
+	ItemCount  int
+	ItemNames  []string // the first maxDigestItemNames added items
+	MoreItems  int      // added items not in ItemNames
+}
+
+// ItemsText lists the added item names
+func (e *DigestEntry) ItemsText() string {
+	return strings.Join(e.ItemNames, ", ")
+}
+
+// Digest is the activity to send to a family member in one message
+type Digest struct {
+	RecipientID uuid.UUID
+	TelegramID  int64
+	Locale      string
+	Entries     []*DigestEntry
+}
+
+type Service struct {
+	db *pgxpool.Pool
+}
+
+func NewService(db *pgxpool.Pool) *Service {
+	return &Service{db: db}
+}
+
+// GetPreferences returns the notification preferences of a user, the defaults when none were set
+func (s *Service) GetPreferences(ctx context.Context, userID uuid.UUID) (*Preferences, error) {
+	ctx, span := tracer.Start(ctx, "notifications.GetPreferences")
+	defer span.End()
+
+	prefs := &Preferences{
+		UserID:               userID,
+		Delivery:             DeliveryInstant,
+		BatchIntervalMinutes: DefaultBatchIntervalMinutes,
+		Timezone:             "UTC",
+		MutedLists:           []MutedList{},
+	}
+
+	err := s.db.QueryRow(ctx, `
+		SELECT delivery, batch_interval_minutes, quiet_start_minute, quiet_end_minute, timezone
+		FROM notification_preferences
+		WHERE user_id = $1
+	`, userID).Scan(&prefs.Delivery, &prefs.BatchIntervalMinutes, &prefs.QuietStartMinute,
+		&prefs.QuietEndMinute, &prefs.Timezone)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT m.list_id, l.name
+		FROM notification_muted_lists m
+		JOIN shopping_lists l ON l.id = m.list_id
+		WHERE m.user_id = $1 AND l.is_archived = false
+		ORDER BY l.name
+	`, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get muted lists: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var muted MutedList
+		if err := rows.Scan(&muted.ListID, &muted.ListName); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan muted list: %w", err)
+		}
+		prefs.MutedLists = append(prefs.MutedLists, muted)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate muted lists: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// SetDelivery sets how the user gets notifications, intervalMinutes is used for batched digests.
+// Turning notifications off drops the activity not sent yet.
+func (s *Service) SetDelivery(ctx context.Context, userID uuid.UUID, delivery string, intervalMinutes int) error {
+	ctx, span := tracer.Start(ctx, "notifications.SetDelivery")
+	defer span.End()
+
+	switch delivery {
+	case DeliveryInstant, DeliveryOff:
+	case DeliveryBatched:
+		if intervalMinutes < MinBatchIntervalMinutes || intervalMinutes > MaxBatchIntervalMinutes {
+			return ErrInvalidBatchInterval
+		}
+	default:
+		return ErrInvalidDelivery
+	}
+	if intervalMinutes == 0 {
+		intervalMinutes = DefaultBatchIntervalMinutes
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO notification_preferences (user_id, delivery, batch_interval_minutes)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET delivery = EXCLUDED.delivery,
+		    batch_interval_minutes = CASE WHEN EXCLUDED.delivery = 'batched'
+		        THEN EXCLUDED.batch_interval_minutes ELSE notification_preferences.batch_interval_minutes END,
+		    updated_at = NOW()
+	`, userID, delivery, intervalMinutes)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to save notification delivery: %w", err)
+	}
+
+	if delivery == DeliveryOff {
+		if _, err := s.db.Exec(ctx, `DELETE FROM family_activity_notifications WHERE recipient_id = $1`, userID); err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to drop pending notifications: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SetQuietHours sets the daily time range in the timezone during which no notifications are sent,
+// the activity of that time is sent once it ends. Nil start and end remove the quiet hours.
+func (s *Service) SetQuietHours(ctx context.Context, userID uuid.UUID, startMinute, endMinute *int, timezone string) error {
+	ctx, span := tracer.Start(ctx, "notifications.SetQuietHours")
+	defer span.End()
+
+	if (startMinute == nil) != (endMinute == nil) {
+		return ErrInvalidQuietHours
+	}
+	for _, minute := range []*int{startMinute, endMinute} {
+		if minute != nil && (*minute < 0 || *minute >= 24*60) {
+			return ErrInvalidQuietHours
+		}
+	}
+	if startMinute != nil && *startMinute == *endMinute {
+		return ErrInvalidQuietHours
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO notification_preferences (user_id, quiet_start_minute, quiet_end_minute, timezone)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET quiet_start_minute = EXCLUDED.quiet_start_minute,
+		    quiet_end_minute = EXCLUDED.quiet_end_minute,
+		    timezone = EXCLUDED.timezone,
+		    updated_at = NOW()
+	`, userID, startMinute, endMinute, timezone)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to save quiet hours: %w", err)
+	}
+
+	return nil
+}
+
+// MuteList stops notifications about a list for the user and drops its activity not sent yet
+func (s *Service) MuteList(ctx context.Context, userID, listID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "notifications.MuteList")
+	defer span.End()
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO notification_muted_lists (user_id, list_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, list_id) DO NOTHING
+	`, userID, listID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to mute list: %w", err)
+	}
+
+	_, err = s.db.Exec(ctx, `
+		DELETE FROM family_activity_notifications WHERE recipient_id = $1 AND list_id = $2
+	`, userID, listID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to drop pending notifications: %w", err)
+	}
+
+	return nil
+}
+
+// UnmuteList resumes notifications about a list for the user
+func (s *Service) UnmuteList(ctx context.Context, userID, listID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "notifications.UnmuteList")
+	defer span.End()
+
+	_, err := s.db.Exec(ctx, `
+		DELETE FROM notification_muted_lists WHERE user_id = $1 AND list_id = $2
+	`, userID, listID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to unmute list: %w", err)
+	}
+
+	return nil
+}
+
+// QueueActivity queues the activity for the recipients who did not mute the list or turn
+// notifications off. Activity already queued for an event is not queued again, so every replica
+// receiving the event may queue it.
+func (s *Service) QueueActivity(ctx context.Context, activity Activity, recipientIDs []uuid.UUID) (int, error) {
+	ctx, span := tracer.Start(ctx, "notifications.QueueActivity")
+	defer span.End()
+
+	if len(recipientIDs) == 0 {
+		return 0, nil
+	}
+
+	result, err := s.db.Exec(ctx, `
+		INSERT INTO family_activity_notifications (event_id, recipient_id, list_id, actor_id, activity, item_name)
+		SELECT $1, r.id, $3, $4, $5, $6
+		FROM unnest($2::uuid[]) AS r(id)
+		WHERE NOT EXISTS (SELECT 1 FROM notification_muted_lists m WHERE m.user_id = r.id AND m.list_id = $3)
+		  AND NOT EXISTS (SELECT 1 FROM notification_preferences p WHERE p.user_id = r.id AND p.delivery = 'off')
+		ON CONFLICT (event_id, recipient_id) DO NOTHING
+	`, activity.EventID, recipientIDs, activity.ListID, activity.ActorID, activity.Activity, activity.ItemName)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to queue family activity: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+// ClaimDueDigests removes and returns the digests due at now: instant ones shortly after the
+// activity, batched ones once the oldest activity is BatchIntervalMinutes old, none during the
+// recipient's quiet hours. A digest is claimed by one replica only.
+func (s *Service) ClaimDueDigests(ctx context.Context, now time.Time) ([]*Digest, error) {
+	ctx, span := tracer.Start(ctx, "notifications.ClaimDueDigests")
+	defer span.End()
+
+	rows, err := s.db.Query(ctx, `
+		SELECT n.recipient_id, u.telegram_id, COALESCE(u.locale, 'en'), MIN(n.created_at),
+		       COALESCE(p.delivery, 'instant'), COALESCE(p.batch_interval_minutes, $1),
+		       p.quiet_start_minute, p.quiet_end_minute, COALESCE(p.timezone, 'UTC')
+		FROM family_activity_notifications n
+		JOIN users u ON u.id = n.recipient_id
+		LEFT JOIN notification_preferences p ON p.user_id = n.recipient_id
+		GROUP BY n.recipient_id, u.telegram_id, u.locale, p.delivery, p.batch_interval_minutes,
+		         p.quiet_start_minute, p.quiet_end_minute, p.timezone
+	`, DefaultBatchIntervalMinutes)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get pending notifications: %w", err)
+	}
+
+	var due []*Digest
+	for rows.Next() {
+		var digest Digest
+		var oldest time.Time
+		prefs := Preferences{}
+		err := rows.Scan(&digest.RecipientID, &digest.TelegramID, &digest.Locale, &oldest,
+			&prefs.Delivery, &prefs.BatchIntervalMinutes, &prefs.QuietStartMinute,
+			&prefs.QuietEndMinute, &prefs.Timezone)
+		if err != nil {
+			rows.Close()
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan pending notifications: %w", err)
+		}
+
+		if !prefs.DigestDue(oldest, now) {
+			continue
+		}
+
+		due = append(due, &digest)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate pending notifications: %w", err)
+	}
+
+	digests := make([]*Digest, 0, len(due))
+	for _, digest := range due {
+		entries, err := s.claimDigestEntries(ctx, digest.RecipientID)
+		if err != nil {
+			span.RecordError(err)
+			return digests, err
+		}
+		// Another replica claimed it first
+		if len(entries) == 0 {
+			continue
+		}
+
+		digest.Entries = entries
+		digests = append(digests, digest)
+	}
+
+	return digests, nil
+}
+
+// claimDigestEntries removes the queued activity of the recipient and groups it by list, member
+// and activity in the order it happened
+func (s *Service) claimDigestEntries(ctx context.Context, recipientID uuid.UUID) ([]*DigestEntry, error) {
+	rows, err := s.db.Query(ctx, `
+		WITH claimed AS (
+			DELETE FROM family_activity_notifications
+			WHERE recipient_id = $1
+			RETURNING list_id, actor_id, activity, item_name, created_at
+		)
+		SELECT c.list_id, l.name, COALESCE(f.name, ''),
+		       COALESCE(NULLIF(u.first_name, ''), u.username, ''), c.activity, c.item_name
+		FROM claimed c
+		JOIN shopping_lists l ON l.id = c.list_id
+		LEFT JOIN families f ON f.id = l.family_id
+		LEFT JOIN users u ON u.id = c.actor_id
+		ORDER BY c.created_at ASC
+	`, recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notifications: %w", err)
+	}
+	defer rows.Close()
+
+	type entryKey struct {
+		listID    uuid.UUID
+		actorName string
+		activity  string
+	}
+
+	var entries []*DigestEntry
+	byKey := make(map[entryKey]*DigestEntry)
+
+	for rows.Next() {
+		var entry DigestEntry
+		var itemName *string
+		err := rows.Scan(&entry.ListID, &entry.ListName, &entry.FamilyName, &entry.ActorName,
+			&entry.Activity, &itemName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan claimed notification: %w", err)
+		}
+
+		key := entryKey{listID: entry.ListID, actorName: entry.ActorName, activity: entry.Activity}
+		existing, ok := byKey[key]
+		if !ok {
+			existing = &entry
+			byKey[key] = existing
+			entries = append(entries, existing)
+		}
+
+		existing.ItemCount++
+		if itemName != nil {
+			if len(existing.ItemNames) < maxDigestItemNames {
+				existing.ItemNames = append(existing.ItemNames, *itemName)
+			} else {
+				existing.MoreItems++
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate claimed notifications: %w", err)
+	}
+
+	return entries, nil
+}
+
+// ParseClockTime parses HH:MM into the minute of the day
+func ParseClockTime(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, ErrInvalidQuietHours
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatMinute(minute *int) string {
+	if minute == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", *minute/60, *minute%60)
+}
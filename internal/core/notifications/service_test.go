@@ -0,0 +1,110 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+)
+
+func minute(hour, min int) *int {
+	m := hour*60 + min
+	return &m
+}
+
+func TestInQuietHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    *int
+		end      *int
+		timezone string
+		now      time.Time
+		want     bool
+	}{
+		{"no quiet hours", nil, nil, "UTC", time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), false},
+		{"only start", minute(22, 0), nil, "UTC", time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), false},
+		{"same day inside", minute(13, 0), minute(15, 0), "UTC", time.Date(2024, 3, 1, 14, 0, 0, 0, time.UTC), true},
+		{"same day at start", minute(13, 0), minute(15, 0), "UTC", time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC), true},
+		{"same day at end", minute(13, 0), minute(15, 0), "UTC", time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC), false},
+		{"same day before", minute(13, 0), minute(15, 0), "UTC", time.Date(2024, 3, 1, 12, 59, 0, 0, time.UTC), false},
+		{"over midnight late", minute(22, 0), minute(7, 0), "UTC", time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC), true},
+		{"over midnight early", minute(22, 0), minute(7, 0), "UTC", time.Date(2024, 3, 1, 6, 59, 0, 0, time.UTC), true},
+		{"over midnight at end", minute(22, 0), minute(7, 0), "UTC", time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC), false},
+		{"over midnight daytime", minute(22, 0), minute(7, 0), "UTC", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), false},
+		// 20:30 UTC is 22:30 in Kyiv in winter
+		{"timezone inside", minute(22, 0), minute(7, 0), "Europe/Kyiv", time.Date(2024, 1, 15, 20, 30, 0, 0, time.UTC), true},
+		// 05:30 UTC is 07:30 in Kyiv in winter
+		{"timezone outside", minute(22, 0), minute(7, 0), "Europe/Kyiv", time.Date(2024, 1, 15, 5, 30, 0, 0, time.UTC), false},
+		// 04:30 UTC is 07:30 in Kyiv in summer, 06:30 in winter
+		{"timezone summer time", minute(22, 0), minute(7, 0), "Europe/Kyiv", time.Date(2024, 7, 15, 4, 30, 0, 0, time.UTC), false},
+		{"timezone winter time", minute(22, 0), minute(7, 0), "Europe/Kyiv", time.Date(2024, 1, 15, 4, 30, 0, 0, time.UTC), true},
+		{"unknown timezone falls back to UTC", minute(22, 0), minute(7, 0), "Mars/Olympus", time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := &Preferences{QuietStartMinute: tt.start, QuietEndMinute: tt.end, Timezone: tt.timezone}
+			if got := prefs.InQuietHours(tt.now); got != tt.want {
+				t.Errorf("InQuietHours(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestDue(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		prefs  Preferences
+		oldest time.Time
+		want   bool
+	}{
+		{"instant too recent", Preferences{Delivery: DeliveryInstant}, now.Add(-30 * time.Second), false},
+		{"instant after delay", Preferences{Delivery: DeliveryInstant}, now.Add(-instantDelay), true},
+		{"batched too recent", Preferences{Delivery: DeliveryBatched, BatchIntervalMinutes: 30}, now.Add(-29 * time.Minute), false},
+		{"batched after interval", Preferences{Delivery: DeliveryBatched, BatchIntervalMinutes: 30}, now.Add(-30 * time.Minute), true},
+		{"off", Preferences{Delivery: DeliveryOff}, now.Add(-24 * time.Hour), false},
+		{
+			name:   "quiet hours",
+			prefs:  Preferences{Delivery: DeliveryInstant, QuietStartMinute: minute(11, 0), QuietEndMinute: minute(13, 0), Timezone: "UTC"},
+			oldest: now.Add(-time.Hour),
+			want:   false,
+		},
+		{
+			name:   "after quiet hours",
+			prefs:  Preferences{Delivery: DeliveryInstant, QuietStartMinute: minute(22, 0), QuietEndMinute: minute(7, 0), Timezone: "UTC"},
+			oldest: now.Add(-8 * time.Hour),
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.prefs.DigestDue(tt.oldest, now); got != tt.want {
+				t.Errorf("DigestDue(%s) = %v, want %v", tt.oldest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClockTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"00:00", 0, false},
+		{"07:30", 450, false},
+		{"23:59", 1439, false},
+		{"7:30", 450, false},
+		{"24:00", 0, true},
+		{"12:60", 0, true},
+		{"noon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseClockTime(tt.value)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseClockTime(%q) = %d, %v, want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
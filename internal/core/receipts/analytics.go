@@ -0,0 +1,380 @@
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported analytics periods
+const (
+	PeriodWeek    = "week"
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// Supported analytics granularities for the per-period breakdown
+const (
+	GranularityDay     = "day"
+	GranularityWeek    = "week"
+	GranularityMonth   = "month"
+	GranularityQuarter = "quarter"
+	GranularityYear    = "year"
+)
+
+// UncategorizedCategory is reported for receipt items without a category
+const UncategorizedCategory = "uncategorized"
+
+// UnknownCurrency is reported for receipts where no currency was extracted. It is the ISO 4217
+// code for "no currency", so such amounts are kept apart instead of being added to a guessed one.
+const UnknownCurrency = "XXX"
+
+// DefaultCurrencyCode is used for budgets and settlements entered without a currency
+const DefaultCurrencyCode = "EUR"
+
+const defaultAnalyticsLimit = 5
+
+// Receipt date and currency used by all analytics queries. Receipts without an extracted
+// transaction date are accounted on their upload date, receipts without a currency in UnknownCurrency.
+const (
+	receiptDateExpr     = `COALESCE(r.transaction_date, r.created_at::date)`
+	receiptCurrencyExpr = `COALESCE(NULLIF(UPPER(TRIM(r.currency_code)), ''), '` + UnknownCurrency + `')`
+	itemCurrencyExpr    = `COALESCE(NULLIF(UPPER(TRIM(ri.currency_code)), ''), NULLIF(UPPER(TRIM(r.currency_code)), ''), '` + UnknownCurrency + `')`
+	receiptAmountExpr   = `COALESCE(r.total_amount, (SELECT SUM(total_price) FROM receipt_items WHERE receipt_id = r.id), 0)`
+)
+
+// SpendingFilter selects the receipts included in spending analytics
+type SpendingFilter struct {
+	UserID      uuid.UUID
+	From        time.Time // inclusive
+	To          time.Time // exclusive
+	Currency    string    // optional, all currencies when empty
+	Granularity string    // day, week, month, quarter or year
+	Limit       int       // number of merchants, categories and items to return
+}
+
+// SpendingAnalytics is the spending overview for a time range.
+// Amounts are never summed across currencies, every entry carries its own currency code.
+type SpendingAnalytics struct {
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Granularity string           `json:"granularity"`
+	Totals      []CurrencyTotal  `json:"totals"`
+	Periods     []PeriodSpending `json:"periods"`
+	Merchants   []MerchantSpend  `json:"merchants"`
+	Categories  []CategorySpend  `json:"categories"`
+	TopItems    []ItemSpend      `json:"top_items"`
+}
+
+// CurrencyTotal is the total spend in one currency
+type CurrencyTotal struct {
+	Currency       string  `json:"currency"`
+	Amount         float64 `json:"amount"`
+	ReceiptsCount  int     `json:"receipts_count"`
+	AverageReceipt float64 `json:"average_receipt"`
+}
+
+// PeriodSpending is the spend in one currency during one period bucket
+type PeriodSpending struct {
+	PeriodStart   time.Time `json:"period_start"`
+	Currency      string    `json:"currency"`
+	Amount        float64   `json:"amount"`
+	ReceiptsCount int       `json:"receipts_count"`
+}
+
+// MerchantSpend is the spend in one currency at one merchant
+type MerchantSpend struct {
+	Merchant      string  `json:"merchant"`
+	Currency      string  `json:"currency"`
+	Amount        float64 `json:"amount"`
+	ReceiptsCount int     `json:"receipts_count"`
+}
+
+// CategorySpend is the spend in one currency for one item category
+type CategorySpend struct {
+	Category   string  `json:"category"`
+	Currency   string  `json:"currency"`
+	Amount     float64 `json:"amount"`
+	ItemsCount int     `json:"items_count"`
+}
+
+// ItemSpend is the spend in one currency for one item description
+type ItemSpend struct {
+	Description   string  `json:"description"`
+	Currency      string  `json:"currency"`
+	Amount        float64 `json:"amount"`
+	Quantity      float64 `json:"quantity"`
+	PurchaseCount int     `json:"purchase_count"`
+}
+
+// PeriodRange returns the calendar range containing now for the given period
+// together with the granularity used for its per-period breakdown
+func PeriodRange(period string, now time.Time) (from, to time.Time, granularity string, err error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch period {
+	case PeriodWeek:
+		// Weeks start on Monday
+		offset := (int(today.Weekday()) + 6) % 7
+		from = today.AddDate(0, 0, -offset)
+		return from, from.AddDate(0, 0, 7), GranularityDay, nil
+	case PeriodMonth:
+		from = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		return from, from.AddDate(0, 1, 0), GranularityWeek, nil
+	case PeriodQuarter:
+		firstMonth := time.Month((int(today.Month())-1)/3*3 + 1)
+		from = time.Date(today.Year(), firstMonth, 1, 0, 0, 0, 0, today.Location())
+		return from, from.AddDate(0, 3, 0), GranularityMonth, nil
+	case PeriodYear:
+		from = time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, today.Location())
+		return from, from.AddDate(1, 0, 0), GranularityMonth, nil
+	default:
+		return time.Time{}, time.Time{}, "", fmt.Errorf("unsupported period: %s", period)
+	}
+}
+
+// ReceiptCurrency returns the upper-cased currency code of a receipt loaded from the database,
+// UnknownCurrency when none was extracted. It matches the currency the analytics queries group by.
+func ReceiptCurrency(code *string) string {
+	if code == nil || strings.TrimSpace(*code) == "" {
+		return UnknownCurrency
+	}
+	return strings.ToUpper(strings.TrimSpace(*code))
+}
+
+// IsValidGranularity reports whether granularity can be used in a SpendingFilter
+func IsValidGranularity(granularity string) bool {
+	switch granularity {
+	case GranularityDay, GranularityWeek, GranularityMonth, GranularityQuarter, GranularityYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetSpendingAnalytics computes spend per currency, period, merchant, category and top items
+// for the processed receipts of a user
+func (s *Service) GetSpendingAnalytics(ctx context.Context, filter SpendingFilter) (*SpendingAnalytics, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetSpendingAnalytics")
+	defer span.End()
+
+	if !filter.To.After(filter.From) {
+		return nil, fmt.Errorf("invalid analytics range: %s - %s", filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly))
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = GranularityDay
+	}
+	if !IsValidGranularity(filter.Granularity) {
+		return nil, fmt.Errorf("unsupported granularity: %s", filter.Granularity)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAnalyticsLimit
+	}
+	filter.Currency = strings.ToUpper(strings.TrimSpace(filter.Currency))
+
+	analytics := &SpendingAnalytics{
+		From:        filter.From,
+		To:          filter.To,
+		Granularity: filter.Granularity,
+	}
+
+	var err error
+	if analytics.Periods, err = s.getPeriodSpending(ctx, filter); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	analytics.Totals = currencyTotals(analytics.Periods)
+	if analytics.Merchants, err = s.getMerchantSpending(ctx, filter); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if analytics.Categories, err = s.getCategorySpending(ctx, filter); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if analytics.TopItems, err = s.getTopItems(ctx, filter); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+// receiptsFilterClause restricts users_receipts r to the filter, using $1-$4 for
+// user, from, to and currency
+func receiptsFilterClause(currencyExpr string) string {
+	return `r.user_id = $1 AND r.processed = TRUE
+		  AND ` + receiptDateExpr + ` >= $2::date AND ` + receiptDateExpr + ` < $3::date
+		  AND ($4 = '' OR ` + currencyExpr + ` = $4)`
+}
+
+// currencyTotals sums the per-period spending into one total per currency, largest first
+func currencyTotals(periods []PeriodSpending) []CurrencyTotal {
+	totals := []CurrencyTotal{}
+	index := make(map[string]int)
+	for _, period := range periods {
+		i, exists := index[period.Currency]
+		if !exists {
+			i = len(totals)
+			index[period.Currency] = i
+			totals = append(totals, CurrencyTotal{Currency: period.Currency})
+		}
+		totals[i].Amount += period.Amount
+		totals[i].ReceiptsCount += period.ReceiptsCount
+	}
+
+	for i := range totals {
+		if totals[i].ReceiptsCount > 0 {
+			totals[i].AverageReceipt = totals[i].Amount / float64(totals[i].ReceiptsCount)
+		}
+	}
+	sort.SliceStable(totals, func(i, j int) bool {
+		return totals[i].Amount > totals[j].Amount
+	})
+
+	return totals
+}
+
+func (s *Service) getPeriodSpending(ctx context.Context, filter SpendingFilter) ([]PeriodSpending, error) {
+	query := `
+		SELECT date_trunc($5, (` + receiptDateExpr + `)::timestamp)::date AS period_start,
+		       ` + receiptCurrencyExpr + ` AS currency,
+		       SUM(` + receiptAmountExpr + `)::float8 AS amount,
+		       COUNT(*) AS receipts_count
+		FROM users_receipts r
+		WHERE ` + receiptsFilterClause(receiptCurrencyExpr) + `
+		GROUP BY period_start, currency
+		ORDER BY period_start ASC, currency ASC`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.From, filter.To, filter.Currency, filter.Granularity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get period spending: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []PeriodSpending{}
+	for rows.Next() {
+		var period PeriodSpending
+		if err := rows.Scan(&period.PeriodStart, &period.Currency, &period.Amount, &period.ReceiptsCount); err != nil {
+			return nil, fmt.Errorf("failed to scan period spending: %w", err)
+		}
+		periods = append(periods, period)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate period spending: %w", err)
+	}
+
+	return periods, nil
+}
+
+func (s *Service) getMerchantSpending(ctx context.Context, filter SpendingFilter) ([]MerchantSpend, error) {
+	query := `
+		SELECT MIN(TRIM(r.merchant_name)) AS merchant,
+		       ` + receiptCurrencyExpr + ` AS currency,
+		       SUM(` + receiptAmountExpr + `)::float8 AS amount,
+		       COUNT(*) AS receipts_count
+		FROM users_receipts r
+		WHERE ` + receiptsFilterClause(receiptCurrencyExpr) + `
+		  AND NULLIF(TRIM(r.merchant_name), '') IS NOT NULL
+		GROUP BY LOWER(TRIM(r.merchant_name)), currency
+		ORDER BY amount DESC
+		LIMIT $5`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.From, filter.To, filter.Currency, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchant spending: %w", err)
+	}
+	defer rows.Close()
+
+	merchants := []MerchantSpend{}
+	for rows.Next() {
+		var merchant MerchantSpend
+		if err := rows.Scan(&merchant.Merchant, &merchant.Currency, &merchant.Amount, &merchant.ReceiptsCount); err != nil {
+			return nil, fmt.Errorf("failed to scan merchant spending: %w", err)
+		}
+		merchants = append(merchants, merchant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate merchant spending: %w", err)
+	}
+
+	return merchants, nil
+}
+
+func (s *Service) getCategorySpending(ctx context.Context, filter SpendingFilter) ([]CategorySpend, error) {
+	query := `
+		SELECT COALESCE(NULLIF(TRIM(ri.user_category), ''), '` + UncategorizedCategory + `') AS category,
+		       ` + itemCurrencyExpr + ` AS currency,
+		       SUM(ri.total_price)::float8 AS amount,
+		       COUNT(*) AS items_count
+		FROM receipt_items ri
+		JOIN users_receipts r ON r.id = ri.receipt_id
+		WHERE ` + receiptsFilterClause(itemCurrencyExpr) + `
+		GROUP BY category, currency
+		ORDER BY amount DESC
+		LIMIT $5`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.From, filter.To, filter.Currency, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category spending: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []CategorySpend{}
+	for rows.Next() {
+		var category CategorySpend
+		if err := rows.Scan(&category.Category, &category.Currency, &category.Amount, &category.ItemsCount); err != nil {
+			return nil, fmt.Errorf("failed to scan category spending: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate category spending: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (s *Service) getTopItems(ctx context.Context, filter SpendingFilter) ([]ItemSpend, error) {
+	query := `
+		SELECT MIN(COALESCE(NULLIF(TRIM(ri.localized_description), ''), TRIM(ri.original_description))) AS description,
+		       ` + itemCurrencyExpr + ` AS currency,
+		       SUM(ri.total_price)::float8 AS amount,
+		       SUM(COALESCE(ri.quantity, 1))::float8 AS quantity,
+		       COUNT(*) AS purchase_count
+		FROM receipt_items ri
+		JOIN users_receipts r ON r.id = ri.receipt_id
+		WHERE ` + receiptsFilterClause(itemCurrencyExpr) + `
+		GROUP BY LOWER(COALESCE(NULLIF(TRIM(ri.localized_description), ''), TRIM(ri.original_description))), currency
+		ORDER BY amount DESC
+		LIMIT $5`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.From, filter.To, filter.Currency, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []ItemSpend{}
+	for rows.Next() {
+		var item ItemSpend
+		if err := rows.Scan(&item.Description, &item.Currency, &item.Amount, &item.Quantity, &item.PurchaseCount); err != nil {
+			return nil, fmt.Errorf("failed to scan top item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate top items: %w", err)
+	}
+
+	return items, nil
+}
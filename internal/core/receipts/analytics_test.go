@@ -0,0 +1,140 @@
+package receipts
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReceiptCurrency(t *testing.T) {
+	tests := []struct {
+		code *string
+		want string
+	}{
+		{str("EUR"), "EUR"},
+		{str(" uah "), "UAH"},
+		{str(""), UnknownCurrency},
+		{str("  "), UnknownCurrency},
+		{nil, UnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		if got := ReceiptCurrency(tt.code); got != tt.want {
+			t.Errorf("ReceiptCurrency(%v) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCurrencyTotals(t *testing.T) {
+	january := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	february := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		periods []PeriodSpending
+		want    []CurrencyTotal
+	}{
+		{
+			name: "no spending",
+			want: []CurrencyTotal{},
+		},
+		{
+			name: "periods of one currency",
+			periods: []PeriodSpending{
+				{PeriodStart: january, Currency: "EUR", Amount: 30, ReceiptsCount: 2},
+				{PeriodStart: february, Currency: "EUR", Amount: 15, ReceiptsCount: 1},
+			},
+			want: []CurrencyTotal{{Currency: "EUR", Amount: 45, ReceiptsCount: 3, AverageReceipt: 15}},
+		},
+		{
+			name: "currencies are never summed, largest first",
+			periods: []PeriodSpending{
+				{PeriodStart: january, Currency: "EUR", Amount: 20, ReceiptsCount: 1},
+				{PeriodStart: january, Currency: "UAH", Amount: 800, ReceiptsCount: 4},
+				{PeriodStart: february, Currency: "EUR", Amount: 10, ReceiptsCount: 2},
+			},
+			want: []CurrencyTotal{
+				{Currency: "UAH", Amount: 800, ReceiptsCount: 4, AverageReceipt: 200},
+				{Currency: "EUR", Amount: 30, ReceiptsCount: 3, AverageReceipt: 10},
+			},
+		},
+		{
+			name: "receipts without a currency are their own bucket",
+			periods: []PeriodSpending{
+				{PeriodStart: january, Currency: "EUR", Amount: 12, ReceiptsCount: 1},
+				{PeriodStart: january, Currency: UnknownCurrency, Amount: 5, ReceiptsCount: 2},
+				{PeriodStart: february, Currency: UnknownCurrency, Amount: 4, ReceiptsCount: 1},
+			},
+			want: []CurrencyTotal{
+				{Currency: "EUR", Amount: 12, ReceiptsCount: 1, AverageReceipt: 12},
+				{Currency: UnknownCurrency, Amount: 9, ReceiptsCount: 3, AverageReceipt: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := currencyTotals(tt.periods)
+			if len(got) != len(tt.want) {
+				t.Fatalf("currencyTotals() = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Currency != want.Currency || got[i].ReceiptsCount != want.ReceiptsCount ||
+					math.Abs(got[i].Amount-want.Amount) > 1e-9 || math.Abs(got[i].AverageReceipt-want.AverageReceipt) > 1e-9 {
+					t.Errorf("total %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestPeriodRange(t *testing.T) {
+	now := time.Date(2025, 5, 14, 15, 30, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		period      string
+		from, to    time.Time
+		granularity string
+	}{
+		{PeriodWeek, time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC), GranularityDay},
+		{PeriodMonth, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), GranularityWeek},
+		{PeriodQuarter, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), GranularityMonth},
+		{PeriodYear, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), GranularityMonth},
+	}
+
+	for _, tt := range tests {
+		from, to, granularity, err := PeriodRange(tt.period, now)
+		if err != nil {
+			t.Errorf("PeriodRange(%s) failed: %v", tt.period, err)
+			continue
+		}
+		if !from.Equal(tt.from) || !to.Equal(tt.to) || granularity != tt.granularity {
+			t.Errorf("PeriodRange(%s) = %s - %s by %s, want %s - %s by %s", tt.period,
+				from.Format(time.DateOnly), to.Format(time.DateOnly), granularity,
+				tt.from.Format(time.DateOnly), tt.to.Format(time.DateOnly), tt.granularity)
+		}
+	}
+
+	// Weeks start on Monday, also on Sunday
+	sunday := time.Date(2025, 5, 18, 23, 0, 0, 0, time.UTC)
+	if from, _, _, _ := PeriodRange(PeriodWeek, sunday); !from.Equal(time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("PeriodRange(week) on Sunday starts on %s", from.Format(time.DateOnly))
+	}
+
+	if _, _, _, err := PeriodRange("decade", now); err == nil {
+		t.Error("PeriodRange accepted an unsupported period")
+	}
+}
+
+func TestIsValidGranularity(t *testing.T) {
+	for _, granularity := range []string{GranularityDay, GranularityWeek, GranularityMonth, GranularityQuarter, GranularityYear} {
+		if !IsValidGranularity(granularity) {
+			t.Errorf("IsValidGranularity(%s) = false", granularity)
+		}
+	}
+	for _, granularity := range []string{"", "hour", "Month"} {
+		if IsValidGranularity(granularity) {
+			t.Errorf("IsValidGranularity(%q) = true", granularity)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetAlertThresholds are the shares of a budget, in percent, announced to the family once
+// per budget period when the spending crosses them
+var BudgetAlertThresholds = []int{80, 100}
+
+// FamilyBudget is a spending limit of a family for a week or a month, either for all
+// spending or for one item category
+type FamilyBudget struct {
+	ID         uuid.UUID     `json:"id"`
+	FamilyID   uuid.UUID     `json:"family_id"`
+	FamilyName string        `json:"family_name"`
+	Category   *ItemCategory `json:"category,omitempty"` // nil for the whole spending
+	Period     string        `json:"period"`             // week or month
+	Amount     float64       `json:"amount"`
+	Currency   string        `json:"currency"`
+	CreatedBy  *uuid.UUID    `json:"created_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// SetFamilyBudgetRequest creates or replaces the budget of a family for a period and category
+type SetFamilyBudgetRequest struct {
+	FamilyID   uuid.UUID
+	CategoryID *uuid.UUID // nil for the whole spending
+	Period     string
+	Amount     float64
+	Currency   string // DefaultCurrencyCode when empty
+	CreatedBy  uuid.UUID
+}
+
+// BudgetStatus is the spending of the family members against a budget in the current period
+type BudgetStatus struct {
+	*FamilyBudget
+	From      time.Time `json:"from"` // inclusive
+	To        time.Time `json:"to"`   // exclusive
+	Spent     float64   `json:"spent"`
+	Remaining float64   `json:"remaining"` // negative when the budget is exceeded
+	Percent   int       `json:"percent"`
+}
+
+// BudgetAlert is a budget threshold crossed for the first time in the current period
+type BudgetAlert struct {
+	*BudgetStatus
+	Threshold int `json:"threshold"`
+}
+
+// IsValidBudgetPeriod reports whether period can be used for a family budget
+func IsValidBudgetPeriod(period string) bool {
+	return period == PeriodWeek || period == PeriodMonth
+}
+
+// LocalizedName returns the category name in the locale, the default name when there is none
+func (c *ItemCategory) LocalizedName(locale string) string {
+	var name *string
+	switch locale {
+	case "en":
+		name = c.NameEn
+	case "ru":
+		name = c.NameRu
+	case "uk":
+		name = c.NameUk
+	}
+	if name != nil && *name != "" {
+		return *name
+	}
+	return c.Name
+}
+
+// FindItemCategory returns the item category with the name in any language, nil when there is none
+func (s *Service) FindItemCategory(ctx context.Context, name string) (*ItemCategory, error) {
+	categories, err := s.GetItemCategories(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	for _, category := range categories {
+		for _, candidate := range []*string{&category.Name, category.NameEn, category.NameRu, category.NameUk} {
+			if candidate != nil && strings.EqualFold(*candidate, name) {
+				return category, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// SetFamilyBudget creates or replaces a family budget. Alerts already sent for the budget are
+// forgotten, so the thresholds of the new amount are announced again.
+func (s *Service) SetFamilyBudget(ctx context.Context, req SetFamilyBudgetRequest) (*FamilyBudget, error) {
+	ctx, span := tracer.Start(ctx, "receipts.SetFamilyBudget")
+	defer span.End()
+
+	if !IsValidBudgetPeriod(req.Period) {
+		return nil, fmt.Errorf("unsupported budget period: %s", req.Period)
+	}
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("budget amount must be positive: %.2f", req.Amount)
+	}
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = DefaultCurrencyCode
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	budget := &FamilyBudget{}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO family_budgets (family_id, category_id, period, amount, currency_code, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (family_id, period, (COALESCE(category_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+		DO UPDATE SET amount = EXCLUDED.amount, currency_code = EXCLUDED.currency_code,
+		              created_by = EXCLUDED.created_by, updated_at = NOW()
+		RETURNING id, family_id, period, amount::float8, currency_code, created_by, created_at, updated_at
+	`, req.FamilyID, req.CategoryID, req.Period, req.Amount, currency, req.CreatedBy).Scan(
+		&budget.ID, &budget.FamilyID, &budget.Period, &budget.Amount, &budget.Currency,
+		&budget.CreatedBy, &budget.CreatedAt, &budget.UpdatedAt,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to save family budget: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM family_budget_alerts WHERE budget_id = $1`, budget.ID); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to reset family budget alerts: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return budget, nil
+}
+
+// DeleteFamilyBudget removes the family budget for a period and category, it reports whether there was one
+func (s *Service) DeleteFamilyBudget(ctx context.Context, familyID uuid.UUID, categoryID *uuid.UUID, period string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "receipts.DeleteFamilyBudget")
+	defer span.End()
+
+	result, err := s.db.Exec(ctx, `
+		DELETE FROM family_budgets
+		WHERE family_id = $1 AND period = $2 AND category_id IS NOT DISTINCT FROM $3
+	`, familyID, period, categoryID)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to delete family budget: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetBudgetStatuses returns the spending against every budget of the families of a user in the
+// period containing now, ordered by family, period and category
+func (s *Service) GetBudgetStatuses(ctx context.Context, userID uuid.UUID, now time.Time) ([]*BudgetStatus, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetBudgetStatuses")
+	defer span.End()
+
+	budgets, err := s.getUserFamilyBudgets(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	statuses := make([]*BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		status, err := s.getBudgetStatus(ctx, budget, now)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// CheckBudgetAlerts records and returns the budget thresholds crossed for the first time in the
+// current period by the families of a user, only the highest new threshold of a budget is returned.
+// It is called after a receipt of the user was processed.
+func (s *Service) CheckBudgetAlerts(ctx context.Context, userID uuid.UUID, now time.Time) ([]*BudgetAlert, error) {
+	ctx, span := tracer.Start(ctx, "receipts.CheckBudgetAlerts")
+	defer span.End()
+
+	statuses, err := s.GetBudgetStatuses(ctx, userID, now)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	var alerts []*BudgetAlert
+	for _, status := range statuses {
+		var alert *BudgetAlert
+		for _, threshold := range BudgetAlertThresholds {
+			if status.Spent < status.Amount*float64(threshold)/100 {
+				break
+			}
+
+			result, err := s.db.Exec(ctx, `
+				INSERT INTO family_budget_alerts (budget_id, period_start, threshold)
+				VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, status.ID, status.From, threshold)
+			if err != nil {
+				span.RecordError(err)
+				return nil, fmt.Errorf("failed to record family budget alert: %w", err)
+			}
+			if result.RowsAffected() > 0 {
+				alert = &BudgetAlert{BudgetStatus: status, Threshold: threshold}
+			}
+		}
+		if alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+// getUserFamilyBudgets loads the budgets of all families the user is a member of
+func (s *Service) getUserFamilyBudgets(ctx context.Context, userID uuid.UUID) ([]*FamilyBudget, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT b.id, b.family_id, f.name, b.period, b.amount::float8, b.currency_code,
+		       b.created_by, b.created_at, b.updated_at, b.category_id
+		FROM family_budgets b
+		JOIN families f ON f.id = b.family_id
+		JOIN family_members fm ON fm.family_id = b.family_id AND fm.user_id = $1
+		LEFT JOIN item_categories c ON c.id = b.category_id
+		ORDER BY f.name, b.period DESC, c.sort_order NULLS FIRST
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*FamilyBudget
+	var categoryIDs []*uuid.UUID
+	for rows.Next() {
+		var budget FamilyBudget
+		var categoryID *uuid.UUID
+		if err := rows.Scan(
+			&budget.ID, &budget.FamilyID, &budget.FamilyName, &budget.Period, &budget.Amount,
+			&budget.Currency, &budget.CreatedBy, &budget.CreatedAt, &budget.UpdatedAt, &categoryID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan family budget: %w", err)
+		}
+		budgets = append(budgets, &budget)
+		categoryIDs = append(categoryIDs, categoryID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate family budgets: %w", err)
+	}
+
+	// Attach the categories of category budgets
+	var categories []*ItemCategory
+	for i, categoryID := range categoryIDs {
+		if categoryID == nil {
+			continue
+		}
+		if categories == nil {
+			if categories, err = s.GetItemCategories(ctx, ""); err != nil {
+				return nil, err
+			}
+		}
+		for _, category := range categories {
+			if category.ID == *categoryID {
+				budgets[i].Category = category
+				break
+			}
+		}
+	}
+
+	return budgets, nil
+}
+
+// getBudgetStatus computes what the family members spent in the budget currency during the
+// budget period containing now
+func (s *Service) getBudgetStatus(ctx context.Context, budget *FamilyBudget, now time.Time) (*BudgetStatus, error) {
+	from, to, _, err := PeriodRange(budget.Period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Receipts shared with another family count there only
+	membersClause := `(r.family_id = $1 OR (r.family_id IS NULL
+		      AND r.user_id IN (SELECT user_id FROM family_members WHERE family_id = $1))) AND r.processed = TRUE
+		  AND ` + receiptDateExpr + ` >= $2::date AND ` + receiptDateExpr + ` < $3::date`
+
+	var spent float64
+	if budget.Category == nil {
+		err = s.db.QueryRow(ctx, `
+			SELECT COALESCE(SUM(`+receiptAmountExpr+`), 0)::float8
+			FROM users_receipts r
+			WHERE `+membersClause+` AND `+receiptCurrencyExpr+` = $4
+		`, budget.FamilyID, from, to, budget.Currency).Scan(&spent)
+	} else {
+		// Receipt items carry the category name they were extracted with, in any language
+		err = s.db.QueryRow(ctx, `
+			SELECT COALESCE(SUM(ri.total_price), 0)::float8
+			FROM receipt_items ri
+			JOIN users_receipts r ON r.id = ri.receipt_id
+			WHERE `+membersClause+` AND `+itemCurrencyExpr+` = $4
+			  AND LOWER(TRIM(ri.user_category)) IN (
+			      SELECT LOWER(n) FROM item_categories c, unnest(ARRAY[c.name, c.name_en, c.name_ru, c.name_uk]) AS n
+			      WHERE c.id = $5 AND n IS NOT NULL)
+		`, budget.FamilyID, from, to, budget.Currency, budget.Category.ID).Scan(&spent)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family budget spending: %w", err)
+	}
+
+	return &BudgetStatus{
+		FamilyBudget: budget,
+		From:         from,
+		To:           to,
+		Spent:        spent,
+		Remaining:    budget.Amount - spent,
+		Percent:      int(spent / budget.Amount * 100),
+	}, nil
+}
@@ -0,0 +1,454 @@
+package receipts
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported receipt export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatJSON = "json"
+	ExportFormatOFX  = "ofx"
+	ExportFormatQIF  = "qif"
+)
+
+// ExportFormats lists the export formats in the order they are offered
+var ExportFormats = []string{ExportFormatCSV, ExportFormatJSON, ExportFormatOFX, ExportFormatQIF}
+
+// ExportFilter selects the receipts of a user included in an export
+type ExportFilter struct {
+	UserID uuid.UUID
+	From   time.Time // inclusive
+	To     time.Time // exclusive
+}
+
+// ReceiptExport holds the processed receipts of a user for a date range with their items,
+// oldest first
+type ReceiptExport struct {
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Receipts []*ReceiptWithItems `json:"receipts"`
+}
+
+// IsValidExportFormat reports whether format is a supported export format
+func IsValidExportFormat(format string) bool {
+	for _, supported := range ExportFormats {
+		if format == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportContentType returns the MIME type of an export format
+func ExportContentType(format string) string {
+	switch format {
+	case ExportFormatCSV:
+		return "text/csv"
+	case ExportFormatJSON:
+		return "application/json"
+	case ExportFormatOFX:
+		return "application/x-ofx"
+	default:
+		return "application/qif"
+	}
+}
+
+// ExportReceipts loads the processed receipts of a user in the date range with their items
+func (s *Service) ExportReceipts(ctx context.Context, filter ExportFilter) (*ReceiptExport, error) {
+	ctx, span := tracer.Start(ctx, "receipts.ExportReceipts")
+	defer span.End()
+
+	if !filter.To.After(filter.From) {
+		return nil, fmt.Errorf("invalid export range: %s - %s", filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly))
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+receiptColumns+`
+		FROM users_receipts r
+		WHERE r.user_id = $1 AND r.processed = TRUE
+		  AND `+receiptDateExpr+` >= $2::date AND `+receiptDateExpr+` < $3::date
+		ORDER BY `+receiptDateExpr+` ASC, r.transaction_time ASC NULLS LAST, r.created_at ASC
+	`, filter.UserID, filter.From, filter.To)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipts for export: %w", err)
+	}
+	defer rows.Close()
+
+	export := &ReceiptExport{
+		From:     filter.From,
+		To:       filter.To,
+		Receipts: []*ReceiptWithItems{},
+	}
+	byID := make(map[uuid.UUID]*ReceiptWithItems)
+	var receiptIDs []uuid.UUID
+
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan receipt: %w", err)
+		}
+
+		withItems := &ReceiptWithItems{Receipt: *receipt, Items: []ReceiptItem{}}
+		export.Receipts = append(export.Receipts, withItems)
+		byID[receipt.ID] = withItems
+		receiptIDs = append(receiptIDs, receipt.ID)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate receipts: %w", err)
+	}
+
+	if len(receiptIDs) == 0 {
+		return export, nil
+	}
+
+	itemRows, err := s.db.Query(ctx, `
+		SELECT id, receipt_id, item_order, original_description, original_language,
+		       localized_description, user_locale, quantity, quantity_unit, unit_price,
+		       total_price, currency_code, user_category, user_notes, is_user_modified,
+		       confidence, bounding_regions, created_at, updated_at
+		FROM receipt_items
+		WHERE receipt_id = ANY($1)
+		ORDER BY receipt_id, item_order ASC
+	`, receiptIDs)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipt items for export: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var item ReceiptItem
+		err := itemRows.Scan(
+			&item.ID, &item.ReceiptID, &item.ItemOrder, &item.OriginalDescription,
+			&item.OriginalLanguage, &item.LocalizedDescription, &item.UserLocale,
+			&item.Quantity, &item.QuantityUnit, &item.UnitPrice, &item.TotalPrice,
+			&item.CurrencyCode, &item.UserCategory, &item.UserNotes, &item.IsUserModified,
+			&item.Confidence, &item.BoundingRegions, &item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan receipt item: %w", err)
+		}
+		if receipt, ok := byID[item.ReceiptID]; ok {
+			receipt.Items = append(receipt.Items, item)
+		}
+	}
+
+	if err := itemRows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate receipt items: %w", err)
+	}
+
+	return export, nil
+}
+
+// FileName returns the download file name of the export in a format, e.g. receipts-2025-01-01-2025-01-31.csv
+func (e *ReceiptExport) FileName(format string) string {
+	return fmt.Sprintf("receipts-%s-%s.%s",
+		e.From.Format(time.DateOnly), e.To.AddDate(0, 0, -1).Format(time.DateOnly), format)
+}
+
+// Write exports the receipts in one of the ExportFormats
+func (e *ReceiptExport) Write(w io.Writer, format string) error {
+	switch format {
+	case ExportFormatCSV:
+		return e.WriteCSV(w)
+	case ExportFormatJSON:
+		return e.WriteJSON(w)
+	case ExportFormatOFX:
+		return e.WriteOFX(w)
+	case ExportFormatQIF:
+		return e.WriteQIF(w)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// WriteCSV exports one row per receipt item with a header line. Receipts without items get a
+// single row with empty item columns.
+func (e *ReceiptExport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"receipt_id", "date", "merchant", "currency", "receipt_total", "receipt_tax",
+		"item_order", "description", "original_description", "quantity", "quantity_unit",
+		"unit_price", "total_price", "category"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write receipts export header: %w", err)
+	}
+
+	for _, receipt := range e.Receipts {
+		receiptFields := []string{
+			receipt.Receipt.ID.String(),
+			exportDate(receipt).Format(time.DateOnly),
+			exportMerchant(receipt),
+			exportCurrency(receipt),
+			formatAmount(exportTotal(receipt)),
+			formatOptionalAmount(receipt.Receipt.TotalTax),
+		}
+
+		if len(receipt.Items) == 0 {
+			record := append(receiptFields, make([]string, len(header)-len(receiptFields))...)
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write receipts export row: %w", err)
+			}
+			continue
+		}
+
+		for _, item := range receipt.Items {
+			record := append(append([]string{}, receiptFields...),
+				strconv.Itoa(item.ItemOrder),
+				itemDescription(item),
+				item.OriginalDescription,
+				formatOptionalQuantity(item.Quantity),
+				stringValue(item.QuantityUnit),
+				formatOptionalAmount(item.UnitPrice),
+				formatAmount(item.TotalPrice),
+				stringValue(item.UserCategory),
+			)
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write receipts export row: %w", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON exports the receipts with all their data and items
+func (e *ReceiptExport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(e); err != nil {
+		return fmt.Errorf("failed to write receipts export: %w", err)
+	}
+	return nil
+}
+
+// WriteOFX exports an OFX 2.2 bank statement per currency with one debit transaction per receipt
+func (e *ReceiptExport) WriteOFX(w io.Writer) error {
+	now := time.Now()
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	b.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	b.WriteString("<OFX>\n")
+	b.WriteString("<SIGNONMSGSRSV1><SONRS>\n")
+	b.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+	fmt.Fprintf(&b, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>\n", ofxDateTime(now))
+	b.WriteString("</SONRS></SIGNONMSGSRSV1>\n")
+	b.WriteString("<BANKMSGSRSV1>\n")
+
+	for i, currency := range e.currencies() {
+		var balance float64
+		fmt.Fprintf(&b, "<STMTTRNRS><TRNUID>%d</TRNUID>\n", i+1)
+		b.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n")
+		fmt.Fprintf(&b, "<STMTRS><CURDEF>%s</CURDEF>\n", xmlEscape(currency))
+		fmt.Fprintf(&b, "<BANKACCTFROM><BANKID>RECEIPTS</BANKID><ACCTID>RECEIPTS-%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>\n", xmlEscape(currency))
+		fmt.Fprintf(&b, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", ofxDate(e.From), ofxDate(e.To))
+
+		for _, receipt := range e.Receipts {
+			if exportCurrency(receipt) != currency {
+				continue
+			}
+			amount := -exportTotal(receipt)
+			balance += amount
+
+			b.WriteString("<STMTTRN><TRNTYPE>DEBIT</TRNTYPE>")
+			fmt.Fprintf(&b, "<DTPOSTED>%s</DTPOSTED>", ofxDate(exportDate(receipt)))
+			fmt.Fprintf(&b, "<TRNAMT>%s</TRNAMT>", formatAmount(amount))
+			fmt.Fprintf(&b, "<FITID>%s</FITID>", receipt.Receipt.ID)
+			fmt.Fprintf(&b, "<NAME>%s</NAME>", xmlEscape(truncateRunes(exportMerchant(receipt), 32)))
+			fmt.Fprintf(&b, "<MEMO>%s</MEMO>", xmlEscape(truncateRunes(exportMemo(receipt), 255)))
+			b.WriteString("</STMTTRN>\n")
+		}
+
+		b.WriteString("</BANKTRANLIST>\n")
+		fmt.Fprintf(&b, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n", formatAmount(balance), ofxDate(e.To))
+		b.WriteString("</STMTRS></STMTTRNRS>\n")
+	}
+
+	b.WriteString("</BANKMSGSRSV1>\n")
+	b.WriteString("</OFX>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write receipts export: %w", err)
+	}
+	return nil
+}
+
+// WriteQIF exports a QIF cash account with one transaction per receipt. Items are written as
+// splits by category when they add up to the receipt total.
+func (e *ReceiptExport) WriteQIF(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("!Type:Cash\n")
+	for _, receipt := range e.Receipts {
+		total := exportTotal(receipt)
+
+		fmt.Fprintf(&b, "D%s\n", exportDate(receipt).Format("01/02/2006"))
+		fmt.Fprintf(&b, "T%s\n", formatAmount(-total))
+		fmt.Fprintf(&b, "P%s\n", qifText(exportMerchant(receipt)))
+		fmt.Fprintf(&b, "M%s\n", qifText(exportMemo(receipt)))
+		fmt.Fprintf(&b, "N%s\n", receipt.Receipt.ID)
+
+		var itemsTotal float64
+		for _, item := range receipt.Items {
+			itemsTotal += item.TotalPrice
+		}
+		if len(receipt.Items) > 0 && math.Abs(itemsTotal-total) < 0.005 {
+			for _, item := range receipt.Items {
+				category := stringValue(item.UserCategory)
+				if category == "" {
+					category = UncategorizedCategory
+				}
+				fmt.Fprintf(&b, "S%s\n", qifText(category))
+				fmt.Fprintf(&b, "E%s\n", qifText(itemDescription(item)))
+				fmt.Fprintf(&b, "$%s\n", formatAmount(-item.TotalPrice))
+			}
+		}
+
+		b.WriteString("^\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write receipts export: %w", err)
+	}
+	return nil
+}
+
+// currencies returns the currencies of the exported receipts, sorted
+func (e *ReceiptExport) currencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, receipt := range e.Receipts {
+		currency := exportCurrency(receipt)
+		if !seen[currency] {
+			seen[currency] = true
+			currencies = append(currencies, currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// exportDate is the transaction date of a receipt, its upload date when none was extracted
+func exportDate(receipt *ReceiptWithItems) time.Time {
+	if receipt.Receipt.TransactionDate != nil {
+		return *receipt.Receipt.TransactionDate
+	}
+	return receipt.Receipt.CreatedAt
+}
+
+// exportTotal is the receipt total, the sum of its items when none was extracted
+func exportTotal(receipt *ReceiptWithItems) float64 {
+	if receipt.Receipt.TotalAmount != nil {
+		return *receipt.Receipt.TotalAmount
+	}
+
+	var total float64
+	for _, item := range receipt.Items {
+		total += item.TotalPrice
+	}
+	return total
+}
+
+func exportCurrency(receipt *ReceiptWithItems) string {
+	return ReceiptCurrency(receipt.Receipt.CurrencyCode)
+}
+
+func exportMerchant(receipt *ReceiptWithItems) string {
+	if receipt.Receipt.MerchantName != nil && *receipt.Receipt.MerchantName != "" {
+		return *receipt.Receipt.MerchantName
+	}
+	return receipt.Receipt.FileName
+}
+
+// exportMemo lists the item descriptions of a receipt
+func exportMemo(receipt *ReceiptWithItems) string {
+	descriptions := make([]string, 0, len(receipt.Items))
+	for _, item := range receipt.Items {
+		descriptions = append(descriptions, qifText(itemDescription(item)))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// itemDescription is the localized description of an item, the original one when not translated
+func itemDescription(item ReceiptItem) string {
+	if item.LocalizedDescription != nil && *item.LocalizedDescription != "" {
+		return *item.LocalizedDescription
+	}
+	return item.OriginalDescription
+}
+
+func formatAmount(amount float64) string {
+	if amount == 0 {
+		amount = 0 // no negative zero for receipts without a total
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatOptionalAmount(amount *float64) string {
+	if amount == nil {
+		return ""
+	}
+	return formatAmount(*amount)
+}
+
+func formatOptionalQuantity(quantity *float64) string {
+	if quantity == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*quantity, 'f', -1, 64)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func ofxDate(t time.Time) string {
+	return t.Format("20060102")
+}
+
+func ofxDateTime(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// qifText removes line breaks, every QIF field and OFX memo is one line
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,267 @@
+package receipts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTestExport returns an export of a grocery receipt in EUR with categorized items, a receipt
+// in UAH without items or total and a receipt without a currency whose items don't add up to its total
+func newTestExport() *ReceiptExport {
+	return &ReceiptExport{
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		Receipts: []*ReceiptWithItems{
+			{
+				Receipt: Receipt{
+					ID:              uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+					FileName:        "receipt.jpg",
+					MerchantName:    str("Bio & Co <Market>"),
+					TransactionDate: timePtr(time.Date(2025, 1, 5, 17, 30, 0, 0, time.UTC)),
+					CreatedAt:       time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC),
+					TotalAmount:     ptr(4.5),
+					TotalTax:        ptr(0.29),
+					CurrencyCode:    str(" eur "),
+				},
+				Items: []ReceiptItem{
+					{
+						ItemOrder:            1,
+						OriginalDescription:  "Vollmilch 3,5%\n1L",
+						LocalizedDescription: str("Whole milk"),
+						Quantity:             ptr(1),
+						QuantityUnit:         str("L"),
+						UnitPrice:            ptr(1.25),
+						TotalPrice:           1.25,
+						UserCategory:         str("dairy"),
+					},
+					{
+						ItemOrder:           2,
+						OriginalDescription: "Brot",
+						Quantity:            ptr(0.5),
+						TotalPrice:          3.25,
+					},
+				},
+			},
+			{
+				Receipt: Receipt{
+					ID:           uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+					FileName:     "чек.jpg",
+					CreatedAt:    time.Date(2025, 1, 20, 9, 0, 0, 0, time.UTC),
+					CurrencyCode: str("UAH"),
+				},
+			},
+			{
+				Receipt: Receipt{
+					ID:              uuid.MustParse("00000000-0000-0000-0000-000000000003"),
+					FileName:        "scan.png",
+					MerchantName:    str("Kiosk"),
+					TransactionDate: timePtr(time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)),
+					TotalAmount:     ptr(10),
+				},
+				Items: []ReceiptItem{
+					{ItemOrder: 1, OriginalDescription: "Coffee", TotalPrice: 2.5},
+				},
+			},
+		},
+	}
+}
+
+func TestReceiptExportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestExport().Write(&buf, ExportFormatCSV); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+
+	want := [][]string{
+		{"receipt_id", "date", "merchant", "currency", "receipt_total", "receipt_tax",
+			"item_order", "description", "original_description", "quantity", "quantity_unit",
+			"unit_price", "total_price", "category"},
+		{"00000000-0000-0000-0000-000000000001", "2025-01-05", "Bio & Co <Market>", "EUR", "4.50", "0.29",
+			"1", "Whole milk", "Vollmilch 3,5%\n1L", "1", "L", "1.25", "1.25", "dairy"},
+		{"00000000-0000-0000-0000-000000000001", "2025-01-05", "Bio & Co <Market>", "EUR", "4.50", "0.29",
+			"2", "Brot", "Brot", "0.5", "", "", "3.25", ""},
+		{"00000000-0000-0000-0000-000000000002", "2025-01-20", "чек.jpg", "UAH", "0.00", "",
+			"", "", "", "", "", "", "", ""},
+		{"00000000-0000-0000-0000-000000000003", "2025-01-31", "Kiosk", "XXX", "10.00", "",
+			"1", "Coffee", "Coffee", "", "", "", "2.50", ""},
+	}
+
+	if len(records) != len(want) {
+		t.Fatalf("export has %d rows, want %d: %q", len(records), len(want), records)
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d =\n%q\nwant\n%q", i, records[i], want[i])
+		}
+	}
+}
+
+func TestReceiptExportQIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestExport().Write(&buf, ExportFormatQIF); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"!Type:Cash",
+		"D01/05/2025",
+		"T-4.50",
+		"PBio & Co <Market>",
+		"MWhole milk, Brot",
+		"N00000000-0000-0000-0000-000000000001",
+		"Sdairy",
+		"EWhole milk",
+		"$-1.25",
+		"S" + UncategorizedCategory,
+		"EBrot",
+		"$-3.25",
+		"^",
+		"D01/20/2025",
+		"T0.00",
+		"Pчек.jpg",
+		"M",
+		"N00000000-0000-0000-0000-000000000002",
+		"^",
+		"D01/31/2025",
+		"T-10.00",
+		"PKiosk",
+		"MCoffee",
+		"N00000000-0000-0000-0000-000000000003",
+		"^",
+	}, "\n") + "\n"
+
+	if got := buf.String(); got != want {
+		t.Errorf("QIF export =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestReceiptExportOFX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newTestExport().Write(&buf, ExportFormatOFX); err != nil {
+		t.Fatal(err)
+	}
+
+	type transaction struct {
+		Type   string `xml:"TRNTYPE"`
+		Posted string `xml:"DTPOSTED"`
+		Amount string `xml:"TRNAMT"`
+		FITID  string `xml:"FITID"`
+		Name   string `xml:"NAME"`
+		Memo   string `xml:"MEMO"`
+	}
+	type statement struct {
+		Currency     string        `xml:"STMTRS>CURDEF"`
+		Account      string        `xml:"STMTRS>BANKACCTFROM>ACCTID"`
+		Start        string        `xml:"STMTRS>BANKTRANLIST>DTSTART"`
+		End          string        `xml:"STMTRS>BANKTRANLIST>DTEND"`
+		Transactions []transaction `xml:"STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance      string        `xml:"STMTRS>LEDGERBAL>BALAMT"`
+	}
+	var ofx struct {
+		Statements []statement `xml:"BANKMSGSRSV1>STMTTRNRS"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &ofx); err != nil {
+		t.Fatalf("export is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	want := []statement{
+		{
+			Currency: "EUR",
+			Account:  "RECEIPTS-EUR",
+			Start:    "20250101",
+			End:      "20250201",
+			Transactions: []transaction{
+				{"DEBIT", "20250105", "-4.50", "00000000-0000-0000-0000-000000000001", "Bio & Co <Market>", "Whole milk, Brot"},
+			},
+			Balance: "-4.50",
+		},
+		{
+			Currency: "UAH",
+			Account:  "RECEIPTS-UAH",
+			Start:    "20250101",
+			End:      "20250201",
+			Transactions: []transaction{
+				{"DEBIT", "20250120", "0.00", "00000000-0000-0000-0000-000000000002", "чек.jpg", ""},
+			},
+			Balance: "0.00",
+		},
+		{
+			Currency: UnknownCurrency,
+			Account:  "RECEIPTS-XXX",
+			Start:    "20250101",
+			End:      "20250201",
+			Transactions: []transaction{
+				{"DEBIT", "20250131", "-10.00", "00000000-0000-0000-0000-000000000003", "Kiosk", "Coffee"},
+			},
+			Balance: "-10.00",
+		},
+	}
+
+	if len(ofx.Statements) != len(want) {
+		t.Fatalf("export has %d statements, want %d", len(ofx.Statements), len(want))
+	}
+	for i := range want {
+		got := ofx.Statements[i]
+		if got.Currency != want[i].Currency || got.Account != want[i].Account || got.Start != want[i].Start ||
+			got.End != want[i].End || got.Balance != want[i].Balance {
+			t.Errorf("statement %d = %+v, want %+v", i, got, want[i])
+		}
+		if len(got.Transactions) != len(want[i].Transactions) {
+			t.Errorf("statement %d has %d transactions, want %d", i, len(got.Transactions), len(want[i].Transactions))
+			continue
+		}
+		for j := range want[i].Transactions {
+			if got.Transactions[j] != want[i].Transactions[j] {
+				t.Errorf("statement %d transaction %d = %+v, want %+v", i, j, got.Transactions[j], want[i].Transactions[j])
+			}
+		}
+	}
+}
+
+func TestReceiptExportFileName(t *testing.T) {
+	export := newTestExport()
+	if got := export.FileName(ExportFormatOFX); got != "receipts-2025-01-01-2025-01-31.ofx" {
+		t.Errorf("FileName() = %q", got)
+	}
+}
+
+func TestReceiptExportUnsupportedFormat(t *testing.T) {
+	if IsValidExportFormat("xlsx") {
+		t.Error("IsValidExportFormat(xlsx) = true")
+	}
+	if err := newTestExport().Write(&bytes.Buffer{}, "xlsx"); err == nil {
+		t.Error("Write() accepted an unsupported format")
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Kiosk", 32, "Kiosk"},
+		{"Kiosk", 5, "Kiosk"},
+		{"Kiosk", 3, "Kio"},
+		{"Сільпо", 4, "Сіль"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateRunes(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func timePtr(t time.Time) *time.Time { return &t }
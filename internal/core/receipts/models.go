@@ -11,6 +11,10 @@ import (
 type Receipt struct {
 	ID              uuid.UUID  `json:"id" db:"id"`
 	UserID          uuid.UUID  `json:"user_id" db:"user_id"`
+	FamilyID        *uuid.UUID `json:"family_id" db:"family_id"`
+	FamilyName      *string    `json:"family_name,omitempty" db:"-"`
+	PaidBy          uuid.UUID  `json:"paid_by" db:"paid_by"` // the uploader unless another member paid
+	PaidByName      string     `json:"paid_by_name" db:"-"`
 	FileURL         string     `json:"file_url" db:"file_url"`
 	FileName        string     `json:"file_name" db:"file_name"`
 	FileSize        int64      `json:"file_size" db:"file_size"`
@@ -53,6 +57,7 @@ type ReceiptItem struct {
 	LocalizedDescription *string          `json:"localized_description" db:"localized_description"`
 	UserLocale           *string          `json:"user_locale" db:"user_locale"`
 	Quantity             *float64         `json:"quantity" db:"quantity"`
+	QuantityUnit         *string          `json:"quantity_unit" db:"quantity_unit"`
 	UnitPrice            *float64         `json:"unit_price" db:"unit_price"`
 	TotalPrice           float64          `json:"total_price" db:"total_price"`
 	CurrencyCode         *string          `json:"currency_code" db:"currency_code"`
@@ -139,6 +144,7 @@ type CreateReceiptItemRequest struct {
 	LocalizedDescription *string
 	UserLocale           *string
 	Quantity             *float64
+	QuantityUnit         *string // kg, g, l, ml or pcs
 	UnitPrice            *float64
 	TotalPrice           float64
 	CurrencyCode         *string
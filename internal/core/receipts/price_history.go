@@ -0,0 +1,562 @@
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Units prices are normalized to, grams and millilitres are converted to kg and l
+const (
+	PriceUnitKg    = "kg"
+	PriceUnitLitre = "l"
+	PriceUnitPiece = "pcs"
+)
+
+// PriceHistoryFilter selects the price observations of one item
+type PriceHistoryFilter struct {
+	UserID uuid.UUID
+	Item   string    // product name, alias or receipt description to look for
+	From   time.Time // optional, inclusive
+	To     time.Time // optional, exclusive
+	Locale string    // language of the returned product name
+}
+
+// PriceHistory is the time series of unit prices paid for an item and their comparison per store
+type PriceHistory struct {
+	Item        string          `json:"item"`
+	ProductID   *uuid.UUID      `json:"product_id,omitempty"`
+	ProductName string          `json:"product_name"`
+	Points      []PricePoint    `json:"points"`
+	Merchants   []MerchantPrice `json:"merchants"` // cheapest latest price first
+}
+
+// PricePoint is the unit price of the item on one receipt
+type PricePoint struct {
+	Date        time.Time `json:"date"`
+	Merchant    string    `json:"merchant"`
+	Description string    `json:"description"`
+	Unit        string    `json:"unit"`
+	UnitPrice   float64   `json:"unit_price"`
+	Currency    string    `json:"currency"`
+	ReceiptID   uuid.UUID `json:"receipt_id"`
+}
+
+// MerchantPrice summarizes the prices of the item at one store in one unit and currency
+type MerchantPrice struct {
+	Merchant          string    `json:"merchant"`
+	Unit              string    `json:"unit"`
+	Currency          string    `json:"currency"`
+	LastPrice         float64   `json:"last_price"`
+	LastSeen          time.Time `json:"last_seen"`
+	MinPrice          float64   `json:"min_price"`
+	AvgPrice          float64   `json:"avg_price"`
+	ObservationsCount int       `json:"observations_count"`
+}
+
+// priceCatalogTTL is how long the loaded catalog of products and parsed items is reused, receipt
+// items are linked to the ones added since once it expires
+const priceCatalogTTL = 10 * time.Minute
+
+// priceHistoryBackfillBatch is the number of receipts recorded per batch of the backfill
+const priceHistoryBackfillBatch = 50
+
+// priceCatalog caches the names of products and parsed items receipt items are linked to
+type priceCatalog struct {
+	mu          sync.Mutex
+	products    []priceCatalogEntry
+	parsedItems []priceCatalogEntry
+	loadedAt    time.Time
+}
+
+// priceCatalogEntry is a product or parsed item with the normalized names it is known by
+type priceCatalogEntry struct {
+	id    uuid.UUID
+	name  string
+	terms [][]string
+}
+
+// RecordPriceHistory stores the normalized unit price of every item of the receipt and links the
+// items to products and parsed items by their original, localized and translated descriptions.
+// Recording a receipt again updates its observations. Returns the number of observations.
+func (s *Service) RecordPriceHistory(ctx context.Context, receiptID uuid.UUID) (int, error) {
+	ctx, span := tracer.Start(ctx, "receipts.RecordPriceHistory")
+	defer span.End()
+
+	query := `
+		SELECT ri.id, r.user_id, r.merchant_name, ` + receiptDateExpr + `, ` + itemCurrencyExpr + `,
+		       ri.original_description, ri.localized_description,
+		       ri.quantity, ri.quantity_unit, ri.unit_price, ri.total_price,
+		       COALESCE(array_agg(DISTINCT t.translated_text) FILTER (WHERE t.translated_text IS NOT NULL), '{}')
+		FROM receipt_items ri
+		JOIN users_receipts r ON r.id = ri.receipt_id
+		LEFT JOIN item_translations t ON t.original_text = ri.original_description
+		WHERE ri.receipt_id = $1
+		GROUP BY ri.id, r.id
+	`
+
+	rows, err := s.db.Query(ctx, query, receiptID)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to get receipt items for price history: %w", err)
+	}
+	defer rows.Close()
+
+	type observation struct {
+		receiptItemID uuid.UUID
+		userID        uuid.UUID
+		merchantName  *string
+		observedOn    time.Time
+		currency      string
+		names         []string
+		unit          string
+		unitPrice     float64
+	}
+
+	var observations []observation
+	for rows.Next() {
+		var o observation
+		var original string
+		var localized, quantityUnit *string
+		var quantity, unitPrice *float64
+		var totalPrice float64
+		var translated []string
+		if err := rows.Scan(&o.receiptItemID, &o.userID, &o.merchantName, &o.observedOn, &o.currency,
+			&original, &localized, &quantity, &quantityUnit, &unitPrice, &totalPrice, &translated); err != nil {
+			span.RecordError(err)
+			return 0, fmt.Errorf("failed to scan receipt item for price history: %w", err)
+		}
+
+		if o.currency == UnknownCurrency {
+			continue // Prices can't be compared without knowing their currency
+		}
+
+		var ok bool
+		o.unitPrice, o.unit, ok = normalizeUnitPrice(quantity, quantityUnit, unitPrice, totalPrice)
+		if !ok {
+			continue // Discounts, deposits and lines without a price
+		}
+
+		o.names = append([]string{original}, translated...)
+		if localized != nil && *localized != "" {
+			o.names = append(o.names, *localized)
+		}
+		observations = append(observations, o)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to iterate receipt items for price history: %w", err)
+	}
+
+	if len(observations) == 0 {
+		return 0, s.markPriceHistoryRecorded(ctx, receiptID)
+	}
+
+	productCatalog, parsedCatalog, err := s.loadPriceCatalog(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	for _, o := range observations {
+		terms := reconcileTerms(o.names...)
+
+		var productID, parsedItemID *uuid.UUID
+		if product := matchPriceCatalog(terms, productCatalog); product != nil {
+			productID = &product.id
+		}
+		if parsedItem := matchPriceCatalog(terms, parsedCatalog); parsedItem != nil {
+			parsedItemID = &parsedItem.id
+		}
+
+		_, err := s.db.Exec(ctx, `
+			INSERT INTO price_observations (
+				receipt_item_id, receipt_id, user_id, product_id, parsed_item_id,
+				merchant_name, observed_on, unit, unit_price, currency_code
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (receipt_item_id) DO UPDATE SET
+				product_id = EXCLUDED.product_id, parsed_item_id = EXCLUDED.parsed_item_id,
+				merchant_name = EXCLUDED.merchant_name, observed_on = EXCLUDED.observed_on,
+				unit = EXCLUDED.unit, unit_price = EXCLUDED.unit_price, currency_code = EXCLUDED.currency_code
+		`, o.receiptItemID, receiptID, o.userID, productID, parsedItemID,
+			o.merchantName, o.observedOn, o.unit, o.unitPrice, o.currency)
+		if err != nil {
+			span.RecordError(err)
+			return 0, fmt.Errorf("failed to store price observation: %w", err)
+		}
+	}
+
+	if err := s.markPriceHistoryRecorded(ctx, receiptID); err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	s.logger.Info("Price history recorded for receipt",
+		"receipt_id", receiptID,
+		"observations", len(observations))
+
+	return len(observations), nil
+}
+
+// markPriceHistoryRecorded keeps the backfill from recording the receipt again
+func (s *Service) markPriceHistoryRecorded(ctx context.Context, receiptID uuid.UUID) error {
+	_, err := s.db.Exec(ctx, `UPDATE users_receipts SET price_history_recorded_at = NOW() WHERE id = $1`, receiptID)
+	if err != nil {
+		return fmt.Errorf("failed to mark price history recorded: %w", err)
+	}
+	return nil
+}
+
+// BackfillPriceHistory records the price history of the receipts with items that were processed
+// before it existed, in batches until none are left or ctx is done. Returns the number of
+// receipts recorded.
+func (s *Service) BackfillPriceHistory(ctx context.Context) (int, error) {
+	ctx, span := tracer.Start(ctx, "receipts.BackfillPriceHistory")
+	defer span.End()
+
+	recorded := 0
+	for ctx.Err() == nil {
+		rows, err := s.db.Query(ctx, `
+			SELECT r.id
+			FROM users_receipts r
+			WHERE r.price_history_recorded_at IS NULL
+			  AND EXISTS (SELECT 1 FROM receipt_items ri WHERE ri.receipt_id = r.id)
+			ORDER BY r.created_at
+			LIMIT $1
+		`, priceHistoryBackfillBatch)
+		if err != nil {
+			span.RecordError(err)
+			return recorded, fmt.Errorf("failed to get receipts without price history: %w", err)
+		}
+
+		var receiptIDs []uuid.UUID
+		for rows.Next() {
+			var receiptID uuid.UUID
+			if err := rows.Scan(&receiptID); err != nil {
+				rows.Close()
+				span.RecordError(err)
+				return recorded, fmt.Errorf("failed to scan receipt without price history: %w", err)
+			}
+			receiptIDs = append(receiptIDs, receiptID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			span.RecordError(err)
+			return recorded, fmt.Errorf("failed to iterate receipts without price history: %w", err)
+		}
+
+		if len(receiptIDs) == 0 {
+			break
+		}
+
+		// Every receipt is marked as recorded, a failing one stops the backfill instead of being
+		// selected again and again
+		for _, receiptID := range receiptIDs {
+			if _, err := s.RecordPriceHistory(ctx, receiptID); err != nil {
+				span.RecordError(err)
+				return recorded, err
+			}
+			recorded++
+		}
+	}
+
+	return recorded, ctx.Err()
+}
+
+// GetPriceHistory returns the unit prices the user paid for an item over time and per store. The item
+// is looked up as a product or parsed item first and as a receipt description otherwise.
+func (s *Service) GetPriceHistory(ctx context.Context, filter PriceHistoryFilter) (*PriceHistory, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetPriceHistory")
+	defer span.End()
+
+	item := strings.TrimSpace(filter.Item)
+	history := &PriceHistory{
+		Item:        item,
+		ProductName: item,
+		Points:      make([]PricePoint, 0),
+		Merchants:   make([]MerchantPrice, 0),
+	}
+	if item == "" {
+		return history, nil
+	}
+
+	productCatalog, parsedCatalog, err := s.loadPriceCatalog(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	args := []interface{}{filter.UserID}
+	var itemCondition string
+	terms := reconcileTerms(item)
+	if product := findPriceCatalogEntry(terms, productCatalog); product != nil {
+		args = append(args, product.id)
+		itemCondition = "po.product_id = $2"
+		history.ProductID = &product.id
+		history.ProductName = s.productName(ctx, product.id, filter.Locale, product.name)
+	} else if parsedItem := findPriceCatalogEntry(terms, parsedCatalog); parsedItem != nil {
+		args = append(args, parsedItem.id)
+		itemCondition = "po.parsed_item_id = $2"
+		history.ProductName = parsedItem.name
+	} else {
+		args = append(args, "%"+escapeLikePattern(item)+"%")
+		itemCondition = "(ri.original_description ILIKE $2 OR ri.localized_description ILIKE $2)"
+	}
+
+	query := `
+		SELECT po.observed_on, COALESCE(NULLIF(TRIM(po.merchant_name), ''), '?'),
+		       COALESCE(ri.localized_description, ri.original_description),
+		       po.unit, po.unit_price, po.currency_code, po.receipt_id
+		FROM price_observations po
+		JOIN receipt_items ri ON ri.id = po.receipt_item_id
+		WHERE po.user_id = $1 AND ` + itemCondition
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		query += fmt.Sprintf(" AND po.observed_on >= $%d", len(args))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		query += fmt.Sprintf(" AND po.observed_on < $%d", len(args))
+	}
+	query += " ORDER BY po.observed_on, po.created_at"
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point PricePoint
+		if err := rows.Scan(&point.Date, &point.Merchant, &point.Description, &point.Unit,
+			&point.UnitPrice, &point.Currency, &point.ReceiptID); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan price observation: %w", err)
+		}
+		history.Points = append(history.Points, point)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate price observations: %w", err)
+	}
+
+	history.Merchants = summarizeMerchantPrices(history.Points)
+
+	return history, nil
+}
+
+// summarizeMerchantPrices groups chronological price points per store, unit and currency, cheapest
+// latest price first within a unit and currency
+func summarizeMerchantPrices(points []PricePoint) []MerchantPrice {
+	index := make(map[string]int)
+	merchants := make([]MerchantPrice, 0)
+	totals := make([]float64, 0)
+
+	for _, point := range points {
+		key := strings.ToLower(point.Merchant) + "|" + point.Unit + "|" + point.Currency
+		i, ok := index[key]
+		if !ok {
+			i = len(merchants)
+			index[key] = i
+			merchants = append(merchants, MerchantPrice{
+				Merchant: point.Merchant,
+				Unit:     point.Unit,
+				Currency: point.Currency,
+				MinPrice: point.UnitPrice,
+			})
+			totals = append(totals, 0)
+		}
+
+		merchant := &merchants[i]
+		merchant.LastPrice = point.UnitPrice
+		merchant.LastSeen = point.Date
+		if point.UnitPrice < merchant.MinPrice {
+			merchant.MinPrice = point.UnitPrice
+		}
+		merchant.ObservationsCount++
+		totals[i] += point.UnitPrice
+	}
+
+	for i := range merchants {
+		merchants[i].AvgPrice = totals[i] / float64(merchants[i].ObservationsCount)
+	}
+
+	// Prices are only comparable in the same unit and currency
+	sort.SliceStable(merchants, func(i, j int) bool {
+		if merchants[i].Currency != merchants[j].Currency {
+			return merchants[i].Currency < merchants[j].Currency
+		}
+		if merchants[i].Unit != merchants[j].Unit {
+			return merchants[i].Unit < merchants[j].Unit
+		}
+		return merchants[i].LastPrice < merchants[j].LastPrice
+	})
+
+	return merchants
+}
+
+// loadPriceCatalog returns the names of products and parsed items receipt items are linked to,
+// loading them again once the cached ones are older than priceCatalogTTL
+func (s *Service) loadPriceCatalog(ctx context.Context) ([]priceCatalogEntry, []priceCatalogEntry, error) {
+	s.priceCatalog.mu.Lock()
+	defer s.priceCatalog.mu.Unlock()
+
+	if !s.priceCatalog.loadedAt.IsZero() && time.Since(s.priceCatalog.loadedAt) < priceCatalogTTL {
+		return s.priceCatalog.products, s.priceCatalog.parsedItems, nil
+	}
+
+	products, parsedItems, err := s.queryPriceCatalog(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.priceCatalog.products = products
+	s.priceCatalog.parsedItems = parsedItems
+	s.priceCatalog.loadedAt = time.Now()
+
+	return products, parsedItems, nil
+}
+
+// queryPriceCatalog loads the names of all products and parsed items
+func (s *Service) queryPriceCatalog(ctx context.Context) ([]priceCatalogEntry, []priceCatalogEntry, error) {
+	rows, err := s.db.Query(ctx, `SELECT id, name_en, name_ru, name_uk, COALESCE(aliases, '{}') FROM products`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load products for price history: %w", err)
+	}
+	defer rows.Close()
+
+	var products []priceCatalogEntry
+	for rows.Next() {
+		var entry priceCatalogEntry
+		var nameRu, nameUk string
+		var aliases []string
+		if err := rows.Scan(&entry.id, &entry.name, &nameRu, &nameUk, &aliases); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan product for price history: %w", err)
+		}
+		entry.terms = reconcileTerms(append([]string{entry.name, nameRu, nameUk}, aliases...)...)
+		products = append(products, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate products for price history: %w", err)
+	}
+
+	parsedRows, err := s.db.Query(ctx, `SELECT id, standardized_name FROM parsed_items`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load parsed items for price history: %w", err)
+	}
+	defer parsedRows.Close()
+
+	var parsedItems []priceCatalogEntry
+	for parsedRows.Next() {
+		var entry priceCatalogEntry
+		if err := parsedRows.Scan(&entry.id, &entry.name); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan parsed item for price history: %w", err)
+		}
+		entry.terms = reconcileTerms(entry.name)
+		parsedItems = append(parsedItems, entry)
+	}
+	if err := parsedRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to iterate parsed items for price history: %w", err)
+	}
+
+	return products, parsedItems, nil
+}
+
+// productName returns the product name in the locale, the fallback when it can't be loaded
+func (s *Service) productName(ctx context.Context, productID uuid.UUID, locale, fallback string) string {
+	var nameEn, nameRu, nameUk string
+	err := s.db.QueryRow(ctx, `SELECT name_en, name_ru, name_uk FROM products WHERE id = $1`, productID).
+		Scan(&nameEn, &nameRu, &nameUk)
+	if err != nil {
+		return fallback
+	}
+
+	switch {
+	case locale == "ru" && nameRu != "":
+		return nameRu
+	case locale == "uk" && nameUk != "":
+		return nameUk
+	default:
+		return nameEn
+	}
+}
+
+// matchPriceCatalog links a receipt line to the entry with the most specific name whose words all
+// appear on the line, e.g. "Chicken Breast" rather than "Chicken" for "chicken breast fillet 500g"
+func matchPriceCatalog(lineTerms [][]string, catalog []priceCatalogEntry) *priceCatalogEntry {
+	var best *priceCatalogEntry
+	bestWords := 0
+	for i := range catalog {
+		for _, entryWords := range catalog[i].terms {
+			if len(entryWords) <= bestWords {
+				continue
+			}
+			for _, lineWords := range lineTerms {
+				if containsAllWords(lineWords, entryWords) {
+					best = &catalog[i]
+					bestWords = len(entryWords)
+					break
+				}
+			}
+		}
+	}
+	return best
+}
+
+// findPriceCatalogEntry resolves a question such as "milk" to the entry named exactly like it, or
+// else the most specific entry contained in it
+func findPriceCatalogEntry(queryTerms [][]string, catalog []priceCatalogEntry) *priceCatalogEntry {
+	for i := range catalog {
+		if reconcileScore(queryTerms, catalog[i].terms) == listMatchExactScore {
+			return &catalog[i]
+		}
+	}
+	return matchPriceCatalog(queryTerms, catalog)
+}
+
+// normalizeUnitPrice returns the price per kg, litre or piece of a receipt line. Lines with a zero
+// or negative total are not prices.
+func normalizeUnitPrice(quantity *float64, quantityUnit *string, unitPrice *float64, totalPrice float64) (float64, string, bool) {
+	if totalPrice <= 0 {
+		return 0, "", false
+	}
+
+	unit := PriceUnitPiece
+	if quantityUnit != nil {
+		unit = strings.ToLower(*quantityUnit)
+	}
+
+	amount := 0.0
+	if quantity != nil {
+		amount = *quantity
+	}
+	if amount <= 0 {
+		if unitPrice != nil && *unitPrice > 0 && unit == PriceUnitPiece {
+			return *unitPrice, PriceUnitPiece, true
+		}
+		amount = 1
+	}
+
+	switch unit {
+	case "g":
+		return totalPrice / (amount / 1000), PriceUnitKg, true
+	case "ml":
+		return totalPrice / (amount / 1000), PriceUnitLitre, true
+	case PriceUnitKg, PriceUnitLitre:
+		return totalPrice / amount, unit, true
+	default:
+		return totalPrice / amount, PriceUnitPiece, true
+	}
+}
+
+// escapeLikePattern escapes the wildcards of a LIKE pattern, so text matches literally
+func escapeLikePattern(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
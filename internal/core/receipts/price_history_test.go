@@ -0,0 +1,89 @@
+package receipts
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalizeUnitPrice(t *testing.T) {
+	tests := []struct {
+		name         string
+		quantity     *float64
+		quantityUnit *string
+		unitPrice    *float64
+		totalPrice   float64
+		wantPrice    float64
+		wantUnit     string
+		wantOK       bool
+	}{
+		{"piece without quantity", nil, nil, nil, 1.29, 1.29, PriceUnitPiece, true},
+		{"pieces", ptr(3.0), str("pcs"), nil, 3.00, 1.00, PriceUnitPiece, true},
+		{"unit price without quantity", nil, nil, ptr(0.99), 1.98, 0.99, PriceUnitPiece, true},
+		{"zero quantity", ptr(0.0), nil, nil, 2.50, 2.50, PriceUnitPiece, true},
+		{"kilograms", ptr(0.5), str("kg"), nil, 2.00, 4.00, PriceUnitKg, true},
+		{"grams", ptr(250), str("g"), nil, 1.50, 6.00, PriceUnitKg, true},
+		{"millilitres", ptr(500), str("ml"), nil, 0.80, 1.60, PriceUnitLitre, true},
+		{"litres upper case", ptr(2), str("L"), nil, 3.00, 1.50, PriceUnitLitre, true},
+		{"unknown unit", ptr(2), str("pack"), nil, 5.00, 2.50, PriceUnitPiece, true},
+		{"grams without quantity", nil, str("g"), nil, 1.00, 1000, PriceUnitKg, true},
+		{"discount", ptr(1), nil, nil, -0.50, 0, "", false},
+		{"free item", ptr(1), nil, nil, 0, 0, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, unit, ok := normalizeUnitPrice(tt.quantity, tt.quantityUnit, tt.unitPrice, tt.totalPrice)
+			if ok != tt.wantOK || unit != tt.wantUnit || math.Abs(price-tt.wantPrice) > 1e-9 {
+				t.Errorf("normalizeUnitPrice() = %v, %q, %v, want %v, %q, %v", price, unit, ok, tt.wantPrice, tt.wantUnit, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSummarizeMerchantPrices(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, time.UTC) }
+	points := []PricePoint{
+		{Date: day(1), Merchant: "Lidl", Unit: PriceUnitLitre, UnitPrice: 1.20, Currency: "EUR"},
+		{Date: day(2), Merchant: "REWE", Unit: PriceUnitLitre, UnitPrice: 1.40, Currency: "EUR"},
+		{Date: day(8), Merchant: "lidl", Unit: PriceUnitLitre, UnitPrice: 1.00, Currency: "EUR"},
+		{Date: day(9), Merchant: "Lidl", Unit: PriceUnitPiece, UnitPrice: 0.50, Currency: "EUR"},
+		{Date: day(10), Merchant: "Сільпо", Unit: PriceUnitLitre, UnitPrice: 45, Currency: "UAH"},
+	}
+
+	merchants := summarizeMerchantPrices(points)
+	if len(merchants) != 4 {
+		t.Fatalf("got %d merchants, want 4: %+v", len(merchants), merchants)
+	}
+
+	lidl := merchants[0]
+	if lidl.Merchant != "Lidl" || lidl.Unit != PriceUnitLitre || lidl.ObservationsCount != 2 ||
+		lidl.LastPrice != 1.00 || lidl.MinPrice != 1.00 || math.Abs(lidl.AvgPrice-1.10) > 1e-9 || !lidl.LastSeen.Equal(day(8)) {
+		t.Errorf("first merchant = %+v, want Lidl per litre with 2 observations", lidl)
+	}
+	if merchants[1].Merchant != "REWE" || merchants[2].Unit != PriceUnitPiece || merchants[3].Currency != "UAH" {
+		t.Errorf("merchants not ordered by currency, unit and last price: %+v", merchants)
+	}
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"milk", "milk"},
+		{"milk 3.5%", `milk 3.5\%`},
+		{"a_b", `a\_b`},
+		{`50\50`, `50\\50`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLikePattern(tt.text); got != tt.want {
+			t.Errorf("escapeLikePattern(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func str(v string) *string { return &v }
@@ -0,0 +1,389 @@
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/ai"
+	"github.com/google/uuid"
+)
+
+// Scores of a receipt item against a shopping list item, pairs below minListMatchScore are not proposed
+const (
+	listMatchExactScore    = 1.0  // same words
+	listMatchContainsScore = 0.85 // every word of the list item is on the receipt line, "milk" for "whole milk 1l"
+	listMatchPartialScore  = 0.7  // every word of the receipt line is in the list item, "milk" for "lactose free milk"
+	minListMatchScore      = listMatchPartialScore
+)
+
+// ListItemMatch is a receipt item proposed as the purchase of an open shopping list item
+type ListItemMatch struct {
+	ShoppingItemID  uuid.UUID `json:"shopping_item_id"`
+	ListID          uuid.UUID `json:"list_id"`
+	ListName        string    `json:"list_name"`
+	ItemName        string    `json:"item_name"`
+	ReceiptItemID   uuid.UUID `json:"receipt_item_id"`
+	ReceiptItemName string    `json:"receipt_item_name"`
+	TotalPrice      float64   `json:"total_price"`
+	CurrencyCode    string    `json:"currency_code"`
+	Score           float64   `json:"score"`
+}
+
+// ListSpending is what was paid for the bought items of a shopping list in one currency
+type ListSpending struct {
+	Currency    string  `json:"currency"`
+	ItemsCount  int     `json:"items_count"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// reconcileCandidate is a receipt item or an open list item with the normalized names it is known by
+type reconcileCandidate struct {
+	id    uuid.UUID
+	terms [][]string
+}
+
+// MatchReceiptToLists proposes which open items of the user's shopping lists the receipt paid for.
+// Receipt items are compared by their original, localized and dictionary translated descriptions,
+// list items by their name, display name and AI-parsed standardized name. Every receipt item and
+// every list item is used at most once, best scores first.
+func (s *Service) MatchReceiptToLists(ctx context.Context, receiptID, userID uuid.UUID) ([]*ListItemMatch, error) {
+	ctx, span := tracer.Start(ctx, "receipts.MatchReceiptToLists")
+	defer span.End()
+
+	// Receipt items not linked to a list item yet, with the translations known for their description
+	receiptQuery := `
+		SELECT ri.id, ri.original_description, ri.localized_description, ri.total_price,
+		       ` + itemCurrencyExpr + `,
+		       COALESCE(array_agg(DISTINCT t.translated_text) FILTER (WHERE t.translated_text IS NOT NULL), '{}')
+		FROM receipt_items ri
+		JOIN users_receipts r ON r.id = ri.receipt_id
+		LEFT JOIN item_translations t ON t.original_text = ri.original_description
+		WHERE ri.receipt_id = $1 AND r.user_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM shopping_item_purchases p WHERE p.receipt_item_id = ri.id)
+		GROUP BY ri.id, r.currency_code
+		ORDER BY ri.item_order
+	`
+
+	rows, err := s.db.Query(ctx, receiptQuery, receiptID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipt items for reconciliation: %w", err)
+	}
+	defer rows.Close()
+
+	receiptItems := make(map[uuid.UUID]*ListItemMatch)
+	var receiptCandidates []reconcileCandidate
+	for rows.Next() {
+		var item ListItemMatch
+		var localized *string
+		var translated []string
+		if err := rows.Scan(&item.ReceiptItemID, &item.ReceiptItemName, &localized, &item.TotalPrice, &item.CurrencyCode, &translated); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan receipt item for reconciliation: %w", err)
+		}
+
+		names := append([]string{item.ReceiptItemName}, translated...)
+		if localized != nil && *localized != "" {
+			names = append(names, *localized)
+			item.ReceiptItemName = *localized
+		}
+
+		receiptItems[item.ReceiptItemID] = &item
+		receiptCandidates = append(receiptCandidates, reconcileCandidate{id: item.ReceiptItemID, terms: reconcileTerms(names...)})
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate receipt items for reconciliation: %w", err)
+	}
+
+	if len(receiptCandidates) == 0 {
+		return nil, nil
+	}
+
+	// Open items of the user's own and family lists
+	listQuery := `
+		SELECT si.id, si.list_id, sl.name, si.name, si.display_name, si.parsed_name, pi.standardized_name
+		FROM shopping_items si
+		JOIN shopping_lists sl ON sl.id = si.list_id
+		LEFT JOIN parsed_items pi ON pi.id = si.parsed_item_id
+		WHERE si.is_completed = false AND sl.is_archived = false
+		  AND (sl.owner_id = $1
+		       OR sl.family_id IN (SELECT family_id FROM family_members WHERE user_id = $1))
+		ORDER BY si.created_at
+	`
+
+	listRows, err := s.db.Query(ctx, listQuery, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get open list items for reconciliation: %w", err)
+	}
+	defer listRows.Close()
+
+	listItems := make(map[uuid.UUID]*ListItemMatch)
+	var listCandidates []reconcileCandidate
+	for listRows.Next() {
+		var item ListItemMatch
+		var displayName, parsedName, standardizedName *string
+		if err := listRows.Scan(&item.ShoppingItemID, &item.ListID, &item.ListName, &item.ItemName, &displayName, &parsedName, &standardizedName); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list item for reconciliation: %w", err)
+		}
+
+		names := []string{item.ItemName}
+		for _, name := range []*string{displayName, parsedName, standardizedName} {
+			if name != nil && *name != "" {
+				names = append(names, *name)
+			}
+		}
+		if displayName != nil && *displayName != "" {
+			item.ItemName = *displayName
+		}
+
+		listItems[item.ShoppingItemID] = &item
+		listCandidates = append(listCandidates, reconcileCandidate{id: item.ShoppingItemID, terms: reconcileTerms(names...)})
+	}
+	if err := listRows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate list items for reconciliation: %w", err)
+	}
+
+	var matches []*ListItemMatch
+	for _, pair := range matchListItems(receiptCandidates, listCandidates) {
+		match := *listItems[pair.listItemID]
+		receiptItem := receiptItems[pair.receiptItemID]
+		match.ReceiptItemID = receiptItem.ReceiptItemID
+		match.ReceiptItemName = receiptItem.ReceiptItemName
+		match.TotalPrice = receiptItem.TotalPrice
+		match.CurrencyCode = receiptItem.CurrencyCode
+		match.Score = pair.score
+		matches = append(matches, &match)
+	}
+
+	s.logger.Info("Receipt reconciled with shopping lists",
+		"receipt_id", receiptID,
+		"user_id", userID,
+		"receipt_items", len(receiptCandidates),
+		"open_list_items", len(listCandidates),
+		"matches", len(matches))
+
+	return matches, nil
+}
+
+// listMatchPair is a receipt item and a list item proposed as its purchase
+type listMatchPair struct {
+	receiptItemID uuid.UUID
+	listItemID    uuid.UUID
+	score         float64
+}
+
+// matchListItems scores every receipt item against every list item and pairs them best scores
+// first, using every receipt item and every list item at most once
+func matchListItems(receiptCandidates, listCandidates []reconcileCandidate) []listMatchPair {
+	var pairs []listMatchPair
+	for _, receiptCandidate := range receiptCandidates {
+		for _, listCandidate := range listCandidates {
+			if score := reconcileScore(receiptCandidate.terms, listCandidate.terms); score >= minListMatchScore {
+				pairs = append(pairs, listMatchPair{receiptItemID: receiptCandidate.id, listItemID: listCandidate.id, score: score})
+			}
+		}
+	}
+
+	// Stable, so equal scores keep receipt order and then the older list item
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].score > pairs[j].score })
+
+	usedReceiptItems := make(map[uuid.UUID]bool)
+	usedListItems := make(map[uuid.UUID]bool)
+	var matched []listMatchPair
+	for _, pair := range pairs {
+		if usedReceiptItems[pair.receiptItemID] || usedListItems[pair.listItemID] {
+			continue
+		}
+		usedReceiptItems[pair.receiptItemID] = true
+		usedListItems[pair.listItemID] = true
+		matched = append(matched, pair)
+	}
+	return matched
+}
+
+// ConfirmListMatches marks the list items of the matches proposed by MatchReceiptToLists as bought
+// by the user and records which receipt item paid for each of them. Exactly the proposed pairs are
+// applied; pairs whose list item was completed, archived or became inaccessible in the meantime,
+// or whose receipt item was used for another list item, are skipped. Returns the applied matches.
+func (s *Service) ConfirmListMatches(ctx context.Context, receiptID, userID uuid.UUID, matches []*ListItemMatch) ([]*ListItemMatch, error) {
+	ctx, span := tracer.Start(ctx, "receipts.ConfirmListMatches")
+	defer span.End()
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	applied := make([]*ListItemMatch, 0, len(matches))
+	for _, match := range matches {
+		result, err := tx.Exec(ctx, `
+			UPDATE shopping_items si
+			SET is_completed = true, completed_by = $2, completed_at = NOW(), updated_at = NOW()
+			FROM shopping_lists sl
+			WHERE si.id = $1 AND si.list_id = $3 AND sl.id = si.list_id
+			  AND si.is_completed = false AND sl.is_archived = false
+			  AND (sl.owner_id = $2
+			       OR sl.family_id IN (SELECT family_id FROM family_members WHERE user_id = $2))
+			  AND EXISTS (
+			      SELECT 1 FROM receipt_items ri
+			      JOIN users_receipts r ON r.id = ri.receipt_id
+			      WHERE ri.id = $4 AND r.id = $5 AND r.user_id = $2)
+			  AND NOT EXISTS (SELECT 1 FROM shopping_item_purchases p WHERE p.receipt_item_id = $4)
+		`, match.ShoppingItemID, userID, match.ListID, match.ReceiptItemID, receiptID)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to complete list item %s: %w", match.ShoppingItemID, err)
+		}
+		if result.RowsAffected() == 0 {
+			continue // Completed, archived or matched by someone else meanwhile
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO shopping_item_purchases (shopping_item_id, list_id, receipt_id, receipt_item_id, match_score, confirmed_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (shopping_item_id) DO NOTHING
+		`, match.ShoppingItemID, match.ListID, receiptID, match.ReceiptItemID, match.Score, userID)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to record purchase of list item %s: %w", match.ShoppingItemID, err)
+		}
+
+		applied = append(applied, match)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if s.itemEvents != nil {
+		for _, match := range applied {
+			s.itemEvents.PublishItemCompleted(ctx, match.ShoppingItemID, userID)
+		}
+	}
+
+	s.logger.Info("Shopping list items marked as bought from receipt",
+		"receipt_id", receiptID,
+		"user_id", userID,
+		"items_count", len(applied))
+
+	return applied, nil
+}
+
+// GetListSpending returns what was paid for the bought items of a shopping list, per currency
+func (s *Service) GetListSpending(ctx context.Context, listID uuid.UUID) ([]ListSpending, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetListSpending")
+	defer span.End()
+
+	query := `
+		SELECT ` + itemCurrencyExpr + ` AS currency, COUNT(*), COALESCE(SUM(ri.total_price), 0)
+		FROM shopping_item_purchases p
+		JOIN receipt_items ri ON ri.id = p.receipt_item_id
+		JOIN users_receipts r ON r.id = p.receipt_id
+		WHERE p.list_id = $1
+		GROUP BY currency
+		ORDER BY currency
+	`
+
+	rows, err := s.db.Query(ctx, query, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list spending: %w", err)
+	}
+	defer rows.Close()
+
+	var spending []ListSpending
+	for rows.Next() {
+		var entry ListSpending
+		if err := rows.Scan(&entry.Currency, &entry.ItemsCount, &entry.TotalAmount); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list spending: %w", err)
+		}
+		spending = append(spending, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate list spending: %w", err)
+	}
+
+	return spending, nil
+}
+
+// reconcileScore returns the best score of any name of the receipt item against any name of the list item
+func reconcileScore(receiptTerms, listTerms [][]string) float64 {
+	best := 0.0
+	for _, receiptWords := range receiptTerms {
+		for _, listWords := range listTerms {
+			receiptHasList := containsAllWords(receiptWords, listWords)
+			listHasReceipt := containsAllWords(listWords, receiptWords)
+
+			score := 0.0
+			switch {
+			case receiptHasList && listHasReceipt:
+				score = listMatchExactScore
+			case receiptHasList:
+				score = listMatchContainsScore
+			case listHasReceipt:
+				score = listMatchPartialScore
+			}
+			if score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+func containsAllWords(words, subset []string) bool {
+	for _, wanted := range subset {
+		found := false
+		for _, word := range words {
+			if word == wanted {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileTerms normalizes names into stemmed words. Words without letters such as "1,5%" or
+// "500g" quantities are dropped, receipt lines carry them and list items usually don't.
+func reconcileTerms(names ...string) [][]string {
+	var terms [][]string
+	for _, name := range names {
+		name = strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+
+		var words []string
+		for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if len([]rune(word)) < 2 || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+				continue
+			}
+			words = append(words, ai.StemWord(word))
+		}
+
+		if len(words) > 0 {
+			terms = append(terms, words)
+		}
+	}
+	return terms
+}
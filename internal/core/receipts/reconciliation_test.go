@@ -0,0 +1,120 @@
+package receipts
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestReconcileTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  [][]string
+	}{
+		{"single word", []string{"Milk"}, [][]string{{"milk"}}},
+		{"plural stems to singular", []string{"Apples"}, [][]string{{"appl"}}},
+		{"quantities and percentages dropped", []string{"Vollmilch 3,5% 1L"}, [][]string{{"vollmilch"}}},
+		{"cyrillic and ё", []string{"Молоко ЙОГУРТ ёжик"}, [][]string{{"молок", "йогурт", "ежик"}}},
+		{"one letter words dropped", []string{"a b c"}, nil},
+		{"one term per name", []string{"whole milk", "", "молоко"}, [][]string{{"whol", "milk"}, {"молок"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reconcileTerms(tt.names...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reconcileTerms(%q) = %q, want %q", tt.names, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		receiptItem []string
+		listItem    []string
+		want        float64
+	}{
+		{"same words", []string{"Milk"}, []string{"milk"}, listMatchExactScore},
+		{"same words in another order", []string{"Milk whole"}, []string{"whole milk"}, listMatchExactScore},
+		{"inflected", []string{"Tomatoes"}, []string{"tomato"}, listMatchExactScore},
+		{"list item on the receipt line", []string{"Whole milk 1L"}, []string{"milk"}, listMatchContainsScore},
+		{"receipt line in the list item", []string{"Milk"}, []string{"lactose free milk"}, listMatchPartialScore},
+		{"no common words", []string{"Bread"}, []string{"milk"}, 0},
+		{"only some words in common", []string{"Whole milk"}, []string{"milk chocolate"}, 0},
+		{"translation of the receipt item", []string{"Vollmilch", "whole milk"}, []string{"whole milk"}, listMatchExactScore},
+		{"best of the list item names", []string{"Молоко"}, []string{"milk", "молоко 2.5%"}, listMatchExactScore},
+		{"no words", []string{"1,5"}, []string{"milk"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reconcileScore(reconcileTerms(tt.receiptItem...), reconcileTerms(tt.listItem...))
+			if got != tt.want {
+				t.Errorf("reconcileScore(%q, %q) = %v, want %v", tt.receiptItem, tt.listItem, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchListItems(t *testing.T) {
+	milkLine, wholeMilkLine, breadLine := uuid.New(), uuid.New(), uuid.New()
+	milk, lactoseFreeMilk, bread, eggs := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	candidate := func(id uuid.UUID, names ...string) reconcileCandidate {
+		return reconcileCandidate{id: id, terms: reconcileTerms(names...)}
+	}
+
+	tests := []struct {
+		name         string
+		receiptItems []reconcileCandidate
+		listItems    []reconcileCandidate
+		want         []listMatchPair
+	}{
+		{
+			name:         "nothing to match",
+			receiptItems: []reconcileCandidate{candidate(breadLine, "Bread")},
+			listItems:    []reconcileCandidate{candidate(eggs, "eggs")},
+		},
+		{
+			// "Whole milk 1L" contains "milk" as well, the exact line takes it first and the whole
+			// milk line has no other list item it contains or is contained in
+			name:         "best score first, each item used once",
+			receiptItems: []reconcileCandidate{candidate(wholeMilkLine, "Whole milk 1L"), candidate(milkLine, "Milk")},
+			listItems:    []reconcileCandidate{candidate(lactoseFreeMilk, "lactose free milk"), candidate(milk, "milk")},
+			want:         []listMatchPair{{receiptItemID: milkLine, listItemID: milk, score: listMatchExactScore}},
+		},
+		{
+			name:         "lower scores pair the rest",
+			receiptItems: []reconcileCandidate{candidate(milkLine, "Milk"), candidate(wholeMilkLine, "Milk"), candidate(breadLine, "Bread rolls")},
+			listItems:    []reconcileCandidate{candidate(lactoseFreeMilk, "lactose free milk"), candidate(milk, "milk"), candidate(bread, "bread")},
+			want: []listMatchPair{
+				{receiptItemID: milkLine, listItemID: milk, score: listMatchExactScore},
+				{receiptItemID: breadLine, listItemID: bread, score: listMatchContainsScore},
+				{receiptItemID: wholeMilkLine, listItemID: lactoseFreeMilk, score: listMatchPartialScore},
+			},
+		},
+		{
+			name:         "equal scores keep the older list item",
+			receiptItems: []reconcileCandidate{candidate(breadLine, "Bread")},
+			listItems:    []reconcileCandidate{candidate(bread, "bread"), candidate(eggs, "bread")},
+			want:         []listMatchPair{{receiptItemID: breadLine, listItemID: bread, score: listMatchExactScore}},
+		},
+		{
+			name:         "equal scores keep the receipt order",
+			receiptItems: []reconcileCandidate{candidate(milkLine, "Bread"), candidate(breadLine, "Bread")},
+			listItems:    []reconcileCandidate{candidate(bread, "bread")},
+			want:         []listMatchPair{{receiptItemID: milkLine, listItemID: bread, score: listMatchExactScore}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchListItems(tt.receiptItems, tt.listItems); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchListItems() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,8 @@ package receipts
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/jackc/pgx/v5"
 	"log/slog"
 	netURL "net/url"
 	"strings"
@@ -42,9 +42,17 @@ type Service struct {
 	cloudService       *cloud.Service
 	aiService          *ai.Service
 	translationService *translations.TranslationService
+	itemEvents         ItemEventPublisher
+	priceCatalog       priceCatalog
 	logger             *slog.Logger
 }
 
+// ItemEventPublisher notifies the subscribers of shopping lists about items the receipts service
+// checked off, it is implemented by the shopping service
+type ItemEventPublisher interface {
+	PublishItemCompleted(ctx context.Context, itemID, completedBy uuid.UUID)
+}
+
 func NewService(db *pgxpool.Pool, cloudService *cloud.Service, aiService *ai.Service, logger *slog.Logger) *Service {
 	aiAdapter := &aiServiceAdapter{aiService: aiService}
 	return &Service{
@@ -56,6 +64,11 @@ func NewService(db *pgxpool.Pool, cloudService *cloud.Service, aiService *ai.Ser
 	}
 }
 
+// SetItemEventPublisher sets where the list items checked off from receipts are announced
+func (s *Service) SetItemEventPublisher(publisher ItemEventPublisher) {
+	s.itemEvents = publisher
+}
+
 func (s *Service) CreateReceipt(ctx context.Context, req CreateReceiptRequest) (*Receipt, error) {
 	ctx, span := tracer.Start(ctx, "receipts.CreateReceipt")
 	defer span.End()
@@ -90,7 +103,7 @@ func (s *Service) CreateReceipt(ctx context.Context, req CreateReceiptRequest) (
 	}()
 
 	go func() {
-		receiptData, err := s.aiService.DocumentIntelligence.AnalyzeReceipt(ctx, req.FileData, req.ContentType)
+		receiptData, err := s.aiService.ReceiptExtractor.AnalyzeReceipt(ctx, req.FileData, req.ContentType)
 		if err != nil {
 			processErrChan <- err
 			return
@@ -181,6 +194,7 @@ func (s *Service) CreateReceipt(ctx context.Context, req CreateReceiptRequest) (
 	receipt := &Receipt{
 		ID:             uuid.New(),
 		UserID:         req.UserID,
+		PaidBy:         req.UserID,
 		FileURL:        fileURL,
 		FileName:       req.FileName,
 		FileSize:       req.FileSize,
@@ -280,6 +294,7 @@ func (s *Service) CreateReceipt(ctx context.Context, req CreateReceiptRequest) (
 				"item_index", i,
 				"name", item.Name,
 				"quantity", item.Quantity,
+				"quantity_unit", item.QuantityUnit,
 				"price", item.Price,
 				"total_price", item.TotalPrice,
 				"category", item.Category,
@@ -359,6 +374,12 @@ func (s *Service) CreateReceipt(ctx context.Context, req CreateReceiptRequest) (
 				"items_count", len(receiptData.Items),
 				"translations_count", len(receiptData.ItemTranslations))
 		}
+
+		if _, err := s.RecordPriceHistory(ctx, receipt.ID); err != nil {
+			s.logger.Error("Failed to record price history",
+				"error", err,
+				"receipt_id", receipt.ID)
+		}
 	}
 
 	s.logger.Info("Receipt uploaded successfully",
@@ -416,7 +437,7 @@ func (s *Service) ProcessReceipt(ctx context.Context, receiptID uuid.UUID) error
 	}
 
 	// Process with Document Intelligence
-	receiptData, err := s.aiService.DocumentIntelligence.AnalyzeReceipt(ctx, fileData, receipt.ContentType)
+	receiptData, err := s.aiService.ReceiptExtractor.AnalyzeReceipt(ctx, fileData, receipt.ContentType)
 	if err != nil {
 		span.RecordError(err)
 		s.logger.Error("Failed to process receipt with AI",
@@ -485,6 +506,12 @@ func (s *Service) ProcessReceipt(ctx context.Context, receiptID uuid.UUID) error
 		// Don't fail the entire operation, just log the error
 	}
 
+	if _, err := s.RecordPriceHistory(ctx, receiptID); err != nil {
+		s.logger.Error("Failed to record price history",
+			"error", err,
+			"receipt_id", receiptID)
+	}
+
 	s.logger.Info("Receipt processed successfully",
 		"receipt_id", receiptID,
 		"merchant", receiptData.MerchantName,
@@ -500,37 +527,18 @@ func (s *Service) GetReceipt(ctx context.Context, id uuid.UUID) (*Receipt, error
 	defer span.End()
 
 	query := `
-		SELECT id, user_id, file_url, file_name, file_size, content_type,
-		       telegram_file_id, processed, processing_error, merchant_name,
-		       total_amount, transaction_date, items_count, created_at, updated_at,
-		       merchant_address, merchant_phone, country_region, transaction_time,
-		       receipt_type, currency_code, total_tax, net_amount, ai_confidence,
-		       extraction_model_version, raw_ai_response, detected_language,
-		       content_locale, auto_translation_enabled, last_translation_update
-		FROM users_receipts
-		WHERE id = $1
+		SELECT ` + receiptColumns + `
+		FROM users_receipts r
+		WHERE r.id = $1
 	`
 
-	var receipt Receipt
-	err := s.db.QueryRow(ctx, query, id).Scan(
-		&receipt.ID, &receipt.UserID, &receipt.FileURL, &receipt.FileName,
-		&receipt.FileSize, &receipt.ContentType, &receipt.TelegramFileID,
-		&receipt.Processed, &receipt.ProcessingError, &receipt.MerchantName,
-		&receipt.TotalAmount, &receipt.TransactionDate, &receipt.ItemsCount,
-		&receipt.CreatedAt, &receipt.UpdatedAt, &receipt.MerchantAddress,
-		&receipt.MerchantPhone, &receipt.CountryRegion, &receipt.TransactionTime,
-		&receipt.ReceiptType, &receipt.CurrencyCode, &receipt.TotalTax,
-		&receipt.NetAmount, &receipt.AIConfidence, &receipt.ExtractionModelVersion,
-		&receipt.RawAIResponse, &receipt.DetectedLanguage, &receipt.ContentLocale,
-		&receipt.AutoTranslationEnabled, &receipt.LastTranslationUpdate,
-	)
-
+	receipt, err := scanReceipt(s.db.QueryRow(ctx, query, id))
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("failed to get receipt: %w", err)
 	}
 
-	return &receipt, nil
+	return receipt, nil
 }
 
 // GetUserReceipts retrieves all receipts for a user
@@ -539,16 +547,10 @@ func (s *Service) GetUserReceipts(ctx context.Context, userID uuid.UUID, limit,
 	defer span.End()
 
 	query := `
-		SELECT id, user_id, file_url, file_name, file_size, content_type,
-		       telegram_file_id, processed, processing_error, merchant_name,
-		       total_amount, transaction_date, items_count, created_at, updated_at,
-		       merchant_address, merchant_phone, country_region, transaction_time,
-		       receipt_type, currency_code, total_tax, net_amount, ai_confidence,
-		       extraction_model_version, raw_ai_response, detected_language,
-		       content_locale, auto_translation_enabled, last_translation_update
-		FROM users_receipts
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT ` + receiptColumns + `
+		FROM users_receipts r
+		WHERE r.user_id = $1
+		ORDER BY r.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
 
@@ -561,24 +563,12 @@ func (s *Service) GetUserReceipts(ctx context.Context, userID uuid.UUID, limit,
 
 	var receipts []*Receipt
 	for rows.Next() {
-		var receipt Receipt
-		err := rows.Scan(
-			&receipt.ID, &receipt.UserID, &receipt.FileURL, &receipt.FileName,
-			&receipt.FileSize, &receipt.ContentType, &receipt.TelegramFileID,
-			&receipt.Processed, &receipt.ProcessingError, &receipt.MerchantName,
-			&receipt.TotalAmount, &receipt.TransactionDate, &receipt.ItemsCount,
-			&receipt.CreatedAt, &receipt.UpdatedAt, &receipt.MerchantAddress,
-			&receipt.MerchantPhone, &receipt.CountryRegion, &receipt.TransactionTime,
-			&receipt.ReceiptType, &receipt.CurrencyCode, &receipt.TotalTax,
-			&receipt.NetAmount, &receipt.AIConfidence, &receipt.ExtractionModelVersion,
-			&receipt.RawAIResponse, &receipt.DetectedLanguage, &receipt.ContentLocale,
-			&receipt.AutoTranslationEnabled, &receipt.LastTranslationUpdate,
-		)
+		receipt, err := scanReceipt(rows)
 		if err != nil {
 			span.RecordError(err)
 			return nil, fmt.Errorf("failed to scan receipt: %w", err)
 		}
-		receipts = append(receipts, &receipt)
+		receipts = append(receipts, receipt)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -694,8 +684,13 @@ func (s *Service) getFileExtension(contentType string) string {
 	}
 }
 
-// extractFileIDFromURL extracts the file ID from an Azure Blob Storage URL
+// extractFileIDFromURL extracts the file ID from a cloud storage URL, falling back to the
+// Azure Blob Storage layout when the provider doesn't resolve it
 func (s *Service) extractFileIDFromURL(url string) (string, error) {
+	if fileID, ok := s.cloudService.FileIDFromURL(url); ok {
+		return fileID, nil
+	}
+
 	// Azure blob URLs typically look like:
 	// https://accountname.blob.core.windows.net/container/path/to/file.ext
 	// We need to extract "path/to/file.ext" as the file ID
@@ -739,6 +734,7 @@ func (s *Service) CreateReceiptItem(ctx context.Context, req CreateReceiptItemRe
 		LocalizedDescription: req.LocalizedDescription,
 		UserLocale:           req.UserLocale,
 		Quantity:             req.Quantity,
+		QuantityUnit:         req.QuantityUnit,
 		UnitPrice:            req.UnitPrice,
 		TotalPrice:           req.TotalPrice,
 		CurrencyCode:         req.CurrencyCode,
@@ -754,12 +750,12 @@ func (s *Service) CreateReceiptItem(ctx context.Context, req CreateReceiptItemRe
 	query := `
 		INSERT INTO receipt_items (
 			id, receipt_id, item_order, original_description, original_language,
-			localized_description, user_locale, quantity, unit_price, total_price,
+			localized_description, user_locale, quantity, quantity_unit, unit_price, total_price,
 			currency_code, user_category, user_notes, is_user_modified,
 			confidence, bounding_regions, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING id, receipt_id, item_order, original_description, original_language,
-		         localized_description, user_locale, quantity, unit_price, total_price,
+		         localized_description, user_locale, quantity, quantity_unit, unit_price, total_price,
 		         currency_code, user_category, user_notes, is_user_modified,
 		         confidence, bounding_regions, created_at, updated_at
 	`
@@ -767,13 +763,13 @@ func (s *Service) CreateReceiptItem(ctx context.Context, req CreateReceiptItemRe
 	err := s.db.QueryRow(ctx, query,
 		item.ID, item.ReceiptID, item.ItemOrder, item.OriginalDescription,
 		item.OriginalLanguage, item.LocalizedDescription, item.UserLocale,
-		item.Quantity, item.UnitPrice, item.TotalPrice, item.CurrencyCode,
+		item.Quantity, item.QuantityUnit, item.UnitPrice, item.TotalPrice, item.CurrencyCode,
 		item.UserCategory, item.UserNotes, item.IsUserModified,
 		item.Confidence, item.BoundingRegions, item.CreatedAt, item.UpdatedAt,
 	).Scan(
 		&item.ID, &item.ReceiptID, &item.ItemOrder, &item.OriginalDescription,
 		&item.OriginalLanguage, &item.LocalizedDescription, &item.UserLocale,
-		&item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
+		&item.Quantity, &item.QuantityUnit, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
 		&item.UserCategory, &item.UserNotes, &item.IsUserModified,
 		&item.Confidence, &item.BoundingRegions, &item.CreatedAt, &item.UpdatedAt,
 	)
@@ -797,7 +793,7 @@ func (s *Service) GetReceiptItems(ctx context.Context, receiptID uuid.UUID) ([]*
 
 	query := `
 		SELECT id, receipt_id, item_order, original_description, original_language,
-		       localized_description, user_locale, quantity, unit_price, total_price,
+		       localized_description, user_locale, quantity, quantity_unit, unit_price, total_price,
 		       currency_code, user_category, user_notes, is_user_modified,
 		       confidence, bounding_regions, created_at, updated_at
 		FROM receipt_items
@@ -818,7 +814,7 @@ func (s *Service) GetReceiptItems(ctx context.Context, receiptID uuid.UUID) ([]*
 		err := rows.Scan(
 			&item.ID, &item.ReceiptID, &item.ItemOrder, &item.OriginalDescription,
 			&item.OriginalLanguage, &item.LocalizedDescription, &item.UserLocale,
-			&item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
+			&item.Quantity, &item.QuantityUnit, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
 			&item.UserCategory, &item.UserNotes, &item.IsUserModified,
 			&item.Confidence, &item.BoundingRegions, &item.CreatedAt, &item.UpdatedAt,
 		)
@@ -1009,17 +1005,13 @@ func (s *Service) processReceiptItems(ctx context.Context, receiptID uuid.UUID,
 			ItemOrder:           i + 1,
 			OriginalDescription: item.Name,
 			OriginalLanguage:    &detectedLang,
-			Quantity:            &item.Price, // Use price as quantity for now, could be enhanced
 			UnitPrice:           &item.Price,
 			TotalPrice:          item.TotalPrice,
 			CurrencyCode:        nil, // Will be set from receipt level
 		}
 
-		// Set quantity properly if available
-		if item.Quantity > 0 {
-			qty := float64(item.Quantity)
-			itemReq.Quantity = &qty
-		}
+		// Set quantity and its unit if available
+		itemReq.Quantity, itemReq.QuantityUnit = receiptItemQuantity(item)
 
 		// Set confidence based on item name length and content
 		confidence := s.calculateItemConfidence(item.Name)
@@ -1055,6 +1047,21 @@ func (s *Service) processReceiptItems(ctx context.Context, receiptID uuid.UUID,
 	return nil
 }
 
+// receiptItemQuantity returns the quantity and unit of an extracted item, nil when they were not recognized
+func receiptItemQuantity(item ai.ReceiptItem) (*float64, *string) {
+	var quantity *float64
+	var unit *string
+	if item.Quantity > 0 {
+		q := item.Quantity
+		quantity = &q
+	}
+	if item.QuantityUnit != "" {
+		u := item.QuantityUnit
+		unit = &u
+	}
+	return quantity, unit
+}
+
 // calculateItemConfidence calculates confidence score for an item based on its characteristics
 func (s *Service) calculateItemConfidence(itemName string) float64 {
 	// Simple heuristic for confidence calculation
@@ -1164,11 +1171,11 @@ func (s *Service) storeReceiptItemsWithTranslations(ctx context.Context, receipt
 			OriginalLanguage:     originalLang,
 			LocalizedDescription: localizedDesc,
 			UserLocale:           finalLocale,
-			Quantity:             func() *float64 { q := float64(item.Quantity); return &q }(),
 			UnitPrice:            &item.Price,
 			TotalPrice:           item.TotalPrice,
 			Confidence:           &confidence,
 		}
+		createReq.Quantity, createReq.QuantityUnit = receiptItemQuantity(item)
 
 		// Set category if available
 		if item.Category != "" {
@@ -1295,11 +1302,11 @@ func (s *Service) storeReceiptItems(ctx context.Context, receiptID uuid.UUID, us
 			OriginalLanguage:     &originalLang,
 			LocalizedDescription: localizedDesc,
 			UserLocale:           finalLocale,
-			Quantity:             func() *float64 { q := float64(item.Quantity); return &q }(),
 			UnitPrice:            &item.Price,
 			TotalPrice:           item.TotalPrice,
 			Confidence:           &confidence,
 		}
+		createReq.Quantity, createReq.QuantityUnit = receiptItemQuantity(item)
 
 		// Set currency if available
 		if item.Category != "" {
@@ -1454,44 +1461,20 @@ func (s *Service) GetReceiptWithItems(ctx context.Context, receiptID uuid.UUID,
 	ctx, span := tracer.Start(ctx, "receipts.GetReceiptWithItems")
 	defer span.End()
 
-	// First get the receipt
-	var receipt Receipt
-	query := `
-		SELECT id, user_id, file_url, file_name, file_size, content_type,
-		       telegram_file_id, processed, processing_error, merchant_name,
-		       total_amount, transaction_date, items_count, created_at, updated_at,
-		       merchant_address, merchant_phone, country_region, transaction_time,
-		       receipt_type, currency_code, total_tax, net_amount, ai_confidence,
-		       extraction_model_version, raw_ai_response, detected_language, content_locale,
-		       auto_translation_enabled, last_translation_update
-		FROM users_receipts
-		WHERE id = $1 AND user_id = $2`
-
-	err := s.db.QueryRow(ctx, query, receiptID, userID).Scan(
-		&receipt.ID, &receipt.UserID, &receipt.FileURL, &receipt.FileName,
-		&receipt.FileSize, &receipt.ContentType, &receipt.TelegramFileID,
-		&receipt.Processed, &receipt.ProcessingError, &receipt.MerchantName,
-		&receipt.TotalAmount, &receipt.TransactionDate, &receipt.ItemsCount,
-		&receipt.CreatedAt, &receipt.UpdatedAt, &receipt.MerchantAddress,
-		&receipt.MerchantPhone, &receipt.CountryRegion, &receipt.TransactionTime,
-		&receipt.ReceiptType, &receipt.CurrencyCode, &receipt.TotalTax,
-		&receipt.NetAmount, &receipt.AIConfidence, &receipt.ExtractionModelVersion,
-		&receipt.RawAIResponse, &receipt.DetectedLanguage, &receipt.ContentLocale,
-		&receipt.AutoTranslationEnabled, &receipt.LastTranslationUpdate)
-
+	// First get the receipt, the uploader and the members of the family it is shared with can see it
+	receipt, err := s.getAccessibleReceipt(ctx, receiptID, userID)
 	if err != nil {
 		span.RecordError(err)
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("receipt not found")
+		if !errors.Is(err, ErrReceiptNotFound) {
+			s.logger.Error("Failed to get receipt", "error", err, "receipt_id", receiptID, "user_id", userID)
 		}
-		s.logger.Error("Failed to get receipt", "error", err, "receipt_id", receiptID, "user_id", userID)
-		return nil, fmt.Errorf("failed to get receipt: %w", err)
+		return nil, err
 	}
 
 	// Get the receipt items
 	itemsQuery := `
 		SELECT id, receipt_id, item_order, original_description, original_language,
-		       localized_description, user_locale, quantity, unit_price, total_price,
+		       localized_description, user_locale, quantity, quantity_unit, unit_price, total_price,
 		       currency_code, user_category, user_notes, is_user_modified, confidence,
 		       bounding_regions, created_at, updated_at
 		FROM receipt_items
@@ -1512,7 +1495,7 @@ func (s *Service) GetReceiptWithItems(ctx context.Context, receiptID uuid.UUID,
 		err := rows.Scan(
 			&item.ID, &item.ReceiptID, &item.ItemOrder, &item.OriginalDescription,
 			&item.OriginalLanguage, &item.LocalizedDescription, &item.UserLocale,
-			&item.Quantity, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
+			&item.Quantity, &item.QuantityUnit, &item.UnitPrice, &item.TotalPrice, &item.CurrencyCode,
 			&item.UserCategory, &item.UserNotes, &item.IsUserModified, &item.Confidence,
 			&item.BoundingRegions, &item.CreatedAt, &item.UpdatedAt)
 		if err != nil {
@@ -1524,7 +1507,7 @@ func (s *Service) GetReceiptWithItems(ctx context.Context, receiptID uuid.UUID,
 	}
 
 	return &ReceiptWithItems{
-		Receipt: receipt,
+		Receipt: *receipt,
 		Items:   items,
 	}, nil
 }
@@ -0,0 +1,248 @@
+package receipts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrReceiptNotFound = errors.New("receipt not found")
+	ErrReceiptNotOwned = errors.New("only the uploader can change who a receipt is shared with")
+	ErrNotFamilyMember = errors.New("user is not a member of the family")
+)
+
+// receiptColumns are the users_receipts r columns read by scanReceipt
+const receiptColumns = `r.id, r.user_id, r.family_id, (SELECT name FROM families WHERE id = r.family_id),
+		       COALESCE(r.paid_by, r.user_id),
+		       (SELECT first_name FROM users WHERE id = COALESCE(r.paid_by, r.user_id)),
+		       r.file_url, r.file_name, r.file_size, r.content_type,
+		       r.telegram_file_id, r.processed, r.processing_error, r.merchant_name,
+		       r.total_amount, r.transaction_date, r.items_count, r.created_at, r.updated_at,
+		       r.merchant_address, r.merchant_phone, r.country_region, r.transaction_time,
+		       r.receipt_type, r.currency_code, r.total_tax, r.net_amount, r.ai_confidence,
+		       r.extraction_model_version, r.raw_ai_response, r.detected_language,
+		       r.content_locale, r.auto_translation_enabled, r.last_translation_update`
+
+// receiptAccessClause restricts users_receipts r to the receipts the user $2 can see: their own
+// and the ones shared with their families
+const receiptAccessClause = `(r.user_id = $2 OR r.family_id IN (SELECT family_id FROM family_members WHERE user_id = $2))`
+
+// ReceiptsFilter selects the receipts listed for a user
+type ReceiptsFilter struct {
+	UserID   uuid.UUID  // the user listing the receipts
+	FamilyID *uuid.UUID // receipts shared with this family of the user instead of the user's own receipts
+	PaidBy   *uuid.UUID // optional, only the receipts this user paid
+	Limit    int
+	Offset   int
+}
+
+// scanReceipt reads a row selected with receiptColumns
+func scanReceipt(row pgx.Row) (*Receipt, error) {
+	var receipt Receipt
+	var paidByName *string
+	err := row.Scan(
+		&receipt.ID, &receipt.UserID, &receipt.FamilyID, &receipt.FamilyName,
+		&receipt.PaidBy, &paidByName, &receipt.FileURL, &receipt.FileName,
+		&receipt.FileSize, &receipt.ContentType, &receipt.TelegramFileID,
+		&receipt.Processed, &receipt.ProcessingError, &receipt.MerchantName,
+		&receipt.TotalAmount, &receipt.TransactionDate, &receipt.ItemsCount,
+		&receipt.CreatedAt, &receipt.UpdatedAt, &receipt.MerchantAddress,
+		&receipt.MerchantPhone, &receipt.CountryRegion, &receipt.TransactionTime,
+		&receipt.ReceiptType, &receipt.CurrencyCode, &receipt.TotalTax,
+		&receipt.NetAmount, &receipt.AIConfidence, &receipt.ExtractionModelVersion,
+		&receipt.RawAIResponse, &receipt.DetectedLanguage, &receipt.ContentLocale,
+		&receipt.AutoTranslationEnabled, &receipt.LastTranslationUpdate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if paidByName != nil {
+		receipt.PaidByName = *paidByName
+	}
+
+	return &receipt, nil
+}
+
+// ListReceipts returns the user's own receipts or the receipts shared with one of the user's
+// families, newest first, together with the number of receipts matching the filter
+func (s *Service) ListReceipts(ctx context.Context, filter ReceiptsFilter) ([]*Receipt, int, error) {
+	ctx, span := tracer.Start(ctx, "receipts.ListReceipts")
+	defer span.End()
+
+	where := `
+		WHERE (($2::uuid IS NULL AND r.user_id = $1)
+		    OR ($2::uuid IS NOT NULL AND r.family_id = $2
+		        AND EXISTS (SELECT 1 FROM family_members fm WHERE fm.family_id = $2 AND fm.user_id = $1)))
+		  AND ($3::uuid IS NULL OR COALESCE(r.paid_by, r.user_id) = $3)`
+
+	var total int
+	err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM users_receipts r`+where,
+		filter.UserID, filter.FamilyID, filter.PaidBy).Scan(&total)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to count receipts: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+receiptColumns+`
+		FROM users_receipts r`+where+`
+		ORDER BY r.created_at DESC
+		LIMIT $4 OFFSET $5`,
+		filter.UserID, filter.FamilyID, filter.PaidBy, filter.Limit, filter.Offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to list receipts: %w", err)
+	}
+	defer rows.Close()
+
+	receipts := []*Receipt{}
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, 0, fmt.Errorf("failed to scan receipt: %w", err)
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to iterate receipts: %w", err)
+	}
+
+	return receipts, total, nil
+}
+
+// ShareReceipt shares a receipt with a family of its uploader, or makes it private again when
+// familyID is nil. The payer is reset to the uploader as they may not be in the new family, and
+// the split among the members of the previous family is forgotten.
+func (s *Service) ShareReceipt(ctx context.Context, receiptID, userID uuid.UUID, familyID *uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "receipts.ShareReceipt")
+	defer span.End()
+
+	receipt, err := s.getAccessibleReceipt(ctx, receiptID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if receipt.UserID != userID {
+		return ErrReceiptNotOwned
+	}
+
+	if familyID != nil {
+		isMember, err := s.isFamilyMember(ctx, *familyID, userID)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if !isMember {
+			return ErrNotFamilyMember
+		}
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE users_receipts
+		SET family_id = $2, paid_by = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, receiptID, familyID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to share receipt: %w", err)
+	}
+
+	// The split was among the members of the previous family
+	if receipt.FamilyID != nil && (familyID == nil || *familyID != *receipt.FamilyID) {
+		if err := clearReceiptSplit(ctx, tx, receiptID); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetReceiptPayer records who paid for a receipt: its uploader or a member of the family it is
+// shared with. Everyone who can see the receipt can change it.
+func (s *Service) SetReceiptPayer(ctx context.Context, receiptID, userID, payerID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "receipts.SetReceiptPayer")
+	defer span.End()
+
+	receipt, err := s.getAccessibleReceipt(ctx, receiptID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if payerID != receipt.UserID {
+		if receipt.FamilyID == nil {
+			return ErrNotFamilyMember
+		}
+		isMember, err := s.isFamilyMember(ctx, *receipt.FamilyID, payerID)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if !isMember {
+			return ErrNotFamilyMember
+		}
+	}
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE users_receipts
+		SET paid_by = NULLIF($2, user_id), updated_at = NOW()
+		WHERE id = $1
+	`, receiptID, payerID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to set receipt payer: %w", err)
+	}
+
+	return nil
+}
+
+// getAccessibleReceipt loads a receipt the user can see, ErrReceiptNotFound otherwise
+func (s *Service) getAccessibleReceipt(ctx context.Context, receiptID, userID uuid.UUID) (*Receipt, error) {
+	query := `
+		SELECT ` + receiptColumns + `
+		FROM users_receipts r
+		WHERE r.id = $1 AND ` + receiptAccessClause
+
+	receipt, err := scanReceipt(s.db.QueryRow(ctx, query, receiptID, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrReceiptNotFound
+		}
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+
+	return receipt, nil
+}
+
+// isFamilyMember reports whether the user belongs to the family
+func (s *Service) isFamilyMember(ctx context.Context, familyID, userID uuid.UUID) (bool, error) {
+	var isMember bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM family_members WHERE family_id = $1 AND user_id = $2)
+	`, familyID, userID).Scan(&isMember)
+	if err != nil {
+		return false, fmt.Errorf("failed to check family membership: %w", err)
+	}
+
+	return isMember, nil
+}
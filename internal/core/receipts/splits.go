@@ -0,0 +1,727 @@
+package receipts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrReceiptNotShared    = errors.New("receipt is not shared with a family")
+	ErrInvalidSettlement   = errors.New("invalid settlement")
+	ErrReceiptItemNotFound = errors.New("receipt item not found")
+)
+
+// SplitMember is a member of the family of a receipt, Included when they share its cost
+type SplitMember struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Included bool      `json:"included"`
+}
+
+// ItemSplit lists the members sharing the cost of one receipt item
+type ItemSplit struct {
+	*ReceiptItem
+	Members []*SplitMember `json:"members"`
+}
+
+// IncludedCount is the number of members sharing the item, none means the item is part of the receipt split
+func (s *ItemSplit) IncludedCount() int {
+	return countIncluded(s.Members)
+}
+
+// MemberShare is what each member sharing the item pays
+func (s *ItemSplit) MemberShare() float64 {
+	count := s.IncludedCount()
+	if count == 0 {
+		return 0
+	}
+	return s.TotalPrice / float64(count)
+}
+
+// ReceiptSplit describes how the cost of a family receipt is split. Items split among their own
+// members are left out, the rest of the total is shared equally by the receipt members.
+type ReceiptSplit struct {
+	Receipt *Receipt       `json:"receipt"`
+	Members []*SplitMember `json:"members"`
+	Items   []*ItemSplit   `json:"items"`
+}
+
+// IncludedCount is the number of members sharing the receipt
+func (s *ReceiptSplit) IncludedCount() int {
+	return countIncluded(s.Members)
+}
+
+// SharedAmount is the receipt total without the items split on their own, the amount shared by
+// the receipt members
+func (s *ReceiptSplit) SharedAmount() float64 {
+	var total, itemsTotal, splitItemsTotal float64
+	for _, item := range s.Items {
+		itemsTotal += item.TotalPrice
+		if item.IncludedCount() > 0 {
+			splitItemsTotal += item.TotalPrice
+		}
+	}
+
+	total = itemsTotal
+	if s.Receipt.TotalAmount != nil {
+		total = *s.Receipt.TotalAmount
+	}
+	return total - splitItemsTotal
+}
+
+// MemberBalance is what a family member is owed (positive) or owes (negative) in one currency
+type MemberBalance struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Amount   float64   `json:"amount"`
+	Currency string    `json:"currency"`
+}
+
+// Debt is what the member owes, zero when they are owed money
+func (b *MemberBalance) Debt() float64 {
+	return math.Max(-b.Amount, 0)
+}
+
+// SettlementTransfer is a payment settling the balances of two family members
+type SettlementTransfer struct {
+	FromUserID uuid.UUID `json:"from_user_id"`
+	FromName   string    `json:"from_name"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+	ToName     string    `json:"to_name"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+}
+
+// FamilyBalances are the balances of the members of a family from their split receipts and the
+// fewest transfers settling them
+type FamilyBalances struct {
+	FamilyID  uuid.UUID             `json:"family_id"`
+	Balances  []*MemberBalance      `json:"balances"`
+	Transfers []*SettlementTransfer `json:"transfers"`
+}
+
+// Settlement is a recorded payment between family members
+type Settlement struct {
+	ID uuid.UUID `json:"id"`
+	SettlementTransfer
+	FamilyID  uuid.UUID  `json:"family_id"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// RecordSettlementRequest records that a family member paid another one back
+type RecordSettlementRequest struct {
+	FamilyID   uuid.UUID
+	FromUserID uuid.UUID
+	ToUserID   uuid.UUID
+	Amount     float64
+	Currency   string // DefaultCurrencyCode when empty
+	CreatedBy  uuid.UUID
+}
+
+// GetReceiptSplit returns how a receipt the user can see is split among the members of its family
+func (s *Service) GetReceiptSplit(ctx context.Context, receiptID, userID uuid.UUID) (*ReceiptSplit, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetReceiptSplit")
+	defer span.End()
+
+	receipt, err := s.getAccessibleReceipt(ctx, receiptID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if receipt.FamilyID == nil {
+		return nil, ErrReceiptNotShared
+	}
+
+	members, err := s.getFamilyMemberNames(ctx, *receipt.FamilyID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	participants, err := s.getUserIDSet(ctx, `
+		SELECT receipt_id, user_id FROM receipt_split_participants WHERE receipt_id = $1
+	`, receiptID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipt split: %w", err)
+	}
+
+	itemParticipants, err := s.getUserIDSet(ctx, `
+		SELECT s.receipt_item_id, s.user_id
+		FROM receipt_item_splits s
+		JOIN receipt_items ri ON ri.id = s.receipt_item_id
+		WHERE ri.receipt_id = $1
+	`, receiptID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipt item splits: %w", err)
+	}
+
+	items, err := s.GetReceiptItems(ctx, receiptID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	split := &ReceiptSplit{
+		Receipt: receipt,
+		Members: splitMembers(members, participants[receiptID]),
+	}
+	for _, item := range items {
+		split.Items = append(split.Items, &ItemSplit{
+			ReceiptItem: item,
+			Members:     splitMembers(members, itemParticipants[item.ID]),
+		})
+	}
+
+	return split, nil
+}
+
+// ToggleReceiptSplitMember adds a family member to the members sharing a receipt, or removes them
+// when they already share it. It reports whether the member shares the receipt now.
+func (s *Service) ToggleReceiptSplitMember(ctx context.Context, receiptID, userID, memberID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "receipts.ToggleReceiptSplitMember")
+	defer span.End()
+
+	if err := s.checkSplitMember(ctx, receiptID, userID, memberID); err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	included, err := s.toggleSplitRow(ctx, "receipt_split_participants", "receipt_id", receiptID, memberID)
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	return included, nil
+}
+
+// ToggleItemSplitMember adds a family member to the members sharing a receipt item, or removes
+// them when they already share it. It reports whether the member shares the item now.
+func (s *Service) ToggleItemSplitMember(ctx context.Context, receiptID, itemID, userID, memberID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "receipts.ToggleItemSplitMember")
+	defer span.End()
+
+	if err := s.checkSplitMember(ctx, receiptID, userID, memberID); err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	var exists bool
+	err := s.db.QueryRow(ctx, `
+		SELECT EXISTS(SELECT 1 FROM receipt_items WHERE id = $1 AND receipt_id = $2)
+	`, itemID, receiptID).Scan(&exists)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to check receipt item: %w", err)
+	}
+	if !exists {
+		return false, ErrReceiptItemNotFound
+	}
+
+	included, err := s.toggleSplitRow(ctx, "receipt_item_splits", "receipt_item_id", itemID, memberID)
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	return included, nil
+}
+
+// GetFamilyBalances computes the balances of the members of a family from the split receipts
+// shared with it and the settlements recorded between them
+func (s *Service) GetFamilyBalances(ctx context.Context, familyID, userID uuid.UUID) (*FamilyBalances, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetFamilyBalances")
+	defer span.End()
+
+	isMember, err := s.isFamilyMember(ctx, familyID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotFamilyMember
+	}
+
+	// Every share of a split receipt or item owed by a member to the payer. Item totals split
+	// on their own are taken off the receipt total shared by the receipt members.
+	rows, err := s.db.Query(ctx, `
+		WITH family_receipts AS (
+			SELECT r.id, COALESCE(r.paid_by, r.user_id) AS payer_id, `+receiptCurrencyExpr+` AS currency_code,
+			       COALESCE(r.total_amount, (SELECT SUM(total_price) FROM receipt_items WHERE receipt_id = r.id), 0) AS total
+			FROM users_receipts r
+			WHERE r.family_id = $1
+		),
+		item_shares AS (
+			SELECT fr.id AS receipt_id, fr.payer_id, fr.currency_code, s.user_id,
+			       ri.total_price / COUNT(*) OVER (PARTITION BY ri.id) AS amount
+			FROM receipt_item_splits s
+			JOIN receipt_items ri ON ri.id = s.receipt_item_id
+			JOIN family_receipts fr ON fr.id = ri.receipt_id
+		),
+		split_items AS (
+			SELECT ri.receipt_id, SUM(ri.total_price) AS amount
+			FROM receipt_items ri
+			WHERE EXISTS (SELECT 1 FROM receipt_item_splits s WHERE s.receipt_item_id = ri.id)
+			GROUP BY ri.receipt_id
+		),
+		receipt_shares AS (
+			SELECT fr.id AS receipt_id, fr.payer_id, fr.currency_code, p.user_id,
+			       (fr.total - COALESCE(si.amount, 0)) / COUNT(*) OVER (PARTITION BY fr.id) AS amount
+			FROM receipt_split_participants p
+			JOIN family_receipts fr ON fr.id = p.receipt_id
+			LEFT JOIN split_items si ON si.receipt_id = fr.id
+		)
+		SELECT payer_id, user_id, currency_code, amount::float8 FROM item_shares
+		UNION ALL
+		SELECT payer_id, user_id, currency_code, amount::float8 FROM receipt_shares
+	`, familyID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get receipt shares: %w", err)
+	}
+	defer rows.Close()
+
+	ledger := newBalanceLedger()
+	for rows.Next() {
+		var payerID, memberID uuid.UUID
+		var currency string
+		var amount float64
+		if err := rows.Scan(&payerID, &memberID, &currency, &amount); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan receipt share: %w", err)
+		}
+		ledger.transfer(memberID, payerID, currency, amount)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate receipt shares: %w", err)
+	}
+
+	// A settlement pays back what the payer owed
+	rows, err = s.db.Query(ctx, `
+		SELECT from_user_id, to_user_id, currency_code, amount::float8
+		FROM family_settlements
+		WHERE family_id = $1
+	`, familyID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get settlements: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fromID, toID uuid.UUID
+		var currency string
+		var amount float64
+		if err := rows.Scan(&fromID, &toID, &currency, &amount); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan settlement: %w", err)
+		}
+		ledger.transfer(toID, fromID, currency, amount)
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate settlements: %w", err)
+	}
+
+	names, err := s.getUserNames(ctx, ledger.userIDs())
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	balances := ledger.balances(names)
+	return &FamilyBalances{
+		FamilyID:  familyID,
+		Balances:  balances,
+		Transfers: settleUp(balances),
+	}, nil
+}
+
+// RecordSettlement records a payment between two members of a family, made by one of them
+func (s *Service) RecordSettlement(ctx context.Context, req RecordSettlementRequest) (*Settlement, error) {
+	ctx, span := tracer.Start(ctx, "receipts.RecordSettlement")
+	defer span.End()
+
+	if req.Amount <= 0 || req.FromUserID == req.ToUserID {
+		return nil, ErrInvalidSettlement
+	}
+	if req.CreatedBy != req.FromUserID && req.CreatedBy != req.ToUserID {
+		return nil, ErrInvalidSettlement
+	}
+	for _, memberID := range []uuid.UUID{req.FromUserID, req.ToUserID} {
+		isMember, err := s.isFamilyMember(ctx, req.FamilyID, memberID)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if !isMember {
+			return nil, ErrNotFamilyMember
+		}
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if currency == "" {
+		currency = DefaultCurrencyCode
+	}
+
+	settlement := &Settlement{FamilyID: req.FamilyID}
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO family_settlements (family_id, from_user_id, to_user_id, amount, currency_code, created_by)
+		VALUES ($1, $2, $3, ROUND($4::numeric, 2), $5, $6)
+		RETURNING id, from_user_id, (SELECT COALESCE(first_name, '') FROM users WHERE id = from_user_id),
+		          to_user_id, (SELECT COALESCE(first_name, '') FROM users WHERE id = to_user_id),
+		          amount::float8, currency_code, created_by, created_at
+	`, req.FamilyID, req.FromUserID, req.ToUserID, req.Amount, currency, req.CreatedBy).Scan(
+		&settlement.ID, &settlement.FromUserID, &settlement.FromName, &settlement.ToUserID,
+		&settlement.ToName, &settlement.Amount, &settlement.Currency, &settlement.CreatedBy,
+		&settlement.CreatedAt,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to record settlement: %w", err)
+	}
+
+	return settlement, nil
+}
+
+// GetSettlements returns the latest settlements between the members of a family of the user
+func (s *Service) GetSettlements(ctx context.Context, familyID, userID uuid.UUID, limit int) ([]*Settlement, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetSettlements")
+	defer span.End()
+
+	isMember, err := s.isFamilyMember(ctx, familyID, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !isMember {
+		return nil, ErrNotFamilyMember
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT fs.id, fs.family_id, fs.from_user_id, COALESCE(fu.first_name, ''),
+		       fs.to_user_id, COALESCE(tu.first_name, ''), fs.amount::float8, fs.currency_code,
+		       fs.created_by, fs.created_at
+		FROM family_settlements fs
+		JOIN users fu ON fu.id = fs.from_user_id
+		JOIN users tu ON tu.id = fs.to_user_id
+		WHERE fs.family_id = $1
+		ORDER BY fs.created_at DESC
+		LIMIT $2
+	`, familyID, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get settlements: %w", err)
+	}
+	defer rows.Close()
+
+	settlements := []*Settlement{}
+	for rows.Next() {
+		var settlement Settlement
+		err := rows.Scan(
+			&settlement.ID, &settlement.FamilyID, &settlement.FromUserID, &settlement.FromName,
+			&settlement.ToUserID, &settlement.ToName, &settlement.Amount, &settlement.Currency,
+			&settlement.CreatedBy, &settlement.CreatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan settlement: %w", err)
+		}
+		settlements = append(settlements, &settlement)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate settlements: %w", err)
+	}
+
+	return settlements, nil
+}
+
+// checkSplitMember checks the user can see the receipt, it is shared with a family and the
+// member belongs to it
+func (s *Service) checkSplitMember(ctx context.Context, receiptID, userID, memberID uuid.UUID) error {
+	receipt, err := s.getAccessibleReceipt(ctx, receiptID, userID)
+	if err != nil {
+		return err
+	}
+	if receipt.FamilyID == nil {
+		return ErrReceiptNotShared
+	}
+
+	isMember, err := s.isFamilyMember(ctx, *receipt.FamilyID, memberID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return ErrNotFamilyMember
+	}
+
+	return nil
+}
+
+// toggleSplitRow deletes the (keyColumn, user_id) row of a split table, or inserts it when there was none
+func (s *Service) toggleSplitRow(ctx context.Context, table, keyColumn string, keyID, memberID uuid.UUID) (bool, error) {
+	result, err := s.db.Exec(ctx, `DELETE FROM `+table+` WHERE `+keyColumn+` = $1 AND user_id = $2`, keyID, memberID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update split: %w", err)
+	}
+	if result.RowsAffected() > 0 {
+		return false, nil
+	}
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO `+table+` (`+keyColumn+`, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`, keyID, memberID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update split: %w", err)
+	}
+
+	return true, nil
+}
+
+// clearReceiptSplit forgets how a receipt is split, used when it leaves its family
+func clearReceiptSplit(ctx context.Context, tx pgx.Tx, receiptID uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM receipt_split_participants WHERE receipt_id = $1`, receiptID); err != nil {
+		return fmt.Errorf("failed to clear receipt split: %w", err)
+	}
+
+	_, err := tx.Exec(ctx, `
+		DELETE FROM receipt_item_splits
+		WHERE receipt_item_id IN (SELECT id FROM receipt_items WHERE receipt_id = $1)
+	`, receiptID)
+	if err != nil {
+		return fmt.Errorf("failed to clear receipt item splits: %w", err)
+	}
+
+	return nil
+}
+
+// getFamilyMemberNames returns the members of a family ordered by name
+func (s *Service) getFamilyMemberNames(ctx context.Context, familyID uuid.UUID) ([]*SplitMember, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT u.id, COALESCE(u.first_name, '')
+		FROM family_members fm
+		JOIN users u ON u.id = fm.user_id
+		WHERE fm.family_id = $1
+		ORDER BY u.first_name, u.id
+	`, familyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []*SplitMember
+	for rows.Next() {
+		var member SplitMember
+		if err := rows.Scan(&member.UserID, &member.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan family member: %w", err)
+		}
+		members = append(members, &member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate family members: %w", err)
+	}
+
+	return members, nil
+}
+
+// getUserIDSet runs a query selecting (key, user_id) pairs and groups the users by key
+func (s *Service) getUserIDSet(ctx context.Context, query string, args ...interface{}) (map[uuid.UUID]map[uuid.UUID]bool, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sets := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for rows.Next() {
+		var key, userID uuid.UUID
+		if err := rows.Scan(&key, &userID); err != nil {
+			return nil, err
+		}
+		if sets[key] == nil {
+			sets[key] = make(map[uuid.UUID]bool)
+		}
+		sets[key][userID] = true
+	}
+
+	return sets, rows.Err()
+}
+
+// getUserNames returns the first names of the users
+func (s *Service) getUserNames(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return names, nil
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT id, COALESCE(first_name, '') FROM users WHERE id = ANY($1)`, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user names: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan user name: %w", err)
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
+// splitMembers copies the family members, marking the ones in the included set
+func splitMembers(members []*SplitMember, included map[uuid.UUID]bool) []*SplitMember {
+	result := make([]*SplitMember, 0, len(members))
+	for _, member := range members {
+		result = append(result, &SplitMember{
+			UserID:   member.UserID,
+			Name:     member.Name,
+			Included: included[member.UserID],
+		})
+	}
+	return result
+}
+
+// countIncluded counts the members marked as included
+func countIncluded(members []*SplitMember) int {
+	count := 0
+	for _, member := range members {
+		if member.Included {
+			count++
+		}
+	}
+	return count
+}
+
+// balanceLedger sums what the members of a family are owed, per user and currency
+type balanceLedger struct {
+	amounts map[uuid.UUID]map[string]float64
+}
+
+func newBalanceLedger() *balanceLedger {
+	return &balanceLedger{amounts: make(map[uuid.UUID]map[string]float64)}
+}
+
+// transfer records that debtor owes creditor the amount
+func (l *balanceLedger) transfer(debtorID, creditorID uuid.UUID, currency string, amount float64) {
+	if debtorID == creditorID {
+		return
+	}
+	l.add(debtorID, currency, -amount)
+	l.add(creditorID, currency, amount)
+}
+
+func (l *balanceLedger) add(userID uuid.UUID, currency string, amount float64) {
+	if l.amounts[userID] == nil {
+		l.amounts[userID] = make(map[string]float64)
+	}
+	l.amounts[userID][currency] += amount
+}
+
+func (l *balanceLedger) userIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(l.amounts))
+	for id := range l.amounts {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// balances returns the non-zero balances rounded to cents, by currency, largest credit first
+func (l *balanceLedger) balances(names map[uuid.UUID]string) []*MemberBalance {
+	var balances []*MemberBalance
+	for userID, amounts := range l.amounts {
+		for currency, amount := range amounts {
+			amount = math.Round(amount*100) / 100
+			if amount == 0 {
+				continue
+			}
+			balances = append(balances, &MemberBalance{
+				UserID:   userID,
+				Name:     names[userID],
+				Amount:   amount,
+				Currency: currency,
+			})
+		}
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		if balances[i].Amount != balances[j].Amount {
+			return balances[i].Amount > balances[j].Amount
+		}
+		return balances[i].UserID.String() < balances[j].UserID.String()
+	})
+
+	return balances
+}
+
+// settleUp returns the transfers settling the balances: per currency, the largest debtor pays the
+// largest creditor until everyone is even
+func settleUp(balances []*MemberBalance) []*SettlementTransfer {
+	type position struct {
+		balance *MemberBalance
+		amount  float64
+	}
+
+	byCurrency := make(map[string][]*position)
+	var currencies []string
+	for _, balance := range balances {
+		if _, ok := byCurrency[balance.Currency]; !ok {
+			currencies = append(currencies, balance.Currency)
+		}
+		byCurrency[balance.Currency] = append(byCurrency[balance.Currency], &position{balance: balance, amount: balance.Amount})
+	}
+
+	var transfers []*SettlementTransfer
+	for _, currency := range currencies {
+		positions := byCurrency[currency]
+		for {
+			var creditor, debtor *position
+			for _, p := range positions {
+				if p.amount >= 0.01 && (creditor == nil || p.amount > creditor.amount) {
+					creditor = p
+				}
+				if p.amount <= -0.01 && (debtor == nil || p.amount < debtor.amount) {
+					debtor = p
+				}
+			}
+			if creditor == nil || debtor == nil {
+				break
+			}
+
+			amount := math.Round(math.Min(creditor.amount, -debtor.amount)*100) / 100
+			transfers = append(transfers, &SettlementTransfer{
+				FromUserID: debtor.balance.UserID,
+				FromName:   debtor.balance.Name,
+				ToUserID:   creditor.balance.UserID,
+				ToName:     creditor.balance.Name,
+				Amount:     amount,
+				Currency:   currency,
+			})
+			creditor.amount -= amount
+			debtor.amount += amount
+		}
+	}
+
+	return transfers
+}
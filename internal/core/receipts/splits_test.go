@@ -0,0 +1,213 @@
+package receipts
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSettleUp(t *testing.T) {
+	anna := uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	boris := uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	chen := uuid.MustParse("00000000-0000-0000-0000-00000000000c")
+	dana := uuid.MustParse("00000000-0000-0000-0000-00000000000d")
+
+	type transfer struct {
+		from, to uuid.UUID
+		amount   float64
+		currency string
+	}
+
+	tests := []struct {
+		name     string
+		balances []*MemberBalance
+		want     []transfer
+	}{
+		{
+			name: "nothing to settle",
+		},
+		{
+			name: "one debtor",
+			balances: []*MemberBalance{
+				{UserID: anna, Amount: 30, Currency: "EUR"},
+				{UserID: boris, Amount: -30, Currency: "EUR"},
+			},
+			want: []transfer{{boris, anna, 30, "EUR"}},
+		},
+		{
+			name: "largest debtor pays largest creditor first",
+			balances: []*MemberBalance{
+				{UserID: anna, Amount: 50, Currency: "EUR"},
+				{UserID: boris, Amount: 10, Currency: "EUR"},
+				{UserID: chen, Amount: -20, Currency: "EUR"},
+				{UserID: dana, Amount: -40, Currency: "EUR"},
+			},
+			want: []transfer{
+				{dana, anna, 40, "EUR"},
+				{chen, anna, 10, "EUR"},
+				{chen, boris, 10, "EUR"},
+			},
+		},
+		{
+			name: "currencies settle apart",
+			balances: []*MemberBalance{
+				{UserID: anna, Amount: 12.5, Currency: "EUR"},
+				{UserID: boris, Amount: -12.5, Currency: "EUR"},
+				{UserID: anna, Amount: -300, Currency: "UAH"},
+				{UserID: boris, Amount: 300, Currency: "UAH"},
+			},
+			want: []transfer{
+				{boris, anna, 12.5, "EUR"},
+				{anna, boris, 300, "UAH"},
+			},
+		},
+		{
+			name: "thirds round to cents",
+			balances: []*MemberBalance{
+				{UserID: anna, Amount: 6.67, Currency: "EUR"},
+				{UserID: boris, Amount: -3.33, Currency: "EUR"},
+				{UserID: chen, Amount: -3.34, Currency: "EUR"},
+			},
+			want: []transfer{
+				{chen, anna, 3.34, "EUR"},
+				{boris, anna, 3.33, "EUR"},
+			},
+		},
+		{
+			name: "less than a cent is even",
+			balances: []*MemberBalance{
+				{UserID: anna, Amount: 0.004, Currency: "EUR"},
+				{UserID: boris, Amount: -0.004, Currency: "EUR"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := settleUp(tt.balances)
+			if len(got) != len(tt.want) {
+				t.Fatalf("settleUp() returned %d transfers, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if got[i].FromUserID != want.from || got[i].ToUserID != want.to ||
+					math.Abs(got[i].Amount-want.amount) > 1e-9 || got[i].Currency != want.currency {
+					t.Errorf("transfer %d = %+v, want %+v", i, *got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestSettleUpEvensBalances(t *testing.T) {
+	ledger := newBalanceLedger()
+	members := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	// Everyone pays a receipt shared by all, the first one twice
+	for i, payer := range append(members, members[0]) {
+		total := 10.0 * float64(i+1)
+		for _, member := range members {
+			ledger.transfer(member, payer, "EUR", total/float64(len(members)))
+		}
+	}
+
+	balances := ledger.balances(nil)
+	remaining := make(map[uuid.UUID]float64)
+	for _, balance := range balances {
+		remaining[balance.UserID] = balance.Amount
+	}
+	for _, transfer := range settleUp(balances) {
+		if transfer.Amount <= 0 {
+			t.Errorf("transfer of %v", transfer.Amount)
+		}
+		remaining[transfer.FromUserID] += transfer.Amount
+		remaining[transfer.ToUserID] -= transfer.Amount
+	}
+
+	for userID, amount := range remaining {
+		if math.Abs(amount) >= 0.01 {
+			t.Errorf("balance of %s is %.4f after settling up", userID, amount)
+		}
+	}
+}
+
+func TestBalanceLedger(t *testing.T) {
+	anna, boris := uuid.New(), uuid.New()
+
+	ledger := newBalanceLedger()
+	ledger.transfer(boris, anna, "EUR", 10.006)
+	ledger.transfer(anna, anna, "EUR", 100)
+	ledger.transfer(anna, boris, "UAH", 50)
+	ledger.transfer(boris, anna, "UAH", 50)
+
+	balances := ledger.balances(map[uuid.UUID]string{anna: "Anna", boris: "Boris"})
+	if len(balances) != 2 {
+		t.Fatalf("balances() = %d balances, want 2: %+v", len(balances), balances)
+	}
+	if b := balances[0]; b.UserID != anna || b.Name != "Anna" || b.Amount != 10.01 || b.Currency != "EUR" {
+		t.Errorf("balances()[0] = %+v, want Anna owed 10.01 EUR", *b)
+	}
+	if b := balances[1]; b.UserID != boris || b.Amount != -10.01 || b.Debt() != 10.01 {
+		t.Errorf("balances()[1] = %+v, want Boris owing 10.01 EUR", *b)
+	}
+}
+
+func TestReceiptSplitSharedAmount(t *testing.T) {
+	member := &SplitMember{UserID: uuid.New(), Included: true}
+
+	tests := []struct {
+		name  string
+		total *float64
+		items []*ItemSplit
+		want  float64
+	}{
+		{
+			name:  "receipt total",
+			total: ptr(20),
+			items: []*ItemSplit{{ReceiptItem: &ReceiptItem{TotalPrice: 12}}, {ReceiptItem: &ReceiptItem{TotalPrice: 7}}},
+			want:  20,
+		},
+		{
+			name:  "items without receipt total",
+			items: []*ItemSplit{{ReceiptItem: &ReceiptItem{TotalPrice: 12}}, {ReceiptItem: &ReceiptItem{TotalPrice: 7}}},
+			want:  19,
+		},
+		{
+			name:  "items split on their own",
+			total: ptr(20),
+			items: []*ItemSplit{
+				{ReceiptItem: &ReceiptItem{TotalPrice: 12}, Members: []*SplitMember{member}},
+				{ReceiptItem: &ReceiptItem{TotalPrice: 7}, Members: []*SplitMember{{UserID: uuid.New()}}},
+			},
+			want: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := &ReceiptSplit{Receipt: &Receipt{TotalAmount: tt.total}, Items: tt.items}
+			if got := split.SharedAmount(); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SharedAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemSplitMemberShare(t *testing.T) {
+	item := &ItemSplit{
+		ReceiptItem: &ReceiptItem{TotalPrice: 9},
+		Members: []*SplitMember{
+			{UserID: uuid.New(), Included: true},
+			{UserID: uuid.New(), Included: false},
+			{UserID: uuid.New(), Included: true},
+		},
+	}
+	if got := item.MemberShare(); got != 4.5 {
+		t.Errorf("MemberShare() = %v, want 4.5", got)
+	}
+
+	item.Members = nil
+	if got := item.MemberShare(); got != 0 {
+		t.Errorf("MemberShare() without members = %v, want 0", got)
+	}
+}
@@ -0,0 +1,209 @@
+package receipts
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UnknownCountryRegion is reported for receipts where no merchant country was extracted
+const UnknownCountryRegion = "unknown"
+
+// Receipt net and tax amounts, derived from the total when only one of them was extracted. Receipts
+// without either count with no tax, so gross is always net plus tax.
+const (
+	receiptWithoutTaxCond = `r.total_tax IS NULL AND r.net_amount IS NULL`
+	receiptTaxExpr        = `COALESCE(r.total_tax, ` + receiptAmountExpr + ` - r.net_amount, 0)`
+	receiptNetExpr        = `COALESCE(r.net_amount, ` + receiptAmountExpr + ` - COALESCE(r.total_tax, 0))`
+)
+
+// TaxSummaryFilter selects the receipts included in a tax summary
+type TaxSummaryFilter struct {
+	UserID      uuid.UUID
+	From        time.Time // inclusive
+	To          time.Time // exclusive
+	Granularity string    // month, quarter or year
+}
+
+// TaxSummary is the tax report for a time range grouped by period, currency and merchant country.
+// Amounts are never summed across currencies.
+type TaxSummary struct {
+	From        time.Time       `json:"from"`
+	To          time.Time       `json:"to"`
+	Granularity string          `json:"granularity"`
+	Totals      []TaxTotal      `json:"totals"`
+	Rows        []TaxSummaryRow `json:"rows"`
+}
+
+// TaxTotal is the tax total in one currency for the whole range
+type TaxTotal struct {
+	Currency           string  `json:"currency"`
+	ReceiptsCount      int     `json:"receipts_count"`
+	ReceiptsWithoutTax int     `json:"receipts_without_tax"`
+	GrossAmount        float64 `json:"gross_amount"`
+	NetAmount          float64 `json:"net_amount"`
+	TaxAmount          float64 `json:"tax_amount"`
+}
+
+// TaxSummaryRow is the tax total in one currency and country during one period
+type TaxSummaryRow struct {
+	PeriodStart        time.Time `json:"period_start"`
+	Period             string    `json:"period"`
+	Currency           string    `json:"currency"`
+	CountryRegion      string    `json:"country_region"`
+	ReceiptsCount      int       `json:"receipts_count"`
+	ReceiptsWithoutTax int       `json:"receipts_without_tax"`
+	GrossAmount        float64   `json:"gross_amount"`
+	NetAmount          float64   `json:"net_amount"`
+	TaxAmount          float64   `json:"tax_amount"`
+}
+
+// YearRange returns the calendar year range
+func YearRange(year int, loc *time.Location) (from, to time.Time) {
+	from = time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	return from, from.AddDate(1, 0, 0)
+}
+
+// PeriodLabel formats the start of a period bucket for reports, e.g. 2025-03, 2025-Q1 or 2025
+func PeriodLabel(granularity string, start time.Time) string {
+	switch granularity {
+	case GranularityYear:
+		return strconv.Itoa(start.Year())
+	case GranularityQuarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case GranularityMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format(time.DateOnly)
+	}
+}
+
+// GetTaxSummary computes gross, net and tax amounts of the processed receipts of a user
+// per period, currency and merchant country
+func (s *Service) GetTaxSummary(ctx context.Context, filter TaxSummaryFilter) (*TaxSummary, error) {
+	ctx, span := tracer.Start(ctx, "receipts.GetTaxSummary")
+	defer span.End()
+
+	if !filter.To.After(filter.From) {
+		return nil, fmt.Errorf("invalid tax summary range: %s - %s", filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly))
+	}
+	if filter.Granularity == "" {
+		filter.Granularity = GranularityQuarter
+	}
+	switch filter.Granularity {
+	case GranularityMonth, GranularityQuarter, GranularityYear:
+	default:
+		return nil, fmt.Errorf("unsupported tax summary granularity: %s", filter.Granularity)
+	}
+
+	query := `
+		SELECT date_trunc($5, (` + receiptDateExpr + `)::timestamp)::date AS period_start,
+		       ` + receiptCurrencyExpr + ` AS currency,
+		       COALESCE(NULLIF(UPPER(r.country_region), ''), '` + UnknownCountryRegion + `') AS country,
+		       COUNT(*) AS receipts_count,
+		       COUNT(*) FILTER (WHERE ` + receiptWithoutTaxCond + `) AS receipts_without_tax,
+		       COALESCE(SUM(` + receiptAmountExpr + `), 0)::float8 AS gross_amount,
+		       COALESCE(SUM(` + receiptNetExpr + `), 0)::float8 AS net_amount,
+		       COALESCE(SUM(` + receiptTaxExpr + `), 0)::float8 AS tax_amount
+		FROM users_receipts r
+		WHERE ` + receiptsFilterClause(receiptCurrencyExpr) + `
+		GROUP BY period_start, currency, country
+		ORDER BY period_start ASC, currency ASC, country ASC`
+
+	rows, err := s.db.Query(ctx, query, filter.UserID, filter.From, filter.To, "", filter.Granularity)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get tax summary: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &TaxSummary{
+		From:        filter.From,
+		To:          filter.To,
+		Granularity: filter.Granularity,
+		Totals:      []TaxTotal{},
+		Rows:        []TaxSummaryRow{},
+	}
+
+	for rows.Next() {
+		var row TaxSummaryRow
+		err := rows.Scan(&row.PeriodStart, &row.Currency, &row.CountryRegion, &row.ReceiptsCount,
+			&row.ReceiptsWithoutTax, &row.GrossAmount, &row.NetAmount, &row.TaxAmount)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan tax summary row: %w", err)
+		}
+		row.Period = PeriodLabel(filter.Granularity, row.PeriodStart)
+		summary.Rows = append(summary.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to iterate tax summary: %w", err)
+	}
+
+	summary.Totals = taxTotals(summary.Rows)
+	return summary, nil
+}
+
+// taxTotals sums the rows per currency in order of first appearance. Receipts without a
+// currency stay in their own UnknownCurrency group.
+func taxTotals(rows []TaxSummaryRow) []TaxTotal {
+	totals := make(map[string]*TaxTotal)
+	var currencies []string
+
+	for _, row := range rows {
+		total, exists := totals[row.Currency]
+		if !exists {
+			total = &TaxTotal{Currency: row.Currency}
+			totals[row.Currency] = total
+			currencies = append(currencies, row.Currency)
+		}
+		total.ReceiptsCount += row.ReceiptsCount
+		total.ReceiptsWithoutTax += row.ReceiptsWithoutTax
+		total.GrossAmount += row.GrossAmount
+		total.NetAmount += row.NetAmount
+		total.TaxAmount += row.TaxAmount
+	}
+
+	result := make([]TaxTotal, 0, len(currencies))
+	for _, currency := range currencies {
+		result = append(result, *totals[currency])
+	}
+	return result
+}
+
+// WriteCSV exports the tax summary rows as CSV with a header line
+func (ts *TaxSummary) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"period", "currency", "country_region", "receipts_count", "receipts_without_tax",
+		"gross_amount", "net_amount", "tax_amount"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write tax summary header: %w", err)
+	}
+
+	for _, row := range ts.Rows {
+		record := []string{
+			row.Period,
+			row.Currency,
+			row.CountryRegion,
+			strconv.Itoa(row.ReceiptsCount),
+			strconv.Itoa(row.ReceiptsWithoutTax),
+			strconv.FormatFloat(row.GrossAmount, 'f', 2, 64),
+			strconv.FormatFloat(row.NetAmount, 'f', 2, 64),
+			strconv.FormatFloat(row.TaxAmount, 'f', 2, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write tax summary row: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,158 @@
+package receipts
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriodLabel(t *testing.T) {
+	tests := []struct {
+		granularity string
+		start       time.Time
+		want        string
+	}{
+		{GranularityYear, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "2025"},
+		{GranularityQuarter, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), "2025-Q1"},
+		{GranularityQuarter, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), "2025-Q1"},
+		{GranularityQuarter, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), "2025-Q2"},
+		{GranularityQuarter, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), "2025-Q3"},
+		{GranularityQuarter, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), "2025-Q4"},
+		{GranularityMonth, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "2025-03"},
+		{GranularityMonth, time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC), "2024-12"},
+		{GranularityWeek, time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), "2025-03-03"},
+		{GranularityDay, time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), "2025-03-05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity+"/"+tt.want, func(t *testing.T) {
+			if got := PeriodLabel(tt.granularity, tt.start); got != tt.want {
+				t.Errorf("PeriodLabel(%q, %s) = %q, want %q", tt.granularity, tt.start.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaxTotals(t *testing.T) {
+	q1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	q2 := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rows []TaxSummaryRow
+		want []TaxTotal
+	}{
+		{
+			name: "no receipts",
+			want: []TaxTotal{},
+		},
+		{
+			name: "countries and periods of one currency are summed",
+			rows: []TaxSummaryRow{
+				{PeriodStart: q1, Currency: "EUR", CountryRegion: "DE", ReceiptsCount: 2, GrossAmount: 21.4, NetAmount: 20, TaxAmount: 1.4},
+				{PeriodStart: q1, Currency: "EUR", CountryRegion: "FR", ReceiptsCount: 1, ReceiptsWithoutTax: 1, GrossAmount: 5, NetAmount: 5},
+				{PeriodStart: q2, Currency: "EUR", CountryRegion: "DE", ReceiptsCount: 1, GrossAmount: 11.9, NetAmount: 10, TaxAmount: 1.9},
+			},
+			want: []TaxTotal{
+				{Currency: "EUR", ReceiptsCount: 4, ReceiptsWithoutTax: 1, GrossAmount: 38.3, NetAmount: 35, TaxAmount: 3.3},
+			},
+		},
+		{
+			name: "currencies are never summed, unknown currency is its own group",
+			rows: []TaxSummaryRow{
+				{PeriodStart: q1, Currency: "EUR", CountryRegion: "DE", ReceiptsCount: 1, GrossAmount: 11.9, NetAmount: 10, TaxAmount: 1.9},
+				{PeriodStart: q1, Currency: "UAH", CountryRegion: "UA", ReceiptsCount: 1, GrossAmount: 120, NetAmount: 100, TaxAmount: 20},
+				{PeriodStart: q1, Currency: UnknownCurrency, CountryRegion: UnknownCountryRegion, ReceiptsCount: 2, ReceiptsWithoutTax: 2, GrossAmount: 7, NetAmount: 7},
+				{PeriodStart: q2, Currency: "EUR", CountryRegion: "DE", ReceiptsCount: 1, GrossAmount: 2.14, NetAmount: 2, TaxAmount: 0.14},
+			},
+			want: []TaxTotal{
+				{Currency: "EUR", ReceiptsCount: 2, GrossAmount: 14.04, NetAmount: 12, TaxAmount: 2.04},
+				{Currency: "UAH", ReceiptsCount: 1, GrossAmount: 120, NetAmount: 100, TaxAmount: 20},
+				{Currency: UnknownCurrency, ReceiptsCount: 2, ReceiptsWithoutTax: 2, GrossAmount: 7, NetAmount: 7},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := taxTotals(tt.rows)
+			if len(got) != len(tt.want) {
+				t.Fatalf("taxTotals() = %+v, want %+v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if got[i].Currency != want.Currency || got[i].ReceiptsCount != want.ReceiptsCount ||
+					got[i].ReceiptsWithoutTax != want.ReceiptsWithoutTax ||
+					math.Abs(got[i].GrossAmount-want.GrossAmount) > 1e-9 ||
+					math.Abs(got[i].NetAmount-want.NetAmount) > 1e-9 ||
+					math.Abs(got[i].TaxAmount-want.TaxAmount) > 1e-9 {
+					t.Errorf("total %d = %+v, want %+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestTaxSummaryWriteCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []TaxSummaryRow
+		want [][]string
+	}{
+		{
+			name: "header only without receipts",
+		},
+		{
+			name: "amounts are rounded to cents",
+			rows: []TaxSummaryRow{
+				{Period: "2025-Q1", Currency: "EUR", CountryRegion: "DE", ReceiptsCount: 3, ReceiptsWithoutTax: 1,
+					GrossAmount: 38.3, NetAmount: 35.004, TaxAmount: 3.296},
+				{Period: "2025-Q1", Currency: UnknownCurrency, CountryRegion: UnknownCountryRegion, ReceiptsCount: 1,
+					ReceiptsWithoutTax: 1, GrossAmount: 7, NetAmount: 7},
+			},
+			want: [][]string{
+				{"2025-Q1", "EUR", "DE", "3", "1", "38.30", "35.00", "3.30"},
+				{"2025-Q1", "XXX", "unknown", "1", "1", "7.00", "7.00", "0.00"},
+			},
+		},
+		{
+			name: "text fields are quoted",
+			rows: []TaxSummaryRow{
+				{Period: "2025", Currency: "EUR", CountryRegion: "DE, \"Berlin\"", ReceiptsCount: 1, GrossAmount: 1, NetAmount: 1},
+			},
+			want: [][]string{
+				{"2025", "EUR", "DE, \"Berlin\"", "1", "0", "1.00", "1.00", "0.00"},
+			},
+		},
+	}
+
+	header := []string{"period", "currency", "country_region", "receipts_count", "receipts_without_tax",
+		"gross_amount", "net_amount", "tax_amount"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			summary := &TaxSummary{Granularity: GranularityQuarter, Rows: tt.rows}
+			if err := summary.WriteCSV(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("tax summary is not valid CSV: %v", err)
+			}
+
+			want := append([][]string{header}, tt.want...)
+			if len(records) != len(want) {
+				t.Fatalf("tax summary has %d rows, want %d: %q", len(records), len(want), records)
+			}
+			for i := range want {
+				if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+					t.Errorf("row %d = %q, want %q", i, records[i], want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,448 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/PocketPalCo/shopping-service/config"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("sessions-service")
+
+const (
+	// Login codes are a public selector and a secret, e.g. 1234-56789012. The selector identifies
+	// the code a guess was made for, so failed guesses can be counted per code.
+	loginCodeSelectorDigits = 4
+	loginCodeDigits         = 8
+	// loginCodeMaxAttempts is the number of failed guesses a code is invalidated after
+	loginCodeMaxAttempts = 5
+
+	tokenBytes = 32
+)
+
+var (
+	ErrInvalidLoginCode = errors.New("invalid or expired login code")
+	ErrSessionNotFound  = errors.New("session not found or expired")
+)
+
+// Session represents an HTTP API session stored in user_sessions.
+// Token is only populated when the session is created or refreshed, the database keeps a hash of it.
+type Session struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	Token        string    `json:"token,omitempty"`
+	ChatID       int64     `json:"chat_id" db:"chat_id"`
+	LastActivity time.Time `json:"last_activity" db:"last_activity"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// LoginCode is a one-time code the bot sends to the user to log in to the HTTP API
+type LoginCode struct {
+	Code      string
+	ExpiresAt time.Time
+}
+
+type Service struct {
+	db  *pgxpool.Pool
+	cfg config.SessionConfig
+}
+
+func NewService(db *pgxpool.Pool, cfg config.SessionConfig) *Service {
+	return &Service{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateLoginCode issues a new one-time login code for the user and invalidates the previous unused ones
+func (s *Service) CreateLoginCode(ctx context.Context, userID uuid.UUID, chatID int64) (*LoginCode, error) {
+	ctx, span := tracer.Start(ctx, "sessions.CreateLoginCode")
+	defer span.End()
+
+	selector, secret, err := generateLoginCode()
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to generate login code: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `DELETE FROM login_codes WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to invalidate previous login codes: %w", err)
+	}
+
+	expiresAt := time.Now().Add(s.cfg.LoginCodeTTL)
+	_, err = tx.Exec(ctx, `
+		INSERT INTO login_codes (user_id, code_selector, code_hash, chat_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, userID, selector, hashSecret(selector+secret), chatID, expiresAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to store login code: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to commit login code: %w", err)
+	}
+
+	return &LoginCode{Code: selector + "-" + secret, ExpiresAt: expiresAt}, nil
+}
+
+// ExchangeLoginCode consumes a one-time login code and creates a session for its user. A wrong
+// guess counts against the codes with the same selector, which are invalidated after
+// loginCodeMaxAttempts failed guesses.
+func (s *Service) ExchangeLoginCode(ctx context.Context, code string) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "sessions.ExchangeLoginCode")
+	defer span.End()
+
+	selector, secret, ok := parseLoginCode(code)
+	if !ok {
+		return nil, ErrInvalidLoginCode
+	}
+
+	query := `
+		UPDATE login_codes
+		SET used_at = NOW()
+		WHERE id = (
+			SELECT id FROM login_codes
+			WHERE code_selector = $1 AND code_hash = $2 AND used_at IS NULL AND expires_at > NOW()
+			ORDER BY created_at DESC
+			LIMIT 1
+		)
+		RETURNING user_id, chat_id
+	`
+
+	var userID uuid.UUID
+	var chatID int64
+	err := s.db.QueryRow(ctx, query, selector, hashSecret(selector+secret)).Scan(&userID, &chatID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if err := s.recordFailedLoginAttempt(ctx, selector); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		return nil, ErrInvalidLoginCode
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to consume login code: %w", err)
+	}
+
+	return s.CreateSession(ctx, userID, chatID)
+}
+
+// recordFailedLoginAttempt counts a wrong guess for the live codes with the selector and expires
+// the ones that reached loginCodeMaxAttempts
+func (s *Service) recordFailedLoginAttempt(ctx context.Context, selector string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE login_codes
+		SET failed_attempts = failed_attempts + 1,
+		    expires_at = CASE WHEN failed_attempts + 1 >= $2 THEN NOW() ELSE expires_at END
+		WHERE code_selector = $1 AND used_at IS NULL AND expires_at > NOW()
+	`, selector, loginCodeMaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to record failed login attempt: %w", err)
+	}
+	return nil
+}
+
+// CreateSession starts a new session for the user and returns it with the plain bearer token
+func (s *Service) CreateSession(ctx context.Context, userID uuid.UUID, chatID int64) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "sessions.CreateSession")
+	defer span.End()
+
+	token, err := generateToken()
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_sessions (user_id, session_token, chat_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, chat_id, last_activity, expires_at, created_at
+	`
+
+	var session Session
+	err = s.db.QueryRow(ctx, query, userID, hashSecret(token), chatID, time.Now().Add(s.cfg.TTL)).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.ChatID,
+		&session.LastActivity,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.Token = token
+	return &session, nil
+}
+
+// Authenticate resolves an active session token to its session and user and records the activity.
+// Returns nil values if the token is unknown or expired.
+func (s *Service) Authenticate(ctx context.Context, token string) (*Session, *users.User, error) {
+	ctx, span := tracer.Start(ctx, "sessions.Authenticate")
+	defer span.End()
+
+	query := `
+		WITH active AS (
+			UPDATE user_sessions
+			SET last_activity = NOW()
+			WHERE session_token = $1 AND expires_at > NOW()
+			RETURNING id, user_id, chat_id, last_activity, expires_at, created_at
+		)
+		SELECT a.id, a.user_id, a.chat_id, a.last_activity, a.expires_at, a.created_at,
+		       u.id, u.telegram_id, u.username, u.first_name, u.last_name, u.is_authorized,
+		       u.authorized_by, u.authorized_at, u.created_at, u.updated_at, u.locale
+		FROM active a
+		JOIN users u ON u.id = a.user_id
+	`
+
+	var session Session
+	var user users.User
+	err := s.db.QueryRow(ctx, query, hashSecret(token)).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.ChatID,
+		&session.LastActivity,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+		&user.ID,
+		&user.TelegramID,
+		&user.Username,
+		&user.FirstName,
+		&user.LastName,
+		&user.IsAuthorized,
+		&user.AuthorizedBy,
+		&user.AuthorizedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Locale,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, fmt.Errorf("failed to authenticate session: %w", err)
+	}
+
+	return &session, &user, nil
+}
+
+// RefreshSession rotates the session token and extends its expiry, the old token stops working
+func (s *Service) RefreshSession(ctx context.Context, sessionID uuid.UUID) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "sessions.RefreshSession")
+	defer span.End()
+
+	token, err := generateToken()
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+
+	query := `
+		UPDATE user_sessions
+		SET session_token = $2, expires_at = $3, last_activity = NOW()
+		WHERE id = $1 AND expires_at > NOW()
+		RETURNING id, user_id, chat_id, last_activity, expires_at, created_at
+	`
+
+	var session Session
+	err = s.db.QueryRow(ctx, query, sessionID, hashSecret(token), time.Now().Add(s.cfg.TTL)).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.ChatID,
+		&session.LastActivity,
+		&session.ExpiresAt,
+		&session.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	session.Token = token
+	return &session, nil
+}
+
+// RevokeSession deletes a single session (logout)
+func (s *Service) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "sessions.RevokeSession")
+	defer span.End()
+
+	result, err := s.db.Exec(ctx, `DELETE FROM user_sessions WHERE id = $1`, sessionID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllUserSessions deletes every session of the user and returns how many were revoked
+func (s *Service) RevokeAllUserSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := tracer.Start(ctx, "sessions.RevokeAllUserSessions")
+	defer span.End()
+
+	result, err := s.db.Exec(ctx, `DELETE FROM user_sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetUserSessions returns the active sessions of the user, most recently used first
+func (s *Service) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	ctx, span := tracer.Start(ctx, "sessions.GetUserSessions")
+	defer span.End()
+
+	query := `
+		SELECT id, user_id, chat_id, last_activity, expires_at, created_at
+		FROM user_sessions
+		WHERE user_id = $1 AND expires_at > NOW()
+		ORDER BY last_activity DESC
+	`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		var session Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.ChatID,
+			&session.LastActivity,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating over sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteExpired removes expired sessions and login codes, returns the number of deleted sessions
+func (s *Service) DeleteExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "sessions.DeleteExpired")
+	defer span.End()
+
+	_, err := s.db.Exec(ctx, `DELETE FROM login_codes WHERE expires_at <= NOW() OR used_at IS NOT NULL`)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to delete expired login codes: %w", err)
+	}
+
+	result, err := s.db.Exec(ctx, `DELETE FROM user_sessions WHERE expires_at <= NOW()`)
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// generateLoginCode returns the random selector and secret of a new login code
+func generateLoginCode() (string, string, error) {
+	selector, err := randomDigits(loginCodeSelectorDigits)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := randomDigits(loginCodeDigits)
+	if err != nil {
+		return "", "", err
+	}
+	return selector, secret, nil
+}
+
+// parseLoginCode splits a login code as typed by the user, with or without the separator and spaces
+func parseLoginCode(code string) (selector, secret string, ok bool) {
+	digits := strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, code)
+
+	if len(digits) != loginCodeSelectorDigits+loginCodeDigits {
+		return "", "", false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", "", false
+		}
+	}
+
+	return digits[:loginCodeSelectorDigits], digits[loginCodeSelectorDigits:], true
+}
+
+func randomDigits(count int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(count)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", count, n), nil
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,44 @@
+package sessions
+
+import "testing"
+
+func TestParseLoginCode(t *testing.T) {
+	tests := []struct {
+		code         string
+		wantSelector string
+		wantSecret   string
+		wantOK       bool
+	}{
+		{"1234-56789012", "1234", "56789012", true},
+		{"123456789012", "1234", "56789012", true},
+		{" 1234 5678 9012\n", "1234", "56789012", true},
+		{"1234-5678901", "", "", false},
+		{"1234-567890123", "", "", false},
+		{"12345678", "", "", false},
+		{"1234-5678901a", "", "", false},
+		{"１２３４-56789012", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		selector, secret, ok := parseLoginCode(tt.code)
+		if selector != tt.wantSelector || secret != tt.wantSecret || ok != tt.wantOK {
+			t.Errorf("parseLoginCode(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.code, selector, secret, ok, tt.wantSelector, tt.wantSecret, tt.wantOK)
+		}
+	}
+}
+
+func TestGenerateLoginCode(t *testing.T) {
+	for range 100 {
+		selector, secret, err := generateLoginCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		gotSelector, gotSecret, ok := parseLoginCode(selector + "-" + secret)
+		if !ok || gotSelector != selector || gotSecret != secret {
+			t.Fatalf("generated code %s-%s doesn't parse back: %q, %q, %v", selector, secret, gotSelector, gotSecret, ok)
+		}
+	}
+}
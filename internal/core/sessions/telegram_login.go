@@ -0,0 +1,66 @@
+package sessions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidTelegramLogin = errors.New("invalid telegram login data")
+
+// VerifyTelegramLogin checks the Telegram Login Widget payload (id, first_name, auth_date, hash, ...)
+// as described in https://core.telegram.org/widgets/login#checking-authorization and returns the Telegram user ID
+func (s *Service) VerifyTelegramLogin(data map[string]string) (int64, error) {
+	if s.cfg.BotToken == "" {
+		return 0, fmt.Errorf("%w: telegram bot token is not configured", ErrInvalidTelegramLogin)
+	}
+
+	receivedHash := data["hash"]
+	if receivedHash == "" {
+		return 0, fmt.Errorf("%w: missing hash", ErrInvalidTelegramLogin)
+	}
+
+	// data-check-string is all received fields except hash, sorted by key, in key=value form separated by \n
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+data[key])
+	}
+
+	secretKey := sha256.Sum256([]byte(s.cfg.BotToken))
+	mac := hmac.New(sha256.New, secretKey[:])
+	mac.Write([]byte(strings.Join(pairs, "\n")))
+	expectedHash := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expectedHash), []byte(strings.ToLower(receivedHash))) {
+		return 0, fmt.Errorf("%w: hash mismatch", ErrInvalidTelegramLogin)
+	}
+
+	authDate, err := strconv.ParseInt(data["auth_date"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid auth_date", ErrInvalidTelegramLogin)
+	}
+	if s.cfg.TelegramAuthMaxAge > 0 && time.Since(time.Unix(authDate, 0)) > s.cfg.TelegramAuthMaxAge {
+		return 0, fmt.Errorf("%w: auth_date is too old", ErrInvalidTelegramLogin)
+	}
+
+	telegramID, err := strconv.ParseInt(data["id"], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid id", ErrInvalidTelegramLogin)
+	}
+
+	return telegramID, nil
+}
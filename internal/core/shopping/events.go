@@ -0,0 +1,328 @@
+package shopping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ListEventsChannel is the Postgres notification channel list changes are published on, so the
+// changes made by any replica (bot or HTTP API) reach the subscribers of every replica
+const ListEventsChannel = "shopping_list_events"
+
+// maxListEventPayload keeps notifications below the 8000 bytes Postgres allows
+const maxListEventPayload = 7900
+
+// ListEventType is the kind of change made to a shopping list
+type ListEventType string
+
+const (
+	ListEventItemAdded       ListEventType = "item_added"
+	ListEventItemUpdated     ListEventType = "item_updated"
+	ListEventItemCompleted   ListEventType = "item_completed"
+	ListEventItemUncompleted ListEventType = "item_uncompleted"
+	ListEventItemDeleted     ListEventType = "item_deleted"
+	ListEventListArchived    ListEventType = "list_archived"
+	ListEventListDeleted     ListEventType = "list_deleted"
+)
+
+// ListEvent is a change made to a shopping list. Item holds the item after the change, it is
+// omitted for deleted items and when it is too large for a notification. ID identifies the event
+// across the replicas that all receive it.
+type ListEvent struct {
+	ID         uuid.UUID     `json:"id"`
+	Type       ListEventType `json:"type"`
+	ListID     uuid.UUID     `json:"list_id"`
+	ItemID     *uuid.UUID    `json:"item_id,omitempty"`
+	Item       *ShoppingItem `json:"item,omitempty"`
+	UserID     *uuid.UUID    `json:"user_id,omitempty"` // who made the change when known
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+// Closes reports whether the list is gone after the event and its subscribers are done
+func (e ListEvent) Closes() bool {
+	return e.Type == ListEventListArchived || e.Type == ListEventListDeleted
+}
+
+// publishListEvent notifies the subscribers of the list. Failures are only logged, the change
+// itself has been made and clients reload the list when they reconnect.
+func (s *Service) publishListEvent(ctx context.Context, event ListEvent) {
+	event.ID = uuid.New()
+	event.OccurredAt = time.Now()
+
+	payload, err := json.Marshal(event)
+	if err == nil && len(payload) > maxListEventPayload && event.Item != nil {
+		event.Item = nil
+		payload, err = json.Marshal(event)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to encode list event", "error", err, "type", event.Type, "list_id", event.ListID)
+		return
+	}
+
+	if _, err := s.db.Exec(ctx, "SELECT pg_notify($1, $2)", ListEventsChannel, string(payload)); err != nil {
+		s.logger.Warn("Failed to publish list event", "error", err, "type", event.Type, "list_id", event.ListID)
+	}
+}
+
+// publishItemEvent publishes a change of an item with the item as it is now
+func (s *Service) publishItemEvent(ctx context.Context, eventType ListEventType, itemID uuid.UUID, userID *uuid.UUID) {
+	item, err := s.getItemByID(ctx, itemID)
+	if err != nil || item == nil {
+		s.logger.Warn("Failed to load item for list event", "error", err, "type", eventType, "item_id", itemID)
+		return
+	}
+
+	s.publishListEvent(ctx, ListEvent{
+		Type:   eventType,
+		ListID: item.ListID,
+		ItemID: &item.ID,
+		Item:   item,
+		UserID: userID,
+	})
+}
+
+// PublishItemCompleted notifies the subscribers of the list that an item was checked off outside
+// of this service, e.g. by confirming the receipt it was bought with
+func (s *Service) PublishItemCompleted(ctx context.Context, itemID, completedBy uuid.UUID) {
+	s.publishItemEvent(ctx, ListEventItemCompleted, itemID, &completedBy)
+}
+
+// getItemByID loads an item of any list, nil when it doesn't exist
+func (s *Service) getItemByID(ctx context.Context, itemID uuid.UUID) (*ShoppingItem, error) {
+	query := `
+		SELECT id, list_id, name, quantity, notes, is_completed, added_by, completed_by, completed_at,
+		       original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+		       created_at, updated_at
+		FROM shopping_items
+		WHERE id = $1`
+
+	var item ShoppingItem
+	err := s.db.QueryRow(ctx, query, itemID).Scan(
+		&item.ID, &item.ListID, &item.Name, &item.Quantity, &item.Notes, &item.IsCompleted,
+		&item.AddedBy, &item.CompletedBy, &item.CompletedAt,
+		&item.OriginalItemID, &item.ParsedItemID, &item.DisplayName, &item.ParsedName, &item.ParsingStatus,
+		&item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	return &item, nil
+}
+
+const (
+	// listEventBuffer is how many events a subscriber may fall behind before it is dropped
+	listEventBuffer = 32
+	// allListEventsBuffer is how many events a subscriber of all lists may fall behind before
+	// events are dropped for it
+	allListEventsBuffer = 256
+)
+
+// ListEventBroker fans the list events published by all replicas out to the subscribers of this
+// replica. It listens on ListEventsChannel with a dedicated connection while Run is active.
+type ListEventBroker struct {
+	db     *pgxpool.Pool
+	logger *slog.Logger
+
+	mutex          sync.Mutex
+	subscribers    map[uuid.UUID]map[chan ListEvent]struct{}
+	allSubscribers map[chan ListEvent]struct{}
+	closed         bool
+}
+
+// NewListEventBroker creates a broker listening for list events on the database
+func NewListEventBroker(db *pgxpool.Pool) *ListEventBroker {
+	return &ListEventBroker{
+		db:             db,
+		logger:         slog.Default().With("component", "list-event-broker"),
+		subscribers:    make(map[uuid.UUID]map[chan ListEvent]struct{}),
+		allSubscribers: make(map[chan ListEvent]struct{}),
+	}
+}
+
+// Subscribe returns the events of a list and a function ending the subscription. The channel is
+// closed when the subscription ends, the broker stops, the list is archived or deleted, or the
+// subscriber falls too far behind; clients should reload the list when they subscribe again.
+func (b *ListEventBroker) Subscribe(listID uuid.UUID) (<-chan ListEvent, func()) {
+	events := make(chan ListEvent, listEventBuffer)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	if b.subscribers[listID] == nil {
+		b.subscribers[listID] = make(map[chan ListEvent]struct{})
+	}
+	b.subscribers[listID][events] = struct{}{}
+
+	return events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.removeLocked(listID, events)
+	}
+}
+
+// SubscribeAll returns the events of all lists and a function ending the subscription. Unlike
+// Subscribe it stays open when lists are archived and events are dropped rather than the
+// subscription when the subscriber falls behind. The channel is closed when the subscription
+// ends or the broker stops.
+func (b *ListEventBroker) SubscribeAll() (<-chan ListEvent, func()) {
+	events := make(chan ListEvent, allListEventsBuffer)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		close(events)
+		return events, func() {}
+	}
+
+	b.allSubscribers[events] = struct{}{}
+
+	return events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if _, ok := b.allSubscribers[events]; ok {
+			delete(b.allSubscribers, events)
+			close(events)
+		}
+	}
+}
+
+// Run listens for list events until ctx is done, reconnecting when the connection is lost, and
+// ends all subscriptions when it returns
+func (b *ListEventBroker) Run(ctx context.Context) {
+	defer b.closeAll()
+
+	backoff := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		b.logger.Error("Listening for list events failed, reconnecting",
+			"error", err,
+			"retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// listen dispatches the notifications of one connection until it fails or ctx is done
+func (b *ListEventBroker) listen(ctx context.Context) error {
+	pooled, err := b.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	// The connection keeps listening, it must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ListEventsChannel); err != nil {
+		return fmt.Errorf("failed to listen for list events: %w", err)
+	}
+
+	b.logger.Info("Listening for list events", "channel", ListEventsChannel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to wait for list events: %w", err)
+		}
+
+		var event ListEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			b.logger.Warn("Ignoring malformed list event", "error", err)
+			continue
+		}
+
+		b.dispatch(event)
+	}
+}
+
+// dispatch sends the event to the subscribers of its list and of all lists
+func (b *ListEventBroker) dispatch(event ListEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for events := range b.allSubscribers {
+		select {
+		case events <- event:
+		default:
+			b.logger.Warn("Dropping list event for slow subscriber", "type", event.Type, "list_id", event.ListID)
+		}
+	}
+
+	for events := range b.subscribers[event.ListID] {
+		select {
+		case events <- event:
+			if event.Closes() {
+				b.removeLocked(event.ListID, events)
+			}
+		default:
+			// A subscriber missing events would show a stale list, it reloads on reconnect instead
+			b.logger.Warn("Dropping slow list event subscriber", "list_id", event.ListID)
+			b.removeLocked(event.ListID, events)
+		}
+	}
+}
+
+// closeAll ends all subscriptions, later ones end right away
+func (b *ListEventBroker) closeAll() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for events := range b.allSubscribers {
+		delete(b.allSubscribers, events)
+		close(events)
+	}
+	for listID, listSubscribers := range b.subscribers {
+		for events := range listSubscribers {
+			b.removeLocked(listID, events)
+		}
+	}
+}
+
+// removeLocked ends a subscription unless it already ended, b.mutex must be held
+func (b *ListEventBroker) removeLocked(listID uuid.UUID, events chan ListEvent) {
+	listSubscribers, ok := b.subscribers[listID]
+	if !ok {
+		return
+	}
+	if _, ok := listSubscribers[events]; !ok {
+		return
+	}
+
+	delete(listSubscribers, events)
+	close(events)
+	if len(listSubscribers) == 0 {
+		delete(b.subscribers, listID)
+	}
+}
@@ -2,7 +2,7 @@ package shopping
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -11,6 +11,7 @@ import (
 	"github.com/PocketPalCo/shopping-service/internal/core/ai"
 	"github.com/PocketPalCo/shopping-service/pkg/telemetry"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -210,19 +211,10 @@ func (s *Service) GetUserShoppingListsWithFamilies(ctx context.Context, userID u
 	ctx, span := tracer.Start(ctx, "shopping.GetUserShoppingListsWithFamilies")
 	defer span.End()
 
-	// First, get the user's family count
-	familyCountQuery := `
-		SELECT COUNT(DISTINCT f.id)
-		FROM families f
-		JOIN family_members fm ON f.id = fm.family_id
-		WHERE fm.user_id = $1
-	`
-
-	var familyCount int
-	err := s.db.QueryRow(ctx, familyCountQuery, userID).Scan(&familyCount)
+	familyCount, err := s.countUserFamilies(ctx, userID)
 	if err != nil {
 		span.RecordError(err)
-		return nil, 0, fmt.Errorf("failed to get user family count: %w", err)
+		return nil, 0, err
 	}
 
 	// Get shopping lists with family names
@@ -243,6 +235,82 @@ func (s *Service) GetUserShoppingListsWithFamilies(ctx context.Context, userID u
 		span.RecordError(err)
 		return nil, 0, fmt.Errorf("failed to get user shopping lists with families: %w", err)
 	}
+
+	lists, err := scanShoppingListsWithFamilies(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, err
+	}
+
+	return lists, familyCount, nil
+}
+
+// GetUserShoppingListsPage returns one page of the active lists the user can see, newest first,
+// with the total number of them and the user's family count
+func (s *Service) GetUserShoppingListsPage(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*ShoppingListWithFamily, int, int, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetUserShoppingListsPage")
+	defer span.End()
+
+	familyCount, err := s.countUserFamilies(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, err
+	}
+
+	const visibleLists = `
+		FROM shopping_lists sl
+		LEFT JOIN families f ON sl.family_id = f.id
+		WHERE (sl.owner_id = $1
+		   OR EXISTS (SELECT 1 FROM family_members fm WHERE fm.family_id = sl.family_id AND fm.user_id = $1))
+		  AND sl.is_archived = false
+	`
+
+	var total int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) `+visibleLists, userID).Scan(&total); err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, fmt.Errorf("failed to count user shopping lists: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT sl.id, sl.name, sl.description, sl.owner_id, sl.family_id, sl.is_shared, sl.is_archived,
+		       sl.created_at, sl.updated_at, f.name as family_name
+		`+visibleLists+`
+		ORDER BY sl.created_at DESC, sl.id
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, fmt.Errorf("failed to get user shopping lists with families: %w", err)
+	}
+
+	lists, err := scanShoppingListsWithFamilies(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, err
+	}
+
+	return lists, total, familyCount, nil
+}
+
+// countUserFamilies returns the number of families the user is a member of
+func (s *Service) countUserFamilies(ctx context.Context, userID uuid.UUID) (int, error) {
+	familyCountQuery := `
+		SELECT COUNT(DISTINCT f.id)
+		FROM families f
+		JOIN family_members fm ON f.id = fm.family_id
+		WHERE fm.user_id = $1
+	`
+
+	var familyCount int
+	if err := s.db.QueryRow(ctx, familyCountQuery, userID).Scan(&familyCount); err != nil {
+		return 0, fmt.Errorf("failed to get user family count: %w", err)
+	}
+
+	return familyCount, nil
+}
+
+// scanShoppingListsWithFamilies reads lists selected with their family name and closes rows
+func scanShoppingListsWithFamilies(rows pgx.Rows) ([]*ShoppingListWithFamily, error) {
 	defer rows.Close()
 
 	var lists []*ShoppingListWithFamily
@@ -263,8 +331,7 @@ func (s *Service) GetUserShoppingListsWithFamilies(ctx context.Context, userID u
 			&familyName,
 		)
 		if err != nil {
-			span.RecordError(err)
-			return nil, 0, fmt.Errorf("failed to scan shopping list with family: %w", err)
+			return nil, fmt.Errorf("failed to scan shopping list with family: %w", err)
 		}
 
 		listWithFamily := &ShoppingListWithFamily{
@@ -274,12 +341,11 @@ func (s *Service) GetUserShoppingListsWithFamilies(ctx context.Context, userID u
 		lists = append(lists, listWithFamily)
 	}
 
-	if err = rows.Err(); err != nil {
-		span.RecordError(err)
-		return nil, 0, fmt.Errorf("error iterating over shopping lists with families: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over shopping lists with families: %w", err)
 	}
 
-	return lists, familyCount, nil
+	return lists, nil
 }
 
 func (s *Service) GetShoppingListByID(ctx context.Context, listID uuid.UUID) (*ShoppingList, error) {
@@ -305,7 +371,7 @@ func (s *Service) GetShoppingListByID(ctx context.Context, listID uuid.UUID) (*S
 		&list.UpdatedAt,
 	)
 
-	if err == sql.ErrNoRows {
+	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
@@ -400,6 +466,8 @@ func (s *Service) AddItemToListWithLanguage(ctx context.Context, listID uuid.UUI
 			))
 	}
 
+	s.publishListEvent(ctx, ListEvent{Type: ListEventItemAdded, ListID: listID, ItemID: &item.ID, Item: &item, UserID: &addedBy})
+
 	return &item, nil
 }
 
@@ -421,6 +489,55 @@ func (s *Service) GetListItems(ctx context.Context, listID uuid.UUID) ([]*Shoppi
 		span.RecordError(err)
 		return nil, fmt.Errorf("failed to get list items: %w", err)
 	}
+
+	items, err := scanShoppingItems(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetListItemsPage returns one page of the items of a list, open items first, with the total number
+// of items
+func (s *Service) GetListItemsPage(ctx context.Context, listID uuid.UUID, limit, offset int) ([]*ShoppingItem, int, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetListItemsPage")
+	defer span.End()
+
+	var total int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) FROM shopping_items WHERE list_id = $1`, listID).Scan(&total); err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to count list items: %w", err)
+	}
+
+	query := `
+		SELECT id, list_id, name, quantity, notes, is_completed, added_by, completed_by, completed_at,
+		       original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+		       created_at, updated_at
+		FROM shopping_items
+		WHERE list_id = $1
+		ORDER BY is_completed ASC, created_at ASC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.Query(ctx, query, listID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, fmt.Errorf("failed to get list items: %w", err)
+	}
+
+	items, err := scanShoppingItems(rows)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, err
+	}
+
+	return items, total, nil
+}
+
+// scanShoppingItems reads items selected with all their columns and closes rows
+func scanShoppingItems(rows pgx.Rows) ([]*ShoppingItem, error) {
 	defer rows.Close()
 
 	var items []*ShoppingItem
@@ -445,20 +562,81 @@ func (s *Service) GetListItems(ctx context.Context, listID uuid.UUID) ([]*Shoppi
 			&item.UpdatedAt,
 		)
 		if err != nil {
-			span.RecordError(err)
 			return nil, fmt.Errorf("failed to scan shopping item: %w", err)
 		}
 		items = append(items, &item)
 	}
 
-	if err = rows.Err(); err != nil {
-		span.RecordError(err)
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating over shopping items: %w", err)
 	}
 
 	return items, nil
 }
 
+// GetListItem returns a single item of a shopping list, or nil if the item does not belong to the list
+func (s *Service) GetListItem(ctx context.Context, listID, itemID uuid.UUID) (*ShoppingItem, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetListItem")
+	defer span.End()
+
+	query := `
+		SELECT id, list_id, name, quantity, notes, is_completed, added_by, completed_by, completed_at,
+		       original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+		       created_at, updated_at
+		FROM shopping_items
+		WHERE id = $1 AND list_id = $2
+	`
+
+	var item ShoppingItem
+	err := s.db.QueryRow(ctx, query, itemID, listID).Scan(
+		&item.ID,
+		&item.ListID,
+		&item.Name,
+		&item.Quantity,
+		&item.Notes,
+		&item.IsCompleted,
+		&item.AddedBy,
+		&item.CompletedBy,
+		&item.CompletedAt,
+		&item.OriginalItemID,
+		&item.ParsedItemID,
+		&item.DisplayName,
+		&item.ParsedName,
+		&item.ParsingStatus,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// IsListCompleted reports whether the list has items and all of them are checked off
+func (s *Service) IsListCompleted(ctx context.Context, listID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "shopping.IsListCompleted")
+	defer span.End()
+
+	var completed bool
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) > 0 AND BOOL_AND(is_completed)
+		FROM shopping_items
+		WHERE list_id = $1
+	`, listID).Scan(&completed)
+	if err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to check list completion: %w", err)
+	}
+
+	return completed, nil
+}
+
 func (s *Service) CompleteItem(ctx context.Context, itemID uuid.UUID, completedBy uuid.UUID) error {
 	ctx, span := tracer.Start(ctx, "shopping.CompleteItem")
 	defer span.End()
@@ -507,6 +685,8 @@ func (s *Service) CompleteItem(ctx context.Context, itemID uuid.UUID, completedB
 		)
 	}
 
+	s.publishItemEvent(ctx, ListEventItemCompleted, itemID, &completedBy)
+
 	return nil
 }
 
@@ -558,6 +738,8 @@ func (s *Service) UncompleteItem(ctx context.Context, itemID uuid.UUID) error {
 		)
 	}
 
+	s.publishItemEvent(ctx, ListEventItemUncompleted, itemID, nil)
+
 	return nil
 }
 
@@ -565,10 +747,11 @@ func (s *Service) DeleteItem(ctx context.Context, itemID uuid.UUID) error {
 	ctx, span := tracer.Start(ctx, "shopping.DeleteItem")
 	defer span.End()
 
-	query := `DELETE FROM shopping_items WHERE id = $1`
+	query := `DELETE FROM shopping_items WHERE id = $1 RETURNING list_id`
 
-	result, err := s.db.Exec(ctx, query, itemID)
-	if err != nil {
+	var listID uuid.UUID
+	err := s.db.QueryRow(ctx, query, itemID).Scan(&listID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		span.RecordError(err)
 		// Record error metric
 		if telemetry.ShoppingItemOperations != nil {
@@ -582,7 +765,7 @@ func (s *Service) DeleteItem(ctx context.Context, itemID uuid.UUID) error {
 		return fmt.Errorf("failed to delete item %d: %w", itemID, err)
 	}
 
-	if result.RowsAffected() == 0 {
+	if errors.Is(err, pgx.ErrNoRows) {
 		// Record not found metric
 		if telemetry.ShoppingItemOperations != nil {
 			telemetry.ShoppingItemOperations.Add(ctx, 1,
@@ -605,6 +788,8 @@ func (s *Service) DeleteItem(ctx context.Context, itemID uuid.UUID) error {
 		)
 	}
 
+	s.publishListEvent(ctx, ListEvent{Type: ListEventItemDeleted, ListID: listID, ItemID: &itemID})
+
 	return nil
 }
 
@@ -700,11 +885,13 @@ func (s *Service) DeleteShoppingList(ctx context.Context, listID uuid.UUID) erro
 		)
 	}
 
+	s.publishListEvent(ctx, ListEvent{Type: ListEventListDeleted, ListID: listID})
+
 	return nil
 }
 
-// ArchiveShoppingList marks a shopping list as archived
-func (s *Service) ArchiveShoppingList(ctx context.Context, listID uuid.UUID) error {
+// ArchiveShoppingList marks a shopping list as archived, archivedBy is the user who finished it
+func (s *Service) ArchiveShoppingList(ctx context.Context, listID uuid.UUID, archivedBy uuid.UUID) error {
 	ctx, span := tracer.Start(ctx, "shopping.ArchiveShoppingList")
 	defer span.End()
 
@@ -752,6 +939,8 @@ func (s *Service) ArchiveShoppingList(ctx context.Context, listID uuid.UUID) err
 		)
 	}
 
+	s.publishListEvent(ctx, ListEvent{Type: ListEventListArchived, ListID: listID, UserID: &archivedBy})
+
 	// Update active lists counter
 	if telemetry.ShoppingListsActive != nil {
 		telemetry.ShoppingListsActive.Add(ctx, -1)
@@ -1024,6 +1213,7 @@ func (s *Service) AddParsedItemsToList(ctx context.Context, listID uuid.UUID, pa
 		}
 
 		addedItems = append(addedItems, &item)
+		s.publishListEvent(ctx, ListEvent{Type: ListEventItemAdded, ListID: listID, ItemID: &item.ID, Item: &item, UserID: &addedBy})
 		s.logger.Info("Successfully added AI-parsed item",
 			"original", itemName,
 			"parsed", parsedResult.StandardizedName,
@@ -1110,6 +1300,7 @@ func (s *Service) AddItemsToListWithAI(ctx context.Context, listID uuid.UUID, ra
 			}
 
 			addedItems = append(addedItems, &item)
+			s.publishListEvent(ctx, ListEvent{Type: ListEventItemAdded, ListID: listID, ItemID: &item.ID, Item: &item, UserID: &addedBy})
 			s.logger.Info("Successfully added AI-parsed item",
 				"original", itemName,
 				"parsed", parsedResult.StandardizedName,
@@ -1186,6 +1377,8 @@ func (s *Service) UpdateShoppingItem(ctx context.Context, itemID uuid.UUID, req
 		"name_updated", req.Name != nil,
 		"quantity_updated", req.Quantity != nil)
 
+	s.publishItemEvent(ctx, ListEventItemUpdated, itemID, &updatedBy)
+
 	return nil
 }
 
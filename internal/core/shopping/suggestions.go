@@ -0,0 +1,336 @@
+package shopping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// suggestionHistoryDays is how far back purchases are learned from
+	suggestionHistoryDays = 365
+	// minSuggestionPurchases is how often a product must have been bought before it is suggested,
+	// two intervals between purchases make a habit
+	minSuggestionPurchases = 3
+	// suggestionLeadDays suggests a product this many days before it is usually bought again
+	suggestionLeadDays = 1
+	// maxListSuggestions is how many products are suggested at once
+	maxListSuggestions = 3
+
+	// acceptedIntervalFactor and dismissedIntervalFactor adjust the learned interval of a
+	// product: suggestions that are dismissed come later, accepted ones earlier again
+	acceptedIntervalFactor  = 0.9
+	dismissedIntervalFactor = 1.25
+	minIntervalFactor       = 0.5
+	maxIntervalFactor       = 4.0
+)
+
+// ErrSuggestionNotFound is returned when a product is not suggested for the list (anymore)
+var ErrSuggestionNotFound = errors.New("suggestion not found")
+
+// ItemSuggestion is a product the family of a list, or the owner of a personal list, usually buys
+// about now
+type ItemSuggestion struct {
+	ParsedItemID    uuid.UUID `json:"parsed_item_id"`
+	Name            string    `json:"name"`
+	IntervalDays    int       `json:"interval_days"` // usually bought every this many days
+	DaysSinceLast   int       `json:"days_since_last"`
+	LastPurchasedOn time.Time `json:"last_purchased_on"`
+	Purchases       int       `json:"purchases"` // days the product was bought on in the history
+
+	intervalFactor float64 // learned from the answers to earlier suggestions of the product
+}
+
+// purchaseHabit is how often a product is bought, learned from the days it was bought on
+type purchaseHabit struct {
+	purchases    int
+	lastDay      time.Time
+	intervalDays float64 // average days between purchases, adjusted by the interval factor
+}
+
+// learnPurchaseHabit averages the time between the distinct purchase days, sorted oldest first,
+// and adjusts it by the interval factor. Products bought on fewer than minSuggestionPurchases
+// days have no habit yet.
+func learnPurchaseHabit(days []time.Time, intervalFactor float64) (purchaseHabit, bool) {
+	if len(days) < minSuggestionPurchases {
+		return purchaseHabit{}, false
+	}
+
+	first, last := days[0], days[len(days)-1]
+	averageGap := daysBetween(first, last) / float64(len(days)-1)
+	if averageGap <= 0 {
+		return purchaseHabit{}, false
+	}
+
+	return purchaseHabit{
+		purchases:    len(days),
+		lastDay:      last,
+		intervalDays: averageGap * intervalFactor,
+	}, true
+}
+
+// daysSinceLast returns the whole days from the last purchase to today
+func (h purchaseHabit) daysSinceLast(today time.Time) int {
+	return int(math.Round(daysBetween(h.lastDay, today)))
+}
+
+// due reports whether the product is bought again within suggestionLeadDays of today
+func (h purchaseHabit) due(today time.Time) bool {
+	return float64(h.daysSinceLast(today)+suggestionLeadDays) >= h.intervalDays
+}
+
+// overdue is the time since the last purchase relative to the interval, suggestions that are most
+// overdue come first
+func (h purchaseHabit) overdue(today time.Time) float64 {
+	return float64(h.daysSinceLast(today)) / h.intervalDays
+}
+
+// adjustIntervalFactor returns the interval factor after a suggestion was accepted or dismissed
+func adjustIntervalFactor(factor float64, accepted bool) float64 {
+	if accepted {
+		factor *= acceptedIntervalFactor
+	} else {
+		factor *= dismissedIntervalFactor
+	}
+	return math.Min(math.Max(factor, minIntervalFactor), maxIntervalFactor)
+}
+
+// daysBetween returns the calendar days from one date to another, in UTC so that a summer time
+// change doesn't make a day shorter
+func daysBetween(from, to time.Time) float64 {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return to.Sub(from).Hours() / 24
+}
+
+// GetListSuggestions proposes products for a list from the purchase history of its family, or of
+// its owner for a personal list. Purchases are the days items were checked off and the days
+// receipts listed them. A product is proposed once the time since it was last bought reaches the
+// average time between its purchases, adjusted by the earlier answers to its suggestions. Products
+// on the list, on another open list of the family or snoozed by a dismissal are left out.
+func (s *Service) GetListSuggestions(ctx context.Context, listID uuid.UUID) ([]*ItemSuggestion, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetListSuggestions")
+	defer span.End()
+
+	list, err := s.GetShoppingListByID(ctx, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if list == nil {
+		return nil, fmt.Errorf("shopping list not found")
+	}
+
+	// Purchase days of the products bought often enough, the habits are learned from them below
+	rows, err := s.db.Query(ctx, `
+		WITH scope_lists AS (
+			SELECT id, is_archived
+			FROM shopping_lists
+			WHERE ($1::uuid IS NOT NULL AND family_id = $1)
+			   OR ($1::uuid IS NULL AND family_id IS NULL AND owner_id = $2)
+		),
+		purchases AS (
+			SELECT si.parsed_item_id, si.completed_at::date AS day
+			FROM shopping_items si
+			JOIN scope_lists sl ON sl.id = si.list_id
+			WHERE si.is_completed AND si.parsed_item_id IS NOT NULL
+			  AND si.completed_at >= CURRENT_DATE - $4::int
+			UNION
+			SELECT po.parsed_item_id, po.observed_on AS day
+			FROM price_observations po
+			JOIN users_receipts ur ON ur.id = po.receipt_id
+			WHERE po.parsed_item_id IS NOT NULL
+			  AND po.observed_on >= CURRENT_DATE - $4::int
+			  AND (($1::uuid IS NOT NULL AND ur.family_id = $1)
+			    OR ($1::uuid IS NULL AND ur.family_id IS NULL AND ur.user_id = $2))
+		)
+		SELECT p.parsed_item_id, pi.standardized_name, array_agg(p.day ORDER BY p.day),
+		       COALESCE(f.interval_factor, 1)::float8
+		FROM purchases p
+		JOIN parsed_items pi ON pi.id = p.parsed_item_id
+		LEFT JOIN item_suggestion_feedback f ON f.parsed_item_id = p.parsed_item_id
+		     AND COALESCE(f.family_id, f.user_id) = COALESCE($1, $2)
+		WHERE (f.snoozed_until IS NULL OR f.snoozed_until <= NOW())
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM shopping_items li
+			JOIN scope_lists ll ON ll.id = li.list_id
+			WHERE li.parsed_item_id = p.parsed_item_id
+			  AND (li.list_id = $3 OR (NOT li.is_completed AND NOT ll.is_archived))
+		  )
+		GROUP BY p.parsed_item_id, pi.standardized_name, f.interval_factor
+		HAVING COUNT(*) >= $5
+	`, list.FamilyID, list.OwnerID, list.ID, suggestionHistoryDays, minSuggestionPurchases)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	today := time.Now().UTC()
+	type candidate struct {
+		suggestion *ItemSuggestion
+		overdue    float64
+	}
+	var candidates []candidate
+	for rows.Next() {
+		suggestion := &ItemSuggestion{}
+		var days []time.Time
+		if err := rows.Scan(&suggestion.ParsedItemID, &suggestion.Name, &days, &suggestion.intervalFactor); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list suggestion: %w", err)
+		}
+
+		habit, ok := learnPurchaseHabit(days, suggestion.intervalFactor)
+		if !ok || !habit.due(today) {
+			continue
+		}
+
+		suggestion.IntervalDays = max(1, int(math.Round(habit.intervalDays)))
+		suggestion.DaysSinceLast = habit.daysSinceLast(today)
+		suggestion.LastPurchasedOn = habit.lastDay
+		suggestion.Purchases = habit.purchases
+		candidates = append(candidates, candidate{suggestion: suggestion, overdue: habit.overdue(today)})
+	}
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list suggestions: %w", err)
+	}
+
+	// Most overdue first, then the products bought most often
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].overdue != candidates[j].overdue {
+			return candidates[i].overdue > candidates[j].overdue
+		}
+		return candidates[i].suggestion.Purchases > candidates[j].suggestion.Purchases
+	})
+
+	var suggestions []*ItemSuggestion
+	for _, candidate := range candidates[:min(len(candidates), maxListSuggestions)] {
+		suggestions = append(suggestions, candidate.suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// AcceptSuggestion adds the suggested product to the list and suggests it a little earlier next
+// time
+func (s *Service) AcceptSuggestion(ctx context.Context, listID, parsedItemID, addedBy uuid.UUID) (*ShoppingItem, error) {
+	ctx, span := tracer.Start(ctx, "shopping.AcceptSuggestion")
+	defer span.End()
+
+	list, suggestion, err := s.findListSuggestion(ctx, listID, parsedItemID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	var item ShoppingItem
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO shopping_items (list_id, name, is_completed, added_by, parsed_item_id, display_name, parsed_name,
+		                            parsing_status, created_at, updated_at)
+		VALUES ($1, $2::text, false, $3, $4, $2::text, $2::text, 'parsed', NOW(), NOW())
+		RETURNING id, list_id, name, quantity, notes, is_completed, added_by, completed_by, completed_at,
+		          original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+		          created_at, updated_at
+	`, list.ID, suggestion.Name, addedBy, suggestion.ParsedItemID).Scan(
+		&item.ID, &item.ListID, &item.Name, &item.Quantity, &item.Notes, &item.IsCompleted,
+		&item.AddedBy, &item.CompletedBy, &item.CompletedAt,
+		&item.OriginalItemID, &item.ParsedItemID, &item.DisplayName, &item.ParsedName, &item.ParsingStatus,
+		&item.CreatedAt, &item.UpdatedAt,
+	)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to add suggested item: %w", err)
+	}
+
+	s.publishListEvent(ctx, ListEvent{Type: ListEventItemAdded, ListID: list.ID, ItemID: &item.ID, Item: &item, UserID: &addedBy})
+
+	if err := s.recordSuggestionFeedback(ctx, list, suggestion, true, nil); err != nil {
+		// The item is on the list, only the learning is lost
+		s.logger.Warn("Failed to record accepted suggestion", "error", err, "list_id", list.ID, "parsed_item_id", parsedItemID)
+	}
+
+	return &item, nil
+}
+
+// DismissSuggestion stops suggesting the product for one more of its intervals and suggests it
+// later from then on
+func (s *Service) DismissSuggestion(ctx context.Context, listID, parsedItemID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "shopping.DismissSuggestion")
+	defer span.End()
+
+	list, suggestion, err := s.findListSuggestion(ctx, listID, parsedItemID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	snoozedUntil := time.Now().AddDate(0, 0, suggestion.IntervalDays)
+	if err := s.recordSuggestionFeedback(ctx, list, suggestion, false, &snoozedUntil); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// findListSuggestion returns the list and the current suggestion of the product for it
+func (s *Service) findListSuggestion(ctx context.Context, listID, parsedItemID uuid.UUID) (*ShoppingList, *ItemSuggestion, error) {
+	list, err := s.GetShoppingListByID(ctx, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if list == nil {
+		return nil, nil, fmt.Errorf("shopping list not found")
+	}
+
+	suggestions, err := s.GetListSuggestions(ctx, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, suggestion := range suggestions {
+		if suggestion.ParsedItemID == parsedItemID {
+			return list, suggestion, nil
+		}
+	}
+
+	return nil, nil, ErrSuggestionNotFound
+}
+
+// recordSuggestionFeedback counts the answer to a suggestion for the family of the list, or its
+// owner for a personal list, and adjusts the interval the product is suggested at
+func (s *Service) recordSuggestionFeedback(ctx context.Context, list *ShoppingList, suggestion *ItemSuggestion, accepted bool, snoozedUntil *time.Time) error {
+	var userID *uuid.UUID
+	if list.FamilyID == nil {
+		userID = &list.OwnerID
+	}
+
+	acceptedCount, dismissedCount := 0, 1
+	if accepted {
+		acceptedCount, dismissedCount = 1, 0
+	}
+	intervalFactor := adjustIntervalFactor(suggestion.intervalFactor, accepted)
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO item_suggestion_feedback (family_id, user_id, parsed_item_id, accepted_count, dismissed_count,
+		                                      interval_factor, snoozed_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT ((COALESCE(family_id, user_id)), parsed_item_id) DO UPDATE
+		SET accepted_count = item_suggestion_feedback.accepted_count + EXCLUDED.accepted_count,
+		    dismissed_count = item_suggestion_feedback.dismissed_count + EXCLUDED.dismissed_count,
+		    interval_factor = EXCLUDED.interval_factor,
+		    snoozed_until = EXCLUDED.snoozed_until,
+		    updated_at = NOW()
+	`, list.FamilyID, userID, suggestion.ParsedItemID, acceptedCount, dismissedCount, intervalFactor, snoozedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to record suggestion feedback: %w", err)
+	}
+
+	return nil
+}
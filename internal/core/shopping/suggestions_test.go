@@ -0,0 +1,136 @@
+package shopping
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLearnPurchaseHabit(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name         string
+		days         []time.Time
+		factor       float64
+		wantOK       bool
+		wantInterval float64
+	}{
+		{
+			name:   "too few purchases",
+			days:   []time.Time{day(3, 1), day(3, 8)},
+			factor: 1,
+		},
+		{
+			name:         "weekly",
+			days:         []time.Time{day(3, 1), day(3, 8), day(3, 15)},
+			factor:       1,
+			wantOK:       true,
+			wantInterval: 7,
+		},
+		{
+			name:         "irregular gaps are averaged",
+			days:         []time.Time{day(3, 1), day(3, 3), day(3, 13), day(3, 16)},
+			factor:       1,
+			wantOK:       true,
+			wantInterval: 5,
+		},
+		{
+			name:         "dismissed suggestions stretch the interval",
+			days:         []time.Time{day(3, 1), day(3, 8), day(3, 15)},
+			factor:       1.25,
+			wantOK:       true,
+			wantInterval: 8.75,
+		},
+		{
+			name:         "across the summer time change",
+			days:         []time.Time{day(3, 23), day(3, 30), time.Date(2025, 4, 6, 0, 0, 0, 0, time.FixedZone("EEST", 3*3600))},
+			factor:       1,
+			wantOK:       true,
+			wantInterval: 7,
+		},
+		{
+			name:   "all on one day",
+			days:   []time.Time{day(3, 1), day(3, 1), day(3, 1)},
+			factor: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit, ok := learnPurchaseHabit(tt.days, tt.factor)
+			if ok != tt.wantOK {
+				t.Fatalf("learnPurchaseHabit() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(habit.intervalDays-tt.wantInterval) > 1e-9 {
+				t.Errorf("intervalDays = %v, want %v", habit.intervalDays, tt.wantInterval)
+			}
+			if habit.purchases != len(tt.days) || !habit.lastDay.Equal(tt.days[len(tt.days)-1]) {
+				t.Errorf("habit = %+v", habit)
+			}
+		})
+	}
+}
+
+func TestPurchaseHabitDue(t *testing.T) {
+	lastDay := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		intervalDays float64
+		today        time.Time
+		wantSince    int
+		wantDue      bool
+		wantOverdue  float64
+	}{
+		{"bought today", 7, time.Date(2025, 3, 15, 18, 0, 0, 0, time.UTC), 0, false, 0},
+		{"two days before", 7, time.Date(2025, 3, 20, 9, 0, 0, 0, time.UTC), 5, false, 5.0 / 7},
+		{"a day before", 7, time.Date(2025, 3, 21, 9, 0, 0, 0, time.UTC), 6, true, 6.0 / 7},
+		{"on the day", 7, time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC), 7, true, 1},
+		{"overdue", 7, time.Date(2025, 3, 29, 0, 0, 0, 0, time.UTC), 14, true, 2},
+		{"fractional interval", 8.75, time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC), 7, false, 0.8},
+		{"fractional interval a day before", 8.75, time.Date(2025, 3, 23, 0, 0, 0, 0, time.UTC), 8, true, 8 / 8.75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			habit := purchaseHabit{purchases: 3, lastDay: lastDay, intervalDays: tt.intervalDays}
+			if got := habit.daysSinceLast(tt.today); got != tt.wantSince {
+				t.Errorf("daysSinceLast() = %d, want %d", got, tt.wantSince)
+			}
+			if got := habit.due(tt.today); got != tt.wantDue {
+				t.Errorf("due() = %v, want %v", got, tt.wantDue)
+			}
+			if got := habit.overdue(tt.today); math.Abs(got-tt.wantOverdue) > 1e-9 {
+				t.Errorf("overdue() = %v, want %v", got, tt.wantOverdue)
+			}
+		})
+	}
+}
+
+func TestAdjustIntervalFactor(t *testing.T) {
+	tests := []struct {
+		name     string
+		factor   float64
+		accepted bool
+		want     float64
+	}{
+		{"accepted comes earlier", 1, true, 0.9},
+		{"dismissed comes later", 1, false, 1.25},
+		{"accepted after a dismissal", 1.25, true, 1.125},
+		{"not earlier than half", 0.52, true, minIntervalFactor},
+		{"not later than four times", 3.5, false, maxIntervalFactor},
+		{"at the limit", maxIntervalFactor, false, maxIntervalFactor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := adjustIntervalFactor(tt.factor, tt.accepted); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("adjustIntervalFactor(%v, %v) = %v, want %v", tt.factor, tt.accepted, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,513 @@
+package shopping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/ai"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidTemplateSchedule is returned for schedules with an unknown weekday, time or timezone
+var ErrInvalidTemplateSchedule = errors.New("invalid template schedule")
+
+// ListTemplate is a reusable shopping list. Lists created from it start with its items, on demand
+// or every week when it has a schedule.
+type ListTemplate struct {
+	ID               uuid.UUID           `json:"id" db:"id"`
+	Name             string              `json:"name" db:"name"`
+	OwnerID          uuid.UUID           `json:"owner_id" db:"owner_id"`
+	FamilyID         *uuid.UUID          `json:"family_id" db:"family_id"`
+	ScheduleWeekday  *int                `json:"schedule_weekday" db:"schedule_weekday"` // 0 is Sunday, nil without a schedule
+	ScheduleMinute   *int                `json:"schedule_minute" db:"schedule_minute"`   // minute of the day
+	ScheduleTimezone string              `json:"schedule_timezone" db:"schedule_timezone"`
+	NextRunAt        *time.Time          `json:"next_run_at" db:"next_run_at"`
+	ItemCount        int                 `json:"item_count"`
+	Items            []*ListTemplateItem `json:"items,omitempty"`
+	CreatedAt        time.Time           `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at" db:"updated_at"`
+}
+
+// ListTemplateItem is an item lists created from a template start with
+type ListTemplateItem struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	TemplateID     uuid.UUID  `json:"template_id" db:"template_id"`
+	Name           string     `json:"name" db:"name"`
+	Quantity       *string    `json:"quantity" db:"quantity"`
+	Notes          *string    `json:"notes" db:"notes"`
+	DisplayName    *string    `json:"display_name" db:"display_name"`
+	ParsedName     *string    `json:"parsed_name" db:"parsed_name"`
+	OriginalItemID *uuid.UUID `json:"original_item_id" db:"original_item_id"`
+	ParsedItemID   *uuid.UUID `json:"parsed_item_id" db:"parsed_item_id"`
+	Position       int        `json:"position" db:"position"`
+}
+
+// HasSchedule reports whether lists are created from the template every week
+func (t *ListTemplate) HasSchedule() bool {
+	return t.ScheduleWeekday != nil && t.ScheduleMinute != nil
+}
+
+// ScheduleDay is the weekday lists are created on, 0 is Sunday and -1 means no schedule
+func (t *ListTemplate) ScheduleDay() int {
+	if t.ScheduleWeekday == nil {
+		return -1
+	}
+	return *t.ScheduleWeekday
+}
+
+// ScheduleTime is the time of the day lists are created at as HH:MM
+func (t *ListTemplate) ScheduleTime() string {
+	if t.ScheduleMinute == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02d:%02d", *t.ScheduleMinute/60, *t.ScheduleMinute%60)
+}
+
+// ItemsText lists the template items one per line the way users type them, for parsing and
+// duplicate detection
+func (t *ListTemplate) ItemsText() string {
+	lines := make([]string, 0, len(t.Items))
+	for _, item := range t.Items {
+		line := item.Name
+		if item.DisplayName != nil && *item.DisplayName != "" {
+			line = *item.DisplayName
+		}
+		if item.Quantity != nil && *item.Quantity != "" {
+			line += " " + *item.Quantity
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ScheduledList is a list created from a template on its schedule
+type ScheduledList struct {
+	Template *ListTemplate
+	List     *ShoppingList
+	Items    []*ShoppingItem
+}
+
+// SaveListAsTemplate creates a template with the name from the items of a list, it belongs to
+// the family of the list
+func (s *Service) SaveListAsTemplate(ctx context.Context, listID uuid.UUID, name string, ownerID uuid.UUID) (*ListTemplate, error) {
+	ctx, span := tracer.Start(ctx, "shopping.SaveListAsTemplate")
+	defer span.End()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var template ListTemplate
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shopping_list_templates (name, owner_id, family_id)
+		SELECT $2, $3, family_id FROM shopping_lists WHERE id = $1
+		RETURNING id, name, owner_id, family_id, schedule_timezone, created_at, updated_at
+	`, listID, name, ownerID).Scan(
+		&template.ID, &template.Name, &template.OwnerID, &template.FamilyID,
+		&template.ScheduleTimezone, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("shopping list not found")
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to create list template: %w", err)
+	}
+
+	result, err := tx.Exec(ctx, `
+		INSERT INTO shopping_list_template_items (template_id, name, quantity, notes, display_name, parsed_name,
+		                                          original_item_id, parsed_item_id, position)
+		SELECT $1, name, quantity, notes, display_name, parsed_name, original_item_id, parsed_item_id,
+		       ROW_NUMBER() OVER (ORDER BY created_at ASC, id ASC)
+		FROM shopping_items
+		WHERE list_id = $2
+	`, template.ID, listID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to copy items to list template: %w", err)
+	}
+	template.ItemCount = int(result.RowsAffected())
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &template, nil
+}
+
+// GetUserListTemplates returns the templates of the user and of their families without items
+func (s *Service) GetUserListTemplates(ctx context.Context, userID uuid.UUID) ([]*ListTemplate, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetUserListTemplates")
+	defer span.End()
+
+	query := `
+		SELECT t.id, t.name, t.owner_id, t.family_id, t.schedule_weekday, t.schedule_minute,
+		       t.schedule_timezone, t.next_run_at, t.created_at, t.updated_at,
+		       (SELECT COUNT(*) FROM shopping_list_template_items i WHERE i.template_id = t.id)
+		FROM shopping_list_templates t
+		WHERE t.owner_id = $1
+		   OR t.family_id IN (SELECT family_id FROM family_members WHERE user_id = $1)
+		ORDER BY t.name ASC
+	`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*ListTemplate
+	for rows.Next() {
+		var template ListTemplate
+		err := rows.Scan(
+			&template.ID, &template.Name, &template.OwnerID, &template.FamilyID,
+			&template.ScheduleWeekday, &template.ScheduleMinute, &template.ScheduleTimezone,
+			&template.NextRunAt, &template.CreatedAt, &template.UpdatedAt, &template.ItemCount,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list template: %w", err)
+		}
+		templates = append(templates, &template)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating over list templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// GetListTemplate returns a template with its items, nil when it doesn't exist
+func (s *Service) GetListTemplate(ctx context.Context, templateID uuid.UUID) (*ListTemplate, error) {
+	ctx, span := tracer.Start(ctx, "shopping.GetListTemplate")
+	defer span.End()
+
+	var template ListTemplate
+	err := s.db.QueryRow(ctx, `
+		SELECT id, name, owner_id, family_id, schedule_weekday, schedule_minute, schedule_timezone,
+		       next_run_at, created_at, updated_at
+		FROM shopping_list_templates
+		WHERE id = $1
+	`, templateID).Scan(
+		&template.ID, &template.Name, &template.OwnerID, &template.FamilyID,
+		&template.ScheduleWeekday, &template.ScheduleMinute, &template.ScheduleTimezone,
+		&template.NextRunAt, &template.CreatedAt, &template.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list template: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, template_id, name, quantity, notes, display_name, parsed_name,
+		       original_item_id, parsed_item_id, position
+		FROM shopping_list_template_items
+		WHERE template_id = $1
+		ORDER BY position ASC
+	`, templateID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get list template items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item ListTemplateItem
+		err := rows.Scan(
+			&item.ID, &item.TemplateID, &item.Name, &item.Quantity, &item.Notes,
+			&item.DisplayName, &item.ParsedName, &item.OriginalItemID, &item.ParsedItemID, &item.Position,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan list template item: %w", err)
+		}
+		template.Items = append(template.Items, &item)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating over list template items: %w", err)
+	}
+	template.ItemCount = len(template.Items)
+
+	return &template, nil
+}
+
+// CanUserAccessTemplate checks if a user owns a template or is a member of its family
+func (s *Service) CanUserAccessTemplate(ctx context.Context, templateID, userID uuid.UUID) (bool, error) {
+	ctx, span := tracer.Start(ctx, "shopping.CanUserAccessTemplate")
+	defer span.End()
+
+	query := `
+		SELECT COUNT(*) > 0
+		FROM shopping_list_templates t
+		LEFT JOIN family_members fm ON t.family_id = fm.family_id
+		WHERE t.id = $1 AND (t.owner_id = $2 OR (t.family_id IS NOT NULL AND fm.user_id = $2))
+	`
+
+	var canAccess bool
+	if err := s.db.QueryRow(ctx, query, templateID, userID).Scan(&canAccess); err != nil {
+		span.RecordError(err)
+		return false, fmt.Errorf("failed to check list template access: %w", err)
+	}
+
+	return canAccess, nil
+}
+
+// DeleteListTemplate deletes a template and its schedule
+func (s *Service) DeleteListTemplate(ctx context.Context, templateID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "shopping.DeleteListTemplate")
+	defer span.End()
+
+	result, err := s.db.Exec(ctx, "DELETE FROM shopping_list_templates WHERE id = $1", templateID)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete list template: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("list template not found")
+	}
+
+	return nil
+}
+
+// CreateListFromTemplate creates a new list of the template's family with the template items
+func (s *Service) CreateListFromTemplate(ctx context.Context, templateID, createdBy uuid.UUID) (*ShoppingList, []*ShoppingItem, error) {
+	ctx, span := tracer.Start(ctx, "shopping.CreateListFromTemplate")
+	defer span.End()
+
+	template, err := s.GetListTemplate(ctx, templateID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+	if template == nil {
+		return nil, nil, fmt.Errorf("list template not found")
+	}
+
+	return s.createListFromTemplate(ctx, template, createdBy, &createdBy)
+}
+
+// createListFromTemplate creates the list for owner and adds the template items, actor is who
+// the item events are published for
+func (s *Service) createListFromTemplate(ctx context.Context, template *ListTemplate, owner uuid.UUID, actor *uuid.UUID) (*ShoppingList, []*ShoppingItem, error) {
+	list, err := s.CreateShoppingList(ctx, CreateShoppingListRequest{
+		Name:     template.Name,
+		OwnerID:  owner,
+		FamilyID: template.FamilyID,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := make([]*ShoppingItem, 0, len(template.Items))
+	for _, templateItem := range template.Items {
+		var item ShoppingItem
+		err := s.db.QueryRow(ctx, `
+			INSERT INTO shopping_items (list_id, name, quantity, notes, is_completed, added_by,
+			                            original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+			                            created_at, updated_at)
+			VALUES ($1, $2, $3, $4, false, $5, $6, $7, $8, $9,
+			        CASE WHEN $7::uuid IS NULL THEN 'pending' ELSE 'parsed' END, NOW(), NOW())
+			RETURNING id, list_id, name, quantity, notes, is_completed, added_by, completed_by, completed_at,
+			          original_item_id, parsed_item_id, display_name, parsed_name, parsing_status,
+			          created_at, updated_at
+		`, list.ID, templateItem.Name, templateItem.Quantity, templateItem.Notes, owner,
+			templateItem.OriginalItemID, templateItem.ParsedItemID, templateItem.DisplayName, templateItem.ParsedName,
+		).Scan(
+			&item.ID, &item.ListID, &item.Name, &item.Quantity, &item.Notes, &item.IsCompleted,
+			&item.AddedBy, &item.CompletedBy, &item.CompletedAt,
+			&item.OriginalItemID, &item.ParsedItemID, &item.DisplayName, &item.ParsedName, &item.ParsingStatus,
+			&item.CreatedAt, &item.UpdatedAt,
+		)
+		if err != nil {
+			s.logger.Error("Failed to add template item to list", "error", err, "item", templateItem.Name, "list_id", list.ID)
+			continue
+		}
+
+		items = append(items, &item)
+		s.publishListEvent(ctx, ListEvent{Type: ListEventItemAdded, ListID: list.ID, ItemID: &item.ID, Item: &item, UserID: actor})
+	}
+
+	return list, items, nil
+}
+
+// CheckTemplateDuplicates parses the template items for adding them to an open list and finds
+// those already on it, like CheckDuplicateItems does for typed items
+func (s *Service) CheckTemplateDuplicates(ctx context.Context, templateID, listID uuid.UUID, languageCode string, addedBy uuid.UUID) ([]*DuplicateItemInfo, []*ai.ParsedResult, error) {
+	ctx, span := tracer.Start(ctx, "shopping.CheckTemplateDuplicates")
+	defer span.End()
+
+	template, err := s.GetListTemplate(ctx, templateID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, nil, err
+	}
+	if template == nil {
+		return nil, nil, fmt.Errorf("list template not found")
+	}
+	if len(template.Items) == 0 {
+		return nil, nil, nil
+	}
+
+	return s.CheckDuplicateItems(ctx, listID, template.ItemsText(), languageCode, addedBy)
+}
+
+// SetListTemplateSchedule creates a list from the template every week on the weekday (0 is
+// Sunday) at the minute of the day in the timezone. A nil weekday removes the schedule.
+func (s *Service) SetListTemplateSchedule(ctx context.Context, templateID uuid.UUID, weekday *int, minute int, timezone string) (*time.Time, error) {
+	ctx, span := tracer.Start(ctx, "shopping.SetListTemplateSchedule")
+	defer span.End()
+
+	var scheduleMinute *int
+	var nextRunAt *time.Time
+	if weekday != nil {
+		if *weekday < 0 || *weekday > 6 || minute < 0 || minute >= 24*60 {
+			return nil, ErrInvalidTemplateSchedule
+		}
+		if timezone == "" {
+			timezone = "UTC"
+		}
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, ErrInvalidTemplateSchedule
+		}
+
+		next := nextTemplateRun(time.Weekday(*weekday), minute, location, time.Now())
+		scheduleMinute = &minute
+		nextRunAt = &next
+	} else {
+		timezone = "UTC"
+	}
+
+	result, err := s.db.Exec(ctx, `
+		UPDATE shopping_list_templates
+		SET schedule_weekday = $2, schedule_minute = $3, schedule_timezone = $4, next_run_at = $5, updated_at = NOW()
+		WHERE id = $1
+	`, templateID, weekday, scheduleMinute, timezone, nextRunAt)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to save list template schedule: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, fmt.Errorf("list template not found")
+	}
+
+	return nextRunAt, nil
+}
+
+// CreateScheduledLists creates the lists of the templates scheduled until now. Each run is
+// claimed by one replica and moved to the next week before its list is created, a run missed
+// while the service was down is made up for once.
+func (s *Service) CreateScheduledLists(ctx context.Context, now time.Time) ([]*ScheduledList, error) {
+	ctx, span := tracer.Start(ctx, "shopping.CreateScheduledLists")
+	defer span.End()
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, schedule_weekday, schedule_minute, schedule_timezone
+		FROM shopping_list_templates
+		WHERE next_run_at <= $1
+		FOR UPDATE SKIP LOCKED
+	`, now)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get scheduled list templates: %w", err)
+	}
+
+	type dueTemplate struct {
+		id       uuid.UUID
+		weekday  int
+		minute   int
+		timezone string
+	}
+
+	var due []dueTemplate
+	for rows.Next() {
+		var template dueTemplate
+		if err := rows.Scan(&template.id, &template.weekday, &template.minute, &template.timezone); err != nil {
+			rows.Close()
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan scheduled list template: %w", err)
+		}
+		due = append(due, template)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating over scheduled list templates: %w", err)
+	}
+
+	for _, template := range due {
+		location, err := time.LoadLocation(template.timezone)
+		if err != nil {
+			location = time.UTC
+		}
+		next := nextTemplateRun(time.Weekday(template.weekday), template.minute, location, now)
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE shopping_list_templates SET next_run_at = $2 WHERE id = $1
+		`, template.id, next); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to reschedule list template: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	var created []*ScheduledList
+	for _, run := range due {
+		template, err := s.GetListTemplate(ctx, run.id)
+		if err != nil || template == nil {
+			s.logger.Error("Failed to load scheduled list template", "error", err, "template_id", run.id)
+			continue
+		}
+
+		// Scheduled items are not added by anyone, the family gets one notice about the list
+		list, items, err := s.createListFromTemplate(ctx, template, template.OwnerID, nil)
+		if err != nil {
+			s.logger.Error("Failed to create scheduled list", "error", err, "template_id", template.ID)
+			continue
+		}
+
+		created = append(created, &ScheduledList{Template: template, List: list, Items: items})
+	}
+
+	return created, nil
+}
+
+// nextTemplateRun is the first time after after falling on the weekday at the minute of the day
+// in the location
+func nextTemplateRun(weekday time.Weekday, minute int, location *time.Location, after time.Time) time.Time {
+	local := after.In(location)
+	days := (int(weekday) - int(local.Weekday()) + 7) % 7
+	run := time.Date(local.Year(), local.Month(), local.Day()+days, minute/60, minute%60, 0, 0, location)
+	if !run.After(after) {
+		run = time.Date(local.Year(), local.Month(), local.Day()+days+7, minute/60, minute%60, 0, 0, location)
+	}
+	return run
+}
@@ -0,0 +1,107 @@
+package shopping
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextTemplateRun(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		weekday  time.Weekday
+		minute   int
+		location *time.Location
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "later the same day",
+			weekday:  time.Friday,
+			minute:   18 * 60,
+			location: time.UTC,
+			after:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), // Friday
+			want:     time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "earlier the same day",
+			weekday:  time.Friday,
+			minute:   8 * 60,
+			location: time.UTC,
+			after:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2024, 3, 8, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "exactly at the run",
+			weekday:  time.Friday,
+			minute:   9 * 60,
+			location: time.UTC,
+			after:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "later in the week",
+			weekday:  time.Sunday,
+			minute:   10*60 + 30,
+			location: time.UTC,
+			after:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2024, 3, 3, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "earlier in the week",
+			weekday:  time.Monday,
+			minute:   0,
+			location: time.UTC,
+			after:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			want:     time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "across the year",
+			weekday:  time.Wednesday,
+			minute:   23*60 + 59,
+			location: time.UTC,
+			after:    time.Date(2024, 12, 30, 12, 0, 0, 0, time.UTC), // Monday
+			want:     time.Date(2025, 1, 1, 23, 59, 0, 0, time.UTC),
+		},
+		{
+			name:     "local weekday differs from UTC",
+			weekday:  time.Saturday,
+			minute:   1 * 60,
+			location: kyiv,
+			after:    time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC), // Saturday 01:30 in Kyiv
+			want:     time.Date(2024, 3, 9, 1, 0, 0, 0, kyiv),
+		},
+		{
+			name:     "local time of the location",
+			weekday:  time.Saturday,
+			minute:   9 * 60,
+			location: kyiv,
+			after:    time.Date(2024, 1, 13, 6, 0, 0, 0, time.UTC), // Saturday 08:00 in Kyiv
+			want:     time.Date(2024, 1, 13, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "keeps the local time over the summer time change",
+			weekday:  time.Monday,
+			minute:   9 * 60,
+			location: kyiv,
+			after:    time.Date(2024, 3, 25, 10, 0, 0, 0, kyiv), // Monday before the change
+			want:     time.Date(2024, 4, 1, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextTemplateRun(tt.weekday, tt.minute, tt.location, tt.after)
+			if !got.Equal(tt.want) {
+				t.Errorf("nextTemplateRun() = %s, want %s", got, tt.want.In(tt.location))
+			}
+			if !got.After(tt.after) {
+				t.Errorf("nextTemplateRun() = %s is not after %s", got, tt.after)
+			}
+		})
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/PocketPalCo/shopping-service/config"
 	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/notifications"
 	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
 	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
 	"github.com/PocketPalCo/shopping-service/internal/core/stt"
 	"github.com/PocketPalCo/shopping-service/internal/core/telegram/commands"
@@ -42,9 +46,23 @@ type BotService struct {
 	languageHandler         *handlers.LanguageHandler
 	userManagementHandler   *handlers.UserManagementHandler
 	receiptsCallbackHandler *handlers.ReceiptsCallbackHandler
+	notificationsHandler    *handlers.NotificationsHandler
+	listTemplatesHandler    *handlers.ListTemplatesHandler
+	suggestionsHandler      *handlers.SuggestionsHandler
+
+	// listViews keeps the list messages open in chats up to date with the changes published on
+	// listEvents, which is nil when list changes are not followed
+	listViews  *handlers.ListViewTracker
+	listEvents *shopping.ListEventBroker
+
+	// webhook is nil when updates are received through long polling
+	webhook *webhookReceiver
+
+	dispatcher   *updateDispatcher
+	drainTimeout time.Duration
 }
 
-func NewBotService(token string, usersService *users.Service, familiesService *families.Service, shoppingService *shopping.Service, receiptsService *receipts.Service, sttClient *stt.Client, logger *slog.Logger, debug bool) (*BotService, error) {
+func NewBotService(token string, usersService *users.Service, familiesService *families.Service, shoppingService *shopping.Service, receiptsService *receipts.Service, sessionsService *sessions.Service, notificationsService *notifications.Service, stateManager *handlers.StateManager, sttClient *stt.Client, listEvents *shopping.ListEventBroker, dispatcherConfig config.TelegramDispatcherConfig, logger *slog.Logger, debug bool) (*BotService, error) {
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
@@ -58,14 +76,15 @@ func NewBotService(token string, usersService *users.Service, familiesService *f
 	}
 
 	// Set up command registry
-	commandRegistry := commands.SetupCommands(bot, usersService, familiesService, shoppingService, receiptsService, templateManager, logger)
+	commandRegistry := commands.SetupCommands(bot, usersService, familiesService, shoppingService, receiptsService, sessionsService, notificationsService, templateManager, logger)
 
 	// Set up user mapper
 	userMapper := NewUserMapper(usersService)
 
 	// Set up handlers
 	baseHandler := handlers.NewBaseHandler(bot, usersService, familiesService, shoppingService, receiptsService, templateManager, logger)
-	stateManager := handlers.NewStateManager(logger)
+	listViews := handlers.NewListViewTracker(baseHandler)
+	baseHandler = baseHandler.WithListViews(listViews)
 	listCallbackHandler := handlers.NewListCallbackHandler(baseHandler, stateManager)
 	generalCallbackHandler := handlers.NewGeneralCallbackHandler(baseHandler, stateManager)
 	duplicateCallbackHandler := handlers.NewDuplicateCallbackHandler(baseHandler, stateManager)
@@ -77,6 +96,9 @@ func NewBotService(token string, usersService *users.Service, familiesService *f
 	languageHandler := handlers.NewLanguageHandler(baseHandler)
 	userManagementHandler := handlers.NewUserManagementHandler(baseHandler)
 	receiptsCallbackHandler := handlers.NewReceiptsCallbackHandler(baseHandler, stateManager)
+	notificationsHandler := handlers.NewNotificationsHandler(baseHandler, notificationsService)
+	listTemplatesHandler := handlers.NewListTemplatesHandler(baseHandler, stateManager, messageHandler)
+	suggestionsHandler := handlers.NewSuggestionsHandler(baseHandler, listCallbackHandler, stateManager)
 	coreMessageHandler := handlers.NewCoreMessageHandler(baseHandler, sttClient, commandRegistry, stateManager, receiptsCallbackHandler)
 
 	// Set up callback router with all handlers
@@ -89,10 +111,13 @@ func NewBotService(token string, usersService *users.Service, familiesService *f
 		productListCallbackHandler,
 		receiptsCallbackHandler,
 		languageHandler,
+		notificationsHandler,
+		listTemplatesHandler,
+		suggestionsHandler,
 		stateManager,
 	)
 
-	return &BotService{
+	botService := &BotService{
 		bot:                        bot,
 		usersService:               usersService,
 		familiesService:            familiesService,
@@ -117,16 +142,41 @@ func NewBotService(token string, usersService *users.Service, familiesService *f
 		languageHandler:         languageHandler,
 		userManagementHandler:   userManagementHandler,
 		receiptsCallbackHandler: receiptsCallbackHandler,
-	}, nil
+		notificationsHandler:    notificationsHandler,
+		listTemplatesHandler:    listTemplatesHandler,
+		suggestionsHandler:      suggestionsHandler,
+
+		listViews:  listViews,
+		listEvents: listEvents,
+
+		drainTimeout: dispatcherConfig.DrainTimeout,
+	}
+
+	botService.dispatcher = newUpdateDispatcher(botService.handleUpdate, dispatcherConfig.Workers, dispatcherConfig.QueueSize, logger)
+
+	return botService, nil
 }
 
 func (s *BotService) Start(ctx context.Context) error {
 	s.logger.Info("Starting refactored bot service")
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	updates := s.receiveUpdates()
+	defer s.webhook.deactivate()
+
+	// Re-render the list messages open in chats when their list changes anywhere
+	if s.listEvents != nil {
+		events, unsubscribe := s.listEvents.SubscribeAll()
+		defer unsubscribe()
+		go s.listViews.Run(ctx, events)
+
+		// Tell family members about the activity in their shared lists
+		activity, unsubscribeActivity := s.listEvents.SubscribeAll()
+		defer unsubscribeActivity()
+		go s.notificationsHandler.Run(ctx, activity)
+	}
 
-	updates := s.bot.GetUpdatesChan(u)
+	// Create the lists of scheduled templates when they are due
+	go s.listTemplatesHandler.Run(ctx)
 
 	for {
 		select {
@@ -134,7 +184,9 @@ func (s *BotService) Start(ctx context.Context) error {
 			s.logger.Info("Bot service context cancelled, stopping")
 			return ctx.Err()
 		case update := <-updates:
-			go s.handleUpdate(ctx, update)
+			if err := s.dispatcher.Dispatch(ctx, update); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to dispatch update", "error", err, "update_id", update.UpdateID)
+			}
 		}
 	}
 }
@@ -221,4 +273,11 @@ func (s *BotService) answerCallback(callbackID, text string) {
 
 func (s *BotService) Stop() {
 	s.bot.StopReceivingUpdates()
+
+	// Let updates that were already received finish before closing their dependencies
+	s.dispatcher.Stop(s.drainTimeout)
+
+	if err := s.stateManager.Close(); err != nil {
+		s.logger.Error("Failed to close state store", "error", err)
+	}
 }
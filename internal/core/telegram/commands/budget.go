@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	"github.com/google/uuid"
+	"golang.org/x/text/currency"
+)
+
+// BudgetCommand handles the /budget command which shows and sets the budgets of the user's families
+type BudgetCommand struct {
+	BaseCommand
+}
+
+// BudgetTemplateData holds data for the budget set and removed templates
+type BudgetTemplateData struct {
+	FamilyName string
+	Period     string
+	Category   *receipts.ItemCategory
+	Amount     float64
+	Currency   string
+	Locale     string
+}
+
+// NewBudgetCommand creates a new budget command
+func NewBudgetCommand(base BaseCommand) *BudgetCommand {
+	return &BudgetCommand{
+		BaseCommand: base,
+	}
+}
+
+// GetName returns the command name
+func (c *BudgetCommand) GetName() string {
+	return "budget"
+}
+
+// RequiresAuth returns true as budget command requires authorization
+func (c *BudgetCommand) RequiresAuth() bool {
+	return true
+}
+
+// RequiresAdmin returns false as budget command doesn't require admin privileges
+func (c *BudgetCommand) RequiresAdmin() bool {
+	return false
+}
+
+// Handle executes the budget command. Without arguments it shows the budget status, otherwise
+// it sets a budget: /budget <family> <week|month> <amount> [currency] [category]
+func (c *BudgetCommand) Handle(ctx context.Context, chatID int64, user *users.User, args []string) error {
+	if len(args) == 0 {
+		return c.showStatus(ctx, chatID, user)
+	}
+	if len(args) < 3 {
+		return c.showUsage(ctx, chatID, user)
+	}
+
+	// The family name may span several words, the longest one matching a family is taken
+	family, n, err := c.findAdminFamily(ctx, chatID, user, args[:len(args)-2])
+	if err != nil || family == nil {
+		return err
+	}
+	args = args[n:]
+
+	period := strings.ToLower(args[0])
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(args[1], ",", "."), 64)
+	if !receipts.IsValidBudgetPeriod(period) || err != nil || amount < 0 {
+		return c.showUsage(ctx, chatID, user)
+	}
+
+	rest := args[2:]
+	var currency string
+	if len(rest) > 0 && isCurrencyCode(rest[0]) {
+		currency = strings.ToUpper(rest[0])
+		rest = rest[1:]
+	}
+
+	var category *receipts.ItemCategory
+	if len(rest) > 0 {
+		category, err = c.receiptsService.FindItemCategory(ctx, strings.Join(rest, " "))
+		if err != nil {
+			c.logger.Error("Failed to find item category", "error", err)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_saving_budget", user.Locale))
+			return err
+		}
+		if category == nil {
+			return c.showUsage(ctx, chatID, user)
+		}
+	}
+
+	data := BudgetTemplateData{
+		FamilyName: family.Name,
+		Period:     period,
+		Category:   category,
+		Amount:     amount,
+		Locale:     user.Locale,
+	}
+
+	var templateName string
+	if amount == 0 {
+		var categoryID *uuid.UUID
+		if category != nil {
+			categoryID = &category.ID
+		}
+		if _, err := c.receiptsService.DeleteFamilyBudget(ctx, family.ID, categoryID, period); err != nil {
+			c.logger.Error("Failed to delete family budget", "error", err, "family_id", family.ID)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_saving_budget", user.Locale))
+			return err
+		}
+		templateName = "budget_removed"
+	} else {
+		req := receipts.SetFamilyBudgetRequest{
+			FamilyID:  family.ID,
+			Period:    period,
+			Amount:    amount,
+			Currency:  currency,
+			CreatedBy: user.ID,
+		}
+		if category != nil {
+			req.CategoryID = &category.ID
+		}
+
+		budget, err := c.receiptsService.SetFamilyBudget(ctx, req)
+		if err != nil {
+			c.logger.Error("Failed to save family budget", "error", err, "family_id", family.ID)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_saving_budget", user.Locale))
+			return err
+		}
+		data.Currency = budget.Currency
+		templateName = "budget_set"
+	}
+
+	message, err := c.templateManager.RenderTemplate(templateName, user.Locale, data)
+	if err != nil {
+		c.logger.Error("Failed to render budget template", "error", err, "template", templateName)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_internal", user.Locale))
+		return err
+	}
+
+	c.logger.Info("Family budget changed",
+		"family_id", family.ID,
+		"period", period,
+		"amount", amount,
+		"user_id", user.ID)
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
+
+// showStatus sends the spending against the budgets of the user's families
+func (c *BudgetCommand) showStatus(ctx context.Context, chatID int64, user *users.User) error {
+	statuses, err := c.receiptsService.GetBudgetStatuses(ctx, user.ID, time.Now())
+	if err != nil {
+		c.logger.Error("Failed to get budget statuses", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_loading_budgets", user.Locale))
+		return err
+	}
+
+	data := struct {
+		Statuses []*receipts.BudgetStatus
+		Locale   string
+	}{
+		Statuses: statuses,
+		Locale:   user.Locale,
+	}
+
+	message, err := c.templateManager.RenderTemplate("budget_status", user.Locale, data)
+	if err != nil {
+		c.logger.Error("Failed to render budget status template", "error", err)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_loading_budgets", user.Locale))
+		return err
+	}
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
+
+// showUsage sends the budget command usage with the available categories
+func (c *BudgetCommand) showUsage(ctx context.Context, chatID int64, user *users.User) error {
+	categories, err := c.receiptsService.GetItemCategories(ctx, user.Locale)
+	if err != nil {
+		c.logger.Warn("Failed to get item categories for budget usage", "error", err)
+	}
+
+	data := struct {
+		Categories []*receipts.ItemCategory
+		Locale     string
+	}{
+		Categories: categories,
+		Locale:     user.Locale,
+	}
+
+	message, err := c.templateManager.RenderTemplate("budget_usage", user.Locale, data)
+	if err != nil {
+		c.logger.Error("Failed to render budget usage template", "error", err)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_internal", user.Locale))
+		return err
+	}
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
+
+// findAdminFamily finds the user's family named by the leading words of args, preferring the
+// longest name, and checks the user administers it. It returns the family and the number of words
+// of its name. The user is told when there is no such family or the user doesn't administer it,
+// nil is returned then.
+func (c *BudgetCommand) findAdminFamily(ctx context.Context, chatID int64, user *users.User, args []string) (*families.Family, int, error) {
+	familiesInfo, err := c.familiesService.GetUserFamiliesWithInfo(ctx, user.ID)
+	if err != nil {
+		c.logger.Error("Failed to get user families", "error", err)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_retrieve_families", user.Locale))
+		return nil, 0, err
+	}
+
+	names := make([]string, len(familiesInfo))
+	for i, info := range familiesInfo {
+		names[i] = info.Family.Name
+	}
+
+	index, n := matchNamePrefix(names, args)
+	if index < 0 {
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_budget_family_not_found", user.Locale))
+		return nil, 0, nil
+	}
+	if familiesInfo[index].UserRole != "admin" {
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_budget_admin_required", user.Locale))
+		return nil, 0, nil
+	}
+
+	return familiesInfo[index].Family, n, nil
+}
+
+// matchNamePrefix finds the longest of names that the leading words of args spell, ignoring case
+// and the spacing between words. It returns the index of the name and the number of words, or -1
+// when no name matches.
+func matchNamePrefix(names []string, args []string) (int, int) {
+	for n := len(args); n > 0; n-- {
+		prefix := strings.Join(args[:n], " ")
+		for i, name := range names {
+			if strings.EqualFold(strings.Join(strings.Fields(name), " "), prefix) {
+				return i, n
+			}
+		}
+	}
+	return -1, 0
+}
+
+// isCurrencyCode reports whether s is a known ISO 4217 currency code, in any case
+func isCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	unit, err := currency.ParseISO(s)
+	return err == nil && unit != currency.XXX
+}
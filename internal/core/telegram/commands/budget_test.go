@@ -0,0 +1,54 @@
+package commands
+
+import "testing"
+
+func TestMatchNamePrefix(t *testing.T) {
+	names := []string{"Home", "Home Office", "Дача  Бабушки", "Work"}
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantIndex int
+		wantWords int
+	}{
+		{"single word", []string{"home", "month"}, 0, 1},
+		{"longest name wins", []string{"Home", "office", "month"}, 1, 2},
+		{"spacing is ignored", []string{"дача", "бабушки", "week"}, 2, 2},
+		{"whole args", []string{"Work"}, 3, 1},
+		{"no family", []string{"Garden", "month"}, -1, 0},
+		{"name longer than args", []string{"Дача"}, -1, 0},
+		{"no args", nil, -1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, words := matchNamePrefix(names, tt.args)
+			if index != tt.wantIndex || words != tt.wantWords {
+				t.Errorf("matchNamePrefix(%q) = %d, %d, want %d, %d", tt.args, index, words, tt.wantIndex, tt.wantWords)
+			}
+		})
+	}
+}
+
+func TestIsCurrencyCode(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"EUR", true},
+		{"uah", true},
+		{"Usd", true},
+		{"Tea", false},
+		{"Pet", false},
+		{"XXX", false},
+		{"EURO", false},
+		{"€", false},
+		{"грн", false},
+	}
+
+	for _, tt := range tests {
+		if got := isCurrencyCode(tt.s); got != tt.want {
+			t.Errorf("isCurrencyCode(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+)
+
+// LoginCommand handles the /login command which issues a one-time code for the HTTP API
+type LoginCommand struct {
+	BaseCommand
+	sessionsService *sessions.Service
+}
+
+// LoginTemplateData holds data for the login code template
+type LoginTemplateData struct {
+	Code             string
+	ExpiresInMinutes int
+	IsPrivateChat    bool
+}
+
+// NewLoginCommand creates a new login command
+func NewLoginCommand(base BaseCommand, sessionsService *sessions.Service) *LoginCommand {
+	return &LoginCommand{
+		BaseCommand:     base,
+		sessionsService: sessionsService,
+	}
+}
+
+// GetName returns the command name
+func (c *LoginCommand) GetName() string {
+	return "login"
+}
+
+// RequiresAuth returns true as only authorized users can log in to the HTTP API
+func (c *LoginCommand) RequiresAuth() bool {
+	return true
+}
+
+// RequiresAdmin returns false as login command doesn't require admin privileges
+func (c *LoginCommand) RequiresAdmin() bool {
+	return false
+}
+
+// Handle executes the login command
+func (c *LoginCommand) Handle(ctx context.Context, chatID int64, user *users.User, args []string) error {
+	// Never post login codes into group chats
+	if chatID != user.TelegramID {
+		message, err := c.templateManager.RenderTemplate("login_code", user.Locale, LoginTemplateData{IsPrivateChat: false})
+		if err != nil {
+			c.logger.Error("Failed to render login code template", "error", err)
+			return err
+		}
+		c.SendHTMLMessage(chatID, message)
+		return nil
+	}
+
+	loginCode, err := c.sessionsService.CreateLoginCode(ctx, user.ID, chatID)
+	if err != nil {
+		c.logger.Error("Failed to create login code", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, "❌ Internal error occurred. Please try again later.")
+		return err
+	}
+
+	data := LoginTemplateData{
+		Code:             loginCode.Code,
+		ExpiresInMinutes: int(math.Ceil(time.Until(loginCode.ExpiresAt).Minutes())),
+		IsPrivateChat:    true,
+	}
+
+	message, err := c.templateManager.RenderTemplate("login_code", user.Locale, data)
+	if err != nil {
+		c.logger.Error("Failed to render login code template", "error", err)
+		c.SendMessage(chatID, "❌ Internal error occurred. Please try again later.")
+		return err
+	}
+
+	c.logger.Info("Login code issued",
+		"user_id", user.ID,
+		"telegram_id", user.TelegramID)
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
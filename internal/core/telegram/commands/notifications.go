@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/notifications"
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+)
+
+// NotificationsCommand handles the /notifications command which shows and changes how the user
+// hears about the activity in the shopping lists of their families
+type NotificationsCommand struct {
+	BaseCommand
+	notificationsService *notifications.Service
+}
+
+// NewNotificationsCommand creates a new notifications command
+func NewNotificationsCommand(base BaseCommand, notificationsService *notifications.Service) *NotificationsCommand {
+	return &NotificationsCommand{
+		BaseCommand:          base,
+		notificationsService: notificationsService,
+	}
+}
+
+// GetName returns the command name
+func (c *NotificationsCommand) GetName() string {
+	return "notifications"
+}
+
+// RequiresAuth returns true as notifications command requires authorization
+func (c *NotificationsCommand) RequiresAuth() bool {
+	return true
+}
+
+// RequiresAdmin returns false as notifications command doesn't require admin privileges
+func (c *NotificationsCommand) RequiresAdmin() bool {
+	return false
+}
+
+// Handle executes the notifications command. Without arguments it shows the settings, otherwise
+// it changes them: /notifications instant|off|batch [minutes]|quiet <from> <to> [timezone]|
+// quiet off|mute <list>|unmute <list>
+func (c *NotificationsCommand) Handle(ctx context.Context, chatID int64, user *users.User, args []string) error {
+	if len(args) == 0 {
+		return c.showSettings(ctx, chatID, user)
+	}
+
+	var err error
+	switch strings.ToLower(args[0]) {
+	case notifications.DeliveryInstant, notifications.DeliveryOff:
+		err = c.notificationsService.SetDelivery(ctx, user.ID, strings.ToLower(args[0]), 0)
+	case "batch":
+		interval := notifications.DefaultBatchIntervalMinutes
+		if len(args) > 1 {
+			interval, err = strconv.Atoi(args[1])
+			if err != nil {
+				err = notifications.ErrInvalidBatchInterval
+				break
+			}
+		}
+		err = c.notificationsService.SetDelivery(ctx, user.ID, notifications.DeliveryBatched, interval)
+	case "quiet":
+		err = c.setQuietHours(ctx, user, args[1:])
+	case "mute", "unmute":
+		if len(args) < 2 {
+			return c.showSettings(ctx, chatID, user)
+		}
+		list, findErr := c.findList(ctx, user, strings.Join(args[1:], " "))
+		if findErr != nil {
+			c.logger.Error("Failed to get user shopping lists", "error", findErr, "user_id", user.ID)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_retrieve_lists", user.Locale))
+			return findErr
+		}
+		if list == nil {
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_notification_list_not_found", user.Locale))
+			return nil
+		}
+		if strings.EqualFold(args[0], "mute") {
+			err = c.notificationsService.MuteList(ctx, user.ID, list.ID)
+		} else {
+			err = c.notificationsService.UnmuteList(ctx, user.ID, list.ID)
+		}
+	default:
+		return c.showSettings(ctx, chatID, user)
+	}
+
+	if err != nil {
+		if isInvalidSetting(err) {
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_invalid_notification_settings", user.Locale))
+			return nil
+		}
+		c.logger.Error("Failed to save notification settings", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_saving_notification_settings", user.Locale))
+		return err
+	}
+
+	c.logger.Info("Notification settings changed", "setting", strings.ToLower(args[0]), "user_id", user.ID)
+
+	return c.showSettings(ctx, chatID, user)
+}
+
+// setQuietHours sets the quiet hours from "<from> <to> [timezone]" or removes them with "off"
+func (c *NotificationsCommand) setQuietHours(ctx context.Context, user *users.User, args []string) error {
+	if len(args) == 1 && strings.EqualFold(args[0], "off") {
+		return c.notificationsService.SetQuietHours(ctx, user.ID, nil, nil, "")
+	}
+	if len(args) < 2 {
+		return notifications.ErrInvalidQuietHours
+	}
+
+	start, err := notifications.ParseClockTime(args[0])
+	if err != nil {
+		return err
+	}
+	end, err := notifications.ParseClockTime(args[1])
+	if err != nil {
+		return err
+	}
+
+	timezone := ""
+	if len(args) > 2 {
+		timezone = args[2]
+	} else {
+		// Keep the timezone set before
+		prefs, err := c.notificationsService.GetPreferences(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		timezone = prefs.Timezone
+	}
+
+	return c.notificationsService.SetQuietHours(ctx, user.ID, &start, &end, timezone)
+}
+
+// showSettings sends the notification settings of the user with the command usage
+func (c *NotificationsCommand) showSettings(ctx context.Context, chatID int64, user *users.User) error {
+	prefs, err := c.notificationsService.GetPreferences(ctx, user.ID)
+	if err != nil {
+		c.logger.Error("Failed to get notification preferences", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_loading_notification_settings", user.Locale))
+		return err
+	}
+
+	message, err := c.templateManager.RenderTemplate("notification_settings", user.Locale, prefs)
+	if err != nil {
+		c.logger.Error("Failed to render notification settings template", "error", err)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_loading_notification_settings", user.Locale))
+		return err
+	}
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
+
+// findList finds a shopping list of the user by name, nil when there is none
+func (c *NotificationsCommand) findList(ctx context.Context, user *users.User, name string) (*shopping.ShoppingList, error) {
+	lists, err := c.shoppingService.GetUserShoppingLists(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, list := range lists {
+		if strings.EqualFold(list.Name, name) {
+			return list, nil
+		}
+	}
+	return nil, nil
+}
+
+// isInvalidSetting reports whether the error is about a setting the user got wrong
+func isInvalidSetting(err error) bool {
+	return errors.Is(err, notifications.ErrInvalidDelivery) ||
+		errors.Is(err, notifications.ErrInvalidQuietHours) ||
+		errors.Is(err, notifications.ErrInvalidTimezone) ||
+		errors.Is(err, notifications.ErrInvalidBatchInterval)
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+)
+
+// Number of latest purchases shown by the price command
+const pricePointsShown = 5
+
+// PriceCommand handles the /price command which shows what an item cost on the user's receipts
+type PriceCommand struct {
+	BaseCommand
+}
+
+// PriceTemplateData holds data for the price history template
+type PriceTemplateData struct {
+	*receipts.PriceHistory
+	Recent   []receipts.PricePoint // latest first
+	Cheapest *receipts.MerchantPrice
+}
+
+// NewPriceCommand creates a new price command
+func NewPriceCommand(base BaseCommand) *PriceCommand {
+	return &PriceCommand{
+		BaseCommand: base,
+	}
+}
+
+// GetName returns the command name
+func (c *PriceCommand) GetName() string {
+	return "price"
+}
+
+// RequiresAuth returns true as price command requires authorization
+func (c *PriceCommand) RequiresAuth() bool {
+	return true
+}
+
+// RequiresAdmin returns false as price command doesn't require admin privileges
+func (c *PriceCommand) RequiresAdmin() bool {
+	return false
+}
+
+// Handle executes the price command, the arguments are the item to look for
+func (c *PriceCommand) Handle(ctx context.Context, chatID int64, user *users.User, args []string) error {
+	item := strings.Join(args, " ")
+	if item == "" {
+		message, err := c.templateManager.RenderTemplate("price_usage", user.Locale, nil)
+		if err != nil {
+			c.logger.Error("Failed to render price usage template", "error", err)
+			return err
+		}
+		c.SendHTMLMessage(chatID, message)
+		return nil
+	}
+
+	history, err := c.receiptsService.GetPriceHistory(ctx, receipts.PriceHistoryFilter{
+		UserID: user.ID,
+		Item:   item,
+		Locale: user.Locale,
+	})
+	if err != nil {
+		c.logger.Error("Failed to get price history", "error", err, "user_id", user.ID, "item", item)
+		c.SendMessage(chatID, "❌ Internal error occurred. Please try again later.")
+		return err
+	}
+
+	data := PriceTemplateData{PriceHistory: history}
+	for i := len(history.Points) - 1; i >= 0 && len(data.Recent) < pricePointsShown; i-- {
+		data.Recent = append(data.Recent, history.Points[i])
+	}
+	// Stores are only comparable in the same unit and currency
+	if len(history.Merchants) > 1 && history.Merchants[1].Unit == history.Merchants[0].Unit &&
+		history.Merchants[1].Currency == history.Merchants[0].Currency {
+		data.Cheapest = &history.Merchants[0]
+	}
+
+	message, err := c.templateManager.RenderTemplate("price_history", user.Locale, data)
+	if err != nil {
+		c.logger.Error("Failed to render price history template", "error", err)
+		c.SendMessage(chatID, "❌ Internal error occurred. Please try again later.")
+		return err
+	}
+
+	c.logger.Info("Price history shown",
+		"user_id", user.ID,
+		"item", item,
+		"points", len(history.Points))
+
+	c.SendHTMLMessage(chatID, message)
+	return nil
+}
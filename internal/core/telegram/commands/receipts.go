@@ -77,12 +77,16 @@ func (c *ReceiptsCommand) createReceiptsMenuKeyboard(locale string) tgbotapi.Inl
 				"receipts:upload",
 			),
 		},
-		// Second row: View Receipts
+		// Second row: View and Export Receipts
 		{
 			tgbotapi.NewInlineKeyboardButtonData(
 				c.templateManager.RenderButton("view_receipts", locale),
 				"receipts:view",
 			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				c.templateManager.RenderButton("export_receipts", locale),
+				"receipts:export",
+			),
 		},
 		// Third row: Tax Summary and Statistics
 		{
@@ -95,7 +99,18 @@ func (c *ReceiptsCommand) createReceiptsMenuKeyboard(locale string) tgbotapi.Inl
 				"receipts:stats",
 			),
 		},
-		// Fourth row: Home
+		// Fourth row: Family Budgets and Balances
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				c.templateManager.RenderButton("budgets", locale),
+				"receipts:budget",
+			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				c.templateManager.RenderButton("balances", locale),
+				"receipts:balances",
+			),
+		},
+		// Fifth row: Home
 		{
 			tgbotapi.NewInlineKeyboardButtonData(
 				c.templateManager.RenderButton("home", locale),
@@ -4,7 +4,9 @@ import (
 	"log/slog"
 
 	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/notifications"
 	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
 	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
 	"github.com/PocketPalCo/shopping-service/internal/core/users"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -17,6 +19,8 @@ func SetupCommands(
 	familiesService *families.Service,
 	shoppingService *shopping.Service,
 	receiptsService *receipts.Service,
+	sessionsService *sessions.Service,
+	notificationsService *notifications.Service,
 	templateManager TemplateRenderer,
 	logger *slog.Logger,
 ) *CommandRegistry {
@@ -36,6 +40,11 @@ func SetupCommands(
 	registry.Register(NewCreateFamilyCommand(base))
 	registry.Register(NewAddFamilyMemberCommand(base))
 	registry.Register(NewReceiptsCommand(base))
+	registry.Register(NewPriceCommand(base))
+	registry.Register(NewBudgetCommand(base))
+	registry.Register(NewTemplatesCommand(base))
+	registry.Register(NewLoginCommand(base, sessionsService))
+	registry.Register(NewNotificationsCommand(base, notificationsService))
 
 	// Admin commands
 	registry.Register(NewAuthorizeCommand(base))
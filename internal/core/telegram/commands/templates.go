@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// defaultScheduleMinute is when scheduled lists are created without a time, 09:00
+const defaultScheduleMinute = 9 * 60
+
+// weekdays maps the day names users may type, in the supported languages, to the weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday, "вс": time.Sunday, "нд": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday, "пн": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday, "вт": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday, "ср": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday, "чт": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday, "пт": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday, "сб": time.Saturday,
+}
+
+// TemplatesCommand handles the /templates command which shows the list templates of the user and
+// their families and schedules them
+type TemplatesCommand struct {
+	BaseCommand
+}
+
+// NewTemplatesCommand creates a new templates command
+func NewTemplatesCommand(base BaseCommand) *TemplatesCommand {
+	return &TemplatesCommand{
+		BaseCommand: base,
+	}
+}
+
+// GetName returns the command name
+func (c *TemplatesCommand) GetName() string {
+	return "templates"
+}
+
+// RequiresAuth returns true as templates command requires authorization
+func (c *TemplatesCommand) RequiresAuth() bool {
+	return true
+}
+
+// RequiresAdmin returns false as templates command doesn't require admin privileges
+func (c *TemplatesCommand) RequiresAdmin() bool {
+	return false
+}
+
+// Handle executes the templates command. Without arguments it shows the templates, otherwise it
+// changes their schedule: /templates schedule <day> [HH:MM] [timezone] <template> or
+// /templates unschedule <template>
+func (c *TemplatesCommand) Handle(ctx context.Context, chatID int64, user *users.User, args []string) error {
+	if len(args) < 2 {
+		return c.showTemplates(ctx, chatID, user)
+	}
+
+	templates, err := c.shoppingService.GetUserListTemplates(ctx, user.ID)
+	if err != nil {
+		c.logger.Error("Failed to get list templates", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return err
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "schedule":
+		weekday, ok := weekdays[strings.ToLower(args[1])]
+		if !ok {
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_invalid_template_schedule", user.Locale))
+			return nil
+		}
+
+		rest := args[2:]
+		minute := defaultScheduleMinute
+		if len(rest) > 0 {
+			if at, err := time.Parse("15:04", rest[0]); err == nil {
+				minute = at.Hour()*60 + at.Minute()
+				rest = rest[1:]
+			}
+		}
+		timezone := ""
+		if len(rest) > 0 && (strings.Contains(rest[0], "/") || strings.EqualFold(rest[0], "UTC")) {
+			timezone = rest[0]
+			rest = rest[1:]
+		}
+
+		template := findTemplate(templates, strings.Join(rest, " "))
+		if template == nil {
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return nil
+		}
+		if timezone == "" {
+			timezone = template.ScheduleTimezone
+		}
+
+		day := int(weekday)
+		if _, err := c.shoppingService.SetListTemplateSchedule(ctx, template.ID, &day, minute, timezone); err != nil {
+			if errors.Is(err, shopping.ErrInvalidTemplateSchedule) {
+				c.SendMessage(chatID, c.templateManager.RenderMessage("error_invalid_template_schedule", user.Locale))
+				return nil
+			}
+			c.logger.Error("Failed to schedule list template", "error", err, "template_id", template.ID)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_save_template", user.Locale))
+			return err
+		}
+
+		c.logger.Info("List template scheduled",
+			"template_id", template.ID,
+			"weekday", weekday,
+			"minute", minute,
+			"timezone", timezone,
+			"user_id", user.ID)
+	case "unschedule":
+		template := findTemplate(templates, strings.Join(args[1:], " "))
+		if template == nil {
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return nil
+		}
+
+		if _, err := c.shoppingService.SetListTemplateSchedule(ctx, template.ID, nil, 0, ""); err != nil {
+			c.logger.Error("Failed to unschedule list template", "error", err, "template_id", template.ID)
+			c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_save_template", user.Locale))
+			return err
+		}
+
+		c.logger.Info("List template unscheduled", "template_id", template.ID, "user_id", user.ID)
+	}
+
+	return c.showTemplates(ctx, chatID, user)
+}
+
+// showTemplates sends the templates of the user with buttons creating lists from them
+func (c *TemplatesCommand) showTemplates(ctx context.Context, chatID int64, user *users.User) error {
+	templates, err := c.shoppingService.GetUserListTemplates(ctx, user.ID)
+	if err != nil {
+		c.logger.Error("Failed to get list templates", "error", err, "user_id", user.ID)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return err
+	}
+
+	message, keyboard, err := BuildTemplatesMessage(c.templateManager, templates, user.Locale)
+	if err != nil {
+		c.logger.Error("Failed to render list templates template", "error", err)
+		c.SendMessage(chatID, c.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return err
+	}
+
+	if len(keyboard.InlineKeyboard) == 0 {
+		c.SendHTMLMessage(chatID, message)
+		return nil
+	}
+
+	c.SendMessageWithKeyboard(chatID, message, keyboard)
+	return nil
+}
+
+// BuildTemplatesMessage creates the message listing the templates with a row of buttons per
+// template: create a list from it, add its items to an open list and delete it
+func BuildTemplatesMessage(templateManager TemplateRenderer, templates []*shopping.ListTemplate, locale string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	data := struct {
+		Templates []*shopping.ListTemplate
+	}{
+		Templates: templates,
+	}
+
+	message, err := templateManager.RenderTemplate("list_templates", locale, data)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{}
+	for _, template := range templates {
+		name := template.Name
+		if utf8.RuneCountInString(name) > 20 {
+			name = string([]rune(name)[:19]) + "…"
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ "+name, fmt.Sprintf("tpl:new:%s", template.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(templateManager.RenderButton("add_template_to_list", locale), fmt.Sprintf("tpl:merge:%s", template.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(templateManager.RenderButton("delete_template", locale), fmt.Sprintf("tpl:del:%s", template.ID)),
+		))
+	}
+
+	return message, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}, nil
+}
+
+// findTemplate finds a template by name, nil when there is none
+func findTemplate(templates []*shopping.ListTemplate, name string) *shopping.ListTemplate {
+	for _, template := range templates {
+		if strings.EqualFold(template.Name, strings.TrimSpace(name)) {
+			return template
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/pkg/telemetry"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	api "go.opentelemetry.io/otel/metric"
+)
+
+// errDispatcherStopped is returned for updates dispatched after Stop
+var errDispatcherStopped = errors.New("update dispatcher is stopped")
+
+// errDispatcherFull is returned by TryDispatch while the queue is full
+var errDispatcherFull = errors.New("update dispatcher queue is full")
+
+// updateDispatcher processes the updates of one chat sequentially, in the order they were received,
+// while updates of different chats run in parallel on a fixed number of workers
+type updateDispatcher struct {
+	handle func(ctx context.Context, update tgbotapi.Update)
+	logger *slog.Logger
+
+	// slots bounds the number of queued and running updates
+	slots chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	chats  map[int64]*chatQueue // chats with queued or running updates
+	ready  []int64              // chats with queued updates and no running one, oldest first
+	closed bool
+
+	// ctx is passed to handlers, it is only cancelled when draining times out
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type chatQueue struct {
+	pending []queuedUpdate
+	running bool
+}
+
+type queuedUpdate struct {
+	update   tgbotapi.Update
+	queuedAt time.Time
+}
+
+// newUpdateDispatcher starts workers goroutines calling handle for dispatched updates
+func newUpdateDispatcher(handle func(ctx context.Context, update tgbotapi.Update), workers, queueSize int, logger *slog.Logger) *updateDispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < workers {
+		queueSize = workers
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &updateDispatcher{
+		handle: handle,
+		logger: logger,
+		slots:  make(chan struct{}, queueSize),
+		chats:  make(map[int64]*chatQueue),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	d.cond = sync.NewCond(&d.mu)
+
+	for range workers {
+		d.wg.Add(1)
+		go d.work()
+	}
+
+	return d
+}
+
+// Dispatch queues an update behind the other updates of its chat. It blocks while the
+// queue is full, until ctx is done.
+func (d *updateDispatcher) Dispatch(ctx context.Context, update tgbotapi.Update) error {
+	select {
+	case d.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return d.enqueue(ctx, update)
+}
+
+// TryDispatch queues an update like Dispatch, but fails instead of blocking while the queue is full
+func (d *updateDispatcher) TryDispatch(ctx context.Context, update tgbotapi.Update) error {
+	select {
+	case d.slots <- struct{}{}:
+	default:
+		return errDispatcherFull
+	}
+
+	return d.enqueue(ctx, update)
+}
+
+// enqueue queues an update that holds a slot
+func (d *updateDispatcher) enqueue(ctx context.Context, update tgbotapi.Update) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		<-d.slots
+		return errDispatcherStopped
+	}
+
+	chatID := updateChatID(update)
+	queue, exists := d.chats[chatID]
+	if !exists {
+		queue = &chatQueue{}
+		d.chats[chatID] = queue
+	}
+
+	queue.pending = append(queue.pending, queuedUpdate{update: update, queuedAt: time.Now()})
+	if !queue.running && len(queue.pending) == 1 {
+		d.ready = append(d.ready, chatID)
+		d.cond.Signal()
+	}
+
+	if telemetry.TelegramUpdatesQueued != nil {
+		telemetry.TelegramUpdatesQueued.Add(ctx, 1)
+	}
+
+	return nil
+}
+
+// Stop stops accepting updates and waits for the queued ones to be processed. Handlers still
+// running after timeout get their context cancelled and queued updates are dropped.
+func (d *updateDispatcher) Stop(timeout time.Duration) {
+	d.mu.Lock()
+	d.closed = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.logger.Info("Telegram update queue drained")
+	case <-time.After(timeout):
+		d.logger.Warn("Telegram update queue not drained in time, cancelling remaining updates",
+			"timeout", timeout,
+			"queued", len(d.slots))
+		d.cancel()
+		<-done
+	}
+
+	d.cancel()
+}
+
+// work processes updates of ready chats until the dispatcher is stopped and drained
+func (d *updateDispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		for len(d.ready) == 0 && !d.closed {
+			d.cond.Wait()
+		}
+		if len(d.ready) == 0 {
+			d.mu.Unlock()
+			return
+		}
+
+		chatID := d.ready[0]
+		d.ready = d.ready[1:]
+		queue := d.chats[chatID]
+		item := queue.pending[0]
+		queue.pending = queue.pending[1:]
+		queue.running = true
+		d.mu.Unlock()
+
+		d.process(item)
+
+		d.mu.Lock()
+		queue.running = false
+		if len(queue.pending) > 0 {
+			// Back of the line, so busy chats don't starve the others
+			d.ready = append(d.ready, chatID)
+			d.cond.Signal()
+		} else {
+			delete(d.chats, chatID)
+		}
+		d.mu.Unlock()
+
+		<-d.slots
+	}
+}
+
+func (d *updateDispatcher) process(item queuedUpdate) {
+	ctx := d.ctx
+	updateType := attribute.String("type", updateType(item.update))
+
+	if telemetry.TelegramUpdatesQueued != nil {
+		defer telemetry.TelegramUpdatesQueued.Add(context.Background(), -1)
+	}
+
+	// Draining timed out, drop the updates that didn't start yet
+	if ctx.Err() != nil {
+		if telemetry.TelegramUpdatesDropped != nil {
+			telemetry.TelegramUpdatesDropped.Add(context.Background(), 1, api.WithAttributes(updateType))
+		}
+		return
+	}
+
+	if telemetry.TelegramUpdateWaitHistogram != nil {
+		telemetry.TelegramUpdateWaitHistogram.Record(ctx, float64(time.Since(item.queuedAt).Milliseconds()),
+			api.WithAttributes(updateType))
+	}
+
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			d.logger.Error("Panic while handling Telegram update",
+				"panic", r,
+				"update_id", item.update.UpdateID)
+		}
+
+		if telemetry.TelegramUpdateHistogram != nil {
+			telemetry.TelegramUpdateHistogram.Record(context.Background(), float64(time.Since(start).Milliseconds()),
+				api.WithAttributes(updateType))
+		}
+	}()
+
+	d.handle(ctx, item.update)
+}
+
+// updateChatID returns the key updates are ordered by, the chat or else the sending user
+func updateChatID(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	if user := update.SentFrom(); user != nil {
+		return user.ID
+	}
+	return 0
+}
+
+func updateType(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,245 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newTestUpdate(updateID int, chatID int64) tgbotapi.Update {
+	return tgbotapi.Update{UpdateID: updateID, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}}}
+}
+
+func newTestDispatcher(handle func(ctx context.Context, update tgbotapi.Update), workers, queueSize int) *updateDispatcher {
+	return newUpdateDispatcher(handle, workers, queueSize, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUpdateDispatcherKeepsChatOrder(t *testing.T) {
+	const chats, updatesPerChat = 4, 50
+
+	var mu sync.Mutex
+	handled := make(map[int64][]int)
+	running := make(map[int64]bool)
+	handle := func(ctx context.Context, update tgbotapi.Update) {
+		chatID := update.Message.Chat.ID
+
+		mu.Lock()
+		if running[chatID] {
+			t.Errorf("two updates of chat %d handled at once", chatID)
+		}
+		running[chatID] = true
+		mu.Unlock()
+
+		time.Sleep(time.Duration(update.UpdateID%3) * 100 * time.Microsecond)
+
+		mu.Lock()
+		running[chatID] = false
+		handled[chatID] = append(handled[chatID], update.UpdateID)
+		mu.Unlock()
+	}
+
+	dispatcher := newTestDispatcher(handle, chats, 8)
+	for i := range updatesPerChat {
+		for chatID := range int64(chats) {
+			if err := dispatcher.Dispatch(context.Background(), newTestUpdate(i, chatID)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	dispatcher.Stop(5 * time.Second)
+
+	for chatID := range int64(chats) {
+		updates := handled[chatID]
+		if len(updates) != updatesPerChat {
+			t.Errorf("chat %d: %d updates handled, want %d", chatID, len(updates), updatesPerChat)
+			continue
+		}
+		for i, updateID := range updates {
+			if updateID != i {
+				t.Errorf("chat %d: update %d handled as number %d", chatID, updateID, i)
+				break
+			}
+		}
+	}
+}
+
+func TestUpdateDispatcherRunsChatsInParallel(t *testing.T) {
+	const workers = 3
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	release := make(chan struct{})
+	handle := func(ctx context.Context, update tgbotapi.Update) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}
+
+	dispatcher := newTestDispatcher(handle, workers, 10)
+	for chatID := range int64(5) {
+		if err := dispatcher.Dispatch(context.Background(), newTestUpdate(int(chatID), chatID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every worker picks up a chat, the other two chats wait
+	waitFor(t, "all workers busy", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running == workers
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	close(release)
+	dispatcher.Stop(5 * time.Second)
+
+	if maxRunning != workers {
+		t.Errorf("%d updates handled at once, want %d", maxRunning, workers)
+	}
+}
+
+func TestUpdateDispatcherQueueLimit(t *testing.T) {
+	release := make(chan struct{})
+	dispatcher := newTestDispatcher(func(ctx context.Context, update tgbotapi.Update) { <-release }, 1, 2)
+	defer dispatcher.Stop(5 * time.Second)
+	defer close(release)
+
+	for i := range 2 {
+		if err := dispatcher.TryDispatch(context.Background(), newTestUpdate(i, 1)); err != nil {
+			t.Fatalf("TryDispatch() = %v with a free slot", err)
+		}
+	}
+	if err := dispatcher.TryDispatch(context.Background(), newTestUpdate(2, 2)); !errors.Is(err, errDispatcherFull) {
+		t.Errorf("TryDispatch() on a full queue = %v, want %v", err, errDispatcherFull)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := dispatcher.Dispatch(ctx, newTestUpdate(3, 2)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Dispatch() on a full queue = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestUpdateDispatcherStopDrains(t *testing.T) {
+	var mu sync.Mutex
+	handled := 0
+	handle := func(ctx context.Context, update tgbotapi.Update) {
+		time.Sleep(time.Millisecond)
+		if ctx.Err() != nil {
+			t.Errorf("handler context cancelled while draining in time")
+		}
+		mu.Lock()
+		handled++
+		mu.Unlock()
+	}
+
+	dispatcher := newTestDispatcher(handle, 2, 20)
+	for i := range 20 {
+		if err := dispatcher.Dispatch(context.Background(), newTestUpdate(i, int64(i%3))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dispatcher.Stop(5 * time.Second)
+
+	if handled != 20 {
+		t.Errorf("%d updates handled before Stop returned, want 20", handled)
+	}
+	if err := dispatcher.Dispatch(context.Background(), newTestUpdate(20, 1)); !errors.Is(err, errDispatcherStopped) {
+		t.Errorf("Dispatch() after Stop = %v, want %v", err, errDispatcherStopped)
+	}
+	if len(dispatcher.slots) != 0 {
+		t.Errorf("%d slots still taken after Stop", len(dispatcher.slots))
+	}
+}
+
+func TestUpdateDispatcherStopTimesOut(t *testing.T) {
+	started := make(chan struct{})
+	var mu sync.Mutex
+	var handled []int
+	handle := func(ctx context.Context, update tgbotapi.Update) {
+		mu.Lock()
+		handled = append(handled, update.UpdateID)
+		mu.Unlock()
+
+		if update.UpdateID == 0 {
+			close(started)
+			<-ctx.Done() // Stuck until the dispatcher gives up
+		}
+	}
+
+	dispatcher := newTestDispatcher(handle, 1, 5)
+	for i := range 3 {
+		if err := dispatcher.Dispatch(context.Background(), newTestUpdate(i, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Stop(20 * time.Millisecond)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not return after its timeout")
+	}
+
+	// The stuck handler was cancelled and the updates queued behind it dropped
+	if len(handled) != 1 {
+		t.Errorf("handled updates %v, want only the stuck one", handled)
+	}
+	if len(dispatcher.slots) != 0 {
+		t.Errorf("%d slots still taken after Stop", len(dispatcher.slots))
+	}
+}
+
+func TestUpdateChatID(t *testing.T) {
+	tests := []struct {
+		name   string
+		update tgbotapi.Update
+		want   int64
+	}{
+		{"message", newTestUpdate(1, 42), 42},
+		{"callback query", tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+			From:    &tgbotapi.User{ID: 7},
+			Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -100}},
+		}}, -100},
+		{"inline query without chat", tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{From: &tgbotapi.User{ID: 7}}}, 7},
+		{"nothing to order by", tgbotapi.Update{}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := updateChatID(tt.update); got != tt.want {
+			t.Errorf("%s: updateChatID() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
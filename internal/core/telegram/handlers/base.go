@@ -27,6 +27,7 @@ type BaseHandler struct {
 	receiptsService *receipts.Service
 	cloudService    *cloud.Service
 	templateManager TemplateRenderer
+	listViews       *ListViewTracker // optional, keeps list messages up to date
 	logger          *slog.Logger
 }
 
@@ -51,6 +52,12 @@ func NewBaseHandler(
 	}
 }
 
+// WithListViews returns a copy of the handler recording the list messages it shows with the tracker
+func (bh BaseHandler) WithListViews(listViews *ListViewTracker) BaseHandler {
+	bh.listViews = listViews
+	return bh
+}
+
 // SendMessage sends a simple text message
 func (bh *BaseHandler) SendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -18,6 +18,9 @@ type CallbackRouter struct {
 	productListCallbackHandler *ProductListCallbackHandler
 	receiptsCallbackHandler    *ReceiptsCallbackHandler
 	languageHandler            *LanguageHandler
+	notificationsHandler       *NotificationsHandler
+	listTemplatesHandler       *ListTemplatesHandler
+	suggestionsHandler         *SuggestionsHandler
 	stateManager               *StateManager
 }
 
@@ -31,6 +34,9 @@ func NewCallbackRouter(
 	productListHandler *ProductListCallbackHandler,
 	receiptsHandler *ReceiptsCallbackHandler,
 	languageHandler *LanguageHandler,
+	notificationsHandler *NotificationsHandler,
+	listTemplatesHandler *ListTemplatesHandler,
+	suggestionsHandler *SuggestionsHandler,
 	stateManager *StateManager,
 ) *CallbackRouter {
 	return &CallbackRouter{
@@ -42,6 +48,9 @@ func NewCallbackRouter(
 		productListCallbackHandler: productListHandler,
 		receiptsCallbackHandler:    receiptsHandler,
 		languageHandler:            languageHandler,
+		notificationsHandler:       notificationsHandler,
+		listTemplatesHandler:       listTemplatesHandler,
+		suggestionsHandler:         suggestionsHandler,
 		stateManager:               stateManager,
 	}
 }
@@ -56,6 +65,10 @@ func (r *CallbackRouter) RouteCallbackQuery(ctx context.Context, callback *tgbot
 		"data", callback.Data,
 		"chat_id", callback.Message.Chat.ID)
 
+	// The message may show something else after this callback, handlers showing a list in it
+	// track it again
+	r.listViews.Forget(callback.Message.Chat.ID, callback.Message.MessageID)
+
 	// Parse callback data - handle both underscore and colon separators
 	var parts []string
 	if strings.Contains(callback.Data, ":") {
@@ -101,6 +114,12 @@ func (r *CallbackRouter) RouteCallbackQuery(ctx context.Context, callback *tgbot
 		}
 	case "lang":
 		r.routeLanguageCallback(ctx, callback, parts, user)
+	case "notif":
+		r.notificationsHandler.HandleNotificationsCallback(ctx, callback, parts, user)
+	case "tpl":
+		r.listTemplatesHandler.HandleListTemplatesCallback(ctx, callback, parts, user)
+	case "sug":
+		r.suggestionsHandler.HandleSuggestionsCallback(ctx, callback, parts, user)
 	default:
 		r.AnswerCallback(callback.ID, "❌ Unknown callback action.")
 	}
@@ -216,6 +216,17 @@ func (h *CoreMessageHandler) HandleTextMessage(ctx context.Context, message *tgb
 		return
 	}
 
+	// Questions like "how much did milk cost?" are answered from the price history
+	if item, ok := parsePriceQuestion(message.Text); ok {
+		if err := h.commandRegistry.ExecuteCommand(ctx, "price", message.Chat.ID, user.User, strings.Fields(item)); err != nil {
+			h.logger.Error("Failed to answer price question",
+				"error", err,
+				"user_id", user.TelegramID,
+				"chat_id", message.Chat.ID)
+		}
+		return
+	}
+
 	// Default message handling
 	messageHandler := NewMessageHandler(h.BaseHandler, h.stateManager)
 	messageHandler.HandleAuthorizedMessage(ctx, message, user.User)
@@ -307,9 +307,13 @@ func (h *DuplicateCallbackHandler) showUpdatedList(ctx context.Context, chatID i
 	msg.ParseMode = tgbotapi.ModeHTML
 	msg.ReplyMarkup = keyboard
 
-	if _, err := h.bot.Send(msg); err != nil {
+	sent, err := h.bot.Send(msg)
+	if err != nil {
 		h.logger.Error("Failed to send updated list view message", "error", err)
+		return
 	}
+
+	h.listViews.Track(listID, chatID, sent.MessageID, user.TelegramID, message, keyboard)
 }
 
 func (h *DuplicateCallbackHandler) updateDuplicateMessage(ctx context.Context, callback *tgbotapi.CallbackQuery, state DuplicateState, user *users.User) {
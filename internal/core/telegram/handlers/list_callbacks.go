@@ -52,6 +52,9 @@ func (h *ListCallbackHandler) BuildListViewMessage(ctx context.Context, listID u
 	list, err := h.shoppingService.GetShoppingListByID(ctx, listID)
 	if err != nil || list == nil {
 		h.logger.Error("Failed to get shopping list", "error", err, "list_id", listID)
+		if err == nil {
+			err = fmt.Errorf("shopping list %s not found", listID)
+		}
 		return "", tgbotapi.InlineKeyboardMarkup{}, err
 	}
 
@@ -104,6 +107,18 @@ func (h *ListCallbackHandler) BuildListViewMessage(ctx context.Context, listID u
 		}
 	}
 
+	// Show what was paid for items marked as bought from receipts
+	if spending, err := h.receiptsService.GetListSpending(ctx, listID); err != nil {
+		h.logger.Warn("Failed to get list spending", "error", err, "list_id", listID)
+	} else {
+		for _, entry := range spending {
+			message += fmt.Sprintf("\n💳 <b>Paid:</b> %.2f %s <i>(%d item(s) from receipts)</i>", entry.TotalAmount, entry.Currency, entry.ItemsCount)
+		}
+		if len(spending) > 0 {
+			message += "\n"
+		}
+	}
+
 	// Create action buttons
 	buttons := [][]tgbotapi.InlineKeyboardButton{
 		{
@@ -147,9 +162,17 @@ func (h *ListCallbackHandler) BuildListViewMessage(ctx context.Context, listID u
 		}
 	}
 
+	// Propose what the family usually buys about now
+	suggestionsText, suggestionRows := h.buildSuggestions(ctx, listID, user.Locale)
+	if suggestionsText != "" {
+		message += "\n" + suggestionsText + "\n"
+		buttons = append(buttons, suggestionRows...)
+	}
+
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
 		tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("complete_list", user.Locale), fmt.Sprintf("list_complete_%s", listID.String())),
 		tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("refresh", user.Locale), fmt.Sprintf("list_view_%s", listID.String())),
+		tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("save_as_template", user.Locale), fmt.Sprintf("list_savetemplate_%s", listID.String())),
 	})
 
 	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
@@ -182,6 +205,7 @@ func (h *ListCallbackHandler) HandleViewList(ctx context.Context, callback *tgbo
 	if _, err := h.bot.Send(editMsg); err != nil {
 		// Check if error is due to message not being modified (content is the same)
 		if strings.Contains(err.Error(), "message is not modified") {
+			h.listViews.Track(listID, callback.Message.Chat.ID, callback.Message.MessageID, user.TelegramID, message, keyboard)
 			h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("success_list_up_to_date", user.Locale))
 			return
 		}
@@ -190,6 +214,9 @@ func (h *ListCallbackHandler) HandleViewList(ctx context.Context, callback *tgbo
 		return
 	}
 
+	// Keep the message up to date with the changes other members make
+	h.listViews.Track(listID, callback.Message.Chat.ID, callback.Message.MessageID, user.TelegramID, message, keyboard)
+
 	h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("success_list_updated", user.Locale))
 }
 
@@ -310,7 +337,7 @@ func (h *ListCallbackHandler) HandleCompleteList(ctx context.Context, callback *
 	}
 
 	// Archive the list
-	err = h.shoppingService.ArchiveShoppingList(ctx, listID)
+	err = h.shoppingService.ArchiveShoppingList(ctx, listID, user.ID)
 	if err != nil {
 		h.logger.Error("Failed to archive shopping list", "error", err, "list_id", listID)
 		h.AnswerCallback(callback.ID, "❌ Failed to complete list.")
@@ -355,6 +382,36 @@ func (h *ListCallbackHandler) HandleCompleteList(ctx context.Context, callback *
 	}
 }
 
+// HandleSaveAsTemplate saves a shopping list as a template named after the list
+func (h *ListCallbackHandler) HandleSaveAsTemplate(ctx context.Context, callback *tgbotapi.CallbackQuery, listID uuid.UUID, user *users.User) {
+	canAccess, err := h.shoppingService.CanUserAccessList(ctx, listID, user.ID)
+	if err != nil || !canAccess {
+		if err != nil {
+			h.logger.Error("Failed to check list access", "error", err, "list_id", listID)
+		}
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_list_not_found", user.Locale))
+		return
+	}
+
+	list, err := h.shoppingService.GetShoppingListByID(ctx, listID)
+	if err != nil || list == nil {
+		h.logger.Error("Failed to get shopping list", "error", err, "list_id", listID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_list_not_found", user.Locale))
+		return
+	}
+
+	template, err := h.shoppingService.SaveListAsTemplate(ctx, listID, list.Name, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to save list as template", "error", err, "list_id", listID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_save_template", user.Locale))
+		return
+	}
+
+	h.logger.Info("List saved as template", "list_id", listID, "template_id", template.ID, "user_id", user.ID)
+
+	h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_saved_as_template", user.Locale))
+}
+
 // HandleListCallback handles list_* callbacks (moved from bot_service.go)
 func (h *ListCallbackHandler) HandleListCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, parts []string, user *users.User, stateManager *StateManager) {
 	if len(parts) < 3 {
@@ -390,6 +447,8 @@ func (h *ListCallbackHandler) HandleListCallback(ctx context.Context, callback *
 		}
 	case "complete":
 		h.HandleCompleteList(ctx, callback, listID, user)
+	case "savetemplate":
+		h.HandleSaveAsTemplate(ctx, callback, listID, user)
 	default:
 		h.AnswerCallback(callback.ID, "❌ Unknown list action.")
 	}
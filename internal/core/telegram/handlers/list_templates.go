@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/telegram/commands"
+	"github.com/PocketPalCo/shopping-service/internal/core/translations"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// scheduledListsInterval is how often lists due from template schedules are created
+const scheduledListsInterval = time.Minute
+
+// ListTemplatesHandler handles the buttons of list templates and creates the lists of scheduled
+// templates
+type ListTemplatesHandler struct {
+	BaseHandler
+	stateManager *StateManager
+	// messageHandler resolves the duplicates found when template items are added to a list
+	messageHandler *MessageHandler
+}
+
+// NewListTemplatesHandler creates a new list templates handler
+func NewListTemplatesHandler(base BaseHandler, stateManager *StateManager, messageHandler *MessageHandler) *ListTemplatesHandler {
+	return &ListTemplatesHandler{
+		BaseHandler:    base,
+		stateManager:   stateManager,
+		messageHandler: messageHandler,
+	}
+}
+
+// HandleListTemplatesCallback handles tpl:* callbacks
+func (h *ListTemplatesHandler) HandleListTemplatesCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, parts []string, user *users.User) {
+	if len(parts) < 2 {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+		return
+	}
+
+	switch parts[1] {
+	case "show":
+		h.showTemplates(ctx, callback, user, "")
+	case "into":
+		// Compact callback with short IDs (tpl:into:shortTemplateID:shortListID)
+		if len(parts) < 4 {
+			h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return
+		}
+		h.handleAddToList(ctx, callback, parts[2], parts[3], user)
+	case "new", "merge", "del":
+		if len(parts) < 3 {
+			h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return
+		}
+
+		templateID, err := uuid.Parse(parts[2])
+		if err != nil {
+			h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return
+		}
+
+		canAccess, err := h.shoppingService.CanUserAccessTemplate(ctx, templateID, user.ID)
+		if err != nil || !canAccess {
+			if err != nil {
+				h.logger.Error("Failed to check list template access", "error", err, "template_id", templateID)
+			}
+			h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+			return
+		}
+
+		switch parts[1] {
+		case "new":
+			h.handleCreateList(ctx, callback, templateID, user)
+		case "merge":
+			h.handleSelectList(ctx, callback, templateID, user)
+		case "del":
+			h.handleDelete(ctx, callback, templateID, user)
+		}
+	default:
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+	}
+}
+
+// handleCreateList creates a new list from the template and shows it
+func (h *ListTemplatesHandler) handleCreateList(ctx context.Context, callback *tgbotapi.CallbackQuery, templateID uuid.UUID, user *users.User) {
+	list, items, err := h.shoppingService.CreateListFromTemplate(ctx, templateID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to create list from template", "error", err, "template_id", templateID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_create_list_from_template", user.Locale))
+		return
+	}
+
+	h.logger.Info("List created from template",
+		"template_id", templateID,
+		"list_id", list.ID,
+		"items", len(items),
+		"user_id", user.ID)
+
+	h.showList(ctx, callback, list.ID, user, h.templateManager.RenderMessage("callback_list_created_from_template", user.Locale))
+}
+
+// handleSelectList asks which open list the template items are added to
+func (h *ListTemplatesHandler) handleSelectList(ctx context.Context, callback *tgbotapi.CallbackQuery, templateID uuid.UUID, user *users.User) {
+	template, err := h.shoppingService.GetListTemplate(ctx, templateID)
+	if err != nil || template == nil {
+		h.logger.Error("Failed to get list template", "error", err, "template_id", templateID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+		return
+	}
+
+	lists, err := h.shoppingService.GetUserShoppingLists(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get user shopping lists", "error", err, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_retrieve_lists", user.Locale))
+		return
+	}
+	if len(lists) == 0 {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_no_open_lists", user.Locale))
+		return
+	}
+
+	data := struct {
+		TemplateName string
+		ItemCount    int
+	}{
+		TemplateName: template.Name,
+		ItemCount:    template.ItemCount,
+	}
+
+	message, err := h.templateManager.RenderTemplate("list_template_merge", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render list template merge template", "error", err)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_internal", user.Locale))
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, list := range lists {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				"📋 "+truncateUTF8(list.Name, 30),
+				fmt.Sprintf("tpl:into:%s:%s", templateID.String()[:8], list.ID.String()[:8]),
+			),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("back", user.Locale), "tpl:show"),
+	))
+
+	h.EditMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, message, tgbotapi.NewInlineKeyboardMarkup(rows...))
+	h.AnswerCallback(callback.ID, "")
+}
+
+// handleAddToList adds the template items to an open list. Items already on the list are
+// resolved like duplicates of typed items, the others are added right away.
+func (h *ListTemplatesHandler) handleAddToList(ctx context.Context, callback *tgbotapi.CallbackQuery, shortTemplateID, shortListID string, user *users.User) {
+	templates, err := h.shoppingService.GetUserListTemplates(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get list templates", "error", err, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return
+	}
+	var template *shopping.ListTemplate
+	for _, candidate := range templates {
+		if strings.HasPrefix(candidate.ID.String(), shortTemplateID) {
+			template = candidate
+			break
+		}
+	}
+
+	lists, err := h.shoppingService.GetUserShoppingLists(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get user shopping lists", "error", err, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_retrieve_lists", user.Locale))
+		return
+	}
+	var list *shopping.ShoppingList
+	for _, candidate := range lists {
+		if strings.HasPrefix(candidate.ID.String(), shortListID) {
+			list = candidate
+			break
+		}
+	}
+
+	if template == nil {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_template_not_found", user.Locale))
+		return
+	}
+	if list == nil {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_list_not_found", user.Locale))
+		return
+	}
+
+	// Parsing the items takes a moment
+	h.AnswerCallback(callback.ID, "")
+	loadingText, err := h.templateManager.RenderTemplate("processing_items", user.Locale, nil)
+	if err != nil {
+		h.logger.Error("Failed to render processing items template", "error", err)
+		loadingText = "🔄 Processing items..."
+	}
+	h.EditMessage(callback.Message.Chat.ID, callback.Message.MessageID, loadingText)
+
+	languageCode := translations.NormalizeLanguageCode(user.Locale)
+	duplicates, uniqueItems, err := h.shoppingService.CheckTemplateDuplicates(ctx, template.ID, list.ID, languageCode, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to check template items for duplicates", "error", err, "template_id", template.ID, "list_id", list.ID)
+		h.EditMessage(callback.Message.Chat.ID, callback.Message.MessageID,
+			h.templateManager.RenderMessage("error_failed_to_add_template_items", user.Locale))
+		return
+	}
+
+	if len(duplicates) > 0 {
+		h.DeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID)
+		h.messageHandler.handleDuplicateItems(ctx, callback.Message.Chat.ID, user, duplicates, uniqueItems, list.ID)
+		return
+	}
+
+	addedItems, failedItems, err := h.shoppingService.AddParsedItemsToList(ctx, list.ID, uniqueItems, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to add template items to list", "error", err, "template_id", template.ID, "list_id", list.ID)
+		h.EditMessage(callback.Message.Chat.ID, callback.Message.MessageID,
+			h.templateManager.RenderMessage("error_failed_to_add_template_items", user.Locale))
+		return
+	}
+
+	h.logger.Info("Template items added to list",
+		"template_id", template.ID,
+		"list_id", list.ID,
+		"added", len(addedItems),
+		"failed", len(failedItems),
+		"user_id", user.ID)
+
+	h.showList(ctx, callback, list.ID, user, "")
+}
+
+// handleDelete deletes the template and shows the remaining ones
+func (h *ListTemplatesHandler) handleDelete(ctx context.Context, callback *tgbotapi.CallbackQuery, templateID uuid.UUID, user *users.User) {
+	if err := h.shoppingService.DeleteListTemplate(ctx, templateID); err != nil {
+		h.logger.Error("Failed to delete list template", "error", err, "template_id", templateID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_save_template", user.Locale))
+		return
+	}
+
+	h.logger.Info("List template deleted", "template_id", templateID, "user_id", user.ID)
+
+	h.showTemplates(ctx, callback, user, h.templateManager.RenderMessage("callback_template_deleted", user.Locale))
+}
+
+// showTemplates shows the templates of the user in the callback message
+func (h *ListTemplatesHandler) showTemplates(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, answer string) {
+	templates, err := h.shoppingService.GetUserListTemplates(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get list templates", "error", err, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return
+	}
+
+	message, keyboard, err := commands.BuildTemplatesMessage(h.templateManager, templates, user.Locale)
+	if err != nil {
+		h.logger.Error("Failed to render list templates template", "error", err)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_load_templates", user.Locale))
+		return
+	}
+
+	h.EditMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, message, keyboard)
+	h.AnswerCallback(callback.ID, answer)
+}
+
+// showList shows the list in the callback message and keeps it up to date like a list opened
+// from /lists
+func (h *ListTemplatesHandler) showList(ctx context.Context, callback *tgbotapi.CallbackQuery, listID uuid.UUID, user *users.User, answer string) {
+	message, keyboard, err := NewListCallbackHandler(h.BaseHandler, h.stateManager).BuildListViewMessage(ctx, listID, user)
+	if err != nil {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_load_list_items", user.Locale))
+		return
+	}
+
+	h.EditMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, message, keyboard)
+	h.listViews.Track(listID, callback.Message.Chat.ID, callback.Message.MessageID, user.TelegramID, message, keyboard)
+
+	if h.stateManager != nil {
+		viewStateData := fmt.Sprintf("%s:%d:%d", listID.String(), callback.Message.Chat.ID, callback.Message.MessageID)
+		h.stateManager.SetUserState(user.TelegramID, "viewing_list", viewStateData)
+	}
+
+	h.AnswerCallback(callback.ID, answer)
+}
+
+// Run creates the lists of scheduled templates when they are due until ctx is done
+func (h *ListTemplatesHandler) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduledListsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			scheduled, err := h.shoppingService.CreateScheduledLists(ctx, time.Now())
+			if err != nil {
+				h.logger.Error("Failed to create scheduled lists", "error", err)
+			}
+			for _, created := range scheduled {
+				h.notifyScheduledList(ctx, created)
+			}
+		}
+	}
+}
+
+// notifyScheduledList tells the members of the template's family, or its owner for a personal
+// template, that a scheduled list was created
+func (h *ListTemplatesHandler) notifyScheduledList(ctx context.Context, created *shopping.ScheduledList) {
+	recipientIDs := []uuid.UUID{created.Template.OwnerID}
+	familyName := ""
+	if created.List.FamilyID != nil {
+		family, err := h.familiesService.GetFamilyWithMembers(ctx, *created.List.FamilyID)
+		if err != nil {
+			h.logger.Error("Failed to get family members for scheduled list", "error", err, "family_id", *created.List.FamilyID)
+			return
+		}
+
+		familyName = family.Family.Name
+		recipientIDs = recipientIDs[:0]
+		for _, member := range family.Members {
+			recipientIDs = append(recipientIDs, member.UserID)
+		}
+	}
+
+	for _, recipientID := range recipientIDs {
+		recipient, err := h.usersService.GetUserByID(ctx, recipientID)
+		if err != nil || recipient == nil {
+			h.logger.Error("Failed to get recipient of scheduled list notice", "error", err, "user_id", recipientID)
+			continue
+		}
+
+		data := struct {
+			ListName   string
+			FamilyName string
+			ItemCount  int
+		}{
+			ListName:   created.List.Name,
+			FamilyName: familyName,
+			ItemCount:  len(created.Items),
+		}
+
+		message, err := h.templateManager.RenderTemplate("scheduled_list_created", recipient.Locale, data)
+		if err != nil {
+			h.logger.Error("Failed to render scheduled list template", "error", err)
+			continue
+		}
+
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					h.templateManager.RenderButton("view_list", recipient.Locale),
+					fmt.Sprintf("list_view_%s", created.List.ID.String()),
+				),
+			),
+		)
+
+		h.SendMessageWithKeyboard(recipient.TelegramID, message, keyboard)
+	}
+
+	h.logger.Info("Scheduled list created",
+		"template_id", created.Template.ID,
+		"list_id", created.List.ID,
+		"items", len(created.Items),
+		"recipients", len(recipientIDs))
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// listViewDebounce is how long the changes of a list are collected before the messages showing it
+// are edited, so a shopping trip with many taps edits each message at most once per interval
+// and stays within Telegram's rate limits
+const listViewDebounce = 3 * time.Second
+
+// listViewPruneInterval is how often the views of lists that don't change anymore are checked for
+// expiry, views of changing lists expire on refresh
+const listViewPruneInterval = 10 * time.Minute
+
+type listViewKey struct {
+	chatID    int64
+	messageID int
+}
+
+type listView struct {
+	telegramID int64
+	signature  string // text and keyboard last shown, views that did not change are not edited
+	shownAt    time.Time
+}
+
+// ListViewTracker keeps the list messages open in chats up to date. Handlers record the messages
+// they render a list into, and when the list changes, from the bot, the HTTP API or another
+// replica, every tracked message is re-rendered for the member it was shown to.
+type ListViewTracker struct {
+	BaseHandler
+	debounce time.Duration
+	ttl      time.Duration
+
+	mutex   sync.Mutex
+	views   map[uuid.UUID]map[listViewKey]listView
+	pending map[uuid.UUID]bool
+}
+
+// NewListViewTracker creates a tracker rendering and editing list messages with the base handler.
+// Messages are followed for as long as the viewing_list state lives.
+func NewListViewTracker(base BaseHandler) *ListViewTracker {
+	return &ListViewTracker{
+		BaseHandler: base,
+		debounce:    listViewDebounce,
+		ttl:         DefaultStateTTLs["viewing_list"],
+		views:       make(map[uuid.UUID]map[listViewKey]listView),
+		pending:     make(map[uuid.UUID]bool),
+	}
+}
+
+// Track records that a message shows the list as rendered for the user. A message shows one list
+// at a time, tracking it for a list stops updating it for any other.
+func (t *ListViewTracker) Track(listID uuid.UUID, chatID int64, messageID int, telegramID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if t == nil || messageID == 0 {
+		return
+	}
+
+	key := listViewKey{chatID: chatID, messageID: messageID}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.forgetLocked(key)
+	if t.views[listID] == nil {
+		t.views[listID] = make(map[listViewKey]listView)
+	}
+	t.views[listID][key] = listView{
+		telegramID: telegramID,
+		signature:  listViewSignature(text, keyboard),
+		shownAt:    time.Now(),
+	}
+}
+
+// Forget stops updating a message, e.g. because it is about to show something else
+func (t *ListViewTracker) Forget(chatID int64, messageID int) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.forgetLocked(listViewKey{chatID: chatID, messageID: messageID})
+}
+
+// Run refreshes the messages of the lists changed by the events until ctx is done or the events
+// channel is closed, and forgets expired messages meanwhile
+func (t *ListViewTracker) Run(ctx context.Context, events <-chan shopping.ListEvent) {
+	ticker := time.NewTicker(listViewPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			t.listChanged(ctx, event)
+		case <-ticker.C:
+			t.prune()
+		}
+	}
+}
+
+// listChanged schedules a refresh of the list unless one is already pending
+func (t *ListViewTracker) listChanged(ctx context.Context, event shopping.ListEvent) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Archived and deleted lists can't be changed from their messages anymore
+	if event.Closes() {
+		delete(t.views, event.ListID)
+		return
+	}
+
+	if len(t.views[event.ListID]) == 0 || t.pending[event.ListID] {
+		return
+	}
+
+	t.pending[event.ListID] = true
+	time.AfterFunc(t.debounce, func() {
+		t.refresh(ctx, event.ListID)
+	})
+}
+
+// refresh re-renders the tracked messages of the list whose content changed
+func (t *ListViewTracker) refresh(ctx context.Context, listID uuid.UUID) {
+	t.mutex.Lock()
+	delete(t.pending, listID)
+	t.pruneLocked(listID, time.Now())
+	views := make(map[listViewKey]listView, len(t.views[listID]))
+	for key, view := range t.views[listID] {
+		views[key] = view
+	}
+	t.mutex.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	renderer := NewListCallbackHandler(t.BaseHandler, nil)
+	viewers := make(map[int64]*users.User)
+
+	for key, view := range views {
+		user, ok := viewers[view.telegramID]
+		if !ok {
+			var err error
+			user, err = t.usersService.GetUserByTelegramID(ctx, view.telegramID)
+			if err != nil {
+				t.logger.Warn("Failed to get list viewer", "error", err, "telegram_id", view.telegramID)
+				continue
+			}
+			viewers[view.telegramID] = user
+		}
+
+		// Members removed from the family stop seeing the list
+		canAccess := false
+		if user != nil {
+			var err error
+			canAccess, err = t.shoppingService.CanUserAccessList(ctx, listID, user.ID)
+			if err != nil {
+				t.logger.Warn("Failed to check list access of viewer", "error", err, "list_id", listID, "user_id", user.ID)
+				continue
+			}
+		}
+		if !canAccess {
+			t.Forget(key.chatID, key.messageID)
+			continue
+		}
+
+		text, keyboard, err := renderer.BuildListViewMessage(ctx, listID, user)
+		if err != nil {
+			t.logger.Warn("Failed to render list view for refresh", "error", err, "list_id", listID, "user_id", user.ID)
+			continue
+		}
+
+		signature := listViewSignature(text, keyboard)
+		if signature == view.signature {
+			continue
+		}
+
+		editMsg := tgbotapi.NewEditMessageText(key.chatID, key.messageID, text)
+		editMsg.ParseMode = tgbotapi.ModeHTML
+		editMsg.ReplyMarkup = &keyboard
+		editMsg.DisableWebPagePreview = true
+
+		if _, err := t.bot.Send(editMsg); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			// The message was deleted or is too old to be edited
+			t.logger.Warn("Failed to refresh list view, no longer updating it",
+				"error", err,
+				"list_id", listID,
+				"chat_id", key.chatID,
+				"message_id", key.messageID)
+			t.Forget(key.chatID, key.messageID)
+			continue
+		}
+
+		t.shown(listID, key, view, signature)
+	}
+}
+
+// shown records the signature a tracked message was refreshed to, unless the message was
+// forgotten or tracked again meanwhile
+func (t *ListViewTracker) shown(listID uuid.UUID, key listViewKey, view listView, signature string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	current, ok := t.views[listID][key]
+	if !ok || current != view {
+		return
+	}
+	current.signature = signature
+	t.views[listID][key] = current
+}
+
+// prune forgets the messages of all lists shown longer ago than the viewing_list state lives
+func (t *ListViewTracker) prune() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	for listID := range t.views {
+		t.pruneLocked(listID, now)
+	}
+}
+
+// pruneLocked forgets the messages of the list shown longer ago than the viewing_list state
+// lives, t.mutex must be held
+func (t *ListViewTracker) pruneLocked(listID uuid.UUID, now time.Time) {
+	for key, view := range t.views[listID] {
+		if now.Sub(view.shownAt) > t.ttl {
+			delete(t.views[listID], key)
+		}
+	}
+	if len(t.views[listID]) == 0 {
+		delete(t.views, listID)
+	}
+}
+
+// forgetLocked removes the message from the list it shows, t.mutex must be held
+func (t *ListViewTracker) forgetLocked(key listViewKey) {
+	for listID, views := range t.views {
+		if _, ok := views[key]; !ok {
+			continue
+		}
+		delete(views, key)
+		if len(views) == 0 {
+			delete(t.views, listID)
+		}
+		return
+	}
+}
+
+// listViewSignature identifies what a list message shows
+func listViewSignature(text string, keyboard tgbotapi.InlineKeyboardMarkup) string {
+	buttons, _ := json.Marshal(keyboard)
+	return text + "\n" + string(buttons)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+func TestListViewTrackerPrune(t *testing.T) {
+	tracker := NewListViewTracker(BaseHandler{})
+	tracker.ttl = time.Hour
+
+	staleList, activeList := uuid.New(), uuid.New()
+	tracker.Track(staleList, 1, 10, 1, "stale", tgbotapi.InlineKeyboardMarkup{})
+	tracker.Track(activeList, 1, 11, 1, "stale", tgbotapi.InlineKeyboardMarkup{})
+	tracker.Track(activeList, 2, 20, 2, "fresh", tgbotapi.InlineKeyboardMarkup{})
+
+	for listID, views := range tracker.views {
+		for key, view := range views {
+			if view.telegramID == 1 {
+				view.shownAt = time.Now().Add(-2 * time.Hour)
+				tracker.views[listID][key] = view
+			}
+		}
+	}
+
+	tracker.prune()
+
+	if _, ok := tracker.views[staleList]; ok {
+		t.Errorf("list with only expired views is still tracked: %v", tracker.views[staleList])
+	}
+	views := tracker.views[activeList]
+	if len(views) != 1 {
+		t.Fatalf("active list has %d views, want 1", len(views))
+	}
+	if _, ok := views[listViewKey{chatID: 2, messageID: 20}]; !ok {
+		t.Errorf("fresh view was pruned: %v", views)
+	}
+}
+
+func TestListViewTrackerTrackMovesMessage(t *testing.T) {
+	tracker := NewListViewTracker(BaseHandler{})
+
+	first, second := uuid.New(), uuid.New()
+	tracker.Track(first, 1, 10, 1, "first", tgbotapi.InlineKeyboardMarkup{})
+	tracker.Track(second, 1, 10, 1, "second", tgbotapi.InlineKeyboardMarkup{})
+
+	if _, ok := tracker.views[first]; ok {
+		t.Errorf("message still tracked for the list it showed before")
+	}
+	if len(tracker.views[second]) != 1 {
+		t.Errorf("message not tracked for the list it shows")
+	}
+
+	tracker.Forget(1, 10)
+	if len(tracker.views) != 0 {
+		t.Errorf("forgotten message still tracked: %v", tracker.views)
+	}
+}
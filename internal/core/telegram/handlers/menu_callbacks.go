@@ -637,12 +637,16 @@ func (h *MenuCallbackHandler) createReceiptsMenuKeyboard(locale string) tgbotapi
 				"receipts:upload",
 			),
 		},
-		// Second row: View Receipts
+		// Second row: View and Export Receipts
 		{
 			tgbotapi.NewInlineKeyboardButtonData(
 				h.templateManager.RenderButton("view_receipts", locale),
 				"receipts:view",
 			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("export_receipts", locale),
+				"receipts:export",
+			),
 		},
 		// Third row: Tax Summary and Statistics
 		{
@@ -655,7 +659,18 @@ func (h *MenuCallbackHandler) createReceiptsMenuKeyboard(locale string) tgbotapi
 				"receipts:stats",
 			),
 		},
-		// Fourth row: Main Menu
+		// Fourth row: Family Budgets and Balances
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("budgets", locale),
+				"receipts:budget",
+			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("balances", locale),
+				"receipts:balances",
+			),
+		},
+		// Fifth row: Main Menu
 		{
 			tgbotapi.NewInlineKeyboardButtonData(
 				h.templateManager.RenderButton("main_menu", locale),
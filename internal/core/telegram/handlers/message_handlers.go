@@ -147,10 +147,18 @@ func (h *MessageHandler) HandleShoppingListNameInput(ctx context.Context, messag
 			tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("add_item", user.Locale), fmt.Sprintf("list_additem_%s", shoppingList.ID.String())),
 			tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("view_list", user.Locale), fmt.Sprintf("list_view_%s", shoppingList.ID.String())),
 		},
-		{
-			tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("all_lists", user.Locale), "show_all_lists"),
-		},
 	}
+
+	// Propose what the family usually buys about now
+	suggestionsText, suggestionRows := h.buildSuggestions(ctx, shoppingList.ID, user.Locale)
+	if suggestionsText != "" {
+		successMessage += "\n\n" + suggestionsText
+		buttons = append(buttons, suggestionRows...)
+	}
+
+	buttons = append(buttons, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(h.templateManager.RenderButton("all_lists", user.Locale), "show_all_lists"),
+	})
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(buttons...)
 
 	// Delete the user's input message
@@ -406,6 +414,7 @@ func (h *MessageHandler) HandleAddItemInput(ctx context.Context, message *tgbota
 				} else {
 					// Replace with updated list view
 					h.EditMessageWithKeyboard(chatID, int(messageID), listViewMessage, listViewKeyboard)
+					h.listViews.Track(listID, chatID, int(messageID), user.TelegramID, listViewMessage, listViewKeyboard)
 
 					// Set viewing state again so user can continue adding items
 					viewStateData := fmt.Sprintf("%s:%d:%d", listID.String(), chatID, messageID)
@@ -437,8 +446,7 @@ func (h *MessageHandler) HandleAddItemInput(ctx context.Context, message *tgbota
 // HandleAuthorizedMessage processes messages from authorized users
 func (h *MessageHandler) HandleAuthorizedMessage(ctx context.Context, message *tgbotapi.Message, user *users.User) {
 	// Check for user states and delegate to appropriate handlers - check if user has any relevant active states
-	allStates := h.stateManager.GetAllStates()
-	userPrefix := fmt.Sprintf("%d:", user.TelegramID)
+	userStates := h.stateManager.GetUserStates(user.TelegramID)
 	hasActiveState := false
 
 	// Only consider list-related states as "active states" that block normal message processing
@@ -451,22 +459,10 @@ func (h *MessageHandler) HandleAuthorizedMessage(ctx context.Context, message *t
 		"duplicate_resolution",
 	}
 
-	for key := range allStates {
-		if strings.HasPrefix(key, userPrefix) {
-			// Extract the state name (everything after "userID:")
-			parts := strings.SplitN(key, ":", 2)
-			if len(parts) == 2 {
-				stateName := parts[1]
-				for _, relevantState := range relevantStates {
-					if stateName == relevantState {
-						hasActiveState = true
-						break
-					}
-				}
-			}
-			if hasActiveState {
-				break
-			}
+	for _, relevantState := range relevantStates {
+		if _, exists := userStates[relevantState]; exists {
+			hasActiveState = true
+			break
 		}
 	}
 
@@ -476,19 +472,17 @@ func (h *MessageHandler) HandleAuthorizedMessage(ctx context.Context, message *t
 
 		// Check for specific states and handle them appropriately
 		// PRIORITY ORDER: Handle direct list operations first, then general product list states
-		if _, exists := h.stateManager.GetUserState(user.TelegramID, "adding_item_to_list"); exists {
+		if listIDStr, exists := userStates["adding_item_to_list"]; exists {
 			// Handle add item input - this takes priority over other states
-			listIDStr := strings.Split(strings.Split(allStates[fmt.Sprintf("%d:adding_item_to_list", user.TelegramID)], ":")[1], "")[0]
 			h.HandleAddItemInput(ctx, message, user, listIDStr)
 			return
-		} else if viewStateData, exists := h.stateManager.GetUserState(user.TelegramID, "viewing_list"); exists {
+		} else if viewStateData, exists := userStates["viewing_list"]; exists {
 			h.logger.Info("Processing message while viewing list", "user_id", user.ID, "list_data", viewStateData, "message_text", message.Text)
 			// Handle message while viewing a list - check if it's a product list
 			h.HandleViewingListMessage(ctx, message, user, viewStateData)
 			return
-		} else if _, exists := h.stateManager.GetUserState(user.TelegramID, "creating_list_for_family"); exists {
+		} else if familyIDStr, exists := userStates["creating_list_for_family"]; exists {
 			// Handle shopping list name input
-			familyIDStr := strings.Split(strings.Split(allStates[fmt.Sprintf("%d:creating_list_for_family", user.TelegramID)], ":")[1], "")[0]
 			h.HandleShoppingListNameInput(ctx, message, user, familyIDStr)
 			return
 		} else if stateData, exists := h.stateManager.GetUserState(user.TelegramID, "product_list_selection"); exists {
@@ -518,7 +512,7 @@ func (h *MessageHandler) HandleAuthorizedMessage(ctx context.Context, message *t
 			return
 		} else {
 			// Unknown state, send generic message
-			h.logger.Warn("User has unknown active state", "user_id", user.ID, "states", allStates)
+			h.logger.Warn("User has unknown active state", "user_id", user.ID, "states", userStates)
 			messageText, err := h.templateManager.RenderTemplate("message_received", user.Locale, nil)
 			if err != nil {
 				h.logger.Error("Failed to render message received template", "error", err)
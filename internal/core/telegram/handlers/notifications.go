@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/notifications"
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// digestInterval is how often due digests are sent
+const digestInterval = 30 * time.Second
+
+// NotificationsHandler tells family members about the activity in their shared lists: items
+// added by other members and lists completed. The activity is queued per member and sent as
+// digests following the member's notification preferences.
+type NotificationsHandler struct {
+	BaseHandler
+	notificationsService *notifications.Service
+}
+
+// NewNotificationsHandler creates a new family activity notifications handler
+func NewNotificationsHandler(base BaseHandler, notificationsService *notifications.Service) *NotificationsHandler {
+	return &NotificationsHandler{
+		BaseHandler:          base,
+		notificationsService: notificationsService,
+	}
+}
+
+// Run queues the family activity of the events until ctx is done or the events channel is
+// closed, and sends the due digests meanwhile. Digests are sent apart from the events, so slow
+// deliveries don't hold up the subscription until it drops events.
+func (h *NotificationsHandler) Run(ctx context.Context, events <-chan shopping.ListEvent) {
+	go h.runDigests(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			h.queueActivity(ctx, event)
+		}
+	}
+}
+
+// runDigests sends the due digests every digestInterval until ctx is done
+func (h *NotificationsHandler) runDigests(ctx context.Context) {
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.sendDigests(ctx)
+		}
+	}
+}
+
+// queueActivity queues added items and completed lists of family lists for the other members. A
+// list is completed when its last item is checked off, or when it is archived before that.
+func (h *NotificationsHandler) queueActivity(ctx context.Context, event shopping.ListEvent) {
+	var activity notifications.Activity
+	switch event.Type {
+	case shopping.ListEventItemAdded:
+		activity.Activity = notifications.ActivityItemsAdded
+		if event.Item != nil {
+			name := event.Item.Name
+			if event.Item.DisplayName != nil && *event.Item.DisplayName != "" {
+				name = *event.Item.DisplayName
+			}
+			activity.ItemName = &name
+		}
+	case shopping.ListEventItemCompleted, shopping.ListEventListArchived:
+		activity.Activity = notifications.ActivityListCompleted
+	default:
+		return
+	}
+
+	// Changes made outside of a user's request are not family activity
+	if event.UserID == nil {
+		return
+	}
+
+	list, err := h.shoppingService.GetShoppingListByID(ctx, event.ListID)
+	if err != nil || list == nil || list.FamilyID == nil {
+		if err != nil {
+			h.logger.Warn("Failed to get list for family activity", "error", err, "list_id", event.ListID)
+		}
+		return
+	}
+
+	if activity.Activity == notifications.ActivityListCompleted {
+		completed, err := h.shoppingService.IsListCompleted(ctx, event.ListID)
+		if err != nil {
+			h.logger.Warn("Failed to check list completion for family activity", "error", err, "list_id", event.ListID)
+			return
+		}
+		// Checking off the last item completed the list, archiving it afterwards is no news
+		if completed != (event.Type == shopping.ListEventItemCompleted) {
+			return
+		}
+	}
+
+	memberIDs, err := h.familiesService.GetFamilyMemberUserIDs(ctx, *list.FamilyID, *event.UserID)
+	if err != nil {
+		h.logger.Warn("Failed to get family members for activity", "error", err, "family_id", *list.FamilyID)
+		return
+	}
+
+	activity.EventID = event.ID
+	activity.ListID = event.ListID
+	activity.ActorID = *event.UserID
+
+	if _, err := h.notificationsService.QueueActivity(ctx, activity, memberIDs); err != nil {
+		h.logger.Warn("Failed to queue family activity", "error", err, "list_id", event.ListID, "type", event.Type)
+	}
+}
+
+// sendDigests sends the digests due now, each in its recipient's language with buttons muting
+// the lists it mentions
+func (h *NotificationsHandler) sendDigests(ctx context.Context) {
+	digests, err := h.notificationsService.ClaimDueDigests(ctx, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to claim family activity digests", "error", err)
+	}
+
+	for _, digest := range digests {
+		data := struct {
+			Entries []*notifications.DigestEntry
+			Locale  string
+		}{
+			Entries: digest.Entries,
+			Locale:  digest.Locale,
+		}
+
+		message, err := h.templateManager.RenderTemplate("family_activity_digest", digest.Locale, data)
+		if err != nil {
+			h.logger.Error("Failed to render family activity digest template", "error", err)
+			continue
+		}
+
+		var rows [][]tgbotapi.InlineKeyboardButton
+		muteButton := h.templateManager.RenderButton("mute_list", digest.Locale)
+		seen := make(map[uuid.UUID]bool)
+		for _, entry := range digest.Entries {
+			if seen[entry.ListID] {
+				continue
+			}
+			seen[entry.ListID] = true
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					fmt.Sprintf("%s %s", muteButton, truncateUTF8(entry.ListName, 30)),
+					fmt.Sprintf("notif:mute:%s", entry.ListID),
+				),
+			))
+		}
+
+		h.SendMessageWithKeyboard(digest.TelegramID, message, tgbotapi.NewInlineKeyboardMarkup(rows...))
+
+		h.logger.Info("Family activity digest sent",
+			"recipient_id", digest.RecipientID,
+			"entries", len(digest.Entries))
+	}
+}
+
+// HandleNotificationsCallback handles the buttons of activity digests (notif:mute:<listID>)
+func (h *NotificationsHandler) HandleNotificationsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, parts []string, user *users.User) {
+	if len(parts) < 3 || parts[1] != "mute" {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_list_id", user.Locale))
+		return
+	}
+
+	listID, err := uuid.Parse(parts[2])
+	if err != nil {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_list_id", user.Locale))
+		return
+	}
+
+	canAccess, err := h.shoppingService.CanUserAccessList(ctx, listID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to check list access", "error", err, "list_id", listID, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_verify_access", user.Locale))
+		return
+	}
+	if !canAccess {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_no_access", user.Locale))
+		return
+	}
+
+	if err := h.notificationsService.MuteList(ctx, user.ID, listID); err != nil {
+		h.logger.Error("Failed to mute list", "error", err, "list_id", listID, "user_id", user.ID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_saving_notification_settings", user.Locale))
+		return
+	}
+
+	h.logger.Info("List muted", "list_id", listID, "user_id", user.ID)
+	h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_list_muted", user.Locale))
+}
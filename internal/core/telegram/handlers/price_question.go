@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// priceQuestionPatterns recognise questions about the price of an item in the supported languages.
+// The first capture group is the item being asked about.
+var priceQuestionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^how much (?:did|does|do|was|were|is|are) (?:the )?(.+?)(?: cost)?$`),
+	regexp.MustCompile(`(?i)^(?:what is |what's )?(?:the )?price (?:of|for) (?:the )?(.+)$`),
+	regexp.MustCompile(`(?i)^where (?:is|are) (?:the )?(.+?) (?:the )?cheapest$`),
+	regexp.MustCompile(`(?i)^(?:where (?:can i buy|to buy) )?(?:the )?cheapest (.+)$`),
+	regexp.MustCompile(`(?i)^сколько (?:стоил|стоила|стоило|стоили|стоит|стоят) (.+)$`),
+	regexp.MustCompile(`(?i)^(?:какая )?цена (?:на )?(.+)$`),
+	regexp.MustCompile(`(?i)^где (?:дешевле(?: всего)?|самый дешёвый|самый дешевый|купить дешевле) (.+)$`),
+	regexp.MustCompile(`(?i)^скільки (?:коштував|коштувала|коштувало|коштували|коштує|коштують) (.+)$`),
+	regexp.MustCompile(`(?i)^(?:яка )?ціна (?:на )?(.+)$`),
+	regexp.MustCompile(`(?i)^де (?:дешевше|найдешевше|купити дешевше) (.+)$`),
+}
+
+// parsePriceQuestion returns the item of a price question like "how much did milk cost?"
+func parsePriceQuestion(text string) (string, bool) {
+	question := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(text), "?!. "))
+	if question == "" || strings.Contains(question, "\n") {
+		return "", false
+	}
+
+	for _, pattern := range priceQuestionPatterns {
+		match := pattern.FindStringSubmatch(question)
+		if match == nil {
+			continue
+		}
+
+		item := strings.TrimSpace(match[1])
+		if item == "" || len(strings.Fields(item)) > 4 {
+			continue
+		}
+		return item, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import "testing"
+
+func TestParsePriceQuestion(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantItem string
+		wantOK   bool
+	}{
+		{"How much did milk cost?", "milk", true},
+		{"how much is the butter", "butter", true},
+		{"What's the price of eggs?", "eggs", true},
+		{"price for oat milk", "oat milk", true},
+		{"Where is bread the cheapest?", "bread", true},
+		{"cheapest coffee beans", "coffee beans", true},
+		{"Сколько стоило молоко?", "молоко", true},
+		{"цена на гречку", "гречку", true},
+		{"где дешевле всего сыр", "сыр", true},
+		{"Скільки коштував хліб?", "хліб", true},
+		{"ціна на масло", "масло", true},
+		{"де найдешевше кава", "кава", true},
+		{"milk, bread, eggs", "", false},
+		{"how much did the large organic free range eggs cost", "", false},
+		{"how much did milk cost\nand bread", "", false},
+		{"?", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			item, ok := parsePriceQuestion(tt.text)
+			if item != tt.wantItem || ok != tt.wantOK {
+				t.Errorf("parsePriceQuestion(%q) = %q, %v, want %q, %v", tt.text, item, ok, tt.wantItem, tt.wantOK)
+			}
+		})
+	}
+}
@@ -215,6 +215,7 @@ func (h *ProductListCallbackHandler) handleProductListAddTo(ctx context.Context,
 	} else {
 		// Replace with updated list view
 		h.EditMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, listViewMessage, listViewKeyboard)
+		h.listViews.Track(targetListID, callback.Message.Chat.ID, callback.Message.MessageID, user.TelegramID, listViewMessage, listViewKeyboard)
 
 		// Set viewing state so user can continue adding items by typing
 		viewStateData := fmt.Sprintf("%s:%d:%d", targetListID.String(), callback.Message.Chat.ID, callback.Message.MessageID)
@@ -416,6 +417,7 @@ func (h *ProductListCallbackHandler) handleCreateAndAddItems(ctx context.Context
 	} else {
 		// Replace with updated list view
 		h.EditMessageWithKeyboard(callback.Message.Chat.ID, callback.Message.MessageID, listViewMessage, listViewKeyboard)
+		h.listViews.Track(list.ID, callback.Message.Chat.ID, callback.Message.MessageID, user.TelegramID, listViewMessage, listViewKeyboard)
 
 		// Set viewing state so user can continue adding items by typing
 		viewStateData := fmt.Sprintf("%s:%d:%d", list.ID.String(), callback.Message.Chat.ID, callback.Message.MessageID)
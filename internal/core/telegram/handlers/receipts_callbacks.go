@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
 	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
 	"github.com/PocketPalCo/shopping-service/internal/core/users"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -51,14 +56,50 @@ func (h *ReceiptsCallbackHandler) HandleReceiptsCallback(ctx context.Context, ca
 	switch action {
 	case "upload":
 		h.handleUploadReceipt(ctx, callback, user)
-	case "view":
+	case "view", "family":
 		h.handleViewReceipts(ctx, callback, user, parts)
 	case "detail":
 		h.handleReceiptDetail(ctx, callback, user, parts)
+	case "share":
+		h.handleShareReceipt(ctx, callback, user, parts)
+	case "shareto":
+		h.handleShareReceiptTo(ctx, callback, user, parts)
+	case "unshare":
+		h.handleUnshareReceipt(ctx, callback, user, parts)
+	case "paid":
+		h.handleReceiptPaid(ctx, callback, user, parts)
+	case "split":
+		h.handleSplitReceipt(ctx, callback, user, parts)
+	case "splitshow":
+		h.handleSplitShow(ctx, callback, user)
+	case "splitm":
+		h.handleSplitMember(ctx, callback, user, parts)
+	case "splititems":
+		h.handleSplitItems(ctx, callback, user, parts)
+	case "splititem":
+		h.handleSplitItem(ctx, callback, user, parts)
+	case "splitim":
+		h.handleSplitItemMember(ctx, callback, user, parts)
+	case "balances":
+		h.handleBalances(ctx, callback, user, parts)
+	case "settle":
+		h.handleSettle(ctx, callback, user, parts)
+	case "settlements":
+		h.handleSettlements(ctx, callback, user)
+	case "export":
+		h.handleExportReceipts(ctx, callback, user, parts)
 	case "taxes":
-		h.handleTaxSummary(ctx, callback, user)
+		h.handleTaxSummary(ctx, callback, user, parts)
+	case "taxexport":
+		h.handleTaxSummaryExport(ctx, callback, user, parts)
 	case "stats":
-		h.handleReceiptStats(ctx, callback, user)
+		h.handleReceiptStats(ctx, callback, user, parts)
+	case "budget":
+		h.handleBudgetStatus(ctx, callback, user)
+	case "reconcile":
+		h.handleReconcileConfirm(ctx, callback, user, parts)
+	case "reconcileskip":
+		h.handleReconcileSkip(callback, user)
 	default:
 		h.logger.Warn("Unknown receipts action", "action", action, "user_id", user.TelegramID)
 		h.answerCallback(callback.ID, "❌ Unknown action.")
@@ -104,14 +145,48 @@ func (h *ReceiptsCallbackHandler) handleUploadReceipt(ctx context.Context, callb
 	h.answerCallback(callback.ID, "📸 Send me a photo of your receipt!")
 }
 
-// handleViewReceipts handles the view receipts action with pagination
+// handleViewReceipts handles the view receipts action with pagination: the user's own receipts
+// (receipts:view[:page]) or the receipts shared with one of their families (receipts:family:<id>[:page])
 func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
-	h.logger.Info("Handling view receipts action", "user_id", user.TelegramID)
+	h.logger.Info("Handling view receipts action", "user_id", user.TelegramID, "action", parts[1])
+
+	// Families of the user, to switch to their receipts or to name the family being viewed
+	userFamilies, err := h.familiesService.GetUserFamilies(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get user families", "error", err, "user_id", user.ID)
+	}
+
+	var family *families.Family
+	pageIndex := 2
+	pageCallback := "receipts:view"
+	if parts[1] == "family" {
+		if len(parts) < 3 {
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_family_id", user.Locale))
+			return
+		}
+		familyID, err := uuid.Parse(parts[2])
+		if err != nil {
+			h.logger.Error("Invalid family ID format", "error", err, "family_id", parts[2])
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_family_id", user.Locale))
+			return
+		}
+		for _, userFamily := range userFamilies {
+			if userFamily.ID == familyID {
+				family = userFamily
+			}
+		}
+		if family == nil {
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_not_family_member", user.Locale))
+			return
+		}
+		pageIndex = 3
+		pageCallback = fmt.Sprintf("receipts:family:%s", family.ID)
+	}
 
 	// Parse page number (defaults to 1)
 	page := 1
-	if len(parts) > 2 {
-		if parsedPage, err := strconv.Atoi(parts[2]); err == nil && parsedPage > 0 {
+	if len(parts) > pageIndex {
+		if parsedPage, err := strconv.Atoi(parts[pageIndex]); err == nil && parsedPage > 0 {
 			page = parsedPage
 		}
 	}
@@ -120,7 +195,16 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 	limit := 5
 	offset := (page - 1) * limit
 
-	receipts, err := h.receiptsService.GetUserReceipts(ctx, user.ID, limit, offset)
+	filter := receipts.ReceiptsFilter{
+		UserID: user.ID,
+		Limit:  limit,
+		Offset: offset,
+	}
+	if family != nil {
+		filter.FamilyID = &family.ID
+	}
+
+	receiptsList, totalCount, err := h.receiptsService.ListReceipts(ctx, filter)
 	if err != nil {
 		h.logger.Error("Failed to get user receipts", "error", err, "user_id", user.ID)
 		// Use template system for error messages
@@ -132,11 +216,23 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 		return
 	}
 
-	// Get total count for pagination
-	totalCount, err := h.receiptsService.GetUserReceiptsCount(ctx, user.ID)
-	if err != nil {
-		h.logger.Error("Failed to get user receipts count", "error", err, "user_id", user.ID)
-		totalCount = 0
+	// Back from a family's receipts goes to the user's own receipts
+	backCallback := "receipts:menu"
+	if family != nil {
+		backCallback = "receipts:view:1"
+	}
+
+	// Buttons switching to the receipts of the user's families
+	var familyRows [][]tgbotapi.InlineKeyboardButton
+	if family == nil {
+		for _, userFamily := range userFamilies {
+			familyRows = append(familyRows, []tgbotapi.InlineKeyboardButton{
+				tgbotapi.NewInlineKeyboardButtonData(
+					fmt.Sprintf("👥 %s", userFamily.Name),
+					fmt.Sprintf("receipts:family:%s", userFamily.ID),
+				),
+			})
+		}
 	}
 
 	totalPages := (totalCount + limit - 1) / limit
@@ -147,23 +243,25 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 	var message string
 	var keyboard *tgbotapi.InlineKeyboardMarkup
 
-	if len(receipts) == 0 && page == 1 {
+	if len(receiptsList) == 0 && page == 1 {
 		// No receipts at all
-		message = h.templateManager.RenderMessage("no_receipts_found", user.Locale)
-		keyboard = &tgbotapi.InlineKeyboardMarkup{
-			InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{
-				{
-					tgbotapi.NewInlineKeyboardButtonData(
-						h.templateManager.RenderButton("back", user.Locale),
-						"receipts:menu",
-					),
-				},
-			},
+		if family != nil {
+			message = h.templateManager.RenderMessage("no_family_receipts_found", user.Locale)
+		} else {
+			message = h.templateManager.RenderMessage("no_receipts_found", user.Locale)
 		}
+		rows := append(familyRows, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				backCallback,
+			),
+		})
+		keyboard = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: rows}
 	} else {
 		// Create receipts list message with pagination
 		data := struct {
 			Receipts   []interface{}
+			FamilyName string
 			Page       int
 			Total      int
 			TotalPages int
@@ -172,13 +270,19 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 			Total:      totalCount,
 			TotalPages: totalPages,
 		}
+		if family != nil {
+			data.FamilyName = family.Name
+		}
 
 		// Convert receipts to interface slice for template
-		for _, receipt := range receipts {
-			var totalAmount interface{}
+		for _, receipt := range receiptsList {
+			var totalAmount, familyName interface{}
 			if receipt.TotalAmount != nil {
 				totalAmount = *receipt.TotalAmount
 			}
+			if receipt.FamilyName != nil && family == nil {
+				familyName = *receipt.FamilyName
+			}
 
 			data.Receipts = append(data.Receipts, map[string]interface{}{
 				"ID":           receipt.ID.String(),
@@ -188,6 +292,9 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 				"TotalAmount":  totalAmount,
 				"CreatedAt":    receipt.CreatedAt.Format("2006-01-02 15:04"),
 				"Processed":    receipt.Processed,
+				"FamilyName":   familyName,
+				"Shared":       receipt.FamilyID != nil,
+				"PaidByName":   receipt.PaidByName,
 			})
 		}
 
@@ -202,7 +309,7 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 		var rows [][]tgbotapi.InlineKeyboardButton
 
 		// Add individual receipt buttons (max 5 per page)
-		for _, receipt := range receipts {
+		for _, receipt := range receiptsList {
 			buttonText := receipt.FileName
 			if receipt.MerchantName != nil && *receipt.MerchantName != "" {
 				buttonText = *receipt.MerchantName
@@ -228,23 +335,24 @@ func (h *ReceiptsCallbackHandler) handleViewReceipts(ctx context.Context, callba
 			paginationRow = append(paginationRow,
 				tgbotapi.NewInlineKeyboardButtonData(
 					h.templateManager.RenderButton("previous", user.Locale),
-					fmt.Sprintf("receipts:view:%d", page-1)))
+					fmt.Sprintf("%s:%d", pageCallback, page-1)))
 		}
 		if page < totalPages {
 			paginationRow = append(paginationRow,
 				tgbotapi.NewInlineKeyboardButtonData(
 					h.templateManager.RenderButton("next", user.Locale),
-					fmt.Sprintf("receipts:view:%d", page+1)))
+					fmt.Sprintf("%s:%d", pageCallback, page+1)))
 		}
 		if len(paginationRow) > 0 {
 			rows = append(rows, paginationRow)
 		}
 
-		// Add back button
+		// Add family receipts and back buttons
+		rows = append(rows, familyRows...)
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
 			tgbotapi.NewInlineKeyboardButtonData(
 				h.templateManager.RenderButton("back", user.Locale),
-				"receipts:menu",
+				backCallback,
 			),
 		})
 
@@ -341,9 +449,13 @@ func (h *ReceiptsCallbackHandler) handleReceiptDetail(ctx context.Context, callb
 
 	// Convert items to interface slice for template
 	for _, item := range receiptWithItems.Items {
-		var quantity, unitPrice interface{}
-		if item.Quantity != nil {
-			quantity = *item.Quantity
+		var quantity, quantityUnit, unitPrice interface{}
+		if item.Quantity != nil && *item.Quantity > 0 {
+			// Weighted items keep up to three decimals, counted items are shown as whole numbers
+			quantity = strconv.FormatFloat(*item.Quantity, 'f', -1, 64)
+		}
+		if item.QuantityUnit != nil {
+			quantityUnit = *item.QuantityUnit
 		}
 		if item.UnitPrice != nil {
 			unitPrice = *item.UnitPrice
@@ -353,6 +465,7 @@ func (h *ReceiptsCallbackHandler) handleReceiptDetail(ctx context.Context, callb
 			"OriginalDescription":  item.OriginalDescription,
 			"LocalizedDescription": item.LocalizedDescription,
 			"Quantity":             quantity,
+			"QuantityUnit":         quantityUnit,
 			"UnitPrice":            unitPrice,
 			"TotalPrice":           item.TotalPrice,
 		})
@@ -365,14 +478,45 @@ func (h *ReceiptsCallbackHandler) handleReceiptDetail(ctx context.Context, callb
 		message = h.templateManager.RenderMessage("error_displaying_receipt", user.Locale)
 	}
 
-	// Create keyboard with back to receipts list button
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
+	// Create keyboard with sharing, payer and back to receipts list buttons
+	receipt := receiptWithItems.Receipt
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if receipt.UserID == user.ID {
+		if receipt.FamilyID == nil {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					h.templateManager.RenderButton("share_receipt", user.Locale),
+					fmt.Sprintf("receipts:share:%s", receipt.ID))))
+		} else {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(
+					h.templateManager.RenderButton("unshare_receipt", user.Locale),
+					fmt.Sprintf("receipts:unshare:%s", receipt.ID))))
+		}
+	}
+	if receipt.FamilyID != nil && receipt.PaidBy != user.ID {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("back_to_list", user.Locale),
-				"receipts:view:1"),
-		),
-	)
+				h.templateManager.RenderButton("i_paid", user.Locale),
+				fmt.Sprintf("receipts:paid:%s", receipt.ID))))
+	}
+	if receipt.FamilyID != nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("split_receipt", user.Locale),
+				fmt.Sprintf("receipts:split:%s", receipt.ID))))
+	}
+
+	backCallback := "receipts:view:1"
+	if receipt.FamilyID != nil && receipt.UserID != user.ID {
+		backCallback = fmt.Sprintf("receipts:family:%s", *receipt.FamilyID)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back_to_list", user.Locale),
+			backCallback),
+	))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
 
 	// Check if content is too long for photo caption (1024 char limit)
 	// If so, prioritize content over image
@@ -425,144 +569,1382 @@ func (h *ReceiptsCallbackHandler) handleReceiptDetail(ctx context.Context, callb
 	h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_receipt_details", user.Locale))
 }
 
-func (h *ReceiptsCallbackHandler) handleTaxSummary(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
-	h.logger.Info("Handling tax summary action", "user_id", user.TelegramID)
+// handleShareReceipt asks which family to share a receipt with (receipts:share:<receiptID>). The
+// receipt is kept in the user's state as both IDs don't fit in the callback data.
+func (h *ReceiptsCallbackHandler) handleShareReceipt(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.parseReceiptID(callback, user, parts)
+	if !ok {
+		return
+	}
 
-	// Feature placeholder: Tax calculation functionality will be implemented
-	// This will analyze receipts to calculate tax summaries and deductions
-	message := h.templateManager.RenderMessage("tax_summary_coming_soon", user.Locale)
+	userFamilies, err := h.familiesService.GetUserFamilies(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get user families", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_retrieve_families", user.Locale))
+		return
+	}
+	if len(userFamilies) == 0 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_no_families_to_share", user.Locale))
+		return
+	}
+	if len(userFamilies) == 1 {
+		h.shareReceipt(ctx, callback, user, receiptID, &userFamilies[0].ID)
+		return
+	}
 
-	// Create keyboard with back button
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
+	if h.stateManager != nil {
+		h.stateManager.SetUserState(user.TelegramID, "sharing_receipt", receiptID.String())
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, family := range userFamilies {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("back", user.Locale),
-				"receipts:menu",
-			),
-		),
-	)
+				fmt.Sprintf("👥 %s", family.Name),
+				fmt.Sprintf("receipts:shareto:%s", family.ID))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back", user.Locale),
+			fmt.Sprintf("receipts:detail:%s", receiptID))))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
 
-	// Update the message
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
-	edit.ReplyMarkup = &keyboard
-	edit.ParseMode = "HTML"
+	message := h.templateManager.RenderMessage("choose_family_to_share_receipt", user.Locale)
+	if callback.Message.Photo != nil {
+		// Receipt details shown with the receipt photo can't be edited into a text message
+		h.SendMessageWithKeyboard(callback.Message.Chat.ID, message, keyboard)
+	} else {
+		h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, &keyboard)
+	}
+	h.answerCallback(callback.ID, "")
+}
 
-	if _, err := h.bot.Send(edit); err != nil {
-		h.logger.Error("Failed to update tax summary message", "error", err)
+// handleShareReceiptTo shares the receipt chosen with handleShareReceipt with a family
+// (receipts:shareto:<familyID>)
+func (h *ReceiptsCallbackHandler) handleShareReceiptTo(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	var receiptIDStr string
+	if h.stateManager != nil {
+		receiptIDStr, _ = h.stateManager.GetUserState(user.TelegramID, "sharing_receipt")
+		h.stateManager.ClearUserState(user.TelegramID, "sharing_receipt")
+	}
+	receiptID, err := uuid.Parse(receiptIDStr)
+	if err != nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_invalid_receipt_id", user.Locale))
+		return
 	}
 
-	h.answerCallback(callback.ID, "💰 Tax summary")
+	if len(parts) < 3 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_family_id", user.Locale))
+		return
+	}
+	familyID, err := uuid.Parse(parts[2])
+	if err != nil {
+		h.logger.Error("Invalid family ID format", "error", err, "family_id", parts[2])
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_family_id", user.Locale))
+		return
+	}
+
+	h.shareReceipt(ctx, callback, user, receiptID, &familyID)
 }
 
-// handleReceiptStats handles the receipt statistics action
-func (h *ReceiptsCallbackHandler) handleReceiptStats(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
-	h.logger.Info("Handling receipt stats action", "user_id", user.TelegramID)
+// handleUnshareReceipt makes a shared receipt private again (receipts:unshare:<receiptID>)
+func (h *ReceiptsCallbackHandler) handleUnshareReceipt(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.parseReceiptID(callback, user, parts)
+	if !ok {
+		return
+	}
 
-	// Feature placeholder: Statistics calculation functionality will be implemented
-	// This will provide analytics on spending patterns, frequent items, and expense trends
-	message := h.templateManager.RenderMessage("receipt_stats_coming_soon", user.Locale)
+	h.shareReceipt(ctx, callback, user, receiptID, nil)
+}
 
-	// Create keyboard with back button
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("back", user.Locale),
-				"receipts:menu",
-			),
-		),
-	)
+// shareReceipt shares the receipt with the family, or unshares it when familyID is nil, and shows
+// the updated receipt details
+func (h *ReceiptsCallbackHandler) shareReceipt(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, receiptID uuid.UUID, familyID *uuid.UUID) {
+	if err := h.receiptsService.ShareReceipt(ctx, receiptID, user.ID, familyID); err != nil {
+		h.logger.Error("Failed to share receipt", "error", err, "receipt_id", receiptID, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
+	}
 
-	// Update the message
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
-	edit.ReplyMarkup = &keyboard
-	edit.ParseMode = "HTML"
+	h.logger.Info("Receipt sharing changed", "receipt_id", receiptID, "family_id", familyID, "user_id", user.ID)
+	h.handleReceiptDetail(ctx, callback, user, []string{"receipts", "detail", receiptID.String()})
+}
 
-	if _, err := h.bot.Send(edit); err != nil {
-		h.logger.Error("Failed to update receipt stats message", "error", err)
+// handleReceiptPaid records the user as the one who paid a shared receipt (receipts:paid:<receiptID>)
+func (h *ReceiptsCallbackHandler) handleReceiptPaid(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.parseReceiptID(callback, user, parts)
+	if !ok {
+		return
 	}
 
-	h.answerCallback(callback.ID, "📊 Statistics")
+	if err := h.receiptsService.SetReceiptPayer(ctx, receiptID, user.ID, user.ID); err != nil {
+		h.logger.Error("Failed to set receipt payer", "error", err, "receipt_id", receiptID, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
+	}
+
+	h.logger.Info("Receipt payer changed", "receipt_id", receiptID, "user_id", user.ID)
+	h.handleReceiptDetail(ctx, callback, user, []string{"receipts", "detail", receiptID.String()})
 }
 
-// handleBackToMenu handles returning to the receipts menu
-func (h *ReceiptsCallbackHandler) HandleBackToReceiptsMenu(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
-	h.logger.Info("Handling back to receipts menu", "user_id", user.TelegramID)
+// parseReceiptID parses the receipt ID of a receipts:<action>:<receiptID> callback, answering the
+// callback when it is invalid
+func (h *ReceiptsCallbackHandler) parseReceiptID(callback *tgbotapi.CallbackQuery, user *users.User, parts []string) (uuid.UUID, bool) {
+	if len(parts) < 3 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_invalid_receipt_id", user.Locale))
+		return uuid.Nil, false
+	}
+
+	receiptID, err := uuid.Parse(parts[2])
+	if err != nil {
+		h.logger.Error("Invalid receipt ID format", "error", err, "receipt_id", parts[2])
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_invalid_receipt_id", user.Locale))
+		return uuid.Nil, false
+	}
+
+	return receiptID, true
+}
+
+// receiptErrorMessage localizes a receipt sharing or splitting error
+func (h *ReceiptsCallbackHandler) receiptErrorMessage(err error, locale string) string {
+	switch {
+	case errors.Is(err, receipts.ErrReceiptNotFound):
+		return h.templateManager.RenderMessage("callback_receipt_not_found", locale)
+	case errors.Is(err, receipts.ErrReceiptNotOwned):
+		return h.templateManager.RenderMessage("callback_receipt_not_owned", locale)
+	case errors.Is(err, receipts.ErrNotFamilyMember):
+		return h.templateManager.RenderMessage("callback_not_family_member", locale)
+	case errors.Is(err, receipts.ErrReceiptNotShared):
+		return h.templateManager.RenderMessage("callback_receipt_not_shared", locale)
+	case errors.Is(err, receipts.ErrReceiptItemNotFound):
+		return h.templateManager.RenderMessage("callback_split_outdated", locale)
+	default:
+		return h.templateManager.RenderMessage("error_sharing_receipt", locale)
+	}
+}
+
+// splitItemsPerPage is the number of receipt items listed per page when splitting by item
+const splitItemsPerPage = 8
+
+// handleSplitReceipt starts splitting a family receipt among members (receipts:split:<receiptID>).
+// The receipt is kept in the user's state, the split screens only pass member and item positions.
+func (h *ReceiptsCallbackHandler) handleSplitReceipt(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.parseReceiptID(callback, user, parts)
+	if !ok {
+		return
+	}
 
-	// Clear any receipts-related states
 	if h.stateManager != nil {
-		h.stateManager.ClearUserState(user.TelegramID, "awaiting_receipt_upload")
-		h.stateManager.ClearUserState(user.TelegramID, "viewing_receipts")
+		h.stateManager.SetUserState(user.TelegramID, "splitting_receipt", receiptID.String())
 	}
+	h.showReceiptSplit(ctx, callback, user, receiptID)
+}
 
-	// Render receipts menu
-	data := struct {
-		IsAuthorized bool
-		UserName     string
-	}{
-		IsAuthorized: user.IsAuthorized,
-		UserName:     user.FirstName,
+// handleSplitShow shows the split of the receipt being split again (receipts:splitshow)
+func (h *ReceiptsCallbackHandler) handleSplitShow(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
+	receiptID, ok := h.splittingReceiptID(callback, user)
+	if !ok {
+		return
 	}
 
-	message, err := h.templateManager.RenderTemplate("receipts_menu", user.Locale, data)
-	if err != nil {
-		h.logger.Error("Failed to render receipts menu template", "error", err)
-		message = "🧾 Receipt Management\n\nChoose an option below:"
+	h.showReceiptSplit(ctx, callback, user, receiptID)
+}
+
+// handleSplitMember adds or removes a member from the members sharing the receipt
+// (receipts:splitm:<member position>)
+func (h *ReceiptsCallbackHandler) handleSplitMember(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.splittingReceiptID(callback, user)
+	if !ok {
+		return
 	}
 
-	// Create receipts menu keyboard
-	keyboard := h.createReceiptsMenuKeyboard(user.Locale)
+	split, ok := h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
 
-	// Update the message
-	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
-	edit.ReplyMarkup = keyboard
-	edit.ParseMode = "HTML"
+	member := splitMemberAt(split.Members, parts, 2)
+	if member == nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_split_outdated", user.Locale))
+		return
+	}
 
-	if _, err := h.bot.Send(edit); err != nil {
-		h.logger.Error("Failed to update receipts menu message", "error", err)
+	if _, err := h.receiptsService.ToggleReceiptSplitMember(ctx, receiptID, user.ID, member.UserID); err != nil {
+		h.logger.Error("Failed to update receipt split", "error", err, "receipt_id", receiptID, "member_id", member.UserID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
 	}
 
-	h.answerCallback(callback.ID, "🧾 Receipts menu")
+	h.showReceiptSplit(ctx, callback, user, receiptID)
 }
 
-// createReceiptsMenuKeyboard creates the receipts menu inline keyboard
-func (h *ReceiptsCallbackHandler) createReceiptsMenuKeyboard(locale string) *tgbotapi.InlineKeyboardMarkup {
-	rows := [][]tgbotapi.InlineKeyboardButton{
-		// First row: Upload Receipt
-		{
-			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("upload_receipt", locale),
-				"receipts:upload",
-			),
-		},
-		// Second row: View Receipts
-		{
-			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("view_receipts", locale),
-				"receipts:view",
-			),
-		},
-		// Third row: Tax Summary and Statistics
-		{
-			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("tax_summary", locale),
-				"receipts:taxes",
-			),
+// handleSplitItems lists the items of the receipt being split (receipts:splititems[:page])
+func (h *ReceiptsCallbackHandler) handleSplitItems(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.splittingReceiptID(callback, user)
+	if !ok {
+		return
+	}
+
+	split, ok := h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
+
+	page := 1
+	if len(parts) > 2 {
+		if parsedPage, err := strconv.Atoi(parts[2]); err == nil && parsedPage > 0 {
+			page = parsedPage
+		}
+	}
+	totalPages := (len(split.Items) + splitItemsPerPage - 1) / splitItemsPerPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	if page > totalPages {
+		page = totalPages
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	start := (page - 1) * splitItemsPerPage
+	for i := start; i < len(split.Items) && i < start+splitItemsPerPage; i++ {
+		item := split.Items[i]
+		buttonText := item.OriginalDescription
+		if item.LocalizedDescription != nil && *item.LocalizedDescription != "" {
+			buttonText = *item.LocalizedDescription
+		}
+		if len([]rune(buttonText)) > 25 {
+			buttonText = string([]rune(buttonText)[:22]) + "..."
+		}
+		buttonText += fmt.Sprintf(" - %.2f", item.TotalPrice)
+		if count := item.IncludedCount(); count > 0 {
+			buttonText += fmt.Sprintf(" 👤%d", count)
+		}
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(buttonText, fmt.Sprintf("receipts:splititem:%d", i))))
+	}
+
+	var paginationRow []tgbotapi.InlineKeyboardButton
+	if page > 1 {
+		paginationRow = append(paginationRow,
 			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("receipt_stats", locale),
-				"receipts:stats",
-			),
-		},
-		// Fourth row: Main Menu
-		{
+				h.templateManager.RenderButton("previous", user.Locale),
+				fmt.Sprintf("receipts:splititems:%d", page-1)))
+	}
+	if page < totalPages {
+		paginationRow = append(paginationRow,
 			tgbotapi.NewInlineKeyboardButtonData(
-				h.templateManager.RenderButton("main_menu", locale),
-				"menu_start",
-			),
-		},
+				h.templateManager.RenderButton("next", user.Locale),
+				fmt.Sprintf("receipts:splititems:%d", page+1)))
+	}
+	if len(paginationRow) > 0 {
+		rows = append(rows, paginationRow)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back", user.Locale),
+			"receipts:splitshow")))
+
+	data := struct {
+		Split      *receipts.ReceiptSplit
+		Page       int
+		TotalPages int
+	}{
+		Split:      split,
+		Page:       page,
+		TotalPages: totalPages,
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_split_items", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt split items template", "error", err)
+		message = h.templateManager.RenderMessage("error_splitting_receipt", user.Locale)
 	}
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
-	return &keyboard
+	h.replaceMessage(callback, message, keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// handleSplitItem shows the members sharing one item of the receipt being split
+// (receipts:splititem:<item position>)
+func (h *ReceiptsCallbackHandler) handleSplitItem(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.splittingReceiptID(callback, user)
+	if !ok {
+		return
+	}
+
+	split, ok := h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
+
+	itemIndex, item := splitItemAt(split.Items, parts, 2)
+	if item == nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_split_outdated", user.Locale))
+		return
+	}
+
+	h.showItemSplit(callback, user, split, itemIndex)
+}
+
+// handleSplitItemMember adds or removes a member from the members sharing an item of the receipt
+// being split (receipts:splitim:<item position>:<member position>)
+func (h *ReceiptsCallbackHandler) handleSplitItemMember(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	receiptID, ok := h.splittingReceiptID(callback, user)
+	if !ok {
+		return
+	}
+
+	split, ok := h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
+
+	itemIndex, item := splitItemAt(split.Items, parts, 2)
+	member := splitMemberAt(split.Members, parts, 3)
+	if item == nil || member == nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_split_outdated", user.Locale))
+		return
+	}
+
+	if _, err := h.receiptsService.ToggleItemSplitMember(ctx, receiptID, item.ID, user.ID, member.UserID); err != nil {
+		h.logger.Error("Failed to update receipt item split", "error", err, "receipt_item_id", item.ID, "member_id", member.UserID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
+	}
+
+	split, ok = h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
+	h.showItemSplit(callback, user, split, itemIndex)
+}
+
+// showReceiptSplit shows who shares a receipt with toggles for every family member
+func (h *ReceiptsCallbackHandler) showReceiptSplit(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, receiptID uuid.UUID) {
+	split, ok := h.loadReceiptSplit(ctx, callback, user, receiptID)
+	if !ok {
+		return
+	}
+
+	var perPerson float64
+	if count := split.IncludedCount(); count > 0 {
+		perPerson = split.SharedAmount() / float64(count)
+	}
+
+	data := struct {
+		Split     *receipts.ReceiptSplit
+		Shared    float64
+		PerPerson float64
+		Currency  string
+	}{
+		Split:     split,
+		Shared:    split.SharedAmount(),
+		PerPerson: perPerson,
+		Currency:  receiptCurrency(split.Receipt),
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_split", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt split template", "error", err)
+		message = h.templateManager.RenderMessage("error_splitting_receipt", user.Locale)
+	}
+
+	rows := splitMemberRows(split.Members, func(i int) string {
+		return fmt.Sprintf("receipts:splitm:%d", i)
+	})
+	if len(split.Items) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("split_by_item", user.Locale),
+				"receipts:splititems:1")))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("split_done", user.Locale),
+			fmt.Sprintf("receipts:detail:%s", receiptID))))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.replaceMessage(callback, message, keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// showItemSplit shows who shares one receipt item with toggles for every family member
+func (h *ReceiptsCallbackHandler) showItemSplit(callback *tgbotapi.CallbackQuery, user *users.User, split *receipts.ReceiptSplit, itemIndex int) {
+	item := split.Items[itemIndex]
+
+	data := struct {
+		Item     *receipts.ItemSplit
+		Currency string
+	}{
+		Item:     item,
+		Currency: receiptCurrency(split.Receipt),
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_split_item", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt item split template", "error", err)
+		message = h.templateManager.RenderMessage("error_splitting_receipt", user.Locale)
+	}
+
+	rows := splitMemberRows(item.Members, func(i int) string {
+		return fmt.Sprintf("receipts:splitim:%d:%d", itemIndex, i)
+	})
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back", user.Locale),
+			fmt.Sprintf("receipts:splititems:%d", itemIndex/splitItemsPerPage+1))))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.replaceMessage(callback, message, keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// splittingReceiptID returns the receipt being split by the user, answering the callback when
+// there is none
+func (h *ReceiptsCallbackHandler) splittingReceiptID(callback *tgbotapi.CallbackQuery, user *users.User) (uuid.UUID, bool) {
+	var receiptIDStr string
+	if h.stateManager != nil {
+		receiptIDStr, _ = h.stateManager.GetUserState(user.TelegramID, "splitting_receipt")
+	}
+
+	receiptID, err := uuid.Parse(receiptIDStr)
+	if err != nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_split_outdated", user.Locale))
+		return uuid.Nil, false
+	}
+
+	return receiptID, true
+}
+
+// loadReceiptSplit loads the split of a receipt, answering the callback when it fails
+func (h *ReceiptsCallbackHandler) loadReceiptSplit(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, receiptID uuid.UUID) (*receipts.ReceiptSplit, bool) {
+	split, err := h.receiptsService.GetReceiptSplit(ctx, receiptID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get receipt split", "error", err, "receipt_id", receiptID, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return nil, false
+	}
+
+	return split, true
+}
+
+// handleBalances shows the balances of a family of the user (receipts:balances[:familyID]), asking
+// which family first when the user is in several
+func (h *ReceiptsCallbackHandler) handleBalances(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	h.logger.Info("Handling family balances action", "user_id", user.TelegramID)
+
+	if len(parts) > 2 {
+		familyID, err := uuid.Parse(parts[2])
+		if err != nil {
+			h.logger.Error("Invalid family ID format", "error", err, "family_id", parts[2])
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_family_id", user.Locale))
+			return
+		}
+		if h.showFamilyBalances(ctx, callback, user, familyID) {
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_balances", user.Locale))
+		}
+		return
+	}
+
+	userFamilies, err := h.familiesService.GetUserFamilies(ctx, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get user families", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_retrieve_families", user.Locale))
+		return
+	}
+	if len(userFamilies) == 0 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_no_families_to_share", user.Locale))
+		return
+	}
+	if len(userFamilies) == 1 {
+		if h.showFamilyBalances(ctx, callback, user, userFamilies[0].ID) {
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_balances", user.Locale))
+		}
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, family := range userFamilies {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("👥 %s", family.Name),
+				fmt.Sprintf("receipts:balances:%s", family.ID))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back", user.Locale),
+			"receipts:menu")))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID,
+		h.templateManager.RenderMessage("choose_family_for_balances", user.Locale), &keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// handleSettle records a suggested settle up transfer of the family whose balances are shown
+// (receipts:settle:<transfer position>) and tells the other member
+func (h *ReceiptsCallbackHandler) handleSettle(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	familyID, ok := h.settlingFamilyID(callback, user)
+	if !ok {
+		return
+	}
+
+	balances, err := h.receiptsService.GetFamilyBalances(ctx, familyID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get family balances", "error", err, "family_id", familyID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
+	}
+
+	// The transfers are computed again, a changed balance makes the position point elsewhere
+	var transfer *receipts.SettlementTransfer
+	if len(parts) > 2 {
+		if index, err := strconv.Atoi(parts[2]); err == nil && index >= 0 && index < len(balances.Transfers) {
+			transfer = balances.Transfers[index]
+		}
+	}
+	if transfer == nil || (transfer.FromUserID != user.ID && transfer.ToUserID != user.ID) {
+		if h.showFamilyBalances(ctx, callback, user, familyID) {
+			h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_settlement_outdated", user.Locale))
+		}
+		return
+	}
+
+	settlement, err := h.receiptsService.RecordSettlement(ctx, receipts.RecordSettlementRequest{
+		FamilyID:   familyID,
+		FromUserID: transfer.FromUserID,
+		ToUserID:   transfer.ToUserID,
+		Amount:     transfer.Amount,
+		Currency:   transfer.Currency,
+		CreatedBy:  user.ID,
+	})
+	if err != nil {
+		h.logger.Error("Failed to record settlement", "error", err, "family_id", familyID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_recording_settlement", user.Locale))
+		return
+	}
+
+	h.logger.Info("Settlement recorded",
+		"family_id", familyID,
+		"from_user_id", settlement.FromUserID,
+		"to_user_id", settlement.ToUserID,
+		"amount", settlement.Amount,
+		"currency", settlement.Currency)
+
+	otherID := settlement.ToUserID
+	if otherID == user.ID {
+		otherID = settlement.FromUserID
+	}
+	if other, err := h.usersService.GetUserByID(ctx, otherID); err != nil {
+		h.logger.Error("Failed to get settlement member", "error", err, "user_id", otherID)
+	} else if message, err := h.templateManager.RenderTemplate("settlement_recorded", other.Locale, settlement); err != nil {
+		h.logger.Error("Failed to render settlement recorded template", "error", err)
+	} else {
+		h.SendMessage(other.TelegramID, message)
+	}
+
+	if h.showFamilyBalances(ctx, callback, user, familyID) {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_settlement_recorded", user.Locale))
+	}
+}
+
+// handleSettlements shows the settle up history of the family whose balances are shown
+// (receipts:settlements)
+func (h *ReceiptsCallbackHandler) handleSettlements(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
+	familyID, ok := h.settlingFamilyID(callback, user)
+	if !ok {
+		return
+	}
+
+	settlements, err := h.receiptsService.GetSettlements(ctx, familyID, user.ID, 20)
+	if err != nil {
+		h.logger.Error("Failed to get settlements", "error", err, "family_id", familyID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return
+	}
+
+	message, err := h.templateManager.RenderTemplate("settlement_history", user.Locale, settlements)
+	if err != nil {
+		h.logger.Error("Failed to render settlement history template", "error", err)
+		message = h.templateManager.RenderMessage("error_loading_balances", user.Locale)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				fmt.Sprintf("receipts:balances:%s", familyID))))
+
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, &keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// showFamilyBalances shows the balances of a family with buttons recording the settle up transfers
+// the user takes part in. The family is kept in the user's state for these buttons. The callback
+// is only answered when the balances can't be loaded.
+func (h *ReceiptsCallbackHandler) showFamilyBalances(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, familyID uuid.UUID) bool {
+	balances, err := h.receiptsService.GetFamilyBalances(ctx, familyID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to get family balances", "error", err, "family_id", familyID, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.receiptErrorMessage(err, user.Locale))
+		return false
+	}
+
+	family, err := h.familiesService.GetFamilyByID(ctx, familyID)
+	if err != nil {
+		h.logger.Error("Failed to get family", "error", err, "family_id", familyID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_balances", user.Locale))
+		return false
+	}
+
+	if h.stateManager != nil {
+		h.stateManager.SetUserState(user.TelegramID, "settling_family", familyID.String())
+	}
+
+	data := struct {
+		*receipts.FamilyBalances
+		FamilyName string
+		UserID     uuid.UUID
+	}{
+		FamilyBalances: balances,
+		FamilyName:     family.Name,
+		UserID:         user.ID,
+	}
+
+	message, err := h.templateManager.RenderTemplate("family_balances", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render family balances template", "error", err)
+		message = h.templateManager.RenderMessage("error_loading_balances", user.Locale)
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, transfer := range balances.Transfers {
+		if transfer.FromUserID != user.ID && transfer.ToUserID != user.ID {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("✅ %s → %s: %.2f %s", transfer.FromName, transfer.ToName, transfer.Amount, transfer.Currency),
+				fmt.Sprintf("receipts:settle:%d", i))))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("settlement_history", user.Locale),
+				"receipts:settlements")),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				"receipts:menu")))
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, &keyboard)
+	return true
+}
+
+// settlingFamilyID returns the family whose balances the user looks at, answering the callback
+// when there is none
+func (h *ReceiptsCallbackHandler) settlingFamilyID(callback *tgbotapi.CallbackQuery, user *users.User) (uuid.UUID, bool) {
+	var familyIDStr string
+	if h.stateManager != nil {
+		familyIDStr, _ = h.stateManager.GetUserState(user.TelegramID, "settling_family")
+	}
+
+	familyID, err := uuid.Parse(familyIDStr)
+	if err != nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_settlement_outdated", user.Locale))
+		return uuid.Nil, false
+	}
+
+	return familyID, true
+}
+
+// replaceMessage shows a screen in place of the callback message. Receipt photos can't become
+// text messages, they are replaced by a new message.
+func (h *ReceiptsCallbackHandler) replaceMessage(callback *tgbotapi.CallbackQuery, text string, keyboard tgbotapi.InlineKeyboardMarkup) {
+	if callback.Message.Photo != nil {
+		h.DeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID)
+		h.SendMessageWithKeyboard(callback.Message.Chat.ID, text, keyboard)
+		return
+	}
+
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, text, &keyboard)
+}
+
+// splitMemberRows creates toggle buttons for the members, two per row
+func splitMemberRows(members []*receipts.SplitMember, callbackData func(i int) string) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, member := range members {
+		mark := "⬜"
+		if member.Included {
+			mark = "✅"
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%s %s", mark, member.Name), callbackData(i)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// splitMemberAt returns the member at the position given in parts[index], nil when invalid
+func splitMemberAt(members []*receipts.SplitMember, parts []string, index int) *receipts.SplitMember {
+	if len(parts) <= index {
+		return nil
+	}
+	position, err := strconv.Atoi(parts[index])
+	if err != nil || position < 0 || position >= len(members) {
+		return nil
+	}
+	return members[position]
+}
+
+// splitItemAt returns the item at the position given in parts[index], nil when invalid
+func splitItemAt(items []*receipts.ItemSplit, parts []string, index int) (int, *receipts.ItemSplit) {
+	if len(parts) <= index {
+		return 0, nil
+	}
+	position, err := strconv.Atoi(parts[index])
+	if err != nil || position < 0 || position >= len(items) {
+		return 0, nil
+	}
+	return position, items[position]
+}
+
+// receiptCurrency returns the currency of a receipt, receipts.UnknownCurrency when none was extracted
+func receiptCurrency(receipt *receipts.Receipt) string {
+	return receipts.ReceiptCurrency(receipt.CurrencyCode)
+}
+
+// handleExportReceipts shows the export period and format choice (receipts:export[:period]) and
+// sends the export as a document once a format is chosen (receipts:export:<period>:<format>)
+func (h *ReceiptsCallbackHandler) handleExportReceipts(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	h.logger.Info("Handling export receipts action", "user_id", user.TelegramID)
+
+	period := receipts.PeriodMonth
+	if len(parts) > 2 {
+		period = parts[2]
+	}
+
+	from, to, _, err := receipts.PeriodRange(period, time.Now())
+	if err != nil {
+		h.logger.Warn("Invalid export period", "period", period, "user_id", user.TelegramID)
+		h.answerCallback(callback.ID, "❌ Unknown action.")
+		return
+	}
+
+	if len(parts) > 3 {
+		h.sendReceiptsExport(ctx, callback, user, from, to, parts[3])
+		return
+	}
+
+	data := struct {
+		Period  string
+		From    time.Time
+		LastDay time.Time
+	}{
+		Period:  period,
+		From:    from,
+		LastDay: to.AddDate(0, 0, -1),
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipts_export", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipts export template", "error", err)
+		message = h.templateManager.RenderMessage("error_exporting_receipts", user.Locale)
+	}
+
+	// Period switcher, format buttons and back button
+	periodButtons := make([]tgbotapi.InlineKeyboardButton, 0, 4)
+	for _, p := range []string{receipts.PeriodWeek, receipts.PeriodMonth, receipts.PeriodQuarter, receipts.PeriodYear} {
+		text := h.templateManager.RenderButton("stats_period_"+p, user.Locale)
+		if p == period {
+			text = "• " + text + " •"
+		}
+		periodButtons = append(periodButtons, tgbotapi.NewInlineKeyboardButtonData(text, "receipts:export:"+p))
+	}
+
+	formatButtons := make([]tgbotapi.InlineKeyboardButton, 0, len(receipts.ExportFormats))
+	for _, format := range receipts.ExportFormats {
+		formatButtons = append(formatButtons, tgbotapi.NewInlineKeyboardButtonData(
+			"📥 "+strings.ToUpper(format),
+			fmt.Sprintf("receipts:export:%s:%s", period, format)))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		periodButtons,
+		formatButtons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				"receipts:menu",
+			),
+		),
+	)
+
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, &keyboard)
+	h.answerCallback(callback.ID, "")
+}
+
+// sendReceiptsExport sends the receipts of the range exported in the format as a document
+func (h *ReceiptsCallbackHandler) sendReceiptsExport(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, from, to time.Time, format string) {
+	if !receipts.IsValidExportFormat(format) {
+		h.answerCallback(callback.ID, "❌ Unknown action.")
+		return
+	}
+
+	export, err := h.receiptsService.ExportReceipts(ctx, receipts.ExportFilter{
+		UserID: user.ID,
+		From:   from,
+		To:     to,
+	})
+	if err != nil {
+		h.logger.Error("Failed to export receipts", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_exporting_receipts", user.Locale))
+		return
+	}
+	if len(export.Receipts) == 0 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_export_empty", user.Locale))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format); err != nil {
+		h.logger.Error("Failed to write receipts export", "error", err, "format", format, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_exporting_receipts", user.Locale))
+		return
+	}
+
+	document := tgbotapi.NewDocument(callback.Message.Chat.ID, tgbotapi.FileBytes{
+		Name:  export.FileName(format),
+		Bytes: buf.Bytes(),
+	})
+	if _, err := h.bot.Send(document); err != nil {
+		h.logger.Error("Failed to send receipts export", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_exporting_receipts", user.Locale))
+		return
+	}
+
+	h.logger.Info("Receipts exported",
+		"user_id", user.ID,
+		"format", format,
+		"receipts", len(export.Receipts))
+
+	h.answerCallback(callback.ID, "📥 "+strings.ToUpper(format))
+}
+
+// handleTaxSummary shows the yearly tax summary (receipts:taxes[:granularity[:year]])
+func (h *ReceiptsCallbackHandler) handleTaxSummary(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	h.logger.Info("Handling tax summary action", "user_id", user.TelegramID)
+
+	summary, ok := h.loadTaxSummary(ctx, callback, user, parts)
+	if !ok {
+		return
+	}
+
+	year := summary.From.Year()
+	data := struct {
+		*receipts.TaxSummary
+		Year int
+	}{
+		TaxSummary: summary,
+		Year:       year,
+	}
+
+	message, err := h.templateManager.RenderTemplate("tax_summary", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render tax summary template", "error", err)
+		message = h.templateManager.RenderMessage("error_loading_tax_summary", user.Locale)
+	}
+
+	// Grouping switcher
+	granularityButtons := make([]tgbotapi.InlineKeyboardButton, 0, 3)
+	for _, g := range []string{receipts.GranularityMonth, receipts.GranularityQuarter, receipts.GranularityYear} {
+		text := h.templateManager.RenderButton("stats_period_"+g, user.Locale)
+		if g == summary.Granularity {
+			text = "• " + text + " •"
+		}
+		granularityButtons = append(granularityButtons,
+			tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("receipts:taxes:%s:%d", g, year)))
+	}
+
+	// Year navigation, future years have no receipts
+	yearButtons := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("◀️ %d", year-1),
+			fmt.Sprintf("receipts:taxes:%s:%d", summary.Granularity, year-1)),
+	}
+	if year < time.Now().Year() {
+		yearButtons = append(yearButtons, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d ▶️", year+1),
+			fmt.Sprintf("receipts:taxes:%s:%d", summary.Granularity, year+1)))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{granularityButtons, yearButtons}
+	if len(summary.Rows) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("tax_export", user.Locale),
+				fmt.Sprintf("receipts:taxexport:%s:%d", summary.Granularity, year),
+			),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(
+			h.templateManager.RenderButton("back", user.Locale),
+			"receipts:menu",
+		),
+	))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	// Update the message
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	edit.ReplyMarkup = &keyboard
+	edit.ParseMode = "HTML"
+
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("Failed to update tax summary message", "error", err)
+	}
+
+	h.answerCallback(callback.ID, "💰 Tax summary")
+}
+
+// handleTaxSummaryExport sends the tax summary as a CSV document (receipts:taxexport:granularity:year)
+func (h *ReceiptsCallbackHandler) handleTaxSummaryExport(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	h.logger.Info("Handling tax summary export action", "user_id", user.TelegramID)
+
+	summary, ok := h.loadTaxSummary(ctx, callback, user, parts)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := summary.WriteCSV(&buf); err != nil {
+		h.logger.Error("Failed to export tax summary", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_tax_summary", user.Locale))
+		return
+	}
+
+	document := tgbotapi.NewDocument(callback.Message.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("tax-summary-%d-%s.csv", summary.From.Year(), summary.Granularity),
+		Bytes: buf.Bytes(),
+	})
+	if _, err := h.bot.Send(document); err != nil {
+		h.logger.Error("Failed to send tax summary export", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_tax_summary", user.Locale))
+		return
+	}
+
+	h.answerCallback(callback.ID, "📥 CSV")
+}
+
+// loadTaxSummary parses the optional granularity and year callback parts and loads the summary,
+// answering the callback itself on failure
+func (h *ReceiptsCallbackHandler) loadTaxSummary(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) (*receipts.TaxSummary, bool) {
+	granularity := receipts.GranularityQuarter
+	if len(parts) >= 3 {
+		granularity = parts[2]
+	}
+
+	year := time.Now().Year()
+	if len(parts) >= 4 {
+		parsedYear, err := strconv.Atoi(parts[3])
+		if err != nil {
+			h.logger.Warn("Invalid tax summary year", "year", parts[3], "user_id", user.TelegramID)
+			h.answerCallback(callback.ID, "❌ Unknown action.")
+			return nil, false
+		}
+		year = parsedYear
+	}
+
+	from, to := receipts.YearRange(year, time.Local)
+	summary, err := h.receiptsService.GetTaxSummary(ctx, receipts.TaxSummaryFilter{
+		UserID:      user.ID,
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get tax summary", "error", err, "user_id", user.ID, "year", year)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_tax_summary", user.Locale))
+		return nil, false
+	}
+
+	return summary, true
+}
+
+// handleReceiptStats shows spending analytics for the selected period (this month by default)
+func (h *ReceiptsCallbackHandler) handleReceiptStats(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	h.logger.Info("Handling receipt stats action", "user_id", user.TelegramID)
+
+	period := receipts.PeriodMonth
+	if len(parts) >= 3 {
+		period = parts[2]
+	}
+
+	from, to, granularity, err := receipts.PeriodRange(period, time.Now())
+	if err != nil {
+		h.logger.Warn("Invalid receipt stats period", "period", period, "user_id", user.TelegramID)
+		h.answerCallback(callback.ID, "❌ Unknown action.")
+		return
+	}
+
+	analytics, err := h.receiptsService.GetSpendingAnalytics(ctx, receipts.SpendingFilter{
+		UserID:      user.ID,
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+	})
+	if err != nil {
+		h.logger.Error("Failed to get spending analytics", "error", err, "user_id", user.ID, "period", period)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_receipt_stats", user.Locale))
+		return
+	}
+
+	data := struct {
+		*receipts.SpendingAnalytics
+		Period  string
+		LastDay time.Time
+	}{
+		SpendingAnalytics: analytics,
+		Period:            period,
+		LastDay:           to.AddDate(0, 0, -1),
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_stats", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt stats template", "error", err)
+		message = h.templateManager.RenderMessage("error_loading_receipt_stats", user.Locale)
+	}
+
+	// Period switcher and back button
+	periodButtons := make([]tgbotapi.InlineKeyboardButton, 0, 3)
+	for _, p := range []string{receipts.PeriodWeek, receipts.PeriodMonth, receipts.PeriodYear} {
+		text := h.templateManager.RenderButton("stats_period_"+p, user.Locale)
+		if p == period {
+			text = "• " + text + " •"
+		}
+		periodButtons = append(periodButtons, tgbotapi.NewInlineKeyboardButtonData(text, "receipts:stats:"+p))
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		periodButtons,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				"receipts:menu",
+			),
+		),
+	)
+
+	// Update the message
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	edit.ReplyMarkup = &keyboard
+	edit.ParseMode = "HTML"
+
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("Failed to update receipt stats message", "error", err)
+	}
+
+	h.answerCallback(callback.ID, "📊 Statistics")
+}
+
+// handleBudgetStatus shows the spending against the budgets of the user's families
+func (h *ReceiptsCallbackHandler) handleBudgetStatus(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
+	h.logger.Info("Handling budget status action", "user_id", user.TelegramID)
+
+	statuses, err := h.receiptsService.GetBudgetStatuses(ctx, user.ID, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to get budget statuses", "error", err, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_loading_budgets", user.Locale))
+		return
+	}
+
+	data := struct {
+		Statuses []*receipts.BudgetStatus
+		Locale   string
+	}{
+		Statuses: statuses,
+		Locale:   user.Locale,
+	}
+
+	message, err := h.templateManager.RenderTemplate("budget_status", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render budget status template", "error", err)
+		message = h.templateManager.RenderMessage("error_loading_budgets", user.Locale)
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("back", user.Locale),
+				"receipts:menu",
+			),
+		),
+	)
+
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, &keyboard)
+	h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_budgets", user.Locale))
+}
+
+// notifyBudgetAlerts tells all members of a family when a receipt of the user made the family
+// spending cross a budget threshold
+func (h *ReceiptsCallbackHandler) notifyBudgetAlerts(ctx context.Context, user *users.User) {
+	alerts, err := h.receiptsService.CheckBudgetAlerts(ctx, user.ID, time.Now())
+	if err != nil {
+		h.logger.Error("Failed to check family budgets", "error", err, "user_id", user.ID)
+		return
+	}
+
+	for _, alert := range alerts {
+		family, err := h.familiesService.GetFamilyWithMembers(ctx, alert.FamilyID)
+		if err != nil {
+			h.logger.Error("Failed to get family members for budget alert", "error", err, "family_id", alert.FamilyID)
+			continue
+		}
+
+		for _, member := range family.Members {
+			memberUser, err := h.usersService.GetUserByID(ctx, member.UserID)
+			if err != nil {
+				h.logger.Error("Failed to get family member for budget alert", "error", err, "user_id", member.UserID)
+				continue
+			}
+
+			data := struct {
+				*receipts.BudgetAlert
+				Locale string
+			}{
+				BudgetAlert: alert,
+				Locale:      memberUser.Locale,
+			}
+
+			message, err := h.templateManager.RenderTemplate("budget_alert", memberUser.Locale, data)
+			if err != nil {
+				h.logger.Error("Failed to render budget alert template", "error", err)
+				continue
+			}
+
+			h.SendMessage(memberUser.TelegramID, message)
+		}
+
+		h.logger.Info("Family budget alert sent",
+			"family_id", alert.FamilyID,
+			"budget_id", alert.ID,
+			"threshold", alert.Threshold,
+			"members", len(family.Members))
+	}
+}
+
+// handleBackToMenu handles returning to the receipts menu
+func (h *ReceiptsCallbackHandler) HandleBackToReceiptsMenu(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User) {
+	h.logger.Info("Handling back to receipts menu", "user_id", user.TelegramID)
+
+	// Clear any receipts-related states
+	if h.stateManager != nil {
+		h.stateManager.ClearUserState(user.TelegramID, "awaiting_receipt_upload")
+		h.stateManager.ClearUserState(user.TelegramID, "viewing_receipts")
+	}
+
+	// Render receipts menu
+	data := struct {
+		IsAuthorized bool
+		UserName     string
+	}{
+		IsAuthorized: user.IsAuthorized,
+		UserName:     user.FirstName,
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipts_menu", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipts menu template", "error", err)
+		message = "🧾 Receipt Management\n\nChoose an option below:"
+	}
+
+	// Create receipts menu keyboard
+	keyboard := h.createReceiptsMenuKeyboard(user.Locale)
+
+	// Update the message
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, message)
+	edit.ReplyMarkup = keyboard
+	edit.ParseMode = "HTML"
+
+	if _, err := h.bot.Send(edit); err != nil {
+		h.logger.Error("Failed to update receipts menu message", "error", err)
+	}
+
+	h.answerCallback(callback.ID, "🧾 Receipts menu")
+}
+
+// createReceiptsMenuKeyboard creates the receipts menu inline keyboard
+func (h *ReceiptsCallbackHandler) createReceiptsMenuKeyboard(locale string) *tgbotapi.InlineKeyboardMarkup {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		// First row: Upload Receipt
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("upload_receipt", locale),
+				"receipts:upload",
+			),
+		},
+		// Second row: View and Export Receipts
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("view_receipts", locale),
+				"receipts:view",
+			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("export_receipts", locale),
+				"receipts:export",
+			),
+		},
+		// Third row: Tax Summary and Statistics
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("tax_summary", locale),
+				"receipts:taxes",
+			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("receipt_stats", locale),
+				"receipts:stats",
+			),
+		},
+		// Fourth row: Family Budgets and Balances
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("budgets", locale),
+				"receipts:budget",
+			),
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("balances", locale),
+				"receipts:balances",
+			),
+		},
+		// Fifth row: Main Menu
+		{
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("main_menu", locale),
+				"menu_start",
+			),
+		},
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// reconcileProposal is the list matches proposed for a receipt, kept in the reconciling_receipt
+// state so that exactly the pairs the user saw are applied on confirmation
+type reconcileProposal struct {
+	ReceiptID uuid.UUID                 `json:"receipt_id"`
+	Matches   []*receipts.ListItemMatch `json:"matches"`
+}
+
+// proposeListMatches offers to mark the open shopping list items found on a processed receipt as bought
+func (h *ReceiptsCallbackHandler) proposeListMatches(ctx context.Context, chatID int64, user *users.User, receiptID uuid.UUID) {
+	matches, err := h.receiptsService.MatchReceiptToLists(ctx, receiptID, user.ID)
+	if err != nil {
+		h.logger.Error("Failed to match receipt with shopping lists", "error", err, "receipt_id", receiptID, "user_id", user.ID)
+		return
+	}
+	if len(matches) == 0 {
+		return
+	}
+
+	proposal, err := json.Marshal(reconcileProposal{ReceiptID: receiptID, Matches: matches})
+	if err != nil {
+		h.logger.Error("Failed to encode receipt list matches", "error", err, "receipt_id", receiptID)
+		return
+	}
+	h.stateManager.SetUserState(user.TelegramID, "reconciling_receipt", string(proposal))
+
+	data := struct {
+		Count   int
+		Matches []*receipts.ListItemMatch
+	}{
+		Count:   len(matches),
+		Matches: matches,
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_list_matches", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt list matches template", "error", err)
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("reconcile_confirm", user.Locale),
+				fmt.Sprintf("receipts:reconcile:%s", receiptID.String()),
+			),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				h.templateManager.RenderButton("reconcile_skip", user.Locale),
+				"receipts:reconcileskip",
+			),
+		),
+	)
+
+	h.SendMessageWithKeyboard(chatID, message, keyboard)
+}
+
+// handleReconcileConfirm marks the matched list items as bought (receipts:reconcile:receiptID)
+func (h *ReceiptsCallbackHandler) handleReconcileConfirm(ctx context.Context, callback *tgbotapi.CallbackQuery, user *users.User, parts []string) {
+	if len(parts) < 3 {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_invalid_receipt_id", user.Locale))
+		return
+	}
+
+	receiptID, err := uuid.Parse(parts[2])
+	if err != nil {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_invalid_receipt_id", user.Locale))
+		return
+	}
+
+	// Only the latest proposal is kept, an older one can't be confirmed any more
+	var proposal reconcileProposal
+	stateData, hasState := h.stateManager.GetUserState(user.TelegramID, "reconciling_receipt")
+	if !hasState || json.Unmarshal([]byte(stateData), &proposal) != nil || proposal.ReceiptID != receiptID {
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_reconcile_outdated", user.Locale))
+		return
+	}
+
+	applied, err := h.receiptsService.ConfirmListMatches(ctx, receiptID, user.ID, proposal.Matches)
+	if err != nil {
+		h.logger.Error("Failed to mark list items as bought", "error", err, "receipt_id", receiptID, "user_id", user.ID)
+		h.answerCallback(callback.ID, h.templateManager.RenderMessage("error_reconciling_receipt", user.Locale))
+		return
+	}
+
+	data := struct {
+		Count   int
+		Matches []*receipts.ListItemMatch
+	}{
+		Count:   len(applied),
+		Matches: applied,
+	}
+
+	message, err := h.templateManager.RenderTemplate("receipt_list_matches_confirmed", user.Locale, data)
+	if err != nil {
+		h.logger.Error("Failed to render receipt list matches confirmed template", "error", err)
+		message = fmt.Sprintf("✅ %d", len(applied))
+	}
+
+	h.stateManager.ClearUserState(user.TelegramID, "reconciling_receipt")
+	h.editMessage(callback.Message.Chat.ID, callback.Message.MessageID, message, nil)
+	h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_list_items_bought", user.Locale))
+}
+
+// handleReconcileSkip leaves the shopping lists as they are and removes the proposal
+func (h *ReceiptsCallbackHandler) handleReconcileSkip(callback *tgbotapi.CallbackQuery, user *users.User) {
+	h.stateManager.ClearUserState(user.TelegramID, "reconciling_receipt")
+	h.DeleteMessage(callback.Message.Chat.ID, callback.Message.MessageID)
+	h.answerCallback(callback.ID, h.templateManager.RenderMessage("callback_reconcile_skipped", user.Locale))
 }
 
 // answerCallback answers the callback query
@@ -734,6 +2116,8 @@ func (h *ReceiptsCallbackHandler) HandleReceiptDocument(ctx context.Context, cha
 
 				h.editMessageWithReceiptsMenu(chatID, messageID, successMsg, user.Locale)
 			}
+			h.proposeListMatches(bgCtx, chatID, user, receipt.ID)
+			h.notifyBudgetAlerts(bgCtx, user)
 			return
 		}
 
@@ -770,6 +2154,8 @@ func (h *ReceiptsCallbackHandler) HandleReceiptDocument(ctx context.Context, cha
 			"user_id", user.ID,
 			"receipt_id", receipt.ID)
 
+		h.proposeListMatches(bgCtx, chatID, user, receipt.ID)
+		h.notifyBudgetAlerts(bgCtx, user)
 	}()
 
 	// Clear the upload state
@@ -927,6 +2313,8 @@ func (h *ReceiptsCallbackHandler) HandleReceiptPhoto(ctx context.Context, chatID
 
 				h.editMessageWithReceiptsMenu(chatID, messageID, successMsg, user.Locale)
 			}
+			h.proposeListMatches(bgCtx, chatID, user, receipt.ID)
+			h.notifyBudgetAlerts(bgCtx, user)
 			return
 		}
 
@@ -962,6 +2350,8 @@ func (h *ReceiptsCallbackHandler) HandleReceiptPhoto(ctx context.Context, chatID
 			"user_id", user.ID,
 			"receipt_id", receipt.ID)
 
+		h.proposeListMatches(bgCtx, chatID, user, receipt.ID)
+		h.notifyBudgetAlerts(bgCtx, user)
 	}()
 
 	// Clear the upload state
@@ -1,82 +1,162 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
-	"sync"
+	"strings"
+	"time"
 )
 
+const stateStoreTimeout = 2 * time.Second
+
+// DefaultStateTTLs define how long each workflow state lives before it expires,
+// so a forgotten "waiting for input" state doesn't hijack the next message
+var DefaultStateTTLs = map[string]time.Duration{
+	"adding_item_to_list":         10 * time.Minute,
+	"add_item_message_id":         10 * time.Minute,
+	"creating_list_for_family":    10 * time.Minute,
+	"create_list_message_id":      10 * time.Minute,
+	"creating_custom_productlist": 10 * time.Minute,
+	"awaiting_receipt_upload":     15 * time.Minute,
+	"upload_message_id":           15 * time.Minute,
+	"product_list_selection":      30 * time.Minute,
+	"duplicate_resolution":        30 * time.Minute,
+	"replace_message_id":          30 * time.Minute,
+	"viewing_list":                30 * time.Minute,
+	"viewing_receipts":            time.Hour,
+	"sharing_receipt":             30 * time.Minute,
+	"splitting_receipt":           30 * time.Minute,
+	"settling_family":             30 * time.Minute,
+	"reconciling_receipt":         24 * time.Hour,
+	"latest_bot_message_id":       24 * time.Hour,
+}
+
 // StateManager manages temporary user states for workflows
 type StateManager struct {
-	states      map[string]string
-	statesMutex sync.RWMutex
-	logger      *slog.Logger
+	store      StateStore
+	ttls       map[string]time.Duration
+	defaultTTL time.Duration
+	logger     *slog.Logger
 }
 
-// NewStateManager creates a new state manager
+// NewStateManager creates a new state manager backed by an in-memory store
 func NewStateManager(logger *slog.Logger) *StateManager {
+	return NewStateManagerWithStore(NewMemoryStateStore(time.Minute), DefaultStateTTLs, 30*time.Minute, logger)
+}
+
+// NewStateManagerWithStore creates a new state manager with the given store and per-state TTLs,
+// states missing from ttls expire after defaultTTL
+func NewStateManagerWithStore(store StateStore, ttls map[string]time.Duration, defaultTTL time.Duration, logger *slog.Logger) *StateManager {
 	return &StateManager{
-		states: make(map[string]string),
-		logger: logger,
+		store:      store,
+		ttls:       ttls,
+		defaultTTL: defaultTTL,
+		logger:     logger,
 	}
 }
 
 // SetUserState stores temporary user state
 func (sm *StateManager) SetUserState(telegramID int64, state, value string) {
-	sm.statesMutex.Lock()
-	defer sm.statesMutex.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
 
-	key := fmt.Sprintf("%d:%s", telegramID, state)
-	sm.states[key] = value
+	ttl := sm.ttlFor(state)
+	if err := sm.store.Set(ctx, stateKey(telegramID, state), value, ttl); err != nil {
+		sm.logger.Error("Failed to set user state", "telegram_id", telegramID, "state", state, "error", err)
+		return
+	}
 
-	sm.logger.Info("Setting user state", "telegram_id", telegramID, "state", state, "value", value)
+	sm.logger.Info("Setting user state", "telegram_id", telegramID, "state", state, "value", value, "ttl", ttl)
 }
 
 // GetUserState retrieves temporary user state
 func (sm *StateManager) GetUserState(telegramID int64, state string) (string, bool) {
-	sm.statesMutex.RLock()
-	defer sm.statesMutex.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
 
-	key := fmt.Sprintf("%d:%s", telegramID, state)
-	value, exists := sm.states[key]
+	value, exists, err := sm.store.Get(ctx, stateKey(telegramID, state))
+	if err != nil {
+		sm.logger.Error("Failed to get user state", "telegram_id", telegramID, "state", state, "error", err)
+		return "", false
+	}
 	return value, exists
 }
 
 // ClearUserState removes temporary user state
 func (sm *StateManager) ClearUserState(telegramID int64, state string) {
-	sm.statesMutex.Lock()
-	defer sm.statesMutex.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
 
-	key := fmt.Sprintf("%d:%s", telegramID, state)
-	delete(sm.states, key)
+	if err := sm.store.Delete(ctx, stateKey(telegramID, state)); err != nil {
+		sm.logger.Error("Failed to clear user state", "telegram_id", telegramID, "state", state, "error", err)
+		return
+	}
 
 	sm.logger.Info("Clearing user state", "telegram_id", telegramID, "state", state)
 }
 
 // ClearAllUserStates removes all states for a specific user
 func (sm *StateManager) ClearAllUserStates(telegramID int64) {
-	sm.statesMutex.Lock()
-	defer sm.statesMutex.Unlock()
-
-	prefix := fmt.Sprintf("%d:", telegramID)
-	for key := range sm.states {
-		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
-			delete(sm.states, key)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
+
+	if err := sm.store.DeleteByPrefix(ctx, userStatePrefix(telegramID)); err != nil {
+		sm.logger.Error("Failed to clear all user states", "telegram_id", telegramID, "error", err)
+		return
 	}
 
 	sm.logger.Info("Clearing all user states", "telegram_id", telegramID)
 }
 
+// GetUserStates returns all active states of a user keyed by state name
+func (sm *StateManager) GetUserStates(telegramID int64) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
+
+	prefix := userStatePrefix(telegramID)
+	states, err := sm.store.GetByPrefix(ctx, prefix)
+	if err != nil {
+		sm.logger.Error("Failed to get user states", "telegram_id", telegramID, "error", err)
+		return map[string]string{}
+	}
+
+	userStates := make(map[string]string, len(states))
+	for key, value := range states {
+		userStates[strings.TrimPrefix(key, prefix)] = value
+	}
+	return userStates
+}
+
 // GetAllStates returns all current states (for debugging)
 func (sm *StateManager) GetAllStates() map[string]string {
-	sm.statesMutex.RLock()
-	defer sm.statesMutex.RUnlock()
+	ctx, cancel := context.WithTimeout(context.Background(), stateStoreTimeout)
+	defer cancel()
 
-	// Return a copy to avoid race conditions
-	states := make(map[string]string)
-	for k, v := range sm.states {
-		states[k] = v
+	states, err := sm.store.GetByPrefix(ctx, "")
+	if err != nil {
+		sm.logger.Error("Failed to get all states", "error", err)
+		return map[string]string{}
 	}
 	return states
 }
+
+// Close releases the underlying state store
+func (sm *StateManager) Close() error {
+	return sm.store.Close()
+}
+
+func (sm *StateManager) ttlFor(state string) time.Duration {
+	if ttl, ok := sm.ttls[state]; ok {
+		return ttl
+	}
+	return sm.defaultTTL
+}
+
+func stateKey(telegramID int64, state string) string {
+	return fmt.Sprintf("%d:%s", telegramID, state)
+}
+
+func userStatePrefix(telegramID int64) string {
+	return fmt.Sprintf("%d:", telegramID)
+}
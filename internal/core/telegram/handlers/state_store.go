@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// StateStore is the storage backend for conversation states.
+// Keys are opaque strings, values expire after the given TTL.
+type StateStore interface {
+	// Set stores the value with the given TTL (0 means no expiry)
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+
+	// Get returns the value and whether it exists and has not expired
+	Get(ctx context.Context, key string) (string, bool, error)
+
+	// Delete removes the given keys
+	Delete(ctx context.Context, keys ...string) error
+
+	// GetByPrefix returns all non-expired values whose key starts with prefix
+	GetByPrefix(ctx context.Context, prefix string) (map[string]string, error)
+
+	// DeleteByPrefix removes all keys starting with prefix
+	DeleteByPrefix(ctx context.Context, prefix string) error
+
+	// Close releases resources held by the store
+	Close() error
+}
+
+type memoryStateEntry struct {
+	value     string
+	expiresAt time.Time // zero means no expiry
+}
+
+func (e memoryStateEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// MemoryStateStore keeps states in a process-local map, states are lost on restart
+type MemoryStateStore struct {
+	states map[string]memoryStateEntry
+	mutex  sync.RWMutex
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryStateStore creates an in-memory state store which removes expired states every sweepInterval
+func NewMemoryStateStore(sweepInterval time.Duration) *MemoryStateStore {
+	store := &MemoryStateStore{
+		states: make(map[string]memoryStateEntry),
+		stop:   make(chan struct{}),
+	}
+
+	if sweepInterval > 0 {
+		go store.sweep(sweepInterval)
+	}
+
+	return store
+}
+
+func (s *MemoryStateStore) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	entry := memoryStateEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	s.mutex.Lock()
+	s.states[key] = entry
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *MemoryStateStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mutex.RLock()
+	entry, exists := s.states[key]
+	s.mutex.RUnlock()
+
+	if !exists || entry.expired(time.Now()) {
+		return "", false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *MemoryStateStore) Delete(_ context.Context, keys ...string) error {
+	s.mutex.Lock()
+	for _, key := range keys {
+		delete(s.states, key)
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *MemoryStateStore) GetByPrefix(_ context.Context, prefix string) (map[string]string, error) {
+	now := time.Now()
+	result := make(map[string]string)
+
+	s.mutex.RLock()
+	for key, entry := range s.states {
+		if strings.HasPrefix(key, prefix) && !entry.expired(now) {
+			result[key] = entry.value
+		}
+	}
+	s.mutex.RUnlock()
+
+	return result, nil
+}
+
+func (s *MemoryStateStore) DeleteByPrefix(_ context.Context, prefix string) error {
+	s.mutex.Lock()
+	for key := range s.states {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.states, key)
+		}
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+func (s *MemoryStateStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+// sweep periodically drops expired states so abandoned workflows don't accumulate
+func (s *MemoryStateStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			s.mutex.Lock()
+			for key, entry := range s.states {
+				if entry.expired(now) {
+					delete(s.states, key)
+				}
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// RedisStateStore keeps states in Redis so they survive restarts and are shared between replicas.
+// Expiry is handled by Redis key TTLs.
+type RedisStateStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisStateStore creates a Redis backed state store, all keys are namespaced with keyPrefix
+func NewRedisStateStore(client *redis.Client, keyPrefix string) *RedisStateStore {
+	return &RedisStateStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+func (s *RedisStateStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.keyPrefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set state %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *RedisStateStore) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := s.client.Get(ctx, s.keyPrefix+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get state %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+func (s *RedisStateStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.keyPrefix + key
+	}
+
+	if err := s.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("failed to delete states: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStateStore) GetByPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keys, err := s.scanKeys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get states: %w", err)
+	}
+
+	for i, value := range values {
+		// Keys may expire between SCAN and MGET
+		if str, ok := value.(string); ok {
+			result[strings.TrimPrefix(keys[i], s.keyPrefix)] = str
+		}
+	}
+
+	return result, nil
+}
+
+func (s *RedisStateStore) DeleteByPrefix(ctx context.Context, prefix string) error {
+	keys, err := s.scanKeys(ctx, prefix)
+	if err != nil {
+		return err
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("failed to delete states: %w", err)
+	}
+	return nil
+}
+
+// Close is a no-op, the Redis client is owned and closed by whoever created it
+func (s *RedisStateStore) Close() error {
+	return nil
+}
+
+func (s *RedisStateStore) scanKeys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, s.keyPrefix+prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan states: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,64 @@
+//go:build redis
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Run with a live Redis: SSV_TEST_REDIS_ADDR=localhost:6379 go test -tags redis ./internal/core/telegram/handlers/
+func newTestRedisStateStore(t *testing.T) *RedisStateStore {
+	t.Helper()
+
+	addr := os.Getenv("SSV_TEST_REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("redis at %s is not reachable: %v", addr, err)
+	}
+
+	// Unique namespace so parallel runs and leftovers don't interfere
+	store := NewRedisStateStore(client, fmt.Sprintf("test:states:%d:", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		_ = store.DeleteByPrefix(context.Background(), "")
+		_ = client.Close()
+	})
+	return store
+}
+
+func TestRedisStateStoreExpiry(t *testing.T) {
+	testStateStoreExpiry(t, newTestRedisStateStore(t))
+}
+
+func TestRedisStateStoreKeyPrefix(t *testing.T) {
+	store := newTestRedisStateStore(t)
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "7:viewing_list", "list", time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	exists, err := store.client.Exists(ctx, store.keyPrefix+"7:viewing_list").Result()
+	if err != nil || exists != 1 {
+		t.Fatalf("raw key exists = %d, %v; want the key namespaced with the prefix", exists, err)
+	}
+
+	states, err := store.GetByPrefix(ctx, "7:")
+	if err != nil {
+		t.Fatalf("GetByPrefix: %v", err)
+	}
+	if len(states) != 1 || states["7:viewing_list"] != "list" {
+		t.Errorf("GetByPrefix = %v, want keys without the store prefix", states)
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const testStateTTL = 50 * time.Millisecond
+
+// testStateStoreExpiry checks the StateStore contract shared by all backends
+func testStateStoreExpiry(t *testing.T, store StateStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "1:short", "a", testStateTTL); err != nil {
+		t.Fatalf("Set short: %v", err)
+	}
+	if err := store.Set(ctx, "1:long", "b", time.Hour); err != nil {
+		t.Fatalf("Set long: %v", err)
+	}
+	if err := store.Set(ctx, "2:other", "c", 0); err != nil {
+		t.Fatalf("Set other: %v", err)
+	}
+
+	if value, ok, err := store.Get(ctx, "1:short"); err != nil || !ok || value != "a" {
+		t.Fatalf("Get before expiry = %q, %v, %v; want a, true, nil", value, ok, err)
+	}
+
+	time.Sleep(2 * testStateTTL)
+
+	if value, ok, err := store.Get(ctx, "1:short"); err != nil || ok {
+		t.Errorf("Get after expiry = %q, %v, %v; want not found", value, ok, err)
+	}
+	if value, ok, err := store.Get(ctx, "2:other"); err != nil || !ok || value != "c" {
+		t.Errorf("Get without ttl = %q, %v, %v; want c, true, nil", value, ok, err)
+	}
+
+	states, err := store.GetByPrefix(ctx, "1:")
+	if err != nil {
+		t.Fatalf("GetByPrefix: %v", err)
+	}
+	if len(states) != 1 || states["1:long"] != "b" {
+		t.Errorf("GetByPrefix = %v, want only 1:long", states)
+	}
+
+	if err := store.DeleteByPrefix(ctx, "1:"); err != nil {
+		t.Fatalf("DeleteByPrefix: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "1:long"); ok {
+		t.Error("1:long still present after DeleteByPrefix")
+	}
+
+	if err := store.Delete(ctx, "2:other"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := store.Get(ctx, "2:other"); ok {
+		t.Error("2:other still present after Delete")
+	}
+}
+
+func TestMemoryStateStoreExpiry(t *testing.T) {
+	store := NewMemoryStateStore(0)
+	defer store.Close()
+
+	testStateStoreExpiry(t, store)
+}
+
+func TestMemoryStateStoreSweep(t *testing.T) {
+	store := NewMemoryStateStore(10 * time.Millisecond)
+	defer store.Close()
+
+	ctx := context.Background()
+	_ = store.Set(ctx, "1:short", "a", testStateTTL)
+	_ = store.Set(ctx, "1:long", "b", time.Hour)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		store.mutex.RLock()
+		_, short := store.states["1:short"]
+		_, long := store.states["1:long"]
+		store.mutex.RUnlock()
+
+		if !short {
+			if !long {
+				t.Fatal("sweep removed a state that has not expired")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("sweep did not remove the expired state")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStateManagerTTLs(t *testing.T) {
+	ttls := map[string]time.Duration{"adding_item_to_list": testStateTTL}
+	sm := NewStateManagerWithStore(NewMemoryStateStore(0), ttls, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer sm.Close()
+
+	sm.SetUserState(42, "adding_item_to_list", "list")
+	sm.SetUserState(42, "viewing_list", "list")
+
+	time.Sleep(2 * testStateTTL)
+
+	if _, ok := sm.GetUserState(42, "adding_item_to_list"); ok {
+		t.Error("adding_item_to_list did not expire after its TTL")
+	}
+	if value, ok := sm.GetUserState(42, "viewing_list"); !ok || value != "list" {
+		t.Errorf("viewing_list = %q, %v; want the default TTL to keep it", value, ok)
+	}
+}
+
+func TestDefaultStateTTLsCoverWorkflows(t *testing.T) {
+	// States that wait for a follow-up callback must not fall back to the generic TTL silently
+	states := []string{
+		"adding_item_to_list",
+		"creating_list_for_family",
+		"creating_custom_productlist",
+		"awaiting_receipt_upload",
+		"product_list_selection",
+		"duplicate_resolution",
+		"viewing_list",
+		"viewing_receipts",
+		"reconciling_receipt",
+		"sharing_receipt",
+		"splitting_receipt",
+		"settling_family",
+	}
+
+	for _, state := range states {
+		if ttl, ok := DefaultStateTTLs[state]; !ok || ttl <= 0 {
+			t.Errorf("DefaultStateTTLs[%q] = %v, %v; want a positive TTL", state, ttl, ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+)
+
+// SuggestionsHandler handles the accept and dismiss buttons of purchase suggestions
+type SuggestionsHandler struct {
+	BaseHandler
+	listCallbackHandler *ListCallbackHandler
+	stateManager        *StateManager
+}
+
+// NewSuggestionsHandler creates a new suggestions handler
+func NewSuggestionsHandler(base BaseHandler, listHandler *ListCallbackHandler, stateManager *StateManager) *SuggestionsHandler {
+	return &SuggestionsHandler{
+		BaseHandler:         base,
+		listCallbackHandler: listHandler,
+		stateManager:        stateManager,
+	}
+}
+
+// buildSuggestions renders the products the family of the list usually buys about now, with a
+// row of accept and dismiss buttons per product. It returns nothing when there are no suggestions
+// or they can't be loaded, the list is shown without them.
+func (bh *BaseHandler) buildSuggestions(ctx context.Context, listID uuid.UUID, locale string) (string, [][]tgbotapi.InlineKeyboardButton) {
+	suggestions, err := bh.shoppingService.GetListSuggestions(ctx, listID)
+	if err != nil {
+		bh.logger.Warn("Failed to get list suggestions", "error", err, "list_id", listID)
+		return "", nil
+	}
+	if len(suggestions) == 0 {
+		return "", nil
+	}
+
+	data := struct {
+		Suggestions []*shopping.ItemSuggestion
+	}{
+		Suggestions: suggestions,
+	}
+
+	message, err := bh.templateManager.RenderTemplate("list_suggestions", locale, data)
+	if err != nil {
+		bh.logger.Error("Failed to render list suggestions template", "error", err)
+		return "", nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, suggestion := range suggestions {
+		// Compact callback with the short product ID (sug:add:listID:shortParsedItemID)
+		shortParsedItemID := suggestion.ParsedItemID.String()[:8]
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➕ "+truncateUTF8(suggestion.Name, 20), fmt.Sprintf("sug:add:%s:%s", listID, shortParsedItemID)),
+			tgbotapi.NewInlineKeyboardButtonData(bh.templateManager.RenderButton("dismiss_suggestion", locale), fmt.Sprintf("sug:no:%s:%s", listID, shortParsedItemID)),
+		))
+	}
+
+	return message, rows
+}
+
+// HandleSuggestionsCallback handles sug:* callbacks
+func (h *SuggestionsHandler) HandleSuggestionsCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, parts []string, user *users.User) {
+	if len(parts) < 4 {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_list_id", user.Locale))
+		return
+	}
+
+	listID, err := uuid.Parse(parts[2])
+	if err != nil {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_list_id", user.Locale))
+		return
+	}
+
+	canAccess, err := h.shoppingService.CanUserAccessList(ctx, listID, user.ID)
+	if err != nil || !canAccess {
+		if err != nil {
+			h.logger.Error("Failed to check list access", "error", err, "list_id", listID)
+		}
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_list_not_found", user.Locale))
+		return
+	}
+
+	suggestions, err := h.shoppingService.GetListSuggestions(ctx, listID)
+	if err != nil {
+		h.logger.Error("Failed to get list suggestions", "error", err, "list_id", listID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_update_suggestion", user.Locale))
+		return
+	}
+	var suggestion *shopping.ItemSuggestion
+	for _, candidate := range suggestions {
+		if strings.HasPrefix(candidate.ParsedItemID.String(), parts[3]) {
+			suggestion = candidate
+			break
+		}
+	}
+
+	switch {
+	case suggestion == nil:
+		// Someone else answered it or the product was added meanwhile
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_suggestion_outdated", user.Locale))
+	case parts[1] == "add":
+		err = h.acceptSuggestion(ctx, callback, listID, suggestion, user)
+	case parts[1] == "no":
+		err = h.dismissSuggestion(ctx, callback, listID, suggestion, user)
+	default:
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_invalid_list_id", user.Locale))
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	h.listCallbackHandler.HandleViewList(ctx, callback, listID, user)
+	if h.stateManager != nil {
+		viewStateData := fmt.Sprintf("%s:%d:%d", listID.String(), callback.Message.Chat.ID, callback.Message.MessageID)
+		h.stateManager.SetUserState(user.TelegramID, "viewing_list", viewStateData)
+	}
+}
+
+// acceptSuggestion adds the suggested product to the list
+func (h *SuggestionsHandler) acceptSuggestion(ctx context.Context, callback *tgbotapi.CallbackQuery, listID uuid.UUID, suggestion *shopping.ItemSuggestion, user *users.User) error {
+	item, err := h.shoppingService.AcceptSuggestion(ctx, listID, suggestion.ParsedItemID, user.ID)
+	if errors.Is(err, shopping.ErrSuggestionNotFound) {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_suggestion_outdated", user.Locale))
+		return nil
+	}
+	if err != nil {
+		h.logger.Error("Failed to accept suggestion", "error", err, "list_id", listID, "parsed_item_id", suggestion.ParsedItemID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_update_suggestion", user.Locale))
+		return err
+	}
+
+	h.logger.Info("Suggestion accepted",
+		"list_id", listID,
+		"item_id", item.ID,
+		"parsed_item_id", suggestion.ParsedItemID,
+		"user_id", user.ID)
+
+	h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_suggestion_added", user.Locale))
+	return nil
+}
+
+// dismissSuggestion stops suggesting the product for now
+func (h *SuggestionsHandler) dismissSuggestion(ctx context.Context, callback *tgbotapi.CallbackQuery, listID uuid.UUID, suggestion *shopping.ItemSuggestion, user *users.User) error {
+	err := h.shoppingService.DismissSuggestion(ctx, listID, suggestion.ParsedItemID)
+	if errors.Is(err, shopping.ErrSuggestionNotFound) {
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_suggestion_outdated", user.Locale))
+		return nil
+	}
+	if err != nil {
+		h.logger.Error("Failed to dismiss suggestion", "error", err, "list_id", listID, "parsed_item_id", suggestion.ParsedItemID)
+		h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("error_failed_to_update_suggestion", user.Locale))
+		return err
+	}
+
+	h.logger.Info("Suggestion dismissed",
+		"list_id", listID,
+		"parsed_item_id", suggestion.ParsedItemID,
+		"user_id", user.ID)
+
+	h.AnswerCallback(callback.ID, h.templateManager.RenderMessage("callback_suggestion_dismissed", user.Locale))
+	return nil
+}
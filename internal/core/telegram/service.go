@@ -4,16 +4,21 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/PocketPalCo/shopping-service/config"
 	"github.com/PocketPalCo/shopping-service/internal/core/ai"
 	"github.com/PocketPalCo/shopping-service/internal/core/cloud"
 	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/notifications"
 	"github.com/PocketPalCo/shopping-service/internal/core/products"
 	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
 	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
 	"github.com/PocketPalCo/shopping-service/internal/core/stt"
+	"github.com/PocketPalCo/shopping-service/internal/core/telegram/handlers"
 	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	"github.com/go-redis/redis/v8"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -22,6 +27,11 @@ type TelegramService interface {
 	Start(ctx context.Context) error
 	Stop()
 	IsEnabled() bool
+
+	// WebhookPath is the URL path to serve the webhook on, empty when updates are polled
+	WebhookPath() string
+	// HandleWebhookUpdate queues an update posted by Telegram to the webhook
+	HandleWebhookUpdate(body []byte) error
 }
 
 // Service implements TelegramService interface
@@ -37,7 +47,10 @@ type Service struct {
 }
 
 // NewTelegramService creates a new Telegram service instance
-func NewTelegramService(cfg *config.Config, db *pgxpool.Pool, logger *slog.Logger) (TelegramService, error) {
+// redisClient is optional, when set conversation states are kept in Redis instead of memory.
+// listEvents is optional, when set the list messages open in chats follow the changes of their list
+// and family members are notified about the activity in their shared lists.
+func NewTelegramService(cfg *config.Config, db *pgxpool.Pool, redisClient *redis.Client, listEvents *shopping.ListEventBroker, logger *slog.Logger) (TelegramService, error) {
 	if cfg.TelegramBotToken == "" {
 		logger.Info("Telegram bot disabled - no token provided")
 		return &Service{
@@ -60,19 +73,15 @@ func NewTelegramService(cfg *config.Config, db *pgxpool.Pool, logger *slog.Logge
 	// Initialize products service
 	productsService := products.NewService(db, logger)
 
-	// Initialize AI service with OpenAI client using config
-	openAIConfig := cfg.GetOpenAIConfig()
-
-	if openAIConfig.APIKey == "" {
-		logger.Error("failed to initialize OpenAI client",
-			"error", "OpenAI API key not configured (set SSV_OPENAI_API_KEY)",
+	// Create the AI client for the configured LLM provider with the products service
+	aiClient, err := ai.NewClient(cfg.GetLLMConfig(), logger, productsService)
+	if err != nil {
+		logger.Error("failed to initialize AI client",
+			"error", err.Error(),
 			"component", "ai_service")
 		return nil, nil
 	}
 
-	// Create OpenAI client with the products service
-	aiClient := ai.NewOpenAIClient(openAIConfig, logger, productsService)
-
 	// Create the AI service with the client
 	aiService, err := ai.NewService(db, aiClient, *cfg, logger)
 	if err != nil {
@@ -92,16 +101,42 @@ func NewTelegramService(cfg *config.Config, db *pgxpool.Pool, logger *slog.Logge
 
 	// Initialize receipts service
 	receiptsService := receipts.NewService(db, cloudService, aiService, logger)
+	receiptsService.SetItemEventPublisher(shoppingService)
+
+	// Initialize HTTP sessions service for /login codes
+	sessionsService := sessions.NewService(db, cfg.GetSessionConfig())
+
+	// Initialize family activity notifications
+	notificationsService := notifications.NewService(db)
+
+	// Initialize conversation state storage
+	var stateStore handlers.StateStore
+	if redisClient != nil {
+		stateStore = handlers.NewRedisStateStore(redisClient, "ssv:state:")
+	} else {
+		stateStore = handlers.NewMemoryStateStore(time.Minute)
+	}
+	stateManager := handlers.NewStateManagerWithStore(stateStore, handlers.DefaultStateTTLs,
+		time.Duration(cfg.StateDefaultTTLMinutes)*time.Minute, logger)
 
 	// Initialize STT client
 	sttClient := stt.NewClient(cfg.AzureSpeechKey, cfg.AzureSpeechRegion)
 
-	botService, err := NewBotService(cfg.TelegramBotToken, usersService, familiesService, shoppingService, receiptsService, sttClient, logger, cfg.TelegramDebug)
+	botService, err := NewBotService(cfg.TelegramBotToken, usersService, familiesService, shoppingService, receiptsService, sessionsService, notificationsService, stateManager, sttClient, listEvents, cfg.GetTelegramDispatcherConfig(), logger, cfg.TelegramDebug)
 	if err != nil {
 		logger.Error("failed to initialize telegram bot", "error", err)
 		return nil, err
 	}
 
+	// Receive updates through the webhook when configured, otherwise through long polling
+	webhookConfig := cfg.GetTelegramWebhookConfig()
+	if webhookConfig.URL != "" {
+		if err := botService.UseWebhook(webhookConfig); err != nil {
+			logger.Error("failed to configure telegram webhook", "error", err)
+			return nil, err
+		}
+	}
+
 	if len(admins) > 0 {
 		logger.Info("Telegram admin users configured", "count", len(admins))
 	} else {
@@ -112,6 +147,7 @@ func NewTelegramService(cfg *config.Config, db *pgxpool.Pool, logger *slog.Logge
 		"bot_enabled", true,
 		"admin_count", len(admins),
 		"debug_mode", cfg.TelegramDebug,
+		"webhook", webhookConfig.URL != "",
 		"component", "telegram_service")
 
 	return &Service{
@@ -174,3 +210,19 @@ func (s *Service) Stop() {
 func (s *Service) IsEnabled() bool {
 	return s.enabled
 }
+
+// WebhookPath returns the URL path of the webhook, empty when the bot is disabled or polls for updates
+func (s *Service) WebhookPath() string {
+	if !s.enabled {
+		return ""
+	}
+	return s.botService.WebhookPath()
+}
+
+// HandleWebhookUpdate passes an update posted to the webhook to the bot
+func (s *Service) HandleWebhookUpdate(body []byte) error {
+	if !s.enabled {
+		return ErrWebhookUnavailable
+	}
+	return s.botService.HandleWebhookUpdate(body)
+}
@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/PocketPalCo/shopping-service/config"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrWebhookUnavailable is returned for webhook updates that can't be accepted right now,
+// because the bot is not running or the update queue is full. Telegram redelivers rejected
+// updates.
+var ErrWebhookUnavailable = errors.New("telegram webhook is not accepting updates")
+
+// webhookReceiver receives the updates posted by Telegram to the HTTP server
+type webhookReceiver struct {
+	config config.TelegramWebhookConfig
+	path   string
+	active atomic.Bool
+}
+
+// UseWebhook switches the bot from long polling to receiving updates through the webhook.
+// It must be called before Start.
+func (s *BotService) UseWebhook(webhookConfig config.TelegramWebhookConfig) error {
+	if webhookConfig.SecretToken == "" {
+		return fmt.Errorf("webhook secret token is required")
+	}
+
+	webhookURL, err := url.Parse(webhookConfig.URL)
+	if err != nil || webhookURL.Host == "" {
+		return fmt.Errorf("invalid webhook URL: %s", webhookConfig.URL)
+	}
+
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	s.webhook = &webhookReceiver{
+		config: webhookConfig,
+		path:   path,
+	}
+	return nil
+}
+
+// WebhookPath returns the URL path the webhook has to be served on, empty in polling mode
+func (s *BotService) WebhookPath() string {
+	if s.webhook == nil {
+		return ""
+	}
+	return s.webhook.path
+}
+
+// HandleWebhookUpdate decodes an update posted by Telegram and queues it in the dispatcher. The
+// update is only acknowledged once the dispatcher holds it, so Stop drains every update that
+// Telegram won't redeliver.
+func (s *BotService) HandleWebhookUpdate(body []byte) error {
+	if s.webhook == nil || !s.webhook.active.Load() {
+		return ErrWebhookUnavailable
+	}
+
+	var update tgbotapi.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		return fmt.Errorf("failed to decode update: %w", err)
+	}
+
+	if err := s.dispatcher.TryDispatch(context.Background(), update); err != nil {
+		s.logger.Warn("Webhook update not accepted", "error", err, "update_id", update.UpdateID)
+		return ErrWebhookUnavailable
+	}
+
+	return nil
+}
+
+// receiveUpdates registers the webhook with Telegram, falling back to long polling
+// when no webhook is configured or it can't be registered. Webhook updates are dispatched
+// by HandleWebhookUpdate, the returned channel is nil then.
+func (s *BotService) receiveUpdates() tgbotapi.UpdatesChannel {
+	if s.webhook != nil {
+		err := s.setWebhook()
+		if err == nil {
+			s.webhook.active.Store(true)
+			s.logger.Info("Receiving Telegram updates through webhook", "path", s.webhook.path)
+			return nil
+		}
+
+		s.logger.Error("Failed to register Telegram webhook, falling back to long polling", "error", err)
+	}
+
+	// Long polling doesn't work while a webhook is registered, which is usually a deployment
+	// using the same bot token, so it is reported instead of being removed
+	if info, err := s.bot.GetWebhookInfo(); err == nil && info.IsSet() {
+		s.logger.Warn("A Telegram webhook is registered for this bot, long polling will fail until it is removed",
+			"webhook_url", info.URL)
+	}
+
+	s.logger.Info("Receiving Telegram updates through long polling")
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	return s.bot.GetUpdatesChan(u)
+}
+
+// setWebhook registers the webhook URL and secret token with Telegram. The secret token is not
+// supported by the library's WebhookConfig, so the request is built directly.
+func (s *BotService) setWebhook() error {
+	params := tgbotapi.Params{
+		"url":          s.webhook.config.URL,
+		"secret_token": s.webhook.config.SecretToken,
+	}
+	if s.webhook.config.MaxConnections > 0 {
+		params["max_connections"] = strconv.Itoa(s.webhook.config.MaxConnections)
+	}
+
+	resp, err := s.bot.MakeRequest("setWebhook", params)
+	if err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("failed to set webhook: %s", resp.Description)
+	}
+
+	return nil
+}
+
+// deactivate stops accepting webhook updates, the webhook stays registered with Telegram
+// so other replicas keep receiving updates
+func (w *webhookReceiver) deactivate() {
+	if w != nil {
+		w.active.Store(false)
+	}
+}
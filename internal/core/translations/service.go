@@ -46,6 +46,7 @@ type ReceiptItem struct {
 	LocalizedDescription *string          `json:"localized_description" db:"localized_description"`
 	UserLocale           *string          `json:"user_locale" db:"user_locale"`
 	Quantity             *float64         `json:"quantity" db:"quantity"`
+	QuantityUnit         *string          `json:"quantity_unit" db:"quantity_unit"`
 	UnitPrice            *float64         `json:"unit_price" db:"unit_price"`
 	TotalPrice           float64          `json:"total_price" db:"total_price"`
 	CurrencyCode         *string          `json:"currency_code" db:"currency_code"`
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	userLocalsKey    = "user"
+	sessionLocalsKey = "session"
+)
+
+// requireUser resolves the "Authorization: Bearer <token>" header to an authorized user
+// and stores the user and its session in the request locals for the downstream handlers
+func requireUser(sessionsService *sessions.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := bearerToken(c)
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "missing bearer token"})
+		}
+
+		session, user, err := sessionsService.Authenticate(c.UserContext(), token)
+		if err != nil {
+			slog.Error("Failed to resolve session token",
+				"component", "http_auth",
+				"path", c.Path(),
+				"error", err.Error())
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to resolve session"})
+		}
+
+		if session == nil || user == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "invalid or expired session"})
+		}
+
+		if !user.IsAuthorized {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "user is not authorized"})
+		}
+
+		c.Locals(userLocalsKey, user)
+		c.Locals(sessionLocalsKey, session)
+		return c.Next()
+	}
+}
+
+// bearerToken returns the session token of the Authorization header, empty when there is none.
+// The token points into the request buffer, clone it to keep it after the handler returns.
+func bearerToken(c *fiber.Ctx) string {
+	token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// currentUser returns the user resolved by requireUser
+func currentUser(c *fiber.Ctx) *users.User {
+	user, _ := c.Locals(userLocalsKey).(*users.User)
+	return user
+}
+
+// currentSession returns the session resolved by requireUser
+func currentSession(c *fiber.Ctx) *sessions.Session {
+	session, _ := c.Locals(sessionLocalsKey).(*sessions.Session)
+	return session
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
+	"github.com/gofiber/fiber/v2"
+)
+
+type loginCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type sessionResponse struct {
+	Token     string      `json:"token"`
+	ExpiresAt string      `json:"expires_at"`
+	User      *users.User `json:"user"`
+}
+
+func registerAuthRoutes(router fiber.Router, auth, loginLimiter fiber.Handler, sessionsService *sessions.Service, usersService *users.Service) {
+	authRoutes := router.Group("/auth")
+
+	// Exchange a one-time code received from the bot (/login) for a session
+	authRoutes.Post("/login", loginLimiter, func(c *fiber.Ctx) error {
+		var req loginCodeRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		session, err := sessionsService.ExchangeLoginCode(c.UserContext(), req.Code)
+		if errors.Is(err, sessions.ErrInvalidLoginCode) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to log in", err)
+		}
+
+		return respondWithSession(c, usersService, session)
+	})
+
+	// Log in with the Telegram Login Widget payload
+	authRoutes.Post("/telegram", func(c *fiber.Ctx) error {
+		data, err := parseTelegramLoginData(c.Body())
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		telegramID, err := sessionsService.VerifyTelegramLogin(data)
+		if err != nil {
+			slog.Warn("Rejected Telegram login",
+				"component", "http_auth",
+				"error", err.Error())
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": sessions.ErrInvalidTelegramLogin.Error()})
+		}
+
+		user, err := usersService.GetUserByTelegramID(c.UserContext(), telegramID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get user", err)
+		}
+		if user == nil || !user.IsAuthorized {
+			// Users have to be created and authorized through the bot first
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "user is not authorized"})
+		}
+
+		// For private chats the chat ID equals the user's Telegram ID
+		session, err := sessionsService.CreateSession(c.UserContext(), user.ID, telegramID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to create session", err)
+		}
+
+		return respondWithSession(c, usersService, session)
+	})
+
+	// Current user and session
+	authRoutes.Get("/me", auth, func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"user":    currentUser(c),
+			"session": currentSession(c),
+		})
+	})
+
+	// Active sessions of the current user
+	authRoutes.Get("/sessions", auth, func(c *fiber.Ctx) error {
+		userSessions, err := sessionsService.GetUserSessions(c.UserContext(), currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get sessions", err)
+		}
+
+		return c.JSON(fiber.Map{"sessions": nonNil(userSessions)})
+	})
+
+	// Rotate the current token and extend the session
+	authRoutes.Post("/refresh", auth, func(c *fiber.Ctx) error {
+		session, err := sessionsService.RefreshSession(c.UserContext(), currentSession(c).ID)
+		if errors.Is(err, sessions.ErrSessionNotFound) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to refresh session", err)
+		}
+
+		return respondWithSession(c, usersService, session)
+	})
+
+	// Revoke the current session
+	authRoutes.Post("/logout", auth, func(c *fiber.Ctx) error {
+		err := sessionsService.RevokeSession(c.UserContext(), currentSession(c).ID)
+		if err != nil && !errors.Is(err, sessions.ErrSessionNotFound) {
+			return apiError(c, fiber.StatusInternalServerError, "failed to log out", err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Revoke every session of the current user, including the current one
+	authRoutes.Post("/logout-all", auth, func(c *fiber.Ctx) error {
+		revoked, err := sessionsService.RevokeAllUserSessions(c.UserContext(), currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to revoke sessions", err)
+		}
+
+		return c.JSON(fiber.Map{"revoked": revoked})
+	})
+}
+
+func respondWithSession(c *fiber.Ctx, usersService *users.Service, session *sessions.Session) error {
+	user, err := usersService.GetUserByID(c.UserContext(), session.UserID)
+	if err != nil {
+		return apiError(c, fiber.StatusInternalServerError, "failed to get user", err)
+	}
+
+	return c.JSON(sessionResponse{
+		Token:     session.Token,
+		ExpiresAt: session.ExpiresAt.UTC().Format(time.RFC3339),
+		User:      user,
+	})
+}
+
+// parseTelegramLoginData converts the widget JSON payload to the string values used for hash verification
+func parseTelegramLoginData(body []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if value == nil {
+			continue
+		}
+		data[key] = fmt.Sprint(value)
+	}
+
+	return data, nil
+}
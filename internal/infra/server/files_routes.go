@@ -0,0 +1,61 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/cloud"
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerFileRoutes serves the files of the local storage provider through the presigned
+// links it generates. Unsigned and expired links are rejected. A base URL without a path serves
+// files below /, so the route has to be registered after all others.
+func registerFileRoutes(app *fiber.App, provider *cloud.LocalProvider) {
+	app.Get(strings.TrimSuffix(provider.BasePath(), "/")+"/*", func(c *fiber.Ctx) error {
+		fileID, err := fileIDParam(c)
+		if err != nil || fileID == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid file path"})
+		}
+
+		expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil || !provider.VerifySignature(fileID, expires, c.Query("signature"), time.Now()) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "invalid or expired link"})
+		}
+
+		file, fileInfo, err := provider.OpenFile(fileID)
+		if err != nil {
+			if errors.Is(err, cloud.ErrFileNotFound) || errors.Is(err, cloud.ErrInvalidFileID) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "file not found"})
+			}
+			return apiError(c, fiber.StatusInternalServerError, "failed to open file", err)
+		}
+
+		if fileInfo.ContentType != "" {
+			c.Set(fiber.HeaderContentType, fileInfo.ContentType)
+		}
+		if fileInfo.ETag != "" {
+			c.Set(fiber.HeaderETag, strconv.Quote(fileInfo.ETag))
+		}
+		c.Set(fiber.HeaderLastModified, fileInfo.LastModified.UTC().Format(http.TimeFormat))
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf("private, max-age=%d", max(expires-time.Now().Unix(), 0)))
+
+		// The stream is closed by fasthttp once the response is written
+		return c.SendStream(file, int(fileInfo.Size))
+	})
+}
+
+// fileIDParam returns the file ID of the wildcard route parameter. Fiber only unescapes route
+// parameters when UnescapePath is enabled, they are unescaped here otherwise.
+func fileIDParam(c *fiber.Ctx) (string, error) {
+	fileID := c.Params("*")
+	if c.App().Config().UnescapePath {
+		return fileID, nil
+	}
+	return url.PathUnescape(fileID)
+}
@@ -6,6 +6,12 @@ import (
 	"time"
 
 	"github.com/PocketPalCo/shopping-service/config"
+	"github.com/PocketPalCo/shopping-service/internal/core/cloud"
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
 	"github.com/PocketPalCo/shopping-service/internal/infra/postgres"
 	"github.com/PocketPalCo/shopping-service/pkg/telemetry"
 	"github.com/gofiber/contrib/otelfiber/v2"
@@ -15,7 +21,6 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	slogfiber "github.com/samber/slog-fiber"
@@ -24,7 +29,9 @@ import (
 	"log/slog"
 )
 
-func initGlobalMiddlewares(app *fiber.App, cfg *config.Config) {
+// initGlobalMiddlewares installs the middlewares of all routes. webhookPath is exempt from
+// rate limiting because Telegram delivers the updates of all users from a few addresses.
+func initGlobalMiddlewares(app *fiber.App, cfg *config.Config, webhookPath string) {
 	app.Use(
 		compress.New(compress.Config{
 			Level: compress.LevelDefault,
@@ -35,7 +42,7 @@ func initGlobalMiddlewares(app *fiber.App, cfg *config.Config) {
 		cors.New(cors.Config{
 			AllowOrigins: "*", // Configure specific origins for production deployment
 			AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Request-ID",
-			AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+			AllowMethods: "GET, POST, PUT, PATCH, DELETE, OPTIONS",
 		}),
 
 		favicon.New(),
@@ -43,6 +50,9 @@ func initGlobalMiddlewares(app *fiber.App, cfg *config.Config) {
 			Max:               cfg.RateLimitMax,
 			Expiration:        time.Duration(cfg.RateLimitWindow) * time.Second,
 			LimiterMiddleware: limiter.SlidingWindow{},
+			Next: func(c *fiber.Ctx) bool {
+				return webhookPath != "" && c.Path() == webhookPath
+			},
 		}),
 	)
 
@@ -83,7 +93,20 @@ func initGlobalMiddlewares(app *fiber.App, cfg *config.Config) {
 	})
 }
 
-func registerHttpRoutes(app *fiber.App, cfg *config.Config, db postgres.DB) {
+// apiServices groups the core services exposed through the HTTP API
+type apiServices struct {
+	users    *users.Service
+	sessions *sessions.Service
+	families *families.Service
+	shopping *shopping.Service
+	receipts *receipts.Service
+	// listEvents streams list changes to the clients subscribed on this replica
+	listEvents *shopping.ListEventBroker
+	// localFiles is set only when files are kept by the local storage provider
+	localFiles *cloud.LocalProvider
+}
+
+func registerHttpRoutes(app *fiber.App, cfg *config.Config, db postgres.DB, services apiServices) {
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok", "timestamp": time.Now().Unix()})
 	})
@@ -94,29 +117,20 @@ func registerHttpRoutes(app *fiber.App, cfg *config.Config, db postgres.DB) {
 
 	apiRoutes := app.Group("/v1")
 
-	// Legacy shopping list endpoint (maintain compatibility)
-	apiRoutes.Get("/list", withMetrics(db, func(c *fiber.Ctx) error {
-		var id uuid.UUID
-		var name string
-		err := db.QueryRow(c.UserContext(), "SELECT id, name FROM shopping_list_items LIMIT 1").Scan(&id, &name)
-		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				slog.Info("No shopping list items found",
-					"component", "http_handler",
-					"endpoint", "/v1/list",
-					"result", "empty")
-				return c.JSON(fiber.Map{"message": "No shopping list items found"})
-			}
-			slog.Error("Database query failed",
-				"component", "http_handler",
-				"endpoint", "/v1/list",
-				"error", err.Error(),
-				"query", "SELECT id, name FROM shopping_list_items LIMIT 1")
-			return c.Status(500).JSON(fiber.Map{"error": "database error"})
-		}
-
-		return c.JSON(fiber.Map{"id": id, "name": name})
-	}))
+	auth := requireUser(services.sessions)
+	// Login codes are short, so guessing them is throttled per client IP
+	loginLimiter := limiter.New(limiter.Config{
+		Max:               cfg.LoginRateLimitMax,
+		Expiration:        time.Duration(cfg.LoginRateLimitWindow) * time.Second,
+		LimiterMiddleware: limiter.SlidingWindow{},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "too many login attempts, try again later"})
+		},
+	})
+	registerAuthRoutes(apiRoutes, auth, loginLimiter, services.sessions, services.users)
+	registerShoppingRoutes(apiRoutes, auth, services.sessions, services.shopping, services.families, services.listEvents)
+	registerListTemplateRoutes(apiRoutes, auth, services.shopping)
+	registerReceiptsRoutes(apiRoutes, auth, services.receipts, services.families)
 
 	// Test endpoint for database connectivity
 	apiRoutes.Get("/test", withMetrics(db, withTransaction(db, func(c *fiber.Ctx, tx pgx.Tx) error {
@@ -138,6 +152,11 @@ func registerHttpRoutes(app *fiber.App, cfg *config.Config, db postgres.DB) {
 	apiRoutes.Get("/error", withMetrics(db, func(c *fiber.Ctx) error {
 		return errors.New("test error endpoint")
 	}))
+
+	// Last, files may be served below / and must not shadow the routes above
+	if services.localFiles != nil {
+		registerFileRoutes(app, services.localFiles)
+	}
 }
 
 type withTransactionHandler func(c *fiber.Ctx, tx pgx.Tx) error
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const templateLocalsKey = "list_template"
+
+type createTemplateRequest struct {
+	ListID uuid.UUID `json:"list_id"`
+	Name   string    `json:"name"`
+}
+
+type mergeTemplateRequest struct {
+	ListID       uuid.UUID `json:"list_id"`
+	LanguageCode string    `json:"language_code"`
+}
+
+type templateScheduleRequest struct {
+	Weekday  *int   `json:"weekday"` // 0 is Sunday, null removes the schedule
+	Time     string `json:"time"`    // HH:MM
+	Timezone string `json:"timezone"`
+}
+
+func registerListTemplateRoutes(router fiber.Router, auth fiber.Handler, shoppingService *shopping.Service) {
+	templates := router.Group("/templates", auth)
+
+	// List the templates of the user and of their families
+	templates.Get("/", func(c *fiber.Ctx) error {
+		userTemplates, err := shoppingService.GetUserListTemplates(c.UserContext(), currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get list templates", err)
+		}
+
+		return c.JSON(fiber.Map{"templates": nonNil(userTemplates)})
+	})
+
+	// Save a list as a template, named after the list unless a name is provided
+	templates.Post("/", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+
+		var req createTemplateRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		canAccess, err := shoppingService.CanUserAccessList(c.UserContext(), req.ListID, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to check list access", err)
+		}
+		list, err := shoppingService.GetShoppingListByID(c.UserContext(), req.ListID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get shopping list", err)
+		}
+		if !canAccess || list == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "shopping list not found"})
+		}
+
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			name = list.Name
+		}
+
+		template, err := shoppingService.SaveListAsTemplate(c.UserContext(), list.ID, name, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to save list template", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(template)
+	})
+
+	template := templates.Group("/:templateID", requireTemplateAccess(shoppingService))
+
+	// Get a template with its items
+	template.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(currentTemplate(c))
+	})
+
+	template.Delete("/", func(c *fiber.Ctx) error {
+		if err := shoppingService.DeleteListTemplate(c.UserContext(), currentTemplate(c).ID); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to delete list template", err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Create a new list from the template
+	template.Post("/lists", func(c *fiber.Ctx) error {
+		list, items, err := shoppingService.CreateListFromTemplate(c.UserContext(), currentTemplate(c).ID, currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to create list from template", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"list":  list,
+			"items": nonNil(items),
+		})
+	})
+
+	// Add the template items to an open list. Items already on the list are skipped and returned
+	// as duplicates.
+	template.Post("/merge", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+
+		var req mergeTemplateRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		canAccess, err := shoppingService.CanUserAccessList(c.UserContext(), req.ListID, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to check list access", err)
+		}
+		if !canAccess {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "shopping list not found"})
+		}
+
+		languageCode := req.LanguageCode
+		if languageCode == "" {
+			languageCode = user.Locale
+		}
+
+		duplicates, uniqueItems, err := shoppingService.CheckTemplateDuplicates(c.UserContext(),
+			currentTemplate(c).ID, req.ListID, languageCode, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to check duplicate items", err)
+		}
+
+		added, failed, err := shoppingService.AddParsedItemsToList(c.UserContext(), req.ListID, uniqueItems, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to add items", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"added":      nonNil(added),
+			"duplicates": nonNil(duplicates),
+			"failed":     nonNil(failed),
+		})
+	})
+
+	// Create a list from the template every week, or stop with a null weekday
+	template.Put("/schedule", func(c *fiber.Ctx) error {
+		var req templateScheduleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		minute := 0
+		if req.Weekday != nil {
+			at, err := time.Parse("15:04", req.Time)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "time must be HH:MM"})
+			}
+			minute = at.Hour()*60 + at.Minute()
+		}
+
+		nextRunAt, err := shoppingService.SetListTemplateSchedule(c.UserContext(), currentTemplate(c).ID,
+			req.Weekday, minute, req.Timezone)
+		if errors.Is(err, shopping.ErrInvalidTemplateSchedule) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid weekday or timezone"})
+		}
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to save template schedule", err)
+		}
+
+		return c.JSON(fiber.Map{"next_run_at": nextRunAt})
+	})
+}
+
+// requireTemplateAccess loads the :templateID template of the user or their families
+func requireTemplateAccess(shoppingService *shopping.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		templateID, err := uuid.Parse(c.Params("templateID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid template id"})
+		}
+
+		canAccess, err := shoppingService.CanUserAccessTemplate(c.UserContext(), templateID, currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to check template access", err)
+		}
+		if !canAccess {
+			// Do not reveal whether the template exists
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "list template not found"})
+		}
+
+		template, err := shoppingService.GetListTemplate(c.UserContext(), templateID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get list template", err)
+		}
+		if template == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "list template not found"})
+		}
+
+		c.Locals(templateLocalsKey, template)
+		return c.Next()
+	}
+}
+
+func currentTemplate(c *fiber.Ctx) *shopping.ListTemplate {
+	template, _ := c.Locals(templateLocalsKey).(*shopping.ListTemplate)
+	return template
+}
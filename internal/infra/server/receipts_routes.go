@@ -0,0 +1,325 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const maxAnalyticsLimit = 50
+
+type shareReceiptRequest struct {
+	FamilyID *uuid.UUID `json:"family_id"` // null makes the receipt private again
+}
+
+type receiptPayerRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func registerReceiptsRoutes(router fiber.Router, auth fiber.Handler, receiptsService *receipts.Service, familiesService *families.Service) {
+	receiptRoutes := router.Group("/receipts", auth)
+
+	// List the user's own receipts, or with family_id the receipts shared with that family.
+	// paid_by limits the list to the receipts a user paid.
+	receiptRoutes.Get("/", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		limit, offset := parsePagination(c)
+
+		filter := receipts.ReceiptsFilter{
+			UserID: user.ID,
+			Limit:  limit,
+			Offset: offset,
+		}
+
+		if param := c.Query("family_id"); param != "" {
+			familyID, err := uuid.Parse(param)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid family id"})
+			}
+
+			isMember, err := familiesService.IsUserFamilyMember(c.UserContext(), familyID, user.ID)
+			if err != nil {
+				return apiError(c, fiber.StatusInternalServerError, "failed to check family membership", err)
+			}
+			if !isMember {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "user is not a member of this family"})
+			}
+			filter.FamilyID = &familyID
+		}
+
+		if param := c.Query("paid_by"); param != "" {
+			paidBy, err := uuid.Parse(param)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid paid_by user id"})
+			}
+			filter.PaidBy = &paidBy
+		}
+
+		list, total, err := receiptsService.ListReceipts(c.UserContext(), filter)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to list receipts", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"receipts":   list,
+			"pagination": pagination{Limit: limit, Offset: offset, Total: total},
+		})
+	})
+
+	// Spending analytics of the current user. Either a calendar period (week, month, quarter, year)
+	// or an explicit from/to date range (YYYY-MM-DD, both inclusive) can be requested.
+	receiptRoutes.Get("/analytics", func(c *fiber.Ctx) error {
+		filter, err := parseSpendingFilter(c, time.Now())
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		filter.UserID = currentUser(c).ID
+
+		analytics, err := receiptsService.GetSpendingAnalytics(c.UserContext(), filter)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get spending analytics", err)
+		}
+
+		return c.JSON(analytics)
+	})
+
+	// Tax summary of the current user for a calendar year (current year by default),
+	// grouped by month, quarter or year. format=csv returns a downloadable CSV file.
+	receiptRoutes.Get("/tax-summary", func(c *fiber.Ctx) error {
+		year := c.QueryInt("year", time.Now().Year())
+		if year < 1970 || year > 9999 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid year"})
+		}
+
+		granularity := c.Query("granularity", receipts.GranularityQuarter)
+		switch granularity {
+		case receipts.GranularityMonth, receipts.GranularityQuarter, receipts.GranularityYear:
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "granularity must be one of month, quarter, year"})
+		}
+
+		format := c.Query("format", "json")
+		if format != "json" && format != "csv" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be one of json, csv"})
+		}
+
+		from, to := receipts.YearRange(year, time.Local)
+		summary, err := receiptsService.GetTaxSummary(c.UserContext(), receipts.TaxSummaryFilter{
+			UserID:      currentUser(c).ID,
+			From:        from,
+			To:          to,
+			Granularity: granularity,
+		})
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get tax summary", err)
+		}
+
+		if format == "csv" {
+			c.Attachment(fmt.Sprintf("tax-summary-%d-%s.csv", year, granularity))
+			if err := summary.WriteCSV(c.Response().BodyWriter()); err != nil {
+				return apiError(c, fiber.StatusInternalServerError, "failed to export tax summary", err)
+			}
+			return nil
+		}
+
+		return c.JSON(summary)
+	})
+
+	// Export of the current user's processed receipts as a downloadable csv (one row per item),
+	// json, ofx or qif (one transaction per receipt) file. The range is chosen like for analytics.
+	receiptRoutes.Get("/export", func(c *fiber.Ctx) error {
+		format := c.Query("format", receipts.ExportFormatCSV)
+		if !receipts.IsValidExportFormat(format) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be one of csv, json, ofx, qif"})
+		}
+
+		filter, err := parseSpendingFilter(c, time.Now())
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		export, err := receiptsService.ExportReceipts(c.UserContext(), receipts.ExportFilter{
+			UserID: currentUser(c).ID,
+			From:   filter.From,
+			To:     filter.To,
+		})
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to export receipts", err)
+		}
+
+		c.Attachment(export.FileName(format))
+		c.Set(fiber.HeaderContentType, receipts.ExportContentType(format))
+		if err := export.Write(c.Response().BodyWriter(), format); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to export receipts", err)
+		}
+		return nil
+	})
+
+	// Price history of an item for the current user: the unit price time series and the prices per
+	// store, cheapest first. An optional from/to date range (YYYY-MM-DD, both inclusive) limits it.
+	receiptRoutes.Get("/prices", func(c *fiber.Ctx) error {
+		item := c.Query("item")
+		if item == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "item is required"})
+		}
+
+		filter := receipts.PriceHistoryFilter{
+			UserID: currentUser(c).ID,
+			Item:   item,
+			Locale: currentUser(c).Locale,
+		}
+
+		if from := c.Query("from"); from != "" {
+			date, err := time.Parse(time.DateOnly, from)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from must be a date in YYYY-MM-DD format"})
+			}
+			filter.From = date
+		}
+		if to := c.Query("to"); to != "" {
+			date, err := time.Parse(time.DateOnly, to)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "to must be a date in YYYY-MM-DD format"})
+			}
+			filter.To = date.AddDate(0, 0, 1)
+		}
+
+		history, err := receiptsService.GetPriceHistory(c.UserContext(), filter)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get price history", err)
+		}
+
+		return c.JSON(history)
+	})
+
+	// Get a receipt with its items, own receipts and receipts shared with the user's families
+	receiptRoutes.Get("/:receiptID", func(c *fiber.Ctx) error {
+		receiptID, err := uuid.Parse(c.Params("receiptID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid receipt id"})
+		}
+
+		receipt, err := receiptsService.GetReceiptWithItems(c.UserContext(), receiptID, currentUser(c).ID)
+		if err != nil {
+			return receiptError(c, "failed to get receipt", err)
+		}
+
+		return c.JSON(receipt)
+	})
+
+	// Share a receipt with a family of the uploader, or make it private with a null family_id
+	receiptRoutes.Put("/:receiptID/family", func(c *fiber.Ctx) error {
+		receiptID, err := uuid.Parse(c.Params("receiptID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid receipt id"})
+		}
+
+		var req shareReceiptRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		user := currentUser(c)
+		if err := receiptsService.ShareReceipt(c.UserContext(), receiptID, user.ID, req.FamilyID); err != nil {
+			return receiptError(c, "failed to share receipt", err)
+		}
+
+		return respondWithReceipt(c, receiptsService, receiptID)
+	})
+
+	// Record which family member paid for a receipt
+	receiptRoutes.Put("/:receiptID/payer", func(c *fiber.Ctx) error {
+		receiptID, err := uuid.Parse(c.Params("receiptID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid receipt id"})
+		}
+
+		var req receiptPayerRequest
+		if err := c.BodyParser(&req); err != nil || req.UserID == uuid.Nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id is required"})
+		}
+
+		if err := receiptsService.SetReceiptPayer(c.UserContext(), receiptID, currentUser(c).ID, req.UserID); err != nil {
+			return receiptError(c, "failed to set receipt payer", err)
+		}
+
+		return respondWithReceipt(c, receiptsService, receiptID)
+	})
+}
+
+func respondWithReceipt(c *fiber.Ctx, receiptsService *receipts.Service, receiptID uuid.UUID) error {
+	receipt, err := receiptsService.GetReceiptWithItems(c.UserContext(), receiptID, currentUser(c).ID)
+	if err != nil {
+		return receiptError(c, "failed to get receipt", err)
+	}
+
+	return c.JSON(receipt)
+}
+
+// receiptError maps receipt access errors to client errors, anything else is an internal error
+func receiptError(c *fiber.Ctx, message string, err error) error {
+	switch {
+	case errors.Is(err, receipts.ErrReceiptNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "receipt not found"})
+	case errors.Is(err, receipts.ErrReceiptNotOwned), errors.Is(err, receipts.ErrNotFamilyMember):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return apiError(c, fiber.StatusInternalServerError, message, err)
+	}
+}
+
+// parseSpendingFilter reads the analytics range and options from the query string
+func parseSpendingFilter(c *fiber.Ctx, now time.Time) (receipts.SpendingFilter, error) {
+	var filter receipts.SpendingFilter
+
+	fromParam, toParam := c.Query("from"), c.Query("to")
+	if fromParam != "" || toParam != "" {
+		if fromParam == "" || toParam == "" {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "both from and to are required")
+		}
+
+		from, err := time.Parse(time.DateOnly, fromParam)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "from must be a date in YYYY-MM-DD format")
+		}
+		to, err := time.Parse(time.DateOnly, toParam)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "to must be a date in YYYY-MM-DD format")
+		}
+		if to.Before(from) {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "to must not be before from")
+		}
+
+		filter.From = from
+		filter.To = to.AddDate(0, 0, 1)
+		filter.Granularity = receipts.GranularityDay
+	} else {
+		from, to, granularity, err := receipts.PeriodRange(c.Query("period", receipts.PeriodMonth), now)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "period must be one of week, month, quarter, year")
+		}
+
+		filter.From = from
+		filter.To = to
+		filter.Granularity = granularity
+	}
+
+	if granularity := c.Query("granularity"); granularity != "" {
+		if !receipts.IsValidGranularity(granularity) {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "granularity must be one of day, week, month, quarter, year")
+		}
+		filter.Granularity = granularity
+	}
+
+	filter.Currency = c.Query("currency")
+
+	// Zero falls back to the service default
+	filter.Limit = min(max(c.QueryInt("limit", 0), 0), maxAnalyticsLimit)
+
+	return filter, nil
+}
@@ -2,12 +2,23 @@ package server
 
 import (
 	"context"
+	"strings"
 	"sync"
 
 	"github.com/PocketPalCo/shopping-service/config"
+	"github.com/PocketPalCo/shopping-service/internal/core/ai"
+	"github.com/PocketPalCo/shopping-service/internal/core/cloud"
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/products"
+	"github.com/PocketPalCo/shopping-service/internal/core/receipts"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
 	"github.com/PocketPalCo/shopping-service/internal/core/telegram"
+	"github.com/PocketPalCo/shopping-service/internal/core/users"
 	"github.com/PocketPalCo/shopping-service/internal/infra/postgres"
+	"github.com/PocketPalCo/shopping-service/internal/infra/redis"
 	"github.com/PocketPalCo/shopping-service/pkg/telemetry"
+	goredis "github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
@@ -30,6 +41,8 @@ type Server struct {
 	traceProvider   *sdktrace.TracerProvider
 	metricProvider  *metric.MeterProvider
 	telegramService telegram.TelegramService
+	apiServices     apiServices
+	redisClient     *goredis.Client
 	loggerProvider  interface{ Shutdown(context.Context) error } // log.LoggerProvider interface
 	ctx             context.Context
 	cancel          context.CancelFunc
@@ -104,14 +117,61 @@ func New(ctx context.Context, cfg *config.Config, dbConn *pgxpool.Pool) *Server
 
 	serverCtx, cancel := context.WithCancel(ctx)
 
+	// Conversation states are kept in memory unless Redis is configured as the state store
+	var redisClient *goredis.Client
+	if strings.EqualFold(cfg.StateStore, "redis") {
+		redisClient, err = redis.NewRedisClient(cfg)
+		if err != nil {
+			slog.Error("failed to connect to redis", slog.String("error", err.Error()))
+			cancel()
+			return nil
+		}
+	}
+
+	// List changes of all replicas, streamed to API clients and to the list messages of the bot
+	listEvents := shopping.NewListEventBroker(dbConn)
+
 	// Initialize Telegram service
-	telegramService, err := telegram.NewTelegramService(cfg, dbConn, slog.Default())
+	telegramService, err := telegram.NewTelegramService(cfg, dbConn, redisClient, listEvents, slog.Default())
 	if err != nil {
 		slog.Error("failed to initialize telegram service", slog.String("error", err.Error()))
 		cancel()
 		return nil
 	}
 
+	admins, err := cfg.GetTelegramAdmins()
+	if err != nil {
+		slog.Error("failed to parse telegram admin IDs", slog.String("error", err.Error()))
+		cancel()
+		return nil
+	}
+
+	// Initialize core services exposed through the HTTP API
+	services := apiServices{
+		users:    users.NewService(dbConn, admins),
+		sessions: sessions.NewService(dbConn, cfg.GetSessionConfig()),
+		families: families.NewService(dbConn),
+		shopping: shopping.NewService(dbConn, newItemParser(cfg, dbConn)),
+		// Receipts are uploaded and processed through the bot, the HTTP API only reads them
+		receipts:   receipts.NewService(dbConn, nil, nil, slog.Default()),
+		listEvents: listEvents,
+	}
+
+	// Files of the local storage provider are served by this server through signed links
+	if strings.EqualFold(cfg.CloudProvider, "local") {
+		localConfig := cfg.GetCloudConfig().Local
+		services.localFiles, err = cloud.NewLocalProvider(cloud.LocalConfig{
+			RootPath:   localConfig.RootPath,
+			BaseURL:    localConfig.BaseURL,
+			SigningKey: localConfig.SigningKey,
+		})
+		if err != nil {
+			slog.Error("failed to initialize local file storage", slog.String("error", err.Error()))
+			cancel()
+			return nil
+		}
+	}
+
 	return &Server{
 		cfg:             cfg,
 		app:             app,
@@ -119,14 +179,43 @@ func New(ctx context.Context, cfg *config.Config, dbConn *pgxpool.Pool) *Server
 		traceProvider:   tp,
 		metricProvider:  provider,
 		telegramService: telegramService,
+		apiServices:     services,
+		redisClient:     redisClient,
 		ctx:             serverCtx,
 		cancel:          cancel,
 	}
 }
 
+// newItemParser builds the AI item parser used by the HTTP API, items are stored unparsed when no LLM provider is configured
+func newItemParser(cfg *config.Config, dbConn *pgxpool.Pool) shopping.AIService {
+	productsService := products.NewService(dbConn, slog.Default())
+	aiClient, err := ai.NewClient(cfg.GetLLMConfig(), slog.Default(), productsService)
+	if err != nil {
+		slog.Warn("LLM provider not configured, items added over HTTP will not be parsed",
+			slog.String("error", err.Error()),
+			"component", "server")
+		return nil
+	}
+
+	aiService, err := ai.NewService(dbConn, aiClient, *cfg, slog.Default())
+	if err != nil {
+		slog.Error("failed to initialize AI service for HTTP API", slog.String("error", err.Error()))
+		return nil
+	}
+
+	return aiService
+}
+
 func (s *Server) Start() {
-	initGlobalMiddlewares(s.app, s.cfg)
-	registerHttpRoutes(s.app, s.cfg, s.db)
+	webhookPath := s.telegramService.WebhookPath()
+	initGlobalMiddlewares(s.app, s.cfg, webhookPath)
+
+	// Telegram posts updates to the webhook when it is configured instead of being polled
+	if webhookPath != "" {
+		registerTelegramWebhookRoute(s.app, webhookPath, s.cfg.TelegramWebhookSecret, s.telegramService)
+	}
+
+	registerHttpRoutes(s.app, s.cfg, s.db, s.apiServices)
 
 	// Start Telegram service
 	if s.telegramService.IsEnabled() {
@@ -139,6 +228,27 @@ func (s *Server) Start() {
 		}()
 	}
 
+	// Forward the list changes of all replicas to the event streams of this one
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.apiServices.listEvents.Run(s.ctx)
+	}()
+
+	// Periodically remove expired sessions and login codes
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.cleanupExpiredSessions()
+	}()
+
+	// Record the price history of receipts processed before it existed
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.backfillPriceHistory()
+	}()
+
 	slog.Info("Starting HTTP server", slog.String("address", s.cfg.ServerAddress))
 
 	// Start HTTP server
@@ -151,6 +261,36 @@ func (s *Server) Start() {
 	}()
 }
 
+func (s *Server) cleanupExpiredSessions() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.apiServices.sessions.DeleteExpired(s.ctx)
+		if err != nil && s.ctx.Err() == nil {
+			slog.Error("failed to delete expired sessions", slog.String("error", err.Error()))
+		} else if deleted > 0 {
+			slog.Info("Deleted expired sessions", slog.Int64("count", deleted))
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) backfillPriceHistory() {
+	recorded, err := s.apiServices.receipts.BackfillPriceHistory(s.ctx)
+	if err != nil && s.ctx.Err() == nil {
+		slog.Error("failed to backfill price history", slog.String("error", err.Error()))
+	}
+	if recorded > 0 {
+		slog.Info("Backfilled price history of receipts", slog.Int("count", recorded))
+	}
+}
+
 func (s *Server) Shutdown() {
 	slog.Info("Shutting down server")
 
@@ -183,6 +323,12 @@ func (s *Server) Shutdown() {
 		}
 	}
 
+	if s.redisClient != nil {
+		if err := s.redisClient.Close(); err != nil {
+			slog.Error("Error closing redis client", slog.String("error", err.Error()))
+		}
+	}
+
 	s.db.Close()
 
 	slog.Info("Server shut down successfully")
@@ -0,0 +1,471 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/families"
+	"github.com/PocketPalCo/shopping-service/internal/core/sessions"
+	"github.com/PocketPalCo/shopping-service/internal/core/shopping"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	listLocalsKey = "shopping_list"
+
+	// listEventsHeartbeat keeps idle event streams open through proxies, the session and access
+	// to the list are checked again on every heartbeat
+	listEventsHeartbeat = 25 * time.Second
+)
+
+type createListRequest struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	FamilyID    *uuid.UUID `json:"family_id"`
+	IsShared    bool       `json:"is_shared"`
+}
+
+type addItemRequest struct {
+	Name         string `json:"name"`
+	Quantity     string `json:"quantity"`
+	LanguageCode string `json:"language_code"`
+}
+
+type pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+func registerShoppingRoutes(router fiber.Router, auth fiber.Handler, sessionsService *sessions.Service, shoppingService *shopping.Service, familiesService *families.Service, listEvents *shopping.ListEventBroker) {
+	lists := router.Group("/lists", auth)
+
+	// List all active shopping lists the user can see (own and family lists)
+	lists.Get("/", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+		limit, offset := parsePagination(c)
+
+		userLists, total, familyCount, err := shoppingService.GetUserShoppingListsPage(c.UserContext(), user.ID, limit, offset)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get shopping lists", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"lists":        nonNil(userLists),
+			"family_count": familyCount,
+			"pagination":   pagination{Limit: limit, Offset: offset, Total: total},
+		})
+	})
+
+	// Create a personal or family shopping list
+	lists.Post("/", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+
+		var req createListRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+		}
+
+		if req.FamilyID != nil {
+			isMember, err := familiesService.IsUserFamilyMember(c.UserContext(), *req.FamilyID, user.ID)
+			if err != nil {
+				return apiError(c, fiber.StatusInternalServerError, "failed to check family membership", err)
+			}
+			if !isMember {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "user is not a member of this family"})
+			}
+		}
+
+		list, err := shoppingService.CreateShoppingList(c.UserContext(), shopping.CreateShoppingListRequest{
+			Name:        req.Name,
+			Description: req.Description,
+			OwnerID:     user.ID,
+			FamilyID:    req.FamilyID,
+			IsShared:    req.IsShared,
+		})
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to create shopping list", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(list)
+	})
+
+	list := lists.Group("/:listID", requireListAccess(shoppingService))
+
+	// Get a shopping list together with its items
+	list.Get("/", func(c *fiber.Ctx) error {
+		shoppingList := currentList(c)
+
+		items, err := shoppingService.GetListItems(c.UserContext(), shoppingList.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get list items", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"list":  shoppingList,
+			"items": nonNil(items),
+		})
+	})
+
+	// Archive a shopping list, the bot "complete list" action
+	list.Delete("/", func(c *fiber.Ctx) error {
+		if err := shoppingService.ArchiveShoppingList(c.UserContext(), currentList(c).ID, currentUser(c).ID); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to archive shopping list", err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	// Stream the changes made to the list by any member, from the bot or the API, as server-sent
+	// events. The stream ends when the list is archived or deleted, when the session expires, is
+	// revoked or rotated, when the user loses access and when the client falls behind; clients
+	// reload the list before they subscribe again.
+	list.Get("/events", func(c *fiber.Ctx) error {
+		listID := currentList(c).ID
+		userID := currentUser(c).ID
+		token := strings.Clone(bearerToken(c))
+		events, unsubscribe := listEvents.Subscribe(listID)
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		// The stream is written after the handler returns, the request context is gone by then
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer unsubscribe()
+			streamListEvents(w, events, func() bool {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				session, user, err := sessionsService.Authenticate(ctx, token)
+				if err != nil {
+					slog.Warn("failed to check session of event stream",
+						"component", "http_handler",
+						"list_id", listID,
+						"error", err.Error())
+					return true
+				}
+				if session == nil || user == nil || !user.IsAuthorized {
+					return false
+				}
+
+				canAccess, err := shoppingService.CanUserAccessList(ctx, listID, userID)
+				if err != nil {
+					slog.Warn("failed to check list access of event stream",
+						"component", "http_handler",
+						"list_id", listID,
+						"error", err.Error())
+					return true
+				}
+				return canAccess
+			})
+		})
+
+		return nil
+	})
+
+	// List items with pagination
+	list.Get("/items", func(c *fiber.Ctx) error {
+		limit, offset := parsePagination(c)
+
+		items, total, err := shoppingService.GetListItemsPage(c.UserContext(), currentList(c).ID, limit, offset)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get list items", err)
+		}
+
+		return c.JSON(fiber.Map{
+			"items":      nonNil(items),
+			"pagination": pagination{Limit: limit, Offset: offset, Total: total},
+		})
+	})
+
+	// Add an item to the list, parsed with the user's language unless one is provided
+	list.Post("/items", func(c *fiber.Ctx) error {
+		user := currentUser(c)
+
+		var req addItemRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name is required"})
+		}
+
+		languageCode := req.LanguageCode
+		if languageCode == "" {
+			languageCode = user.Locale
+		}
+
+		item, err := shoppingService.AddItemToListWithLanguage(c.UserContext(), currentList(c).ID,
+			req.Name, strings.TrimSpace(req.Quantity), languageCode, user.ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to add item", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(item)
+	})
+
+	// Products the family usually buys about now, learned from checked off items and receipts
+	list.Get("/suggestions", func(c *fiber.Ctx) error {
+		suggestions, err := shoppingService.GetListSuggestions(c.UserContext(), currentList(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get suggestions", err)
+		}
+
+		return c.JSON(fiber.Map{"suggestions": nonNil(suggestions)})
+	})
+
+	// Add a suggested product to the list
+	list.Post("/suggestions/:parsedItemID/accept", func(c *fiber.Ctx) error {
+		parsedItemID, err := uuid.Parse(c.Params("parsedItemID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid suggestion id"})
+		}
+
+		item, err := shoppingService.AcceptSuggestion(c.UserContext(), currentList(c).ID, parsedItemID, currentUser(c).ID)
+		if errors.Is(err, shopping.ErrSuggestionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "suggestion not found"})
+		}
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to accept suggestion", err)
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(item)
+	})
+
+	// Stop suggesting a product for now, it is suggested less often from then on
+	list.Post("/suggestions/:parsedItemID/dismiss", func(c *fiber.Ctx) error {
+		parsedItemID, err := uuid.Parse(c.Params("parsedItemID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid suggestion id"})
+		}
+
+		err = shoppingService.DismissSuggestion(c.UserContext(), currentList(c).ID, parsedItemID)
+		if errors.Is(err, shopping.ErrSuggestionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "suggestion not found"})
+		}
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to dismiss suggestion", err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	item := list.Group("/items/:itemID", requireListItem(shoppingService))
+
+	// Update item name and/or quantity
+	item.Patch("/", func(c *fiber.Ctx) error {
+		var req shopping.UpdateShoppingItemRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+
+		if req.Name == nil && req.Quantity == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "nothing to update"})
+		}
+		if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must not be empty"})
+		}
+
+		itemID := currentItemID(c)
+		if err := shoppingService.UpdateShoppingItem(c.UserContext(), itemID, req, currentUser(c).ID); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to update item", err)
+		}
+
+		return respondWithItem(c, shoppingService, itemID)
+	})
+
+	// Mark item as bought
+	item.Post("/complete", func(c *fiber.Ctx) error {
+		itemID := currentItemID(c)
+		if err := shoppingService.CompleteItem(c.UserContext(), itemID, currentUser(c).ID); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to complete item", err)
+		}
+
+		return respondWithItem(c, shoppingService, itemID)
+	})
+
+	// Mark item as not bought
+	item.Post("/uncomplete", func(c *fiber.Ctx) error {
+		itemID := currentItemID(c)
+		if err := shoppingService.UncompleteItem(c.UserContext(), itemID); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to uncomplete item", err)
+		}
+
+		return respondWithItem(c, shoppingService, itemID)
+	})
+
+	item.Delete("/", func(c *fiber.Ctx) error {
+		if err := shoppingService.DeleteItem(c.UserContext(), currentItemID(c)); err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to delete item", err)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
+
+// streamListEvents writes the events as server-sent events until the channel is closed, the
+// client goes away or canAccess reports the session ended or the user can no longer see the list
+func streamListEvents(w *bufio.Writer, events <-chan shopping.ListEvent, canAccess func() bool) {
+	heartbeat := time.NewTicker(listEventsHeartbeat)
+	defer heartbeat.Stop()
+
+	// Opening comment so clients know the subscription is active
+	fmt.Fprint(w, ": connected\n\n")
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.Warn("failed to encode list event", "component", "http_handler", "error", err.Error())
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		case <-heartbeat.C:
+			if !canAccess() {
+				return
+			}
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := w.Flush(); err != nil {
+			// The client disconnected
+			return
+		}
+	}
+}
+
+// requireListAccess loads the :listID list and applies the same access check the bot uses
+func requireListAccess(shoppingService *shopping.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		listID, err := uuid.Parse(c.Params("listID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid list id"})
+		}
+
+		canAccess, err := shoppingService.CanUserAccessList(c.UserContext(), listID, currentUser(c).ID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to check list access", err)
+		}
+		if !canAccess {
+			// Do not reveal whether the list exists
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "shopping list not found"})
+		}
+
+		list, err := shoppingService.GetShoppingListByID(c.UserContext(), listID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get shopping list", err)
+		}
+		if list == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "shopping list not found"})
+		}
+
+		c.Locals(listLocalsKey, list)
+		return c.Next()
+	}
+}
+
+// requireListItem makes sure :itemID belongs to the list resolved by requireListAccess
+func requireListItem(shoppingService *shopping.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		itemID, err := uuid.Parse(c.Params("itemID"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid item id"})
+		}
+
+		item, err := shoppingService.GetListItem(c.UserContext(), currentList(c).ID, itemID)
+		if err != nil {
+			return apiError(c, fiber.StatusInternalServerError, "failed to get item", err)
+		}
+		if item == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not found"})
+		}
+
+		return c.Next()
+	}
+}
+
+func currentList(c *fiber.Ctx) *shopping.ShoppingList {
+	list, _ := c.Locals(listLocalsKey).(*shopping.ShoppingList)
+	return list
+}
+
+func currentItemID(c *fiber.Ctx) uuid.UUID {
+	// Already validated by requireListItem
+	itemID, _ := uuid.Parse(c.Params("itemID"))
+	return itemID
+}
+
+func respondWithItem(c *fiber.Ctx, shoppingService *shopping.Service, itemID uuid.UUID) error {
+	item, err := shoppingService.GetListItem(c.UserContext(), currentList(c).ID, itemID)
+	if err != nil {
+		return apiError(c, fiber.StatusInternalServerError, "failed to get item", err)
+	}
+	if item == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "item not found"})
+	}
+
+	return c.JSON(item)
+}
+
+// apiError logs the underlying error and returns a generic message to the client
+func apiError(c *fiber.Ctx, status int, message string, err error) error {
+	slog.Error(message,
+		"component", "http_handler",
+		"method", c.Method(),
+		"path", c.Path(),
+		"error", err.Error())
+	return c.Status(status).JSON(fiber.Map{"error": message})
+}
+
+// parsePagination reads limit/offset query parameters with sane bounds
+func parsePagination(c *fiber.Ctx) (limit, offset int) {
+	limit = c.QueryInt("limit", defaultPageLimit)
+	offset = c.QueryInt("offset", 0)
+
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// nonNil makes sure empty slices are encoded as [] instead of null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
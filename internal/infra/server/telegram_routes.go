@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/PocketPalCo/shopping-service/internal/core/telegram"
+	"github.com/gofiber/fiber/v2"
+)
+
+// telegramSecretTokenHeader carries the secret token registered with the webhook
+const telegramSecretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// registerTelegramWebhookRoute accepts updates posted by Telegram and queues them for the bot.
+// Requests without the webhook secret token are rejected.
+func registerTelegramWebhookRoute(app *fiber.App, path, secretToken string, telegramService telegram.TelegramService) {
+	app.Post(path, func(c *fiber.Ctx) error {
+		token := c.Get(telegramSecretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secretToken)) != 1 {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+
+		// The body is reused by fasthttp after the handler returns
+		body := append([]byte(nil), c.Body()...)
+
+		if err := telegramService.HandleWebhookUpdate(body); err != nil {
+			// Telegram retries updates answered with an error, another replica may accept it
+			if errors.Is(err, telegram.ErrWebhookUnavailable) {
+				return c.SendStatus(fiber.StatusServiceUnavailable)
+			}
+			return apiError(c, fiber.StatusBadRequest, "invalid telegram update", err)
+		}
+
+		return c.SendStatus(fiber.StatusOK)
+	})
+}
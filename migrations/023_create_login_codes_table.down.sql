@@ -0,0 +1,5 @@
+COMMENT ON COLUMN user_sessions.session_token IS NULL;
+DROP INDEX IF EXISTS idx_login_codes_expires;
+DROP INDEX IF EXISTS idx_login_codes_user_id;
+DROP INDEX IF EXISTS idx_login_codes_code_hash;
+DROP TABLE IF EXISTS login_codes;
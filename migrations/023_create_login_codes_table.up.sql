@@ -0,0 +1,22 @@
+-- One-time login codes issued by the Telegram bot (/login) and exchanged for HTTP sessions
+CREATE TABLE IF NOT EXISTS login_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL, -- SHA256 hash of the code, the plain code is only sent to the user
+    chat_id BIGINT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Index for code lookups on login
+CREATE INDEX IF NOT EXISTS idx_login_codes_code_hash ON login_codes(code_hash);
+
+-- Index for invalidating previous codes of a user
+CREATE INDEX IF NOT EXISTS idx_login_codes_user_id ON login_codes(user_id);
+
+-- Index for cleanup of expired codes
+CREATE INDEX IF NOT EXISTS idx_login_codes_expires ON login_codes(expires_at);
+
+COMMENT ON TABLE login_codes IS 'One-time codes sent by the bot to log in to the HTTP API';
+COMMENT ON COLUMN user_sessions.session_token IS 'SHA256 hash of the bearer token, the plain token is only returned to the client';
@@ -0,0 +1,3 @@
+-- Remove quantity unit and restore the original quantity precision
+ALTER TABLE receipt_items DROP COLUMN IF EXISTS quantity_unit;
+ALTER TABLE receipt_items ALTER COLUMN quantity TYPE DECIMAL(8,2);
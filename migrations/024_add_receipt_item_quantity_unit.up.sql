@@ -0,0 +1,7 @@
+-- Keep weighted quantities such as 0.734 kg, DECIMAL(8,2) rounded them to two decimals
+ALTER TABLE receipt_items ALTER COLUMN quantity TYPE DECIMAL(10,3);
+
+-- Unit of measure of the quantity: kg, g, l, ml or pcs
+ALTER TABLE receipt_items ADD COLUMN IF NOT EXISTS quantity_unit VARCHAR(10);
+
+COMMENT ON COLUMN receipt_items.quantity_unit IS 'Unit of measure of quantity (kg, g, l, ml, pcs), unit_price is per this unit';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS shopping_item_purchases;
@@ -0,0 +1,22 @@
+-- Links a completed shopping list item to the receipt item it was bought as
+CREATE TABLE IF NOT EXISTS shopping_item_purchases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    shopping_item_id UUID NOT NULL REFERENCES shopping_items(id) ON DELETE CASCADE,
+    list_id UUID NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    receipt_id UUID NOT NULL REFERENCES users_receipts(id) ON DELETE CASCADE,
+    receipt_item_id UUID NOT NULL REFERENCES receipt_items(id) ON DELETE CASCADE,
+    match_score DECIMAL(3,2), -- how well the receipt item matched the list item (0.00-1.00)
+    confirmed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A list item is bought once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shopping_item_purchases_item ON shopping_item_purchases(shopping_item_id);
+
+-- Index for what was paid per list
+CREATE INDEX IF NOT EXISTS idx_shopping_item_purchases_list_id ON shopping_item_purchases(list_id);
+
+-- Index for the list items fulfilled by a receipt
+CREATE INDEX IF NOT EXISTS idx_shopping_item_purchases_receipt_id ON shopping_item_purchases(receipt_id);
+
+COMMENT ON TABLE shopping_item_purchases IS 'Receipt items that completed shopping list items, confirmed by the user after a receipt upload';
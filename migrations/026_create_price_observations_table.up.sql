@@ -0,0 +1,25 @@
+-- Normalized unit prices of receipt items, linked to the product they were recognized as
+CREATE TABLE IF NOT EXISTS price_observations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    receipt_item_id UUID NOT NULL REFERENCES receipt_items(id) ON DELETE CASCADE,
+    receipt_id UUID NOT NULL REFERENCES users_receipts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    parsed_item_id UUID REFERENCES parsed_items(id) ON DELETE SET NULL,
+    merchant_name VARCHAR(255),
+    observed_on DATE NOT NULL, -- transaction date, upload date when none was extracted
+    unit VARCHAR(10) NOT NULL, -- kg, l or pcs, grams and millilitres are converted
+    unit_price DECIMAL(12,4) NOT NULL, -- price per unit
+    currency_code VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- A receipt item is observed once, re-processing a receipt updates it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_price_observations_receipt_item ON price_observations(receipt_item_id);
+
+-- Indexes for the price history of a product
+CREATE INDEX IF NOT EXISTS idx_price_observations_user_product ON price_observations(user_id, product_id, observed_on) WHERE product_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_price_observations_user_parsed_item ON price_observations(user_id, parsed_item_id, observed_on) WHERE parsed_item_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_price_observations_receipt_id ON price_observations(receipt_id);
+
+COMMENT ON TABLE price_observations IS 'Price per kg, litre or piece of every receipt item, for price history and store comparison';
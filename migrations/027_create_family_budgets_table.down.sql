@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS family_budget_alerts;
+DROP TABLE IF EXISTS family_budgets;
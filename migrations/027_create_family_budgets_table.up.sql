@@ -0,0 +1,28 @@
+-- Weekly or monthly spending budgets of a family, optionally for one item category
+CREATE TABLE IF NOT EXISTS family_budgets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES item_categories(id) ON DELETE CASCADE, -- NULL for the whole spending
+    period VARCHAR(10) NOT NULL CHECK (period IN ('week', 'month')),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    currency_code VARCHAR(3) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- One budget per family, period and category
+CREATE UNIQUE INDEX IF NOT EXISTS idx_family_budgets_scope
+    ON family_budgets(family_id, period, (COALESCE(category_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+
+-- Alerts already sent, a threshold is announced once per budget period
+CREATE TABLE IF NOT EXISTS family_budget_alerts (
+    budget_id UUID NOT NULL REFERENCES family_budgets(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    threshold INTEGER NOT NULL, -- percent of the budget amount
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (budget_id, period_start, threshold)
+);
+
+COMMENT ON TABLE family_budgets IS 'Spending limits of a family, computed from the receipts of its members';
+COMMENT ON TABLE family_budget_alerts IS 'Budget thresholds (80%, 100%) already announced to the family members';
@@ -0,0 +1,4 @@
+-- Remove receipt sharing with families
+DROP INDEX IF EXISTS idx_users_receipts_family_id;
+ALTER TABLE users_receipts DROP COLUMN IF EXISTS paid_by;
+ALTER TABLE users_receipts DROP COLUMN IF EXISTS family_id;
@@ -0,0 +1,11 @@
+-- Receipts can be shared with one family of the uploader, its members can see them
+ALTER TABLE users_receipts ADD COLUMN IF NOT EXISTS family_id UUID REFERENCES families(id) ON DELETE SET NULL;
+
+-- Who paid for the purchase, NULL when the uploader paid
+ALTER TABLE users_receipts ADD COLUMN IF NOT EXISTS paid_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Index for the receipts of a family
+CREATE INDEX IF NOT EXISTS idx_users_receipts_family_id ON users_receipts(family_id, created_at DESC) WHERE family_id IS NOT NULL;
+
+COMMENT ON COLUMN users_receipts.family_id IS 'Family the receipt is shared with, visible to all its members';
+COMMENT ON COLUMN users_receipts.paid_by IS 'Family member who paid, the uploader when NULL';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS family_settlements;
+DROP TABLE IF EXISTS receipt_item_splits;
+DROP TABLE IF EXISTS receipt_split_participants;
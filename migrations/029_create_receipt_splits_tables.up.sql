@@ -0,0 +1,36 @@
+-- Family members sharing the cost of a family receipt equally, the payer included when listed
+CREATE TABLE IF NOT EXISTS receipt_split_participants (
+    receipt_id UUID NOT NULL REFERENCES users_receipts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (receipt_id, user_id)
+);
+
+-- Family members sharing the cost of one receipt item, such items are left out of the receipt split
+CREATE TABLE IF NOT EXISTS receipt_item_splits (
+    receipt_item_id UUID NOT NULL REFERENCES receipt_items(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (receipt_item_id, user_id)
+);
+
+-- Payments between family members settling their balances
+CREATE TABLE IF NOT EXISTS family_settlements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL REFERENCES families(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    currency_code VARCHAR(3) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipt_split_participants_user_id ON receipt_split_participants(user_id);
+CREATE INDEX IF NOT EXISTS idx_receipt_item_splits_user_id ON receipt_item_splits(user_id);
+CREATE INDEX IF NOT EXISTS idx_family_settlements_family_id ON family_settlements(family_id, created_at DESC);
+
+COMMENT ON TABLE receipt_split_participants IS 'Members splitting a family receipt, each owes the payer an equal share';
+COMMENT ON TABLE receipt_item_splits IS 'Members splitting a single receipt item instead of the receipt split';
+COMMENT ON TABLE family_settlements IS 'Settle up history: money paid back between family members';
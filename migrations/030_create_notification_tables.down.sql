@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS family_activity_notifications;
+DROP TABLE IF EXISTS notification_muted_lists;
+DROP TABLE IF EXISTS notification_preferences;
@@ -0,0 +1,41 @@
+-- How each user hears about the activity in the shopping lists of their families
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    delivery VARCHAR(10) NOT NULL DEFAULT 'instant' CHECK (delivery IN ('instant', 'batched', 'off')),
+    batch_interval_minutes INTEGER NOT NULL DEFAULT 30 CHECK (batch_interval_minutes BETWEEN 5 AND 1440),
+    quiet_start_minute SMALLINT CHECK (quiet_start_minute BETWEEN 0 AND 1439), -- minute of the day, NULL without quiet hours
+    quiet_end_minute SMALLINT CHECK (quiet_end_minute BETWEEN 0 AND 1439),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((quiet_start_minute IS NULL) = (quiet_end_minute IS NULL))
+);
+
+-- Lists a user doesn't want to hear about
+CREATE TABLE IF NOT EXISTS notification_muted_lists (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    list_id UUID NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, list_id)
+);
+
+-- Activity waiting to be sent to a family member in the next digest
+CREATE TABLE IF NOT EXISTS family_activity_notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id UUID NOT NULL, -- the list event, every replica receives it
+    recipient_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    list_id UUID NOT NULL REFERENCES shopping_lists(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    activity VARCHAR(20) NOT NULL CHECK (activity IN ('items_added', 'list_completed')),
+    item_name TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_family_activity_notifications_event
+    ON family_activity_notifications(event_id, recipient_id);
+CREATE INDEX IF NOT EXISTS idx_family_activity_notifications_recipient
+    ON family_activity_notifications(recipient_id, created_at);
+
+COMMENT ON TABLE notification_preferences IS 'Delivery of family activity notifications: instant, batched digests or off, and quiet hours';
+COMMENT ON TABLE notification_muted_lists IS 'Shopping lists a user gets no activity notifications for';
+COMMENT ON TABLE family_activity_notifications IS 'List activity queued for the next digest of a family member, removed once sent';
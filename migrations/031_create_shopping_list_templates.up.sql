@@ -0,0 +1,41 @@
+-- Reusable shopping lists, e.g. the weekly staples, optionally created on a weekly schedule
+CREATE TABLE IF NOT EXISTS shopping_list_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID REFERENCES families(id) ON DELETE CASCADE,
+    schedule_weekday SMALLINT CHECK (schedule_weekday BETWEEN 0 AND 6), -- 0 is Sunday, NULL without a schedule
+    schedule_minute SMALLINT CHECK (schedule_minute BETWEEN 0 AND 1439), -- minute of the day
+    schedule_timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK ((schedule_weekday IS NULL) = (schedule_minute IS NULL)),
+    CHECK ((schedule_weekday IS NULL) = (next_run_at IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_shopping_list_templates_owner_id ON shopping_list_templates(owner_id);
+CREATE INDEX IF NOT EXISTS idx_shopping_list_templates_family_id ON shopping_list_templates(family_id);
+CREATE INDEX IF NOT EXISTS idx_shopping_list_templates_next_run
+    ON shopping_list_templates(next_run_at) WHERE next_run_at IS NOT NULL;
+
+-- Items copied from a list into a template, with their parsing so new lists need no AI
+CREATE TABLE IF NOT EXISTS shopping_list_template_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    template_id UUID NOT NULL REFERENCES shopping_list_templates(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    quantity TEXT,
+    notes TEXT,
+    display_name VARCHAR(255),
+    parsed_name VARCHAR(255),
+    original_item_id UUID REFERENCES original_items(id) ON DELETE SET NULL,
+    parsed_item_id UUID REFERENCES parsed_items(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shopping_list_template_items_template
+    ON shopping_list_template_items(template_id, position);
+
+COMMENT ON TABLE shopping_list_templates IS 'Reusable shopping lists, created as new lists on demand or every week';
+COMMENT ON TABLE shopping_list_template_items IS 'Items a list created from the template starts with';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_shopping_items_parsed_completed;
+DROP TABLE IF EXISTS item_suggestion_feedback;
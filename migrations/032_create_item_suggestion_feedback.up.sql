@@ -0,0 +1,25 @@
+-- How a family, or the owner of personal lists, answered purchase suggestions of a product.
+-- Dismissals stretch the learned buying interval of the product, accepts shorten it again.
+CREATE TABLE IF NOT EXISTS item_suggestion_feedback (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID REFERENCES families(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE, -- set for personal lists, which have no family
+    parsed_item_id UUID NOT NULL REFERENCES parsed_items(id) ON DELETE CASCADE,
+    accepted_count INTEGER NOT NULL DEFAULT 0,
+    dismissed_count INTEGER NOT NULL DEFAULT 0,
+    interval_factor DECIMAL(4,2) NOT NULL DEFAULT 1.00 CHECK (interval_factor > 0), -- multiplies the average days between purchases
+    snoozed_until TIMESTAMP WITH TIME ZONE, -- not suggested again before, set by a dismissal
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (num_nonnulls(family_id, user_id) = 1)
+);
+
+-- One row per product of a family or user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_suggestion_feedback_scope
+    ON item_suggestion_feedback((COALESCE(family_id, user_id)), parsed_item_id);
+
+-- Index for the purchase history of a product
+CREATE INDEX IF NOT EXISTS idx_shopping_items_parsed_completed
+    ON shopping_items(parsed_item_id, completed_at) WHERE is_completed AND parsed_item_id IS NOT NULL;
+
+COMMENT ON TABLE item_suggestion_feedback IS 'Accepted and dismissed purchase suggestions, tuning how often a product is suggested';
@@ -0,0 +1,3 @@
+-- Remove the price history backfill state of receipts
+DROP INDEX IF EXISTS idx_users_receipts_price_history_pending;
+ALTER TABLE users_receipts DROP COLUMN IF EXISTS price_history_recorded_at;
@@ -0,0 +1,14 @@
+-- When the items of a receipt were recorded in the price history, receipts processed before it
+-- existed are recorded by the backfill on startup
+ALTER TABLE users_receipts ADD COLUMN IF NOT EXISTS price_history_recorded_at TIMESTAMP WITH TIME ZONE;
+
+-- Receipts that already have price observations don't need the backfill
+UPDATE users_receipts r
+SET price_history_recorded_at = NOW()
+WHERE price_history_recorded_at IS NULL
+  AND EXISTS (SELECT 1 FROM price_observations po WHERE po.receipt_id = r.id);
+
+-- Index for the receipts the backfill still has to record
+CREATE INDEX IF NOT EXISTS idx_users_receipts_price_history_pending ON users_receipts(created_at) WHERE price_history_recorded_at IS NULL;
+
+COMMENT ON COLUMN users_receipts.price_history_recorded_at IS 'When the receipt items were recorded in price_observations, NULL when still pending';
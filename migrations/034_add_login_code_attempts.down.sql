@@ -0,0 +1,4 @@
+-- Remove the per-code attempt limit of login codes
+DROP INDEX IF EXISTS idx_login_codes_selector;
+ALTER TABLE login_codes DROP COLUMN IF EXISTS failed_attempts;
+ALTER TABLE login_codes DROP COLUMN IF EXISTS code_selector;
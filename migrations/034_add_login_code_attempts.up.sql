@@ -0,0 +1,15 @@
+-- Login codes get a public selector, so failed guesses are counted per code and a code is
+-- invalidated after too many of them
+ALTER TABLE login_codes ADD COLUMN IF NOT EXISTS code_selector VARCHAR(4);
+ALTER TABLE login_codes ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Codes issued before can't be exchanged anymore
+DELETE FROM login_codes WHERE code_selector IS NULL;
+
+ALTER TABLE login_codes ALTER COLUMN code_selector SET NOT NULL;
+
+-- Index for code lookups on login
+CREATE INDEX IF NOT EXISTS idx_login_codes_selector ON login_codes(code_selector) WHERE used_at IS NULL;
+
+COMMENT ON COLUMN login_codes.code_selector IS 'First digits of the code, identifies the code a guess was made for';
+COMMENT ON COLUMN login_codes.failed_attempts IS 'Wrong guesses for the code, it expires after too many';
@@ -14,6 +14,12 @@ var (
 	TelegramUsersActive   api.Int64UpDownCounter
 	TelegramErrorsTotal   api.Int64Counter
 
+	// Telegram update dispatcher metrics
+	TelegramUpdatesQueued       api.Int64UpDownCounter
+	TelegramUpdatesDropped      api.Int64Counter
+	TelegramUpdateWaitHistogram api.Float64Histogram
+	TelegramUpdateHistogram     api.Float64Histogram
+
 	// Shopping List metrics
 	ShoppingListOperations api.Int64Counter
 	ShoppingItemOperations api.Int64Counter
@@ -61,6 +67,30 @@ func InitBusinessMetrics(provider *metric.MeterProvider) error {
 		return err
 	}
 
+	TelegramUpdatesQueued, err = meter.Int64UpDownCounter("telegram.updates.queued",
+		api.WithDescription("Number of Telegram updates waiting for or being processed"))
+	if err != nil {
+		return err
+	}
+
+	TelegramUpdatesDropped, err = meter.Int64Counter("telegram.updates.dropped.total",
+		api.WithDescription("Total Telegram updates not processed because the bot was stopped"))
+	if err != nil {
+		return err
+	}
+
+	TelegramUpdateWaitHistogram, err = meter.Float64Histogram("telegram.update.wait.duration.ms",
+		api.WithDescription("Time Telegram updates spend queued before processing in milliseconds"))
+	if err != nil {
+		return err
+	}
+
+	TelegramUpdateHistogram, err = meter.Float64Histogram("telegram.update.duration.ms",
+		api.WithDescription("Telegram update processing duration in milliseconds by type"))
+	if err != nil {
+		return err
+	}
+
 	// Shopping List Metrics
 	ShoppingListOperations, err = meter.Int64Counter("shopping.list.operations.total",
 		api.WithDescription("Total shopping list operations by type (create, update, delete)"))